@@ -9,14 +9,59 @@ integration {
     name = "DigitalOcean Image"
     slug = "image"
   }
+  component {
+    type = "data-source"
+    name = "DigitalOcean Image Channel"
+    slug = "image-channel"
+  }
+  component {
+    type = "data-source"
+    name = "DigitalOcean Size"
+    slug = "size"
+  }
+  component {
+    type = "data-source"
+    name = "DigitalOcean Self-Test"
+    slug = "selftest"
+  }
+  component {
+    type = "data-source"
+    name = "DigitalOcean Capabilities"
+    slug = "capabilities"
+  }
   component {
     type = "builder"
     name = "DigitalOcean"
     slug = "digitalocean"
   }
+  component {
+    type = "builder"
+    name = "DigitalOcean chroot"
+    slug = "chroot"
+  }
+  component {
+    type = "builder"
+    name = "DigitalOcean Snapshot Copy"
+    slug = "snapshot-copy"
+  }
   component {
     type = "post-processor"
     name = "DigitalOcean Import"
     slug = "import"
   }
+  component {
+    type = "post-processor"
+    name = "DigitalOcean Convert"
+    slug = "convert"
+  }
+  component {
+    type = "post-processor"
+    name = "DigitalOcean Lock"
+    slug = "lock"
+  }
+  component {
+    type = "post-processor"
+    name = "DigitalOcean Prune"
+    slug = "prune"
+  }
 }
@@ -0,0 +1,135 @@
+// The digitaloceanchroot package contains a packersdk.Builder implementation
+// that builds a custom image from a volume provisioned in a chroot on the
+// droplet Packer runs on, without creating a droplet for the build.
+
+package digitaloceanchroot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"runtime"
+
+	"github.com/digitalocean/godo"
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/chroot"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+type Builder struct {
+	config Config
+	runner multistep.Runner
+}
+
+var _ packersdk.Builder = new(Builder)
+
+type wrappedCommandTemplate struct {
+	Command string
+}
+
+func (b *Builder) ConfigSpec() hcldec.ObjectSpec { return b.config.FlatMapstructure().HCL2Spec() }
+
+func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
+	if err := b.config.Prepare(raws...); err != nil {
+		return nil, nil, err
+	}
+	return nil, nil, nil
+}
+
+func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook) (packersdk.Artifact, error) {
+	if runtime.GOOS != "linux" {
+		return nil, errors.New("The digitalocean-chroot builder only works on Linux droplets.")
+	}
+
+	client, err := newClient(&b.config)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedCommand := func(command string) (string, error) {
+		ictx := b.config.ctx
+		ictx.Data = &wrappedCommandTemplate{Command: command}
+		return interpolate.Render(b.config.CommandWrapper, &ictx)
+	}
+
+	state := new(multistep.BasicStateBag)
+	state.Put("config", &b.config)
+	state.Put("client", client)
+	state.Put("hook", hook)
+	state.Put("ui", ui)
+	state.Put("wrappedCommand", common.CommandWrapper(wrappedCommand))
+
+	steps := []multistep.Step{
+		new(stepWorkerInfo),
+		new(stepCreateVolume),
+		new(stepAttachVolume),
+		&chroot.StepPreMountCommands{
+			Commands: b.config.PreMountCommands,
+		},
+		new(stepMountDevice),
+		&chroot.StepPostMountCommands{
+			Commands: b.config.PostMountCommands,
+		},
+		&chroot.StepMountExtra{
+			ChrootMounts: b.config.ChrootMounts,
+		},
+		&chroot.StepCopyFiles{
+			Files: b.config.CopyFiles,
+		},
+		new(chroot.StepChrootProvision),
+		new(stepUnmount),
+		new(stepSnapshotVolume),
+		new(stepExportVolume),
+		new(stepImportImage),
+		new(stepTransferImage),
+	}
+
+	b.runner = commonsteps.NewRunner(steps, b.config.PackerConfig, ui)
+	b.runner.Run(ctx, state)
+
+	if rawErr, ok := state.GetOk("error"); ok {
+		return nil, rawErr.(error)
+	}
+
+	// If we were interrupted or cancelled, then just exit.
+	if _, ok := state.GetOk(multistep.StateCancelled); ok {
+		return nil, nil
+	}
+	if _, ok := state.GetOk(multistep.StateHalted); ok {
+		return nil, nil
+	}
+
+	rawImage, ok := state.GetOk("image")
+	if !ok {
+		log.Println("Failed to find image in state. Bug?")
+		return nil, nil
+	}
+	image := rawImage.(*godo.Image)
+	stateData := map[string]interface{}{
+		"hcp_image_id_format": b.config.HCPImageIDFormat,
+	}
+	if generatedData, ok := state.GetOk("generated_data"); ok {
+		stateData["generated_data"] = generatedData
+	}
+	if snapshots, ok := state.GetOk("volume_snapshots"); ok {
+		stateData["volume_snapshots"] = snapshots
+	}
+	artifact := &digitalocean.Artifact{
+		SnapshotName: image.Name,
+		SnapshotId:   image.ID,
+		RegionNames:  state.Get("regions").([]string),
+		Client:       client,
+		StateData:    stateData,
+	}
+
+	return artifact, nil
+}
+
+func newClient(c *Config) (*godo.Client, error) {
+	return digitalocean.NewClient(c.APIToken, c.APIURL, &c.Retry)
+}
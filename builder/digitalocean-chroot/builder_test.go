@@ -0,0 +1,90 @@
+package digitaloceanchroot
+
+import (
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"api_token":                 "bar",
+		"spaces_key":                "key",
+		"spaces_secret":             "secret",
+		"space_name":                "images",
+		"source_volume_snapshot_id": "fbe805e8-866b-11e6-96bf-000f53315a41",
+		"image_name":                "app-1.4.0",
+	}
+}
+
+func TestBuilder_ImplementsBuilder(t *testing.T) {
+	var _ packersdk.Builder = new(Builder)
+}
+
+func TestBuilderPrepare(t *testing.T) {
+	var b Builder
+	if _, _, err := b.Prepare(testConfig()); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.MountPartition != "1" {
+		t.Errorf("mount_partition should default to 1: %s", b.config.MountPartition)
+	}
+	if len(b.config.ChrootMounts) != 5 {
+		t.Errorf("chroot_mounts should have defaults: %v", b.config.ChrootMounts)
+	}
+	if len(b.config.CopyFiles) != 1 || b.config.CopyFiles[0] != "/etc/resolv.conf" {
+		t.Errorf("copy_files should default to resolv.conf: %v", b.config.CopyFiles)
+	}
+	if b.config.ImageTimeout == 0 || b.config.StateTimeout == 0 {
+		t.Error("timeouts should have defaults")
+	}
+
+	for _, key := range []string{"space_name", "image_name", "spaces_key"} {
+		b = Builder{}
+		config := testConfig()
+		delete(config, key)
+		if _, _, err := b.Prepare(config); err == nil {
+			t.Fatalf("should have error without %s", key)
+		}
+	}
+
+	b = Builder{}
+	config := testConfig()
+	config["chroot_mounts"] = [][]string{{"proc", "/proc"}}
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error with an incomplete chroot mount")
+	}
+}
+
+func TestBuilderPrepare_FromScratch(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	delete(config, "source_volume_snapshot_id")
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error without a source")
+	}
+
+	config["from_scratch"] = true
+	config["volume_size"] = 5
+	config["pre_mount_commands"] = []string{"parted {{.Device}} mklabel msdos"}
+	b = Builder{}
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.PreMountCommands[0] != "parted {{.Device}} mklabel msdos" {
+		t.Errorf("pre_mount_commands should be rendered at mount time: %s", b.config.PreMountCommands[0])
+	}
+
+	delete(config, "volume_size")
+	b = Builder{}
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error without volume_size")
+	}
+
+	config["volume_size"] = 5
+	config["source_volume_snapshot_id"] = "fbe805e8-866b-11e6-96bf-000f53315a41"
+	b = Builder{}
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error with a source snapshot")
+	}
+}
@@ -0,0 +1,257 @@
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+package digitaloceanchroot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// A personal access token used to communicate with the DigitalOcean v2 API.
+	// This may also be set using the `DIGITALOCEAN_TOKEN` or
+	// `DIGITALOCEAN_ACCESS_TOKEN` environmental variables.
+	APIToken string `mapstructure:"api_token" required:"true"`
+	// Non standard api endpoint URL. Set this if you are
+	// using a DigitalOcean API compatible service. It can also be specified via
+	// environment variable DIGITALOCEAN_API_URL.
+	APIURL string `mapstructure:"api_url"`
+	// Controls how failed API requests are retried. See the
+	// [retry configuration](#retry-configuration) section below.
+	Retry digitalocean.RetryConfig `mapstructure:"retry" required:"false"`
+	// The access key used to upload the volume's disk image to Spaces. This
+	// may also be set using the `DIGITALOCEAN_SPACES_ACCESS_KEY`
+	// environmental variable.
+	SpacesKey string `mapstructure:"spaces_key" required:"true"`
+	// The secret key used to upload the volume's disk image to Spaces. This
+	// may also be set using the `DIGITALOCEAN_SPACES_SECRET_KEY`
+	// environmental variable.
+	SpacesSecret string `mapstructure:"spaces_secret" required:"true"`
+	// The name of the Space the disk image is uploaded to for the import. It
+	// must exist when the build runs.
+	SpaceName string `mapstructure:"space_name" required:"true"`
+	// The region of the Space, such as `nyc3`. Defaults to the region of the
+	// worker droplet.
+	SpacesRegion string `mapstructure:"spaces_region" required:"false"`
+	// Set to true to leave the disk image in the Space after the import.
+	// Defaults to `false`.
+	SkipClean bool `mapstructure:"skip_clean" required:"false"`
+	// The ID of the volume snapshot to create the build's volume from, such
+	// as the `volume_snapshot_name` snapshot of an earlier build. It must
+	// hold a whole disk, with a partition table and a boot loader, and be
+	// available in the region of the worker droplet. Either this or
+	// `from_scratch` must be set.
+	SourceVolumeSnapshotID string `mapstructure:"source_volume_snapshot_id" required:"false"`
+	// Set to true to start from an empty volume, which `pre_mount_commands`
+	// must partition and format. Requires `volume_size`. Defaults to `false`.
+	FromScratch bool `mapstructure:"from_scratch" required:"false"`
+	// The size of the build's volume in GiB. It is the size of the disk of
+	// the image too, so droplets need at least that much disk to use it.
+	// Defaults to the minimum size of `source_volume_snapshot_id`.
+	VolumeSize int64 `mapstructure:"volume_size" required:"false"`
+	// Commands to run on the worker droplet before the volume is mounted,
+	// such as to partition and format it with `from_scratch`. The device of
+	// the volume is available as `{{ .Device }}`.
+	PreMountCommands []string `mapstructure:"pre_mount_commands" required:"false"`
+	// The path the volume is mounted at on the worker droplet, as a template
+	// with the volume's device name available as `{{ .Device }}`. Defaults
+	// to `/mnt/packer-digitalocean-chroot/{{ .Device }}`.
+	MountPath string `mapstructure:"mount_path" required:"false"`
+	// The partition of the volume to mount as the root filesystem, or `0`
+	// to mount the whole device. Defaults to `1`.
+	MountPartition string `mapstructure:"mount_partition" required:"false"`
+	// Options to mount the root filesystem with, such as `noatime`, passed
+	// to `mount -o`.
+	MountOptions []string `mapstructure:"mount_options" required:"false"`
+	// Commands to run on the worker droplet after the volume is mounted,
+	// with `{{ .Device }}` and `{{ .MountPath }}` available.
+	PostMountCommands []string `mapstructure:"post_mount_commands" required:"false"`
+	// The filesystems to mount into the chroot, each given as the filesystem
+	// type, the source and the path inside the chroot. Defaults to `/proc`,
+	// `/sys`, `/dev`, `/dev/pts` and `/proc/sys/fs/binfmt_misc`.
+	ChrootMounts [][]string `mapstructure:"chroot_mounts" required:"false"`
+	// Files to copy from the worker droplet into the chroot before
+	// provisioning. Defaults to `/etc/resolv.conf`, so that the chroot can
+	// resolve names.
+	CopyFiles []string `mapstructure:"copy_files" required:"false"`
+	// A template wrapping the commands run on the worker droplet, such as
+	// `sudo {{ .Command }}` when Packer doesn't run as root. Defaults to
+	// `{{ .Command }}`.
+	CommandWrapper string `mapstructure:"command_wrapper" required:"false"`
+	// The name of the custom image to create.
+	ImageName string `mapstructure:"image_name" required:"true"`
+	// The description of the custom image.
+	ImageDescription string `mapstructure:"image_description" required:"false"`
+	// The distribution of the custom image, such as `Ubuntu`. Defaults to
+	// `Unknown`.
+	ImageDistribution string `mapstructure:"image_distribution" required:"false"`
+	// Tags to apply to the custom image.
+	ImageTags []string `mapstructure:"image_tags" required:"false"`
+	// The regions to make the custom image available in. It is imported in
+	// the first and transferred to the others. Defaults to the region of the
+	// worker droplet.
+	ImageRegions []string `mapstructure:"image_regions" required:"false"`
+	// The name of a snapshot to take of the volume once it is provisioned,
+	// for a later build to start from with `source_volume_snapshot_id`. It
+	// is included in the artifact. By default the volume isn't snapshotted.
+	VolumeSnapshotName string `mapstructure:"volume_snapshot_name" required:"false"`
+	// How long to wait for the volume to attach or detach. Defaults to "6m".
+	StateTimeout time.Duration `mapstructure:"state_timeout" required:"false"`
+	// How long to wait for the custom image to be imported, and for each
+	// transfer to another region. Defaults to "30m".
+	ImageTimeout time.Duration `mapstructure:"image_timeout" required:"false"`
+	// The format of the image IDs reported to HCP Packer for each region of
+	// the image, as a template with the `{{ .ID }}`, `{{ .Name }}` and
+	// `{{ .Region }}` variables, such as `{{ .Region }}:{{ .ID }}`. Defaults
+	// to `{{ .ID }}`, the image ID.
+	HCPImageIDFormat string `mapstructure:"hcp_image_id_format" required:"false"`
+
+	ctx interpolate.Context
+}
+
+// GetContext returns the interpolation context the chroot steps render the
+// mount commands with.
+func (c *Config) GetContext() interpolate.Context {
+	return c.ctx
+}
+
+func (c *Config) Prepare(raws ...interface{}) error {
+	err := config.Decode(c, &config.DecodeOpts{
+		Interpolate:        true,
+		InterpolateContext: &c.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{
+				"command_wrapper",
+				"mount_path",
+				"pre_mount_commands",
+				"post_mount_commands",
+				"hcp_image_id_format",
+			},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if c.APIToken == "" {
+		c.APIToken = os.Getenv("DIGITALOCEAN_TOKEN")
+	}
+	if c.APIToken == "" {
+		c.APIToken = os.Getenv("DIGITALOCEAN_ACCESS_TOKEN")
+	}
+	if c.APIURL == "" {
+		c.APIURL = os.Getenv("DIGITALOCEAN_API_URL")
+	}
+	if c.SpacesKey == "" {
+		c.SpacesKey = os.Getenv("DIGITALOCEAN_SPACES_ACCESS_KEY")
+	}
+	if c.SpacesSecret == "" {
+		c.SpacesSecret = os.Getenv("DIGITALOCEAN_SPACES_SECRET_KEY")
+	}
+	if c.MountPath == "" {
+		c.MountPath = "/mnt/packer-digitalocean-chroot/{{.Device}}"
+	}
+	if c.MountPartition == "" {
+		c.MountPartition = "1"
+	}
+	if c.ChrootMounts == nil {
+		c.ChrootMounts = [][]string{
+			{"proc", "proc", "/proc"},
+			{"sysfs", "sysfs", "/sys"},
+			{"bind", "/dev", "/dev"},
+			{"devpts", "devpts", "/dev/pts"},
+			{"binfmt_misc", "binfmt_misc", "/proc/sys/fs/binfmt_misc"},
+		}
+	}
+	if c.CopyFiles == nil {
+		c.CopyFiles = []string{"/etc/resolv.conf"}
+	}
+	if c.CommandWrapper == "" {
+		c.CommandWrapper = "{{.Command}}"
+	}
+	if c.ImageDistribution == "" {
+		c.ImageDistribution = "Unknown"
+	}
+	if c.StateTimeout == 0 {
+		c.StateTimeout = 6 * time.Minute
+	}
+	if c.ImageTimeout == 0 {
+		c.ImageTimeout = 30 * time.Minute
+	}
+	if c.HCPImageIDFormat == "" {
+		c.HCPImageIDFormat = digitalocean.DefaultHCPImageIDFormat
+	}
+
+	errs := new(packersdk.MultiError)
+
+	if es := c.Retry.Prepare(nil, nil, nil); len(es) > 0 {
+		errs = packersdk.MultiErrorAppend(errs, es...)
+	}
+
+	requiredArgs := map[string]string{
+		"api_token":     c.APIToken,
+		"spaces_key":    c.SpacesKey,
+		"spaces_secret": c.SpacesSecret,
+		"space_name":    c.SpaceName,
+		"image_name":    c.ImageName,
+	}
+	for key, value := range requiredArgs {
+		if value == "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("%s must be set", key))
+		}
+	}
+
+	if c.FromScratch {
+		if c.SourceVolumeSnapshotID != "" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("source_volume_snapshot_id can not be used with from_scratch"))
+		}
+		if c.VolumeSize == 0 {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("volume_size must be set with from_scratch"))
+		}
+		if len(c.PreMountCommands) == 0 {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("pre_mount_commands must be set with from_scratch"))
+		}
+	} else if c.SourceVolumeSnapshotID == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("one of source_volume_snapshot_id or from_scratch must be set"))
+	}
+	if c.VolumeSize < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("volume_size must not be negative"))
+	}
+
+	for _, mount := range c.ChrootMounts {
+		if len(mount) != 3 {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("each chroot_mounts entry must have a type, a source and a path"))
+			break
+		}
+	}
+
+	tagRe := regexp.MustCompile("^[[:alnum:]:_-]{1,255}$")
+	for _, t := range c.ImageTags {
+		if !tagRe.MatchString(t) {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid tag: %s", t))
+		}
+	}
+
+	if err := digitalocean.ValidateHCPImageIDFormat(c.HCPImageIDFormat); err != nil {
+		errs = packersdk.MultiErrorAppend(errs, err)
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(c.APIToken, c.SpacesKey, c.SpacesSecret)
+	return nil
+}
@@ -0,0 +1,102 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package digitaloceanchroot
+
+import (
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatConfig struct {
+	PackerBuildName        *string                       `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType      *string                       `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion      *string                       `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug            *bool                         `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce            *bool                         `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError          *string                       `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerUserVars         map[string]string             `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars    []string                      `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	APIToken               *string                       `mapstructure:"api_token" required:"true" cty:"api_token" hcl:"api_token"`
+	APIURL                 *string                       `mapstructure:"api_url" cty:"api_url" hcl:"api_url"`
+	Retry                  *digitalocean.FlatRetryConfig `mapstructure:"retry" required:"false" cty:"retry" hcl:"retry"`
+	SpacesKey              *string                       `mapstructure:"spaces_key" required:"true" cty:"spaces_key" hcl:"spaces_key"`
+	SpacesSecret           *string                       `mapstructure:"spaces_secret" required:"true" cty:"spaces_secret" hcl:"spaces_secret"`
+	SpaceName              *string                       `mapstructure:"space_name" required:"true" cty:"space_name" hcl:"space_name"`
+	SpacesRegion           *string                       `mapstructure:"spaces_region" required:"false" cty:"spaces_region" hcl:"spaces_region"`
+	SkipClean              *bool                         `mapstructure:"skip_clean" required:"false" cty:"skip_clean" hcl:"skip_clean"`
+	SourceVolumeSnapshotID *string                       `mapstructure:"source_volume_snapshot_id" required:"false" cty:"source_volume_snapshot_id" hcl:"source_volume_snapshot_id"`
+	FromScratch            *bool                         `mapstructure:"from_scratch" required:"false" cty:"from_scratch" hcl:"from_scratch"`
+	VolumeSize             *int64                        `mapstructure:"volume_size" required:"false" cty:"volume_size" hcl:"volume_size"`
+	PreMountCommands       []string                      `mapstructure:"pre_mount_commands" required:"false" cty:"pre_mount_commands" hcl:"pre_mount_commands"`
+	MountPath              *string                       `mapstructure:"mount_path" required:"false" cty:"mount_path" hcl:"mount_path"`
+	MountPartition         *string                       `mapstructure:"mount_partition" required:"false" cty:"mount_partition" hcl:"mount_partition"`
+	MountOptions           []string                      `mapstructure:"mount_options" required:"false" cty:"mount_options" hcl:"mount_options"`
+	PostMountCommands      []string                      `mapstructure:"post_mount_commands" required:"false" cty:"post_mount_commands" hcl:"post_mount_commands"`
+	ChrootMounts           [][]string                    `mapstructure:"chroot_mounts" required:"false" cty:"chroot_mounts" hcl:"chroot_mounts"`
+	CopyFiles              []string                      `mapstructure:"copy_files" required:"false" cty:"copy_files" hcl:"copy_files"`
+	CommandWrapper         *string                       `mapstructure:"command_wrapper" required:"false" cty:"command_wrapper" hcl:"command_wrapper"`
+	ImageName              *string                       `mapstructure:"image_name" required:"true" cty:"image_name" hcl:"image_name"`
+	ImageDescription       *string                       `mapstructure:"image_description" required:"false" cty:"image_description" hcl:"image_description"`
+	ImageDistribution      *string                       `mapstructure:"image_distribution" required:"false" cty:"image_distribution" hcl:"image_distribution"`
+	ImageTags              []string                      `mapstructure:"image_tags" required:"false" cty:"image_tags" hcl:"image_tags"`
+	ImageRegions           []string                      `mapstructure:"image_regions" required:"false" cty:"image_regions" hcl:"image_regions"`
+	VolumeSnapshotName     *string                       `mapstructure:"volume_snapshot_name" required:"false" cty:"volume_snapshot_name" hcl:"volume_snapshot_name"`
+	StateTimeout           *string                       `mapstructure:"state_timeout" required:"false" cty:"state_timeout" hcl:"state_timeout"`
+	ImageTimeout           *string                       `mapstructure:"image_timeout" required:"false" cty:"image_timeout" hcl:"image_timeout"`
+	HCPImageIDFormat       *string                       `mapstructure:"hcp_image_id_format" required:"false" cty:"hcp_image_id_format" hcl:"hcp_image_id_format"`
+}
+
+// FlatMapstructure returns a new FlatConfig.
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatConfig)
+}
+
+// HCL2Spec returns the hcl spec of a Config.
+// This spec is used by HCL to read the fields of Config.
+// The decoded values from this spec will then be applied to a FlatConfig.
+func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"packer_build_name":          &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
+		"packer_builder_type":        &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
+		"packer_core_version":        &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
+		"packer_debug":               &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
+		"packer_force":               &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
+		"packer_on_error":            &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_user_variables":      &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
+		"packer_sensitive_variables": &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
+		"api_token":                  &hcldec.AttrSpec{Name: "api_token", Type: cty.String, Required: false},
+		"api_url":                    &hcldec.AttrSpec{Name: "api_url", Type: cty.String, Required: false},
+		"retry":                      &hcldec.BlockSpec{TypeName: "retry", Nested: hcldec.ObjectSpec((*digitalocean.FlatRetryConfig)(nil).HCL2Spec())},
+		"spaces_key":                 &hcldec.AttrSpec{Name: "spaces_key", Type: cty.String, Required: false},
+		"spaces_secret":              &hcldec.AttrSpec{Name: "spaces_secret", Type: cty.String, Required: false},
+		"space_name":                 &hcldec.AttrSpec{Name: "space_name", Type: cty.String, Required: false},
+		"spaces_region":              &hcldec.AttrSpec{Name: "spaces_region", Type: cty.String, Required: false},
+		"skip_clean":                 &hcldec.AttrSpec{Name: "skip_clean", Type: cty.Bool, Required: false},
+		"source_volume_snapshot_id":  &hcldec.AttrSpec{Name: "source_volume_snapshot_id", Type: cty.String, Required: false},
+		"from_scratch":               &hcldec.AttrSpec{Name: "from_scratch", Type: cty.Bool, Required: false},
+		"volume_size":                &hcldec.AttrSpec{Name: "volume_size", Type: cty.Number, Required: false},
+		"pre_mount_commands":         &hcldec.AttrSpec{Name: "pre_mount_commands", Type: cty.List(cty.String), Required: false},
+		"mount_path":                 &hcldec.AttrSpec{Name: "mount_path", Type: cty.String, Required: false},
+		"mount_partition":            &hcldec.AttrSpec{Name: "mount_partition", Type: cty.String, Required: false},
+		"mount_options":              &hcldec.AttrSpec{Name: "mount_options", Type: cty.List(cty.String), Required: false},
+		"post_mount_commands":        &hcldec.AttrSpec{Name: "post_mount_commands", Type: cty.List(cty.String), Required: false},
+		"chroot_mounts":              &hcldec.AttrSpec{Name: "chroot_mounts", Type: cty.List(cty.List(cty.String)), Required: false},
+		"copy_files":                 &hcldec.AttrSpec{Name: "copy_files", Type: cty.List(cty.String), Required: false},
+		"command_wrapper":            &hcldec.AttrSpec{Name: "command_wrapper", Type: cty.String, Required: false},
+		"image_name":                 &hcldec.AttrSpec{Name: "image_name", Type: cty.String, Required: false},
+		"image_description":          &hcldec.AttrSpec{Name: "image_description", Type: cty.String, Required: false},
+		"image_distribution":         &hcldec.AttrSpec{Name: "image_distribution", Type: cty.String, Required: false},
+		"image_tags":                 &hcldec.AttrSpec{Name: "image_tags", Type: cty.List(cty.String), Required: false},
+		"image_regions":              &hcldec.AttrSpec{Name: "image_regions", Type: cty.List(cty.String), Required: false},
+		"volume_snapshot_name":       &hcldec.AttrSpec{Name: "volume_snapshot_name", Type: cty.String, Required: false},
+		"state_timeout":              &hcldec.AttrSpec{Name: "state_timeout", Type: cty.String, Required: false},
+		"image_timeout":              &hcldec.AttrSpec{Name: "image_timeout", Type: cty.String, Required: false},
+		"hcp_image_id_format":        &hcldec.AttrSpec{Name: "hcp_image_id_format", Type: cty.String, Required: false},
+	}
+	return s
+}
@@ -0,0 +1,119 @@
+package digitaloceanchroot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// volumeDevicePrefix is where udev links the devices of the attached
+// volumes, by volume name.
+const volumeDevicePrefix = "/dev/disk/by-id/scsi-0DO_Volume_"
+
+// waitInterval is how long the waits for actions and devices wait between
+// checks.
+const waitInterval = 3 * time.Second
+
+// stepAttachVolume attaches the volume to the worker droplet, waits for its
+// device to appear, and detaches it again once the build is over.
+type stepAttachVolume struct {
+	volumeID  string
+	dropletID int
+}
+
+func (s *stepAttachVolume) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	worker := state.Get("worker").(*godo.Droplet)
+	volume := state.Get("volume").(*godo.Volume)
+
+	ui.Say(fmt.Sprintf("Attaching volume %s to the worker droplet...", volume.Name))
+	action, _, err := client.StorageActions.Attach(ctx, volume.ID, worker.ID)
+	if err == nil {
+		err = waitForVolumeAction(ctx, client, volume.ID, action.ID, c.StateTimeout)
+	}
+	if err != nil {
+		err := fmt.Errorf("Error attaching volume: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	s.volumeID = volume.ID
+	s.dropletID = worker.ID
+
+	device := volumeDevicePrefix + volume.Name
+	if err := waitForDevice(ctx, device, c.StateTimeout); err != nil {
+		err := fmt.Errorf("Error waiting for the volume's device: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	log.Printf("Volume device: %s", device)
+
+	state.Put("device", device)
+	return multistep.ActionContinue
+}
+
+func (s *stepAttachVolume) Cleanup(state multistep.StateBag) {
+	if s.volumeID == "" {
+		return
+	}
+
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	ui.Say("Detaching volume from the worker droplet...")
+	action, _, err := client.StorageActions.DetachByDropletID(context.TODO(), s.volumeID, s.dropletID)
+	if err == nil {
+		err = waitForVolumeAction(context.TODO(), client, s.volumeID, action.ID, c.StateTimeout)
+	}
+	if err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error detaching volume. Please detach and delete it manually: %s", err))
+	}
+}
+
+// waitForVolumeAction blocks until the volume action has completed, failing
+// if it errors or once timeout elapses.
+func waitForVolumeAction(ctx context.Context, client *godo.Client, volumeID string, actionID int, timeout time.Duration) error {
+	err := digitalocean.PollEvery(ctx, waitInterval, timeout, func(ctx context.Context, attempt int) (bool, error) {
+		log.Printf("Checking volume action status... (attempt: %d)", attempt)
+		action, _, err := client.StorageActions.Get(ctx, volumeID, actionID)
+		if err != nil {
+			return false, err
+		}
+		switch action.Status {
+		case godo.ActionCompleted:
+			return true, nil
+		case "errored":
+			return false, fmt.Errorf("volume action %d errored", actionID)
+		}
+		return false, nil
+	})
+	if err == digitalocean.ErrPollTimeout {
+		return fmt.Errorf("Timeout while waiting for volume action %d to complete", actionID)
+	}
+	return err
+}
+
+// waitForDevice blocks until the device path exists, once udev has linked
+// it, or timeout elapses.
+func waitForDevice(ctx context.Context, device string, timeout time.Duration) error {
+	err := digitalocean.PollEvery(ctx, waitInterval, timeout, func(context.Context, int) (bool, error) {
+		_, err := os.Stat(device)
+		return err == nil, nil
+	})
+	if err == digitalocean.ErrPollTimeout {
+		return fmt.Errorf("%s didn't appear in time", device)
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package digitaloceanchroot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/uuid"
+)
+
+// stepCreateVolume creates the volume the image is built on in the worker
+// droplet's region, from source_volume_snapshot_id or empty, and deletes it
+// once the build is over.
+type stepCreateVolume struct {
+	volumeID string
+}
+
+func (s *stepCreateVolume) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	worker := state.Get("worker").(*godo.Droplet)
+
+	size := c.VolumeSize
+	if c.SourceVolumeSnapshotID != "" {
+		snapshot, _, err := client.Snapshots.Get(ctx, c.SourceVolumeSnapshotID)
+		if err != nil {
+			err := fmt.Errorf("Error retrieving source volume snapshot %s: %s", c.SourceVolumeSnapshotID, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		if err := checkSourceSnapshot(snapshot, worker.Region.Slug); err != nil {
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		if size == 0 {
+			size = int64(snapshot.MinDiskSize)
+		}
+	}
+
+	name := fmt.Sprintf("packer-%s", uuid.TimeOrderedUUID())
+	ui.Say(fmt.Sprintf("Creating %d GiB volume %s...", size, name))
+	volume, _, err := client.Storage.CreateVolume(ctx, &godo.VolumeCreateRequest{
+		Region:        worker.Region.Slug,
+		Name:          name,
+		Description:   fmt.Sprintf("Packer chroot volume for %s", c.ImageName),
+		SizeGigaBytes: size,
+		SnapshotID:    c.SourceVolumeSnapshotID,
+	})
+	if err != nil {
+		err := fmt.Errorf("Error creating volume: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	s.volumeID = volume.ID
+	log.Printf("Volume ID: %s", volume.ID)
+	state.Put("volume", volume)
+	return multistep.ActionContinue
+}
+
+func (s *stepCreateVolume) Cleanup(state multistep.StateBag) {
+	if s.volumeID == "" {
+		return
+	}
+
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	ui.Say(fmt.Sprintf("Deleting volume %s...", s.volumeID))
+	if _, err := client.Storage.DeleteVolume(context.TODO(), s.volumeID); err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error deleting volume. Please delete it manually: %s", err))
+	}
+}
+
+// checkSourceSnapshot checks that snapshot is a volume snapshot a volume
+// can be created from in region.
+func checkSourceSnapshot(snapshot *godo.Snapshot, region string) error {
+	if snapshot.ResourceType != "volume" {
+		return fmt.Errorf("source_volume_snapshot_id %s is a %s snapshot, not a volume snapshot",
+			snapshot.ID, snapshot.ResourceType)
+	}
+	for _, r := range snapshot.Regions {
+		if r == region {
+			return nil
+		}
+	}
+	return fmt.Errorf("source_volume_snapshot_id %s is not available in %s, the region of the worker droplet",
+		snapshot.ID, region)
+}
@@ -0,0 +1,81 @@
+package digitaloceanchroot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepCreateVolume(t *testing.T) {
+	var created godo.VolumeCreateRequest
+	deleted := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/snapshots/fbe805e8":
+			w.Write([]byte(`{"snapshot": {"id": "fbe805e8", "resource_type": "volume", "regions": ["nyc3"], "min_disk_size": 10}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/volumes":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"volume": {"id": "506f78a4", "name": "` + created.Name + `"}}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/volumes/506f78a4":
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("config", &Config{SourceVolumeSnapshotID: "fbe805e8", ImageName: "app"})
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("worker", &godo.Droplet{ID: 3164444, Region: &godo.Region{Slug: "nyc3"}})
+
+	step := new(stepCreateVolume)
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	if created.Region != "nyc3" || created.SizeGigaBytes != 10 || created.SnapshotID != "fbe805e8" {
+		t.Errorf("bad volume request: %+v", created)
+	}
+	if !strings.HasPrefix(created.Name, "packer-") {
+		t.Errorf("bad volume name: %s", created.Name)
+	}
+
+	step.Cleanup(state)
+	if !deleted {
+		t.Fatal("should have deleted the volume")
+	}
+}
+
+func TestCheckSourceSnapshot(t *testing.T) {
+	snapshot := &godo.Snapshot{ID: "fbe805e8", ResourceType: "volume", Regions: []string{"nyc3", "sfo3"}}
+	if err := checkSourceSnapshot(snapshot, "sfo3"); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if err := checkSourceSnapshot(snapshot, "ams3"); err == nil || !strings.Contains(err.Error(), "not available in ams3") {
+		t.Fatalf("bad error: %v", err)
+	}
+
+	snapshot.ResourceType = "droplet"
+	if err := checkSourceSnapshot(snapshot, "nyc3"); err == nil || !strings.Contains(err.Error(), "not a volume snapshot") {
+		t.Fatalf("bad error: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package digitaloceanchroot
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/digitalocean/godo"
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// exportPartSize is the size of the parts the disk image is uploaded in.
+// Uploads have at most 10,000 parts, so this allows images of up to 625
+// GiB once compressed.
+const exportPartSize = 64 * 1024 * 1024
+
+// stepExportVolume uploads the volume's disk, compressed with gzip, to
+// space_name for the import, since DigitalOcean can only create custom
+// images from a URL. The object is deleted once the build is over unless
+// skip_clean is set.
+type stepExportVolume struct {
+	svc    s3iface.S3API
+	bucket string
+	key    string
+}
+
+func (s *stepExportVolume) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	worker := state.Get("worker").(*godo.Droplet)
+	volume := state.Get("volume").(*godo.Volume)
+	device := state.Get("device").(string)
+
+	region := c.SpacesRegion
+	if region == "" {
+		region = worker.Region.Slug
+	}
+	svc, err := digitalocean.SpacesClient(c.SpacesKey, c.SpacesSecret, region)
+	if err != nil {
+		err := fmt.Errorf("Error creating Spaces client: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	key := fmt.Sprintf("packer-chroot/%s.img.gz", volume.Name)
+	ui.Say(fmt.Sprintf("Uploading the volume's disk image to spaces://%s/%s...", c.SpaceName, key))
+	if err := exportDevice(ctx, svc, device, c.SpaceName, key); err != nil {
+		err := fmt.Errorf("Error uploading the volume's disk image: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	s.svc, s.bucket, s.key = svc, c.SpaceName, key
+
+	// The import fetches the image through a presigned URL, so the object
+	// doesn't have to be public
+	req, _ := svc.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(c.SpaceName),
+		Key:    aws.String(key),
+	})
+	url, err := req.Presign(c.ImageTimeout)
+	if err != nil {
+		err := fmt.Errorf("Error presigning the disk image URL: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	state.Put("image_url", url)
+	return multistep.ActionContinue
+}
+
+func (s *stepExportVolume) Cleanup(state multistep.StateBag) {
+	c := state.Get("config").(*Config)
+	if s.key == "" || c.SkipClean {
+		return
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+
+	ui.Say(fmt.Sprintf("Deleting spaces://%s/%s...", s.bucket, s.key))
+	_, err := s.svc.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+	})
+	if err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error deleting the disk image. Please delete it manually: %s", err))
+	}
+}
+
+// exportDevice uploads the contents of device to key in bucket, compressed
+// with gzip as it is read.
+func exportDevice(ctx context.Context, svc s3iface.S3API, device, bucket, key string) error {
+	f, err := os.Open(device)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		gz := gzip.NewWriter(pw)
+		n, err := io.Copy(gz, f)
+		if err == nil {
+			err = gz.Close()
+		}
+		log.Printf("Read %d bytes from %s", n, device)
+		pw.CloseWithError(err)
+	}()
+	defer pr.Close()
+
+	uploader := s3manager.NewUploaderWithClient(svc, func(u *s3manager.Uploader) {
+		u.PartSize = exportPartSize
+	})
+	_, err = uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   pr,
+	})
+	return err
+}
@@ -0,0 +1,78 @@
+package digitaloceanchroot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/digitalocean/godo"
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepImportImage creates the custom image from the exported disk image in
+// the first of image_regions, and deletes it again if the build fails.
+type stepImportImage struct {
+	imageID int
+}
+
+func (s *stepImportImage) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	worker := state.Get("worker").(*godo.Droplet)
+	url := state.Get("image_url").(string)
+
+	region := worker.Region.Slug
+	if len(c.ImageRegions) > 0 {
+		region = c.ImageRegions[0]
+	}
+
+	ui.Say(fmt.Sprintf("Importing custom image %s in %s...", c.ImageName, region))
+	image, _, err := client.Images.Create(ctx, &godo.CustomImageCreateRequest{
+		Name:         c.ImageName,
+		Url:          url,
+		Region:       region,
+		Distribution: c.ImageDistribution,
+		Description:  c.ImageDescription,
+		Tags:         c.ImageTags,
+	})
+	if err != nil {
+		err := fmt.Errorf("Error importing custom image: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	s.imageID = image.ID
+	log.Printf("Custom image ID: %d", image.ID)
+
+	ui.Message("Waiting for the import to complete (may take a while)...")
+	if err := digitalocean.WaitForImageAvailable(ctx, client, image.ID, c.ImageTimeout); err != nil {
+		err := fmt.Errorf("Error importing custom image: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	state.Put("image", image)
+	state.Put("regions", []string{region})
+	return multistep.ActionContinue
+}
+
+func (s *stepImportImage) Cleanup(state multistep.StateBag) {
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if s.imageID == 0 || (!cancelled && !halted) {
+		return
+	}
+
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	ui.Say(fmt.Sprintf("Deleting custom image %d...", s.imageID))
+	if _, err := client.Images.Delete(context.TODO(), s.imageID); err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error deleting custom image. Please delete it manually: %s", err))
+	}
+}
@@ -0,0 +1,138 @@
+package digitaloceanchroot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+type mountPathData struct {
+	Device string
+}
+
+// stepMountDevice mounts the volume's root filesystem at mount_path, and
+// unmounts it once the build is over unless stepUnmount already has.
+type stepMountDevice struct {
+	mountPath string
+}
+
+func (s *stepMountDevice) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	device := state.Get("device").(string)
+	wrappedCommand := state.Get("wrappedCommand").(common.CommandWrapper)
+
+	ictx := c.ctx
+	ictx.Data = &mountPathData{Device: filepath.Base(device)}
+	mountPath, err := interpolate.Render(c.MountPath, &ictx)
+	if err != nil {
+		err := fmt.Errorf("Error preparing mount directory: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	mountPath, err = filepath.Abs(mountPath)
+	if err != nil {
+		err := fmt.Errorf("Error preparing mount directory: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	log.Printf("Mount path: %s", mountPath)
+
+	if err := os.MkdirAll(mountPath, 0755); err != nil {
+		err := fmt.Errorf("Error creating mount directory: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	source := partitionDevice(device, c.MountPartition)
+	// The partitions of a volume formatted by pre_mount_commands take a
+	// moment to be linked
+	if err := waitForDevice(ctx, source, c.StateTimeout); err != nil {
+		err := fmt.Errorf("Error waiting for the partition to mount: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say("Mounting the root device...")
+	opts := ""
+	if len(c.MountOptions) > 0 {
+		opts = "-o " + strings.Join(c.MountOptions, " -o ")
+	}
+	mountCommand, err := wrappedCommand(fmt.Sprintf("mount %s %s %s", opts, source, mountPath))
+	if err != nil {
+		err := fmt.Errorf("Error creating mount command: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	log.Printf("[DEBUG] (step mount) mount command is %s", mountCommand)
+	stderr := new(bytes.Buffer)
+	cmd := common.ShellCommand(mountCommand)
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		err := fmt.Errorf("Error mounting root volume: %s\nStderr: %s", err, stderr.String())
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	s.mountPath = mountPath
+	state.Put("mount_path", mountPath)
+	state.Put("mount_device_cleanup", s)
+	return multistep.ActionContinue
+}
+
+func (s *stepMountDevice) Cleanup(state multistep.StateBag) {
+	ui := state.Get("ui").(packersdk.Ui)
+	if err := s.CleanupFunc(state); err != nil {
+		ui.Error(err.Error())
+	}
+}
+
+func (s *stepMountDevice) CleanupFunc(state multistep.StateBag) error {
+	if s.mountPath == "" {
+		return nil
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	wrappedCommand := state.Get("wrappedCommand").(common.CommandWrapper)
+
+	ui.Say("Unmounting the root device...")
+	unmountCommand, err := wrappedCommand(fmt.Sprintf("umount %s", s.mountPath))
+	if err != nil {
+		return fmt.Errorf("Error creating unmount command: %s", err)
+	}
+
+	stderr := new(bytes.Buffer)
+	cmd := common.ShellCommand(unmountCommand)
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Error unmounting root device: %s\nStderr: %s", err, stderr.String())
+	}
+
+	s.mountPath = ""
+	return nil
+}
+
+// partitionDevice returns the device of the partition of device, or device
+// itself for partition "0". udev links the partitions of the volumes with a
+// -partN suffix.
+func partitionDevice(device, partition string) string {
+	if partition == "0" {
+		return device
+	}
+	return device + "-part" + partition
+}
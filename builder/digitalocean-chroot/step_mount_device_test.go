@@ -0,0 +1,13 @@
+package digitaloceanchroot
+
+import "testing"
+
+func TestPartitionDevice(t *testing.T) {
+	device := volumeDevicePrefix + "packer-1234"
+	if got := partitionDevice(device, "1"); got != device+"-part1" {
+		t.Errorf("bad partition: %s", got)
+	}
+	if got := partitionDevice(device, "0"); got != device {
+		t.Errorf("bad whole device: %s", got)
+	}
+}
@@ -0,0 +1,73 @@
+package digitaloceanchroot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepSnapshotVolume snapshots the provisioned volume with
+// volume_snapshot_name, for later builds to start from. The snapshot is
+// recorded in the state as volume_snapshots for the artifact, and deleted
+// again if the build fails.
+type stepSnapshotVolume struct {
+	snapshotID string
+}
+
+func (s *stepSnapshotVolume) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	volume := state.Get("volume").(*godo.Volume)
+
+	if c.VolumeSnapshotName == "" {
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Creating snapshot of the volume: %s", c.VolumeSnapshotName))
+	snapshot, _, err := client.Storage.CreateSnapshot(ctx, &godo.SnapshotCreateRequest{
+		VolumeID: volume.ID,
+		Name:     c.VolumeSnapshotName,
+	})
+	if err != nil {
+		err := fmt.Errorf("Error creating snapshot of the volume: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	s.snapshotID = snapshot.ID
+	log.Printf("Volume snapshot ID: %s", snapshot.ID)
+
+	state.Put("volume_snapshots", []interface{}{
+		map[string]string{
+			"id":        snapshot.ID,
+			"name":      snapshot.Name,
+			"volume_id": volume.ID,
+			"regions":   strings.Join(snapshot.Regions, ","),
+		},
+	})
+	return multistep.ActionContinue
+}
+
+func (s *stepSnapshotVolume) Cleanup(state multistep.StateBag) {
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if s.snapshotID == "" || (!cancelled && !halted) {
+		return
+	}
+
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	ui.Say(fmt.Sprintf("Deleting volume snapshot %s...", s.snapshotID))
+	if _, err := client.Snapshots.Delete(context.TODO(), s.snapshotID); err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error deleting volume snapshot. Please delete it manually: %s", err))
+	}
+}
@@ -0,0 +1,79 @@
+package digitaloceanchroot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"golang.org/x/sync/errgroup"
+)
+
+// stepTransferImage transfers the custom image to the rest of
+// image_regions.
+type stepTransferImage struct{}
+
+func (s *stepTransferImage) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	image := state.Get("image").(*godo.Image)
+	regions := state.Get("regions").([]string)
+
+	regionSet := map[string]bool{regions[0]: true}
+	var transfers []string
+	for _, region := range c.ImageRegions {
+		if regionSet[region] {
+			continue
+		}
+		regionSet[region] = true
+		transfers = append(transfers, region)
+	}
+	if len(transfers) == 0 {
+		return multistep.ActionContinue
+	}
+
+	eg, gCtx := errgroup.WithContext(ctx)
+	for _, r := range transfers {
+		region := r
+		eg.Go(func() error {
+			transferRequest := &godo.ActionRequest{
+				"type":   "transfer",
+				"region": region,
+			}
+
+			ui.Say(fmt.Sprintf("Transferring custom image (ID: %d) to %s...", image.ID, region))
+			imageTransfer, _, err := client.ImageActions.Transfer(gCtx, image.ID, transferRequest)
+			if err != nil {
+				return fmt.Errorf("Error transferring custom image: %s", err)
+			}
+
+			if err := digitalocean.WaitForImageStateContext(
+				gCtx,
+				godo.ActionCompleted,
+				image.ID,
+				imageTransfer.ID,
+				client, c.ImageTimeout); err != nil {
+				return fmt.Errorf("Error waiting for custom image transfer: %s", err)
+			}
+			ui.Say(fmt.Sprintf("Transfer to %s is complete.", region))
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	state.Put("regions", append(regions, transfers...))
+
+	return multistep.ActionContinue
+}
+
+func (s *stepTransferImage) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
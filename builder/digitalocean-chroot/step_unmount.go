@@ -0,0 +1,42 @@
+package digitaloceanchroot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/packer-plugin-sdk/chroot"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepUnmount removes the copied files and unmounts the chroot once it is
+// provisioned, so that the volume can be snapshotted and exported. Unlike
+// chroot.StepEarlyCleanup it leaves the volume attached, since the export
+// reads it from the worker droplet.
+type stepUnmount struct{}
+
+func (s *stepUnmount) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+
+	for _, key := range []string{
+		"copy_files_cleanup",
+		"mount_extra_cleanup",
+		"mount_device_cleanup",
+	} {
+		c := state.Get(key).(chroot.Cleanup)
+		log.Printf("Running cleanup func: %s", key)
+		if err := c.CleanupFunc(state); err != nil {
+			err := fmt.Errorf("Error cleaning up: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepUnmount) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
@@ -0,0 +1,82 @@
+package digitaloceanchroot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// metadataIDURL is where the metadata service tells a droplet its ID.
+var metadataIDURL = "http://169.254.169.254/metadata/v1/id"
+
+// stepWorkerInfo finds the droplet Packer runs on, which the volume is
+// attached to, through the metadata service.
+type stepWorkerInfo struct{}
+
+func (s *stepWorkerInfo) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	ui.Say("Finding the worker droplet...")
+	id, err := workerDropletID(ctx)
+	if err != nil {
+		err := fmt.Errorf("Error finding the droplet Packer runs on, which the digitalocean-chroot builder requires: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	droplet, _, err := client.Droplets.Get(ctx, id)
+	if err != nil {
+		err := fmt.Errorf("Error retrieving the worker droplet %d: %s", id, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	ui.Message(fmt.Sprintf("Worker droplet: %s (ID: %d) in region %s", droplet.Name, droplet.ID, droplet.Region.Slug))
+
+	state.Put("worker", droplet)
+	return multistep.ActionContinue
+}
+
+func (s *stepWorkerInfo) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// workerDropletID asks the metadata service for the ID of the droplet
+// Packer runs on.
+func workerDropletID(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataIDURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("the metadata service answered %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(string(body)))
+	if err != nil {
+		return 0, fmt.Errorf("the metadata service returned an invalid droplet ID: %q", body)
+	}
+	return id, nil
+}
@@ -0,0 +1,27 @@
+package digitaloceanchroot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWorkerDropletID(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("3164444\n"))
+	}))
+	defer ts.Close()
+
+	old := metadataIDURL
+	metadataIDURL = ts.URL
+	defer func() { metadataIDURL = old }()
+
+	id, err := workerDropletID(context.Background())
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if id != 3164444 {
+		t.Fatalf("bad droplet ID: %d", id)
+	}
+}
@@ -0,0 +1,29 @@
+package digitaloceansnapshotcopy
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// Artifact is the snapshot the builder released. It is the same artifact
+// the digitalocean builder produces, so post-processors accept it, except
+// that destroying it leaves the snapshot alone: the build didn't create it.
+type Artifact struct {
+	*digitalocean.Artifact
+}
+
+var _ packersdk.Artifact = new(Artifact)
+
+func (a *Artifact) Destroy() error {
+	log.Printf("Not destroying image %d (%s): it existed before the build", a.SnapshotId, a.SnapshotName)
+	return nil
+}
+
+func (a *Artifact) String() string {
+	return fmt.Sprintf("A snapshot was released: '%v' (ID: %v) in regions '%v'",
+		a.SnapshotName, a.SnapshotId, strings.Join(a.RegionNames, ","))
+}
@@ -0,0 +1,94 @@
+// The digitaloceansnapshotcopy package contains a packersdk.Builder
+// implementation that releases an existing DigitalOcean snapshot, without
+// creating a droplet.
+
+package digitaloceansnapshotcopy
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/digitalocean/godo"
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+type Builder struct {
+	config Config
+	runner multistep.Runner
+}
+
+var _ packersdk.Builder = new(Builder)
+
+func (b *Builder) ConfigSpec() hcldec.ObjectSpec { return b.config.FlatMapstructure().HCL2Spec() }
+
+func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
+	if err := b.config.Prepare(raws...); err != nil {
+		return nil, nil, err
+	}
+	return nil, nil, nil
+}
+
+func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook) (packersdk.Artifact, error) {
+	client, err := newClient(&b.config)
+	if err != nil {
+		return nil, err
+	}
+
+	state := new(multistep.BasicStateBag)
+	state.Put("config", &b.config)
+	state.Put("client", client)
+	state.Put("hook", hook)
+	state.Put("ui", ui)
+
+	steps := []multistep.Step{
+		new(stepSnapshotInfo),
+		new(stepRenameSnapshot),
+		new(stepTagSnapshot),
+		new(stepTransferSnapshot),
+	}
+
+	b.runner = commonsteps.NewRunner(steps, b.config.PackerConfig, ui)
+	b.runner.Run(ctx, state)
+
+	if rawErr, ok := state.GetOk("error"); ok {
+		return nil, rawErr.(error)
+	}
+
+	// If we were interrupted or cancelled, then just exit.
+	if _, ok := state.GetOk(multistep.StateCancelled); ok {
+		return nil, nil
+	}
+	if _, ok := state.GetOk(multistep.StateHalted); ok {
+		return nil, nil
+	}
+
+	rawImage, ok := state.GetOk("image")
+	if !ok {
+		log.Println("Failed to find image in state. Bug?")
+		return nil, nil
+	}
+	image := rawImage.(*godo.Image)
+	artifact := &Artifact{
+		Artifact: &digitalocean.Artifact{
+			SnapshotName: image.Name,
+			SnapshotId:   image.ID,
+			RegionNames:  state.Get("regions").([]string),
+			Client:       client,
+			StateData: map[string]interface{}{
+				"source_image_id":     fmt.Sprint(image.ID),
+				"hcp_image_id_format": b.config.HCPImageIDFormat,
+			},
+		},
+	}
+
+	return artifact, nil
+}
+
+func newClient(c *Config) (*godo.Client, error) {
+	return digitalocean.NewClient(c.APIToken, c.APIURL, &c.Retry)
+}
@@ -0,0 +1,147 @@
+package digitaloceansnapshotcopy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"api_token":   "bar",
+		"snapshot_id": 7555620,
+	}
+}
+
+func TestBuilder_ImplementsBuilder(t *testing.T) {
+	var _ packersdk.Builder = new(Builder)
+}
+
+func TestBuilderPrepare(t *testing.T) {
+	var b Builder
+	if _, _, err := b.Prepare(testConfig()); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !*b.config.WaitSnapshotTransfer {
+		t.Fatal("wait_snapshot_transfer should default to true")
+	}
+	if b.config.TransferTimeout == 0 {
+		t.Fatal("transfer_timeout should have a default")
+	}
+
+	b = Builder{}
+	config := testConfig()
+	delete(config, "snapshot_id")
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error without snapshot_id")
+	}
+
+	b = Builder{}
+	config = testConfig()
+	config["hcp_image_id_format"] = "{{ .Snapshot }}"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error with an invalid hcp_image_id_format")
+	}
+}
+
+func TestBuilderRun(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+string(body)))
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/images/7555620":
+			w.Write([]byte(`{"image": {"id": 7555620, "name": "packer-1", "status": "available", "regions": ["nyc3"]}}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/images/7555620":
+			w.Write([]byte(`{"image": {"id": 7555620, "name": "release-1", "status": "available", "regions": ["nyc3"]}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/tags":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"tag": {"name": "release"}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/tags/release/resources":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/images/7555620/actions":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"action": {"id": 36805022, "status": "in-progress", "type": "transfer"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/images/7555620/actions/36805022":
+			w.Write([]byte(`{"action": {"id": 36805022, "status": "completed", "type": "transfer"}}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	var b Builder
+	config := testConfig()
+	config["api_url"] = ts.URL
+	config["snapshot_name"] = "release-1"
+	config["snapshot_tags"] = []string{"release"}
+	config["snapshot_regions"] = []string{"nyc3", "sfo3"}
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	var out bytes.Buffer
+	ui := &packersdk.BasicUi{Writer: &out, ErrorWriter: &out}
+	artifact, err := b.Run(context.Background(), ui, new(packersdk.MockHook))
+	if err != nil {
+		t.Fatalf("should not have error: %s: %s", err, out.String())
+	}
+
+	expected := []string{
+		"GET /v2/images/7555620",
+		`PUT /v2/images/7555620 {"name":"release-1"}`,
+		`POST /v2/tags {"name":"release"}`,
+		`POST /v2/tags/release/resources {"resources":[{"resource_id":"7555620","resource_type":"image"}]}`,
+		`POST /v2/images/7555620/actions {"region":"sfo3","type":"transfer"}`,
+		"GET /v2/images/7555620/actions/36805022",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Fatalf("bad requests: %#v", requests)
+	}
+	if artifact.Id() != "nyc3,sfo3:7555620" {
+		t.Fatalf("bad id: %s", artifact.Id())
+	}
+	if !strings.Contains(artifact.String(), "'release-1'") {
+		t.Fatalf("bad string: %s", artifact.String())
+	}
+
+	// The snapshot existed before the build, so it must not be deleted.
+	requests = nil
+	if err := artifact.Destroy(); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(requests) > 0 {
+		t.Fatalf("should not make requests: %v", requests)
+	}
+}
+
+func TestBuilderRun_Cancelled(t *testing.T) {
+	var b Builder
+	if _, _, err := b.Prepare(testConfig()); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	// The build is cancelled before any step runs, so no snapshot is in the
+	// state.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var out bytes.Buffer
+	ui := &packersdk.BasicUi{Writer: &out, ErrorWriter: &out}
+	artifact, err := b.Run(ctx, ui, new(packersdk.MockHook))
+	if err != nil {
+		t.Fatalf("should not have error: %s: %s", err, out.String())
+	}
+	if artifact != nil {
+		t.Fatalf("should not have artifact: %#v", artifact)
+	}
+}
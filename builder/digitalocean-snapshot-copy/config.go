@@ -0,0 +1,114 @@
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+package digitaloceansnapshotcopy
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// A personal access token used to communicate with the DigitalOcean v2 API.
+	// This may also be set using the `DIGITALOCEAN_TOKEN` or
+	// `DIGITALOCEAN_ACCESS_TOKEN` environmental variables.
+	APIToken string `mapstructure:"api_token" required:"true"`
+	// Non standard api endpoint URL. Set this if you are
+	// using a DigitalOcean API compatible service. It can also be specified via
+	// environment variable DIGITALOCEAN_API_URL.
+	APIURL string `mapstructure:"api_url"`
+	// Controls how failed API requests are retried. See the
+	// [retry configuration](#retry-configuration) section below.
+	Retry digitalocean.RetryConfig `mapstructure:"retry" required:"false"`
+	// The ID of the existing snapshot to release.
+	SnapshotID int `mapstructure:"snapshot_id" required:"true"`
+	// A new name for the snapshot. Defaults to keeping its name.
+	SnapshotName string `mapstructure:"snapshot_name" required:"false"`
+	// Tags to apply to the snapshot.
+	SnapshotTags []string `mapstructure:"snapshot_tags" required:"false"`
+	// Regions to transfer the snapshot to. Regions the snapshot is already
+	// available in are skipped.
+	SnapshotRegions []string `mapstructure:"snapshot_regions" required:"false"`
+	// When true, Packer will block until all snapshot transfers have been completed
+	// and report errors. When false, Packer will initiate the snapshot transfers
+	// and exit successfully without waiting for completion. Defaults to true.
+	WaitSnapshotTransfer *bool `mapstructure:"wait_snapshot_transfer" required:"false"`
+	// How long to wait for a snapshot to be transferred to an additional region
+	// before timing out. The default transfer timeout is "30m" (valid time units
+	// include `s` for seconds, `m` for minutes, and `h` for hours).
+	TransferTimeout time.Duration `mapstructure:"transfer_timeout" required:"false"`
+	// The format of the image IDs reported to HCP Packer for each region of
+	// the snapshot, as a template with the `{{ .ID }}`, `{{ .Name }}` and
+	// `{{ .Region }}` variables, such as `{{ .Region }}:{{ .ID }}`. Defaults
+	// to `{{ .ID }}`, the snapshot ID.
+	HCPImageIDFormat string `mapstructure:"hcp_image_id_format" required:"false"`
+
+	ctx interpolate.Context
+}
+
+func (c *Config) Prepare(raws ...interface{}) error {
+	err := config.Decode(c, &config.DecodeOpts{
+		Interpolate:        true,
+		InterpolateContext: &c.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{
+				"hcp_image_id_format",
+			},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if c.APIToken == "" {
+		c.APIToken = os.Getenv("DIGITALOCEAN_TOKEN")
+	}
+	if c.APIToken == "" {
+		c.APIToken = os.Getenv("DIGITALOCEAN_ACCESS_TOKEN")
+	}
+	if c.APIURL == "" {
+		c.APIURL = os.Getenv("DIGITALOCEAN_API_URL")
+	}
+	if c.WaitSnapshotTransfer == nil {
+		c.WaitSnapshotTransfer = godo.PtrTo(true)
+	}
+	if c.TransferTimeout == 0 {
+		c.TransferTimeout = 30 * time.Minute
+	}
+	if c.HCPImageIDFormat == "" {
+		c.HCPImageIDFormat = digitalocean.DefaultHCPImageIDFormat
+	}
+
+	errs := new(packersdk.MultiError)
+
+	if es := c.Retry.Prepare(nil, nil, nil); len(es) > 0 {
+		errs = packersdk.MultiErrorAppend(errs, es...)
+	}
+
+	if c.APIToken == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("api_token must be set"))
+	}
+	if c.SnapshotID == 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("snapshot_id must be set"))
+	}
+	if err := digitalocean.ValidateHCPImageIDFormat(c.HCPImageIDFormat); err != nil {
+		errs = packersdk.MultiErrorAppend(errs, err)
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(c.APIToken)
+	return nil
+}
@@ -0,0 +1,43 @@
+package digitaloceansnapshotcopy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepRenameSnapshot renames the snapshot to snapshot_name.
+type stepRenameSnapshot struct{}
+
+func (s *stepRenameSnapshot) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	image := state.Get("image").(*godo.Image)
+
+	if c.SnapshotName == "" || c.SnapshotName == image.Name {
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Renaming snapshot %q to %q...", image.Name, c.SnapshotName))
+	renamed, _, err := client.Images.Update(ctx, image.ID, &godo.ImageUpdateRequest{Name: c.SnapshotName})
+	if err != nil {
+		err := fmt.Errorf("Error renaming snapshot: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if renamed.Name == "" {
+		renamed.Name = c.SnapshotName
+	}
+	state.Put("image", renamed)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepRenameSnapshot) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
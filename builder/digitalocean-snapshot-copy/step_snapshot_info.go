@@ -0,0 +1,51 @@
+package digitaloceansnapshotcopy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepSnapshotInfo looks up the snapshot set with snapshot_id, which must
+// be a snapshot of the account.
+type stepSnapshotInfo struct{}
+
+func (s *stepSnapshotInfo) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	ui.Say(fmt.Sprintf("Looking up snapshot (ID: %d)...", c.SnapshotID))
+	image, _, err := client.Images.GetByID(ctx, c.SnapshotID)
+	if err != nil {
+		err := fmt.Errorf("Error looking up snapshot %d: %s", c.SnapshotID, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if image.Public {
+		err := fmt.Errorf("Image %d (%s) is a public image, not a snapshot of the account", image.ID, image.Name)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if image.Status != "" && image.Status != "available" {
+		err := fmt.Errorf("Snapshot %d (%s) is %s, not available", image.ID, image.Name, image.Status)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Message(fmt.Sprintf("Snapshot %q is available in %v", image.Name, image.Regions))
+	state.Put("image", image)
+	state.Put("regions", append([]string(nil), image.Regions...))
+
+	return multistep.ActionContinue
+}
+
+func (s *stepSnapshotInfo) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
@@ -0,0 +1,42 @@
+package digitaloceansnapshotcopy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepTagSnapshot applies snapshot_tags to the snapshot, creating the tags
+// that don't exist yet.
+type stepTagSnapshot struct{}
+
+func (s *stepTagSnapshot) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	image := state.Get("image").(*godo.Image)
+
+	if len(c.SnapshotTags) == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Tagging snapshot (ID: %d)...", image.ID))
+	for _, tag := range c.SnapshotTags {
+		if err := digitalocean.TagImage(ctx, client, image.ID, tag); err != nil {
+			err := fmt.Errorf("Error tagging snapshot with %s: %s", tag, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepTagSnapshot) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
@@ -0,0 +1,64 @@
+package digitalocean
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/digitalocean/godo"
+)
+
+const (
+	accountProfileURL = "https://cloud.digitalocean.com/account/profile"
+	accountBillingURL = "https://cloud.digitalocean.com/account/billing"
+)
+
+// accountStatusError returns an error explaining why the account can't
+// create droplets, or nil if it can. New accounts commonly can't until the
+// email address is verified or a payment method is added.
+func accountStatusError(account *godo.Account) error {
+	// API compatible services may not report the status at all
+	if account.Status == "" {
+		return nil
+	}
+	if account.Status == "locked" {
+		msg := "The DigitalOcean account is locked"
+		if account.StatusMessage != "" {
+			msg += ": " + account.StatusMessage
+		}
+		return fmt.Errorf("%s. Droplets can't be created until it is unlocked; "+
+			"check the billing status at %s or contact support.", msg, accountBillingURL)
+	}
+	if !account.EmailVerified {
+		return fmt.Errorf("The email address of the DigitalOcean account (%s) has not been "+
+			"verified. Droplets can't be created until it is; verify it at %s.",
+			account.Email, accountProfileURL)
+	}
+	return nil
+}
+
+// explainAccountError adds the remediation to an API error caused by the
+// status of the account rather than by the request. Other errors are
+// returned unchanged.
+func explainAccountError(err error) error {
+	var errResp *godo.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return err
+	}
+	if code := errResp.Response.StatusCode; code != 403 && code != 422 {
+		return err
+	}
+
+	msg := strings.ToLower(errResp.Message)
+	switch {
+	case strings.Contains(msg, "verify") && strings.Contains(msg, "email"):
+		return fmt.Errorf("%s\nThe account's email address must be verified first: %s", err, accountProfileURL)
+	case strings.Contains(msg, "locked"), strings.Contains(msg, "billing"), strings.Contains(msg, "payment"):
+		return fmt.Errorf("%s\nThe account is limited by its billing status; check %s", err, accountBillingURL)
+	case strings.Contains(msg, "droplet limit"):
+		return fmt.Errorf("%s\nThe account has no room for the build droplet under its droplet limit; "+
+			"set concurrency_policy to %q to check for room before anything is created, or to %q to wait for it",
+			err, ConcurrencyPolicyFail, ConcurrencyPolicyWait)
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package digitalocean
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+)
+
+func TestAccountStatusError(t *testing.T) {
+	tests := []struct {
+		name    string
+		account godo.Account
+		want    string
+	}{
+		{"active", godo.Account{Status: "active", EmailVerified: true}, ""},
+		{"no status", godo.Account{}, ""},
+		{"warning", godo.Account{Status: "warning", EmailVerified: true}, ""},
+		{"unverified", godo.Account{Status: "active", Email: "ops@example.com"}, accountProfileURL},
+		{"locked", godo.Account{Status: "locked", StatusMessage: "payment overdue", EmailVerified: true}, accountBillingURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := accountStatusError(&tt.account)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error mentioning %s, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestExplainAccountError(t *testing.T) {
+	apiError := func(code int, msg string) error {
+		return &godo.ErrorResponse{
+			Response: &http.Response{StatusCode: code, Request: &http.Request{}},
+			Message:  msg,
+		}
+	}
+
+	err := explainAccountError(apiError(403, "You must verify your email address to create Droplets."))
+	if !strings.Contains(err.Error(), accountProfileURL) {
+		t.Errorf("expected remediation, got %s", err)
+	}
+
+	err = explainAccountError(apiError(422, "Your account is locked due to a billing issue."))
+	if !strings.Contains(err.Error(), accountBillingURL) {
+		t.Errorf("expected remediation, got %s", err)
+	}
+
+	err = explainAccountError(apiError(422, "creating this/these droplet(s) will exceed your droplet limit"))
+	if !strings.Contains(err.Error(), "concurrency_policy") {
+		t.Errorf("expected remediation, got %s", err)
+	}
+
+	orig := apiError(422, "Region is not available")
+	if err := explainAccountError(orig); err != orig {
+		t.Errorf("unrelated error changed: %s", err)
+	}
+
+	orig = errors.New("connection refused")
+	if err := explainAccountError(orig); err != orig {
+		t.Errorf("unrelated error changed: %s", err)
+	}
+}
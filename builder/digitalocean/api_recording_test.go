@@ -0,0 +1,132 @@
+package digitalocean
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+)
+
+func TestAPIRecorder_Replay(t *testing.T) {
+	var creates int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/droplets":
+			creates++
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"droplet":{"id":42,"name":"packer","status":"new"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/account/keys/7":
+			w.Write([]byte(`{"ssh_key":{"id":7,"public_key":"ssh-ed25519 AAAA","name":"build"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"id":"not_found","message":"The resource you were accessing could not be found."}`))
+		}
+	}))
+	defer ts.Close()
+
+	recorder := new(apiRecorder)
+	recorder.base = http.DefaultTransport
+	client, err := godo.New(&http.Client{Transport: recorder}, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatalf("client: %s", err)
+	}
+
+	ctx := context.Background()
+	if _, _, err := client.Droplets.Create(ctx, &godo.DropletCreateRequest{
+		Name:     "packer",
+		Region:   "nyc3",
+		Size:     "s-1vcpu-1gb",
+		Image:    godo.DropletCreateImage{Slug: "ubuntu-22-04-x64"},
+		UserData: "#!/bin/sh\necho secret",
+	}); err != nil {
+		t.Fatalf("create: %s", err)
+	}
+	if _, _, err := client.Keys.GetByID(ctx, 7); err != nil {
+		t.Fatalf("get key: %s", err)
+	}
+	if _, _, err := client.Droplets.Get(ctx, 1); err == nil {
+		t.Fatal("getting a missing droplet should fail")
+	}
+
+	path := filepath.Join(t.TempDir(), "recording.json")
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("save: %s", err)
+	}
+	recording, err := readAPIRecording(path)
+	if err != nil {
+		t.Fatalf("read: %s", err)
+	}
+	if len(recording.Interactions) != 3 {
+		t.Fatalf("bad interactions: %#v", recording.Interactions)
+	}
+	create := recording.Interactions[0]
+	if strings.Contains(create.RequestBody, "secret") || !strings.Contains(create.RequestBody, `"user_data":"REDACTED"`) {
+		t.Errorf("user data not redacted: %s", create.RequestBody)
+	}
+	if key := recording.Interactions[1]; strings.Contains(key.Body, "AAAA") {
+		t.Errorf("public key not redacted: %s", key.Body)
+	}
+
+	client = godo.NewClient(&http.Client{Transport: newAPIReplayer(recording)})
+	droplet, _, err := client.Droplets.Create(ctx, &godo.DropletCreateRequest{Name: "packer"})
+	if err != nil {
+		t.Fatalf("replayed create: %s", err)
+	}
+	if droplet.ID != 42 {
+		t.Errorf("bad replayed droplet: %#v", droplet)
+	}
+	if _, _, err := client.Droplets.Get(ctx, 1); err == nil || !strings.Contains(err.Error(), "could not be found") {
+		t.Errorf("the recorded error should be replayed: %v", err)
+	}
+	if _, _, err := client.Droplets.Get(ctx, 2); err == nil || !strings.Contains(err.Error(), "no response to GET /v2/droplets/2") {
+		t.Errorf("bad error for a request that wasn't recorded: %v", err)
+	}
+	if creates != 1 {
+		t.Errorf("replaying should not send requests, got %d creates", creates)
+	}
+}
+
+func TestAPIReplayer_RepeatsLastResponse(t *testing.T) {
+	replayer := newAPIReplayer(&apiRecording{
+		Version: apiRecordingVersion,
+		Interactions: []apiInteraction{
+			{Method: "GET", URI: "/v2/actions/1", Status: 200, Body: `{"action":{"id":1,"status":"in-progress"}}`},
+			{Method: "GET", URI: "/v2/actions/1", Status: 200, Body: `{"action":{"id":1,"status":"completed"}}`},
+		},
+	})
+	client := godo.NewClient(&http.Client{Transport: replayer})
+
+	for _, want := range []string{"in-progress", "completed", "completed"} {
+		action, _, err := client.Actions.Get(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("get action: %s", err)
+		}
+		if action.Status != want {
+			t.Fatalf("bad status %q, want %q", action.Status, want)
+		}
+	}
+}
+
+func TestSanitizeBody_PresignedURL(t *testing.T) {
+	body := `{"name":"base","url":"https://images.nyc3.digitaloceanspaces.com/base.img.gz?` +
+		`X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=DO00SPACESKEY%2F20250601%2Fnyc3%2Fs3%2Faws4_request` +
+		`&X-Amz-Date=20250601T120000Z&X-Amz-Expires=3600&X-Amz-SignedHeaders=host&X-Amz-Signature=8c1f2d9e4b7a"}`
+
+	got := sanitizeBody([]byte(body))
+	for _, secret := range []string{"DO00SPACESKEY", "8c1f2d9e4b7a"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("%s not redacted: %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, "X-Amz-Signature=REDACTED") || !strings.Contains(got, "X-Amz-Expires=3600") {
+		t.Errorf("bad redaction: %s", got)
+	}
+	if !strings.Contains(got, `"name":"base"`) {
+		t.Errorf("other fields should be kept: %s", got)
+	}
+}
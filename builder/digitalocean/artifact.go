@@ -6,12 +6,17 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/digitalocean/godo"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	registryimage "github.com/hashicorp/packer-plugin-sdk/packer/registry/image"
 )
 
+// LockedTag is the tag marking an image as protected. Images carrying it are
+// never deleted by Packer.
+const LockedTag = "locked"
+
 type Artifact struct {
 	// The name of the snapshot
 	SnapshotName string
@@ -46,7 +51,12 @@ func (a *Artifact) Id() string {
 }
 
 func (a *Artifact) String() string {
-	return fmt.Sprintf("A snapshot was created: '%v' (ID: %v) in regions '%v'", a.SnapshotName, a.SnapshotId, strings.Join(a.RegionNames[:], ","))
+	s := fmt.Sprintf("A snapshot was created: '%v' (ID: %v) in regions '%v'", a.SnapshotName, a.SnapshotId, strings.Join(a.RegionNames[:], ","))
+	for _, v := range a.volumeSnapshots() {
+		s += fmt.Sprintf("\nA volume snapshot was created: '%v' (ID: %v) of volume %v in regions '%v'",
+			v["name"], v["id"], v["volume_id"], v["regions"])
+	}
+	return s
 }
 
 func (a *Artifact) State(name string) interface{} {
@@ -57,9 +67,112 @@ func (a *Artifact) State(name string) interface{} {
 }
 
 func (a *Artifact) Destroy() error {
+	image, _, err := a.Client.Images.GetByID(context.TODO(), a.SnapshotId)
+	if err != nil {
+		return err
+	}
+	if IsLocked(image) {
+		return fmt.Errorf("Refusing to destroy image %d (%s): it is tagged %q",
+			a.SnapshotId, a.SnapshotName, LockedTag)
+	}
+
 	log.Printf("Destroying image: %d (%s)", a.SnapshotId, a.SnapshotName)
-	_, err := a.Client.Images.Delete(context.TODO(), a.SnapshotId)
-	return err
+	_, err = a.Client.Images.Delete(context.TODO(), a.SnapshotId)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range a.volumeSnapshots() {
+		log.Printf("Destroying volume snapshot: %s (%s)", v["id"], v["name"])
+		if _, err := a.Client.Snapshots.Delete(context.TODO(), v["id"]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// volumeSnapshots returns the snapshots of the volumes attached during the
+// build, which make the artifact a composite of the droplet image and its
+// data disks. Each has an id, name, volume_id and comma-separated regions.
+func (a *Artifact) volumeSnapshots() []map[string]string {
+	raw, ok := a.StateData["volume_snapshots"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	snapshots := make([]map[string]string, 0, len(raw))
+	for _, v := range raw {
+		if snapshot, ok := v.(map[string]string); ok {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots
+}
+
+// IsLocked reports whether the image carries the LockedTag.
+func IsLocked(image *godo.Image) bool {
+	for _, t := range image.Tags {
+		if t == LockedTag {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpiresTagPrefix prefixes the tag recording the date an image built with
+// image_ttl expires on, such as "expires:2025-06-01". The prune
+// post-processor deletes images from that date on.
+const ExpiresTagPrefix = "expires:"
+
+// expiresDateLayout is the layout of the date in expiry tags.
+const expiresDateLayout = "2006-01-02"
+
+// ExpiresTag returns the tag recording that an image expires at t, which is
+// rounded down to its date in UTC.
+func ExpiresTag(t time.Time) string {
+	return ExpiresTagPrefix + t.UTC().Format(expiresDateLayout)
+}
+
+// DeprecatedTagPrefix prefixes the tag recording the date a snapshot was
+// superseded on with deprecate_previous, such as "deprecated:2025-06-01".
+const DeprecatedTagPrefix = "deprecated:"
+
+// DeprecatedTag returns the tag recording that an image was superseded at
+// t, which is rounded down to its date in UTC.
+func DeprecatedTag(t time.Time) string {
+	return DeprecatedTagPrefix + t.UTC().Format(expiresDateLayout)
+}
+
+// ImageDeprecation returns the date the image was superseded on, from its
+// deprecation tag.
+func ImageDeprecation(image *godo.Image) (time.Time, bool) {
+	for _, t := range image.Tags {
+		if !strings.HasPrefix(t, DeprecatedTagPrefix) {
+			continue
+		}
+		deprecated, err := time.Parse(expiresDateLayout, strings.TrimPrefix(t, DeprecatedTagPrefix))
+		if err != nil {
+			continue
+		}
+		return deprecated, true
+	}
+	return time.Time{}, false
+}
+
+// ImageExpiry returns the date the image expires on, from its expiry tag.
+func ImageExpiry(image *godo.Image) (time.Time, bool) {
+	for _, t := range image.Tags {
+		if !strings.HasPrefix(t, ExpiresTagPrefix) {
+			continue
+		}
+		expires, err := time.Parse(expiresDateLayout, strings.TrimPrefix(t, ExpiresTagPrefix))
+		if err != nil {
+			continue
+		}
+		return expires, true
+	}
+	return time.Time{}, false
 }
 
 func (a *Artifact) stateHCPPackerRegistryMetadata() interface{} {
@@ -92,10 +205,40 @@ func (a *Artifact) stateHCPPackerRegistryMetadata() interface{} {
 		if ok {
 			labels["droplet_name"] = drpName
 		}
+		// Get and set the features of the build region
+		if features, ok := a.StateData["region_features"].([]string); ok {
+			labels["region_features"] = strings.Join(features, ",")
+		}
+		// Get and set the team the image was built in
+		if teamUUID, ok := a.StateData["team_uuid"].(string); ok {
+			labels["team_uuid"] = teamUUID
+		}
+		if teamName, ok := a.StateData["team_name"].(string); ok {
+			labels["team_name"] = teamName
+		}
+		// Get and set the snapshots of the volumes shipped with the image
+		if volumeSnapshots := a.volumeSnapshots(); len(volumeSnapshots) > 0 {
+			ids := make([]string, len(volumeSnapshots))
+			for i, v := range volumeSnapshots {
+				ids[i] = v["id"]
+			}
+			labels["volume_snapshot_ids"] = strings.Join(ids, ",")
+		}
+		// Get and set the checksum of the user data the droplet ran
+		if checksum, ok := a.StateData["user_data_sha256"].(string); ok {
+			labels["user_data_sha256"] = checksum
+		}
+		// Render the image ID in the format of hcp_image_id_format
+		format, _ := a.StateData["hcp_image_id_format"].(string)
+		imageID, err := RenderHCPImageID(format, a.SnapshotId, a.SnapshotName, region)
+		if err != nil {
+			log.Printf("[DEBUG] error rendering HCP Packer image ID, using the snapshot ID: %s", err)
+			imageID = strconv.Itoa(a.SnapshotId)
+		}
 		// instantiate the image
 		img, err := registryimage.FromArtifact(a,
 			registryimage.WithSourceID(sourceID),
-			registryimage.WithID(strconv.Itoa(a.SnapshotId)),
+			registryimage.WithID(imageID),
 			registryimage.WithProvider("digitalocean"),
 			registryimage.WithRegion(region),
 		)
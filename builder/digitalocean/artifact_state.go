@@ -0,0 +1,200 @@
+package digitalocean
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// ArtifactStateVersion is the version of the ArtifactState schema written by
+// this plugin. It is only bumped when a field changes meaning or is removed;
+// new fields are added without bumping it.
+const ArtifactStateVersion = 1
+
+// ArtifactStateKey is the key under which artifacts expose their
+// ArtifactState, encoded as JSON.
+const ArtifactStateKey = "artifact_state"
+
+// ArtifactState is the information the builder shares with post-processors
+// and other artifact consumers. Artifacts also expose each field under its
+// JSON name, as before the schema was introduced; consumers should prefer
+// ParseArtifactState, which reads both.
+type ArtifactState struct {
+	// Version is the ArtifactStateVersion the state was written with, or 0
+	// for state read from artifacts predating the schema.
+	Version             int                    `json:"version"`
+	GeneratedData       map[string]interface{} `json:"generated_data,omitempty"`
+	SourceImageID       string                 `json:"source_image_id,omitempty"`
+	DropletSize         string                 `json:"droplet_size,omitempty"`
+	DropletName         string                 `json:"droplet_name,omitempty"`
+	BuildRegion         string                 `json:"build_region,omitempty"`
+	RegionFeatures      []string               `json:"region_features,omitempty"`
+	SSHKeyIDs           []int                  `json:"ssh_key_ids,omitempty"`
+	ProvisionReconnects int                    `json:"provision_reconnects"`
+	NetworkInterfaces   []map[string]string    `json:"network_interfaces,omitempty"`
+	NetworkVPC          map[string]string      `json:"network_vpc,omitempty"`
+	VolumeSnapshots     []map[string]string    `json:"volume_snapshots,omitempty"`
+	TeamUUID            string                 `json:"team_uuid,omitempty"`
+	TeamName            string                 `json:"team_name,omitempty"`
+	ProjectID           string                 `json:"project_id,omitempty"`
+	UserDataSHA256      string                 `json:"user_data_sha256,omitempty"`
+	ReservedIP          string                 `json:"reserved_ip,omitempty"`
+	OutboundAllowed     []string               `json:"outbound_allowed,omitempty"`
+	DropletMetrics      *DropletMetrics        `json:"droplet_metrics,omitempty"`
+	HCPImageIDFormat    string                 `json:"hcp_image_id_format,omitempty"`
+	SnapshotTags        []string               `json:"snapshot_tags,omitempty"`
+	BuildCost           *BuildCost             `json:"build_cost,omitempty"`
+}
+
+// newArtifactState collects the artifact state from the state bag of a
+// build.
+func newArtifactState(state multistep.StateBag) *ArtifactState {
+	s := &ArtifactState{Version: ArtifactStateVersion}
+
+	s.GeneratedData, _ = state.Get("generated_data").(map[string]interface{})
+	s.SourceImageID, _ = stateSourceImageID.GetOk(state)
+	s.DropletSize, _ = stateDropletSize.GetOk(state)
+	s.DropletName, _ = stateDropletName.GetOk(state)
+	s.BuildRegion, _ = stateBuildRegion.GetOk(state)
+	s.RegionFeatures, _ = stateRegionFeatures.GetOk(state)
+	s.SSHKeyIDs, _ = stateInstalledSSHKeyIDs.GetOk(state)
+	s.ProvisionReconnects, _ = stateProvisionReconnects.GetOk(state)
+	interfaces, _ := stateNetworkInterfaces.GetOk(state)
+	s.NetworkInterfaces = stringMaps(interfaces)
+	s.NetworkVPC, _ = stateNetworkVPC.GetOk(state)
+	volumeSnapshots, _ := stateVolumeSnapshots.GetOk(state)
+	s.VolumeSnapshots = stringMaps(volumeSnapshots)
+	s.TeamUUID, _ = stateTeamUUID.GetOk(state)
+	s.TeamName, _ = stateTeamName.GetOk(state)
+	s.ProjectID, _ = stateProjectID.GetOk(state)
+	s.UserDataSHA256, _ = stateUserDataSHA256.GetOk(state)
+	s.ReservedIP, _ = stateReservedIP.GetOk(state)
+	s.OutboundAllowed, _ = stateOutboundAllowed.GetOk(state)
+	s.DropletMetrics, _ = stateDropletMetrics.GetOk(state)
+	s.HCPImageIDFormat, _ = stateHCPImageIDFormat.GetOk(state)
+	s.SnapshotTags, _ = stateSnapshotTags.GetOk(state)
+	s.BuildCost, _ = stateBuildCost.GetOk(state)
+
+	return s
+}
+
+// StateData returns the state data of an artifact: the JSON encoding of the
+// state under ArtifactStateKey, along with each field under its JSON name
+// and in the shape it had before the schema. Unset fields are left out, so
+// State returns nil for them as it always has.
+func (s *ArtifactState) StateData() map[string]interface{} {
+	data := map[string]interface{}{
+		"provision_reconnects": s.ProvisionReconnects,
+	}
+	if encoded, err := json.Marshal(s); err == nil {
+		data[ArtifactStateKey] = string(encoded)
+	}
+
+	put := func(key string, value interface{}, set bool) {
+		if set {
+			data[key] = value
+		}
+	}
+	put("generated_data", s.GeneratedData, s.GeneratedData != nil)
+	put("source_image_id", s.SourceImageID, s.SourceImageID != "")
+	put("droplet_size", s.DropletSize, s.DropletSize != "")
+	put("droplet_name", s.DropletName, s.DropletName != "")
+	put("build_region", s.BuildRegion, s.BuildRegion != "")
+	put("region_features", s.RegionFeatures, s.RegionFeatures != nil)
+	put("ssh_key_ids", s.SSHKeyIDs, s.SSHKeyIDs != nil)
+	put("network_interfaces", interfaceSlice(s.NetworkInterfaces), s.NetworkInterfaces != nil)
+	put("network_vpc", s.NetworkVPC, s.NetworkVPC != nil)
+	put("volume_snapshots", interfaceSlice(s.VolumeSnapshots), s.VolumeSnapshots != nil)
+	put("team_uuid", s.TeamUUID, s.TeamUUID != "")
+	put("team_name", s.TeamName, s.TeamName != "")
+	put("project_id", s.ProjectID, s.ProjectID != "")
+	put("user_data_sha256", s.UserDataSHA256, s.UserDataSHA256 != "")
+	put("reserved_ip", s.ReservedIP, s.ReservedIP != "")
+	put("outbound_allowed", s.OutboundAllowed, s.OutboundAllowed != nil)
+	if m := s.DropletMetrics; m != nil {
+		put("droplet_metrics", map[string]float64{
+			"peak_cpu_percent":    m.PeakCPUPercent,
+			"peak_memory_percent": m.PeakMemoryPercent,
+			"peak_memory_bytes":   m.PeakMemoryBytes,
+			"total_memory_bytes":  m.TotalMemoryBytes,
+		}, true)
+	}
+	put("hcp_image_id_format", s.HCPImageIDFormat, s.HCPImageIDFormat != "")
+	put("snapshot_tags", s.SnapshotTags, s.SnapshotTags != nil)
+	if c := s.BuildCost; c != nil {
+		put("build_cost", map[string]interface{}{
+			"size":                  c.Size,
+			"estimated_hourly_cost": c.EstimatedHourlyCost,
+			"droplet_hours":         c.DropletHours,
+			"accrued_cost":          c.AccruedCost,
+		}, true)
+	}
+
+	return data
+}
+
+// ParseArtifactState reads the ArtifactState of an artifact built by this
+// plugin. Artifacts predating the schema are read from the keys named after
+// the fields, with a Version of 0.
+func ParseArtifactState(a packersdk.Artifact) (*ArtifactState, error) {
+	if encoded, ok := a.State(ArtifactStateKey).(string); ok {
+		s := new(ArtifactState)
+		if err := json.Unmarshal([]byte(encoded), s); err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", ArtifactStateKey, err)
+		}
+		return s, nil
+	}
+
+	// Round-trip the values under the fields' keys through JSON, which
+	// copes with the shapes they take after crossing the plugin boundary.
+	legacy := make(map[string]interface{})
+	fields := reflect.TypeOf(ArtifactState{})
+	for i := 0; i < fields.NumField(); i++ {
+		key, _, _ := strings.Cut(fields.Field(i).Tag.Get("json"), ",")
+		if key == "version" {
+			continue
+		}
+		if v := a.State(key); v != nil {
+			legacy[key] = v
+		}
+	}
+	encoded, err := json.Marshal(legacy)
+	if err != nil {
+		return nil, err
+	}
+	s := new(ArtifactState)
+	if err := json.Unmarshal(encoded, s); err != nil {
+		return nil, fmt.Errorf("invalid legacy artifact state: %s", err)
+	}
+	s.Version = 0
+	return s, nil
+}
+
+// stringMaps converts a list of string maps stored as []interface{} back to
+// its type.
+func stringMaps(raw []interface{}) []map[string]string {
+	if raw == nil {
+		return nil
+	}
+	maps := make([]map[string]string, 0, len(raw))
+	for _, m := range raw {
+		if m, ok := m.(map[string]string); ok {
+			maps = append(maps, m)
+		}
+	}
+	return maps
+}
+
+// interfaceSlice stores a list of string maps as []interface{}, the shape
+// artifacts have always exposed it in.
+func interfaceSlice(maps []map[string]string) []interface{} {
+	raw := make([]interface{}, 0, len(maps))
+	for _, m := range maps {
+		raw = append(raw, m)
+	}
+	return raw
+}
@@ -0,0 +1,86 @@
+package digitalocean
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestArtifactState(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+	state.Put("generated_data", map[string]interface{}{"DropletID": 3164444})
+	state.Put("source_image_id", "ubuntu-22-04-x64")
+	state.Put("build_region", "nyc3")
+	state.Put("region_features", []string{"ipv6"})
+	state.Put("installed_ssh_key_ids", []int{512189})
+	state.Put("provision_reconnects", 1)
+	state.Put("volume_snapshots", []interface{}{
+		map[string]string{"id": "fbe805e8", "name": "build-data", "volume_id": "506f78a4", "regions": "nyc3"},
+	})
+	state.Put("team_uuid", "6ff7e2a3-0b9b-4c8b-a24c-3e6a7e6e2d1f")
+
+	data := newArtifactState(state).StateData()
+
+	// The legacy keys keep their shapes
+	if data["source_image_id"] != "ubuntu-22-04-x64" {
+		t.Errorf("bad source_image_id: %#v", data["source_image_id"])
+	}
+	if !reflect.DeepEqual(data["ssh_key_ids"], []int{512189}) {
+		t.Errorf("bad ssh_key_ids: %#v", data["ssh_key_ids"])
+	}
+	if _, ok := data["volume_snapshots"].([]interface{}); !ok {
+		t.Errorf("bad volume_snapshots: %#v", data["volume_snapshots"])
+	}
+	if v, ok := data["team_name"]; ok {
+		t.Errorf("unset team_name should be left out: %#v", v)
+	}
+
+	artifact := &Artifact{StateData: data}
+	parsed, err := ParseArtifactState(artifact)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if parsed.Version != ArtifactStateVersion {
+		t.Errorf("bad version: %d", parsed.Version)
+	}
+	if parsed.BuildRegion != "nyc3" || parsed.ProvisionReconnects != 1 || parsed.TeamUUID == "" {
+		t.Errorf("bad state: %#v", parsed)
+	}
+	if len(parsed.VolumeSnapshots) != 1 || parsed.VolumeSnapshots[0]["id"] != "fbe805e8" {
+		t.Errorf("bad volume snapshots: %#v", parsed.VolumeSnapshots)
+	}
+}
+
+func TestParseArtifactState_legacy(t *testing.T) {
+	artifact := &Artifact{
+		StateData: map[string]interface{}{
+			"source_image_id":      "ubuntu-22-04-x64",
+			"droplet_size":         "s-1vcpu-1gb",
+			"ssh_key_ids":          []int{512189},
+			"provision_reconnects": 0,
+			"network_vpc":          map[string]string{"uuid": "5a4981aa", "name": "default-nyc3"},
+			"user_data_sha256":     nil,
+		},
+	}
+
+	parsed, err := ParseArtifactState(artifact)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if parsed.Version != 0 {
+		t.Errorf("bad version: %d", parsed.Version)
+	}
+	if parsed.DropletSize != "s-1vcpu-1gb" || parsed.NetworkVPC["name"] != "default-nyc3" {
+		t.Errorf("bad state: %#v", parsed)
+	}
+	if !reflect.DeepEqual(parsed.SSHKeyIDs, []int{512189}) {
+		t.Errorf("bad ssh key ids: %#v", parsed.SSHKeyIDs)
+	}
+
+	// Invalid encoded state
+	artifact = &Artifact{StateData: map[string]interface{}{ArtifactStateKey: "{"}}
+	if _, err := ParseArtifactState(artifact); err == nil {
+		t.Fatal("should have error")
+	}
+}
@@ -3,7 +3,9 @@ package digitalocean
 import (
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/digitalocean/godo"
 	registryimage "github.com/hashicorp/packer-plugin-sdk/packer/registry/image"
 	"github.com/mitchellh/mapstructure"
 )
@@ -121,3 +123,172 @@ func TestArtifactState_hcpPackerRegistryMetadata(t *testing.T) {
 		t.Fatalf("Bad: expected %#v got %#v", expected, images)
 	}
 }
+
+func TestArtifactState_hcpPackerRegistryMetadataImageIDFormat(t *testing.T) {
+	artifact := &Artifact{
+		SnapshotName: "snapshot-1",
+		SnapshotId:   12345,
+		RegionNames:  []string{"nyc1", "nyc3"},
+		StateData:    map[string]interface{}{"hcp_image_id_format": "{{ .Region }}:{{ .ID }}:{{ .Name }}"},
+	}
+
+	var images []registryimage.Image
+	if err := mapstructure.Decode(artifact.State(registryimage.ArtifactStateURI), &images); err != nil {
+		t.Fatalf("Bad: unexpected error decoding images: %s", err)
+	}
+	var ids []string
+	for _, img := range images {
+		ids = append(ids, img.ImageID)
+	}
+	expected := []string{"nyc1:12345:snapshot-1", "nyc3:12345:snapshot-1"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("Bad: expected %v got %v", expected, ids)
+	}
+}
+
+func TestArtifactState_hcpPackerRegistryMetadataRegionFeatures(t *testing.T) {
+	artifact := &Artifact{
+		SnapshotName: "snapshot-1",
+		SnapshotId:   12345,
+		RegionNames:  []string{"nyc3"},
+		StateData: map[string]interface{}{
+			"region_features": []string{"private_networking", "ipv6", "metadata"},
+		},
+	}
+
+	var images []registryimage.Image
+	err := mapstructure.Decode(artifact.State(registryimage.ArtifactStateURI), &images)
+	if err != nil {
+		t.Fatalf("Bad: unexpected error when trying to decode state into registryimage.Image %v", err)
+	}
+	if len(images) != 1 {
+		t.Fatalf("Bad: expected one image but got %d", len(images))
+	}
+
+	expected := "private_networking,ipv6,metadata"
+	if got := images[0].Labels["region_features"]; got != expected {
+		t.Fatalf("Bad: expected region_features label %q got %q", expected, got)
+	}
+}
+
+func TestArtifactState_hcpPackerRegistryMetadataTeam(t *testing.T) {
+	artifact := &Artifact{
+		SnapshotName: "snapshot-1",
+		SnapshotId:   12345,
+		RegionNames:  []string{"nyc3"},
+		StateData: map[string]interface{}{
+			"team_uuid": "5df3e3004a17e242b7c20ca6c9fc25b701a47ece",
+			"team_name": "Platform",
+		},
+	}
+
+	var images []registryimage.Image
+	err := mapstructure.Decode(artifact.State(registryimage.ArtifactStateURI), &images)
+	if err != nil {
+		t.Fatalf("Bad: unexpected error when trying to decode state into registryimage.Image %v", err)
+	}
+	if len(images) != 1 {
+		t.Fatalf("Bad: expected one image but got %d", len(images))
+	}
+
+	if got := images[0].Labels["team_uuid"]; got != "5df3e3004a17e242b7c20ca6c9fc25b701a47ece" {
+		t.Fatalf("Bad: unexpected team_uuid label %q", got)
+	}
+	if got := images[0].Labels["team_name"]; got != "Platform" {
+		t.Fatalf("Bad: unexpected team_name label %q", got)
+	}
+}
+
+func TestArtifactIsLocked(t *testing.T) {
+	if !IsLocked(&godo.Image{Tags: []string{"prod", LockedTag}}) {
+		t.Error("image tagged locked should be locked")
+	}
+	if IsLocked(&godo.Image{Tags: []string{"prod"}}) {
+		t.Error("image without the locked tag should not be locked")
+	}
+}
+
+func TestArtifactImageExpiry(t *testing.T) {
+	tag := ExpiresTag(time.Date(2025, 6, 1, 23, 30, 0, 0, time.FixedZone("EST", -5*3600)))
+	if tag != "expires:2025-06-02" {
+		t.Fatalf("bad tag: %s", tag)
+	}
+
+	expires, ok := ImageExpiry(&godo.Image{Tags: []string{"prod", "expires:soon", tag}})
+	if !ok {
+		t.Fatal("image should have an expiry")
+	}
+	if !expires.Equal(time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("bad expiry: %s", expires)
+	}
+
+	if _, ok := ImageExpiry(&godo.Image{Tags: []string{"prod"}}); ok {
+		t.Fatal("image without an expiry tag should not have an expiry")
+	}
+}
+
+func TestArtifactImageDeprecation(t *testing.T) {
+	tag := DeprecatedTag(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	if tag != "deprecated:2025-06-01" {
+		t.Fatalf("bad tag: %s", tag)
+	}
+
+	deprecated, ok := ImageDeprecation(&godo.Image{Tags: []string{"expires:2025-07-01", tag}})
+	if !ok {
+		t.Fatal("image should be deprecated")
+	}
+	if !deprecated.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("bad deprecation: %s", deprecated)
+	}
+
+	if _, ok := ImageDeprecation(&godo.Image{Tags: []string{"prod", "deprecated:soon"}}); ok {
+		t.Fatal("image without a valid deprecation tag should not be deprecated")
+	}
+}
+
+func TestArtifactStringWithVolumeSnapshots(t *testing.T) {
+	a := &Artifact{
+		SnapshotName: "packer-foobar",
+		SnapshotId:   42,
+		RegionNames:  []string{"sfo"},
+		StateData: map[string]interface{}{
+			"volume_snapshots": []interface{}{
+				map[string]string{"id": "abc", "name": "packer-foobar-data", "volume_id": "vol-1", "regions": "sfo"},
+			},
+		},
+	}
+	expected := "A snapshot was created: 'packer-foobar' (ID: 42) in regions 'sfo'\n" +
+		"A volume snapshot was created: 'packer-foobar-data' (ID: abc) of volume vol-1 in regions 'sfo'"
+
+	if a.String() != expected {
+		t.Fatalf("artifact string should match: %v, got %v", expected, a.String())
+	}
+}
+
+func TestArtifactState_hcpPackerRegistryMetadataVolumeSnapshots(t *testing.T) {
+	artifact := &Artifact{
+		SnapshotName: "snapshot-1",
+		SnapshotId:   12345,
+		RegionNames:  []string{"nyc3"},
+		StateData: map[string]interface{}{
+			"volume_snapshots": []interface{}{
+				map[string]string{"id": "fbe805e8-866b-11e6-96bf-000f53315a41", "name": "snapshot-1-data"},
+				map[string]string{"id": "0d1b1f2e-866c-11e6-96bf-000f53315a41", "name": "snapshot-1-logs"},
+			},
+		},
+	}
+
+	var images []registryimage.Image
+	err := mapstructure.Decode(artifact.State(registryimage.ArtifactStateURI), &images)
+	if err != nil {
+		t.Fatalf("Bad: unexpected error when trying to decode state into registryimage.Image %v", err)
+	}
+	if len(images) != 1 {
+		t.Fatalf("Bad: expected one image but got %d", len(images))
+	}
+
+	expected := "fbe805e8-866b-11e6-96bf-000f53315a41,0d1b1f2e-866c-11e6-96bf-000f53315a41"
+	if got := images[0].Labels["volume_snapshot_ids"]; got != expected {
+		t.Fatalf("Bad: expected volume_snapshot_ids label %q got %q", expected, got)
+	}
+}
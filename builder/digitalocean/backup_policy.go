@@ -0,0 +1,54 @@
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type BackupPolicy
+
+package digitalocean
+
+import (
+	"fmt"
+	"strings"
+)
+
+var validBackupWeekdays = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+// BackupPolicy sets when the backups of the build droplet are taken. It is
+// set with a `backup_policy` block and requires `backups` to be enabled.
+type BackupPolicy struct {
+	// How often backups are taken: `daily` or `weekly`. Defaults to
+	// `weekly`.
+	Plan string `mapstructure:"plan" required:"false"`
+	// The day of the week weekly backups start on: `SUN`, `MON`, `TUE`,
+	// `WED`, `THU`, `FRI` or `SAT`.
+	Weekday string `mapstructure:"weekday" required:"false"`
+	// The hour of the day (UTC) the backup window starts at. One of 0, 4, 8,
+	// 12, 16 or 20. Defaults to 0.
+	Hour int `mapstructure:"hour" required:"false"`
+}
+
+// Prepare sets the defaults for the backup policy and validates it.
+func (p *BackupPolicy) Prepare() []error {
+	var errs []error
+
+	if p.Plan == "" {
+		p.Plan = "weekly"
+	}
+	p.Weekday = strings.ToUpper(p.Weekday)
+
+	switch p.Plan {
+	case "daily":
+		if p.Weekday != "" {
+			errs = append(errs, fmt.Errorf("backup_policy: weekday can only be set for weekly backups"))
+		}
+	case "weekly":
+		if p.Weekday != "" && !containsString(validBackupWeekdays, p.Weekday) {
+			errs = append(errs, fmt.Errorf("backup_policy: weekday must be one of %v", validBackupWeekdays))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("backup_policy: plan must be daily or weekly"))
+	}
+
+	if p.Hour < 0 || p.Hour > 20 || p.Hour%4 != 0 {
+		errs = append(errs, fmt.Errorf("backup_policy: hour must be one of 0, 4, 8, 12, 16 or 20"))
+	}
+
+	return errs
+}
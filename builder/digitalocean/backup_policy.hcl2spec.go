@@ -0,0 +1,35 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package digitalocean
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatBackupPolicy is an auto-generated flat version of BackupPolicy.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatBackupPolicy struct {
+	Plan    *string `mapstructure:"plan" required:"false" cty:"plan" hcl:"plan"`
+	Weekday *string `mapstructure:"weekday" required:"false" cty:"weekday" hcl:"weekday"`
+	Hour    *int    `mapstructure:"hour" required:"false" cty:"hour" hcl:"hour"`
+}
+
+// FlatMapstructure returns a new FlatBackupPolicy.
+// FlatBackupPolicy is an auto-generated flat version of BackupPolicy.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*BackupPolicy) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatBackupPolicy)
+}
+
+// HCL2Spec returns the hcl spec of a BackupPolicy.
+// This spec is used by HCL to read the fields of BackupPolicy.
+// The decoded values from this spec will then be applied to a FlatBackupPolicy.
+func (*FlatBackupPolicy) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"plan":    &hcldec.AttrSpec{Name: "plan", Type: cty.String, Required: false},
+		"weekday": &hcldec.AttrSpec{Name: "weekday", Type: cty.String, Required: false},
+		"hour":    &hcldec.AttrSpec{Name: "hour", Type: cty.Number, Required: false},
+	}
+	return s
+}
@@ -0,0 +1,74 @@
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalocean/godo"
+)
+
+func TestBackupPolicy_Prepare(t *testing.T) {
+	p := &BackupPolicy{Weekday: "sun", Hour: 8}
+	if errs := p.Prepare(); len(errs) > 0 {
+		t.Fatalf("bad: %v", errs)
+	}
+	if p.Plan != "weekly" || p.Weekday != "SUN" {
+		t.Fatalf("bad: %#v", p)
+	}
+
+	for _, p := range []*BackupPolicy{
+		{Plan: "hourly"},
+		{Plan: "daily", Weekday: "MON"},
+		{Weekday: "SUNDAY"},
+		{Hour: 6},
+		{Hour: 24},
+	} {
+		if errs := p.Prepare(); len(errs) == 0 {
+			t.Errorf("should have error: %#v", p)
+		}
+	}
+}
+
+func TestCreateDropletWithBackupPolicy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v2/droplets" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body["name"] != "packer-build" || body["backups"] != true {
+			t.Errorf("bad droplet request: %v", body)
+		}
+		policy, _ := body["backup_policy"].(map[string]interface{})
+		if policy["plan"] != "daily" || policy["hour"] != 0.0 {
+			t.Errorf("bad backup policy: %v", body["backup_policy"])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"droplet": {"id": 3164444, "name": "packer-build"}}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	droplet, _, err := createDropletWithBackupPolicy(context.Background(), client, &godo.DropletCreateRequest{
+		Name:    "packer-build",
+		Backups: true,
+	}, &BackupPolicy{Plan: "daily"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if droplet.ID != 3164444 {
+		t.Fatalf("bad droplet: %#v", droplet)
+	}
+}
@@ -7,17 +7,18 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/url"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/digitalocean/godo"
-	"github.com/digitalocean/packer-plugin-digitalocean/version"
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/hashicorp/packer-plugin-sdk/communicator"
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
-	"github.com/hashicorp/packer-plugin-sdk/useragent"
-	"golang.org/x/oauth2"
 )
 
 // The unique id for the builder
@@ -39,60 +40,114 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 		errs = packersdk.MultiErrorAppend(errs,
 			fmt.Errorf("Must specify a `ssh_private_key_file` when using `ssh_key_id`."))
 	}
+	if b.config.SSHKeyFingerprint != "" && b.config.Comm.SSHPrivateKeyFile == "" {
+		errs = packersdk.MultiErrorAppend(errs,
+			fmt.Errorf("Must specify a `ssh_private_key_file` when using `ssh_key_fingerprint`."))
+	}
+	if b.config.SSHKeyName != "" && b.config.Comm.SSHPrivateKeyFile == "" {
+		errs = packersdk.MultiErrorAppend(errs,
+			fmt.Errorf("Must specify a `ssh_private_key_file` when using `ssh_key_name`."))
+	}
 	if errs != nil {
 		return nil, warnings, errs
 	}
 
-	return nil, warnings, nil
-}
-
-func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook) (packersdk.Artifact, error) {
-	ua := useragent.String(version.PluginVersion.FormattedVersion())
-	opts := []godo.ClientOpt{godo.SetUserAgent(ua)}
-	if b.config.APIURL != "" {
-		_, err := url.Parse(b.config.APIURL)
+	if b.config.CatalogWarnings {
+		client, err := newClient(&b.config, nil)
 		if err != nil {
-			return nil, fmt.Errorf("DigitalOcean: Invalid API URL, %s.", err)
+			return nil, warnings, err
 		}
+		warnings = append(warnings, catalogWarnings(client, &b.config)...)
+	}
 
-		opts = append(opts, godo.SetBaseURL(b.config.APIURL))
+	// Declare every key the build publishes, so that templates referencing
+	// them pass validation.
+	generatedData := []string{
+		"GPUDriverVersion",
+		"CUDAVersion",
+		"KeyInjection",
+		"DropletID",
+		"DropletName",
+		"DropletIP",
+		"Region",
+		"Size",
+		"ReservedIP",
 	}
-	if *b.config.HTTPRetryMax > 0 {
-		opts = append(opts, godo.WithRetryAndBackoffs(godo.RetryConfig{
-			RetryMax:     *b.config.HTTPRetryMax,
-			RetryWaitMin: b.config.HTTPRetryWaitMin,
-			RetryWaitMax: b.config.HTTPRetryWaitMax,
-			Logger:       log.Default(),
-		}))
+
+	return generatedData, warnings, nil
+}
+
+func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook) (packersdk.Artifact, error) {
+	var recorder *apiRecorder
+	if b.config.APIRecordFile != "" {
+		recorder = new(apiRecorder)
+		defer func() {
+			if err := recorder.Save(b.config.APIRecordFile); err != nil {
+				ui.Error(fmt.Sprintf("Error writing api_record_file: %s", err))
+				return
+			}
+			ui.Say(fmt.Sprintf("Recorded %d API requests to %s", recorder.Len(), b.config.APIRecordFile))
+		}()
 	}
 
-	client, err := godo.New(oauth2.NewClient(context.TODO(), &APITokenSource{
-		AccessToken: b.config.APIToken,
-	}), opts...)
+	client, err := newClient(&b.config, recorder)
 	if err != nil {
-		return nil, fmt.Errorf("DigitalOcean: could not create client, %s", err)
+		return nil, err
 	}
 
-	if len(b.config.SnapshotRegions) > 0 {
-		opt := &godo.ListOptions{
-			Page:    1,
-			PerPage: 200,
+	if b.config.DropletID != 0 {
+		// The build uses the region and size of the existing droplet.
+		droplet, _, err := client.Droplets.Get(context.TODO(), b.config.DropletID)
+		if err != nil {
+			return nil, fmt.Errorf("DigitalOcean: Unable to get droplet %d, %s", b.config.DropletID, err)
 		}
-		regions, _, err := client.Regions.List(context.TODO(), opt)
+		if droplet.Region != nil {
+			b.config.Region = droplet.Region.Slug
+		}
+		b.config.Size = droplet.SizeSlug
+		b.config.DropletName = droplet.Name
+	}
+
+	var regions []godo.Region
+	if !b.config.MinimalAPIMode {
+		regions, err = listRegions(client)
 		if err != nil {
 			return nil, fmt.Errorf("DigitalOcean: Unable to get regions, %s", err)
 		}
 
+		// Fail before creating anything when the droplet can't be created
+		// with its size in its region.
+		if b.config.DropletID == 0 {
+			warns, err := checkPlacements(&b.config, regions)
+			if err != nil {
+				return nil, fmt.Errorf("DigitalOcean: %s", err)
+			}
+			for _, w := range warns {
+				ui.Error("Warning: " + w)
+			}
+		}
+	}
+
+	if len(b.config.SnapshotRegions) > 0 && !b.config.MinimalAPIMode {
 		validRegions := make(map[string]struct{})
 		for _, val := range regions {
 			validRegions[val.Slug] = struct{}{}
 		}
 
-		for _, region := range append(b.config.SnapshotRegions, b.config.Region) {
+		for _, region := range append(append(b.config.SnapshotRegions, b.config.SnapshotRegionsExclude...), b.config.Region) {
+			if region == SnapshotRegionsAll {
+				continue
+			}
 			if _, ok := validRegions[region]; !ok {
 				return nil, fmt.Errorf("DigitalOcean: Invalid region, %s", region)
 			}
 		}
+
+		if containsString(b.config.SnapshotRegions, SnapshotRegionsAll) {
+			b.config.SnapshotRegions = allSnapshotRegions(&b.config, regions)
+			ui.Say(fmt.Sprintf("Distributing the snapshot to all regions: %s",
+				strings.Join(b.config.SnapshotRegions, ", ")))
+		}
 	}
 
 	// Set up the state
@@ -101,12 +156,44 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	state.Put("client", client)
 	state.Put("hook", hook)
 	state.Put("ui", ui)
+	stateHCPImageIDFormat.Put(state, b.config.HCPImageIDFormat)
 
-	// Only generate the temp key pair if one is not already provided
-	genTempKeyPair := !b.config.SkipKeygen && (b.config.SSHKeyID == 0 || b.config.Comm.SSHPrivateKeyFile == "")
+	// Only generate the temp key pair if one is not already provided, and
+	// the droplet is created so that it can be installed.
+	genTempKeyPair := !b.config.SkipKeygen && b.config.DropletID == 0 &&
+		((b.config.SSHKeyID == 0 && b.config.SSHKeyFingerprint == "" && b.config.SSHKeyName == "") ||
+			b.config.Comm.SSHPrivateKeyFile == "")
+	// A temporary key signed by a certificate authority isn't installed on
+	// the droplet.
+	signTempKey := b.config.SSHCAPrivateKeyFile != "" || len(b.config.SSHCASignerCommand) > 0
+	sshConfig := certificateSSHConfig(b.config.Comm.SSHConfigFunc())
+
+	retainDroplet := b.config.ArtifactType == ArtifactTypeDroplet
+
+	connect := &communicator.StepConnect{
+		Config:    &b.config.Comm,
+		Host:      bracketIPv6(communicator.CommHost(b.config.Comm.Host(), "droplet_ip")),
+		SSHConfig: sshConfig,
+	}
 
 	// Build the steps
 	steps := []multistep.Step{
+		&stepVerifyCleanup{
+			Attempts: 3,
+			Interval: 5 * time.Second,
+		},
+		&stepWebhook{Event: WebhookBuildFailed},
+		new(stepAPICapabilities),
+		&stepServiceStatus{Before: "the build", ReportFailure: true},
+		multistep.If(!retainDroplet && !b.config.MinimalAPIMode, new(stepSnapshotNameCheck)),
+		multistep.If(!b.config.MinimalAPIMode, new(stepAccount)),
+		new(stepConcurrency),
+		new(stepImportSourceImage),
+		new(stepSourceImageInfo),
+		new(stepDeprecatedImage),
+		new(stepDiskCompatibility),
+		new(stepSSHKeyFingerprint),
+		new(stepSSHKeyName),
 		multistep.If(genTempKeyPair,
 			&communicator.StepSSHKeyGen{
 				CommConf:            &b.config.Comm,
@@ -119,56 +206,164 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 				SSH:  &b.config.Comm.SSH,
 			},
 		),
-		multistep.If(genTempKeyPair, new(stepCreateSSHKey)),
+		multistep.If(genTempKeyPair && signTempKey, new(stepSignSSHKey)),
+		multistep.If(genTempKeyPair && !signTempKey, new(stepCreateSSHKey)),
+		commonsteps.HTTPServerFromHTTPConfig(&b.config.HTTPConfig),
+		new(stepHTTPTunnel),
+		new(stepVPCName),
+		new(stepProjectName),
+		new(stepVPCPeering),
+		new(stepTemporaryVPC),
+		new(stepBuildCost),
 		new(stepCreateDroplet),
+		new(stepAssignProject),
 		new(stepDropletInfo),
-		&communicator.StepConnect{
-			Config:    &b.config.Comm,
+		new(stepReservedIP),
+		new(stepAttachFirewalls),
+		new(stepTemporaryFirewall),
+		&stepWebhook{Event: WebhookDropletCreated},
+		&stepWaitSSHKey{
 			Host:      communicator.CommHost(b.config.Comm.Host(), "droplet_ip"),
-			SSHConfig: b.config.Comm.SSHConfigFunc(),
+			SSHConfig: sshConfig,
 		},
+		&stepConnectRecovery{Connect: connect},
+		&stepProvisionReconnect{Connect: connect},
+		new(stepWaitUserData),
+		new(stepWaitCloudInit),
+		new(stepWaitGPU),
+		new(stepSpacesAssets),
 		new(commonsteps.StepProvision),
+		&stepWebhook{Event: WebhookProvisioningFinished},
+		new(stepDropletMetrics),
 		multistep.If(genTempKeyPair,
 			&commonsteps.StepCleanupTempKeys{
 				Comm: &b.config.Comm,
 			},
 		),
-		new(stepShutdown),
-		new(stepPowerOff),
-		&stepSnapshot{
+		new(stepImageRelease),
+		new(stepGeneralize),
+		new(stepReclaimFreeSpace),
+		new(stepNetworkConfig),
+		multistep.If(!b.config.SkipSnapshot, &stepWaitPendingActions{Before: "the shutdown"}),
+		multistep.If(!b.config.SkipSnapshot && b.config.UserDataCompletion != UserDataCompletionPowerOff,
+			new(stepShutdown)),
+		multistep.If(!b.config.SkipSnapshot, new(stepPowerOff)),
+		multistep.If(!b.config.SkipSnapshot, new(stepPauseBeforeSnapshot)),
+		multistep.If(!b.config.SkipSnapshot, new(stepSnapshotVolumes)),
+		multistep.If(retainDroplet, new(stepRetainDroplet)),
+		multistep.If(!retainDroplet, new(stepNameRegistry)),
+		multistep.If(!retainDroplet, &stepServiceStatus{Before: "the snapshot"}),
+		multistep.If(!retainDroplet, &stepWaitPendingActions{Before: "the snapshot"}),
+		multistep.If(!retainDroplet, &stepSnapshot{
 			snapshotTimeout:         b.config.SnapshotTimeout,
 			transferTimeout:         b.config.TransferTimeout,
 			waitForSnapshotTransfer: *b.config.WaitSnapshotTransfer,
-		},
+			transferConcurrency:     b.config.TransferConcurrency,
+			transferRetries:         *b.config.TransferRetries,
+			snapshotRetryTimeout:    b.config.SnapshotRetryTimeout,
+		}),
+		multistep.If(!retainDroplet, new(stepTagSnapshot)),
+		multistep.If(!retainDroplet, new(stepReplaceSnapshots)),
+		multistep.If(!retainDroplet, new(stepDeprecateSnapshots)),
+		multistep.If(b.config.LeaveDropletRunning, new(stepPowerOn)),
+		multistep.If(!retainDroplet, &stepWebhook{Event: WebhookSnapshotCreated}),
 	}
 
 	// Run the steps
 	b.runner = commonsteps.NewRunner(steps, b.config.PackerConfig, ui)
-	b.runner.Run(ctx, state)
+	b.runner.Run(withPollInterval(ctx, b.config.StatePollInterval), state)
 
 	// If there was an error, return that
 	if rawErr, ok := state.GetOk("error"); ok {
 		return nil, rawErr.(error)
 	}
 
-	if _, ok := state.GetOk("snapshot_name"); !ok {
+	stateData := newArtifactState(state).StateData()
+
+	if retainDroplet {
+		artifact := &DropletArtifact{
+			DropletId:   stateDropletID.Get(state),
+			DropletName: b.config.DropletName,
+			RegionName:  b.config.Region,
+			Running:     b.config.SkipSnapshot,
+			Client:      client,
+			StateData:   stateData,
+		}
+		if b.config.SkipSnapshot {
+			droplet, _, err := client.Droplets.Get(context.TODO(), artifact.DropletId)
+			if err != nil {
+				ui.Error(fmt.Sprintf("Warning: Error retrieving the droplet's IP addresses: %s", err))
+			} else {
+				artifact.PublicIPv4, _ = droplet.PublicIPv4()
+				artifact.PrivateIPv4, _ = droplet.PrivateIPv4()
+				artifact.PublicIPv6, _ = droplet.PublicIPv6()
+			}
+		}
+
+		return artifact, nil
+	}
+
+	if _, ok := stateSnapshotName.GetOk(state); !ok {
 		log.Println("Failed to find snapshot_name in state. Bug?")
 		return nil, nil
 	}
 
 	artifact := &Artifact{
-		SnapshotName: state.Get("snapshot_name").(string),
-		SnapshotId:   state.Get("snapshot_image_id").(int),
-		RegionNames:  state.Get("regions").([]string),
+		SnapshotName: stateSnapshotName.Get(state),
+		SnapshotId:   stateSnapshotImageID.Get(state),
+		RegionNames:  stateRegions.Get(state),
 		Client:       client,
-		StateData: map[string]interface{}{
-			"generated_data":  state.Get("generated_data"),
-			"source_image_id": state.Get("source_image_id"),
-			"droplet_size":    state.Get("droplet_size"),
-			"droplet_name":    state.Get("droplet_name"),
-			"build_region":    state.Get("build_region"),
-		},
+		StateData:    stateData,
 	}
 
 	return artifact, nil
 }
+
+// newClient returns a client for the API, or for the recording
+// api_replay_file names. The requests go through recorder, unless it's nil.
+func newClient(c *Config, recorder *apiRecorder) (*godo.Client, error) {
+	var base http.RoundTripper = http.DefaultTransport
+	if c.APIReplayFile != "" {
+		recording, err := readAPIRecording(c.APIReplayFile)
+		if err != nil {
+			return nil, fmt.Errorf("DigitalOcean: could not read api_replay_file, %s", err)
+		}
+		base = newAPIReplayer(recording)
+	}
+	if recorder != nil {
+		recorder.base = base
+		base = recorder
+	}
+
+	return newClientWithTransport(c.APIToken, c.APIURL, &c.Retry, base)
+}
+
+// allSnapshotRegions returns the regions snapshot_regions ["all"] stands
+// for: the available regions other than the build's region and those in
+// snapshot_regions_exclude.
+func allSnapshotRegions(c *Config, regions []godo.Region) []string {
+	var all []string
+	for _, r := range regions {
+		if !r.Available || r.Slug == c.Region || containsString(c.SnapshotRegionsExclude, r.Slug) {
+			continue
+		}
+		all = append(all, r.Slug)
+	}
+	sort.Strings(all)
+	return all
+}
+
+// bracketIPv6 brackets the IPv6 addresses host returns, since the
+// communicators join the host and port without doing so.
+func bracketIPv6(host func(multistep.StateBag) (string, error)) func(multistep.StateBag) (string, error) {
+	return func(state multistep.StateBag) (string, error) {
+		h, err := host(state)
+		if err != nil {
+			return h, err
+		}
+		if ip := net.ParseIP(h); ip != nil && ip.To4() == nil {
+			return "[" + h + "]", nil
+		}
+		return h, nil
+	}
+}
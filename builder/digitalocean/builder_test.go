@@ -1,9 +1,14 @@
 package digitalocean
 
 import (
+	"path/filepath"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/digitalocean/godo"
 )
 
 func testConfig() map[string]interface{} {
@@ -182,6 +187,39 @@ func TestBuilderPrepare_StateTimeout(t *testing.T) {
 	}
 }
 
+func TestBuilderPrepare_UnlockTimeout(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test default
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	if b.config.UnlockTimeout != 6*time.Minute {
+		t.Errorf("invalid: %s", b.config.UnlockTimeout)
+	}
+
+	// Test set
+	config["unlock_timeout"] = "10m"
+	b = Builder{}
+	_, warnings, err = b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	if b.config.UnlockTimeout != 10*time.Minute {
+		t.Errorf("invalid: %s", b.config.UnlockTimeout)
+	}
+}
+
 func TestBuilderPrepare_SnapshotTimeout(t *testing.T) {
 	var b Builder
 	config := testConfig()
@@ -404,3 +442,1865 @@ func TestBuilderPrepare_ConnectWithPrivateIP(t *testing.T) {
 		t.Fatal("should not have error")
 	}
 }
+
+func TestBuilderPrepare_SSHInterface(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test default
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.SSHInterface != SSHInterfacePublicIP {
+		t.Errorf("invalid: %s", b.config.SSHInterface)
+	}
+
+	// Test the connect_with_private_ip alias
+	config["connect_with_private_ip"] = true
+	config["private_networking"] = true
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.SSHInterface != SSHInterfacePrivateIP {
+		t.Errorf("invalid: %s", b.config.SSHInterface)
+	}
+
+	// Test the alias with a conflicting interface
+	config["ssh_interface"] = "ipv6"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test ipv6
+	delete(config, "connect_with_private_ip")
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ConnectWithPrivateIP {
+		t.Error("connect_with_private_ip should not be set")
+	}
+	if !b.config.IPv6 {
+		t.Error("ipv6 should be enabled")
+	}
+
+	// Test private_ip without private networking
+	config["ssh_interface"] = "private_ip"
+	delete(config, "private_networking")
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test bad
+	config["ssh_interface"] = "public_dns"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_GPUImage(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test with an AI/ML image on a non-GPU size
+	config["image"] = "gpu-h100x1-base"
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with an AI/ML image on a GPU size
+	config["size"] = "gpu-h100x1-80gb"
+	b = Builder{}
+	_, warnings, err = b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
+
+func TestBuilderPrepare_GPUSize(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test defaults for a GPU size
+	config["size"] = "gpu-h100x8-640gb"
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.StateTimeout != 20*time.Minute {
+		t.Errorf("invalid: %s", b.config.StateTimeout)
+	}
+	if b.config.UnlockTimeout != 20*time.Minute {
+		t.Errorf("invalid: %s", b.config.UnlockTimeout)
+	}
+	if b.config.GPUReadyTimeout != 10*time.Minute {
+		t.Errorf("invalid: %s", b.config.GPUReadyTimeout)
+	}
+
+	// Test an explicit state timeout
+	config["state_timeout"] = "30m"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.StateTimeout != 30*time.Minute {
+		t.Errorf("invalid: %s", b.config.StateTimeout)
+	}
+
+	// Test a malformed GPU size
+	config["size"] = "gpu-h100"
+	b = Builder{}
+	_, warnings, err = b.Prepare(config)
+	if len(warnings) != 1 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
+
+func TestBuilderPrepare_SSHTunnels(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["ssh_remote_tunnels"] = []string{"8080:localhost:80"}
+	config["ssh_local_tunnels"] = []string{"5432:localhost:5432"}
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(b.config.Comm.SSHRemoteTunnels) != 1 || b.config.Comm.SSHRemoteTunnels[0] != "8080:localhost:80" {
+		t.Errorf("bad remote tunnels: %#v", b.config.Comm.SSHRemoteTunnels)
+	}
+	if len(b.config.Comm.SSHLocalTunnels) != 1 || b.config.Comm.SSHLocalTunnels[0] != "5432:localhost:5432" {
+		t.Errorf("bad local tunnels: %#v", b.config.Comm.SSHLocalTunnels)
+	}
+
+	// Test with an invalid tunnel
+	config["ssh_local_tunnels"] = []string{"localhost:5432"}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_ImageInit(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test default
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ImageInit != ImageInitAuto {
+		t.Errorf("invalid: %s", b.config.ImageInit)
+	}
+
+	// Test set
+	config["image_init"] = "none"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ImageInit != ImageInitNone {
+		t.Errorf("invalid: %s", b.config.ImageInit)
+	}
+
+	// Test bad
+	config["image_init"] = "systemd"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_HTTPReverseTunnel(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test default
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if *b.config.HTTPReverseTunnel {
+		t.Errorf("http_reverse_tunnel should default to false")
+	}
+
+	// Test default with private networking
+	config["private_networking"] = true
+	config["connect_with_private_ip"] = true
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !*b.config.HTTPReverseTunnel {
+		t.Errorf("http_reverse_tunnel should default to true with connect_with_private_ip")
+	}
+
+	// Test with a non-SSH communicator
+	config["http_reverse_tunnel"] = true
+	config["communicator"] = "none"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_SnapshotVolumes(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test without volumes
+	config["snapshot_volumes"] = true
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with volumes
+	config["volumes"] = []string{"506f78a4-e098-11e5-ad9f-000f53306ae1"}
+	config["snapshot_name"] = "golden"
+	b = Builder{}
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.VolumeSnapshotName != "golden" {
+		t.Errorf("invalid: %s", b.config.VolumeSnapshotName)
+	}
+	if b.config.VolumeSnapshotTimeout != 10*time.Minute {
+		t.Errorf("invalid: %s", b.config.VolumeSnapshotTimeout)
+	}
+
+	// Test with an invalid tag
+	config["volume_snapshot_tags"] = []string{"bad tag"}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_SpacesAssets(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test without Spaces credentials
+	config["spaces_assets"] = []map[string]interface{}{
+		{"bucket": "build-inputs", "prefix": "models/", "destination": "/opt/models"},
+	}
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with Spaces credentials
+	config["spaces_key"] = "DO00TESTSPACESKEY"
+	config["spaces_secret"] = "TESTSPACESSECRET"
+	b = Builder{}
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.SpacesAssets[0].Region != "nyc2" {
+		t.Errorf("invalid: %s", b.config.SpacesAssets[0].Region)
+	}
+	if b.config.SpacesURLTTL != time.Hour {
+		t.Errorf("invalid: %s", b.config.SpacesURLTTL)
+	}
+
+	// Test without a destination
+	config["spaces_assets"] = []map[string]interface{}{
+		{"bucket": "build-inputs"},
+	}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_SSHKeyFingerprint(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test without a private key file
+	config["ssh_key_fingerprint"] = "3b:16:bf:e4:8b:00:8b:b8:59:8c:a9:d3:f0:19:45:fa"
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with ssh_key_id
+	config["ssh_key_id"] = 512190
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_SSHKeyName(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test without a private key file
+	config["ssh_key_name"] = "ops"
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with ssh_key_fingerprint
+	config["ssh_key_fingerprint"] = "3b:16:bf:e4:8b:00:8b:b8:59:8c:a9:d3:f0:19:45:fa"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_SSHKeyIDs(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["ssh_key_ids"] = []int{1024, 2048}
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !reflect.DeepEqual(b.config.SSHKeyIDs, []int{1024, 2048}) {
+		t.Errorf("invalid: %v", b.config.SSHKeyIDs)
+	}
+
+	// Test bad
+	config["ssh_key_ids"] = []int{0}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_SSHCertificateAuthority(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["ssh_ca_signer_command"] = []string{"vault", "write", "-field=signed_key", "ssh/sign/packer", "public_key=-"}
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(b.config.SSHCertificatePrincipals) != 1 || b.config.SSHCertificatePrincipals[0] != "root" {
+		t.Errorf("invalid: %v", b.config.SSHCertificatePrincipals)
+	}
+	if b.config.SSHCertificateTTL != 2*time.Hour {
+		t.Errorf("invalid: %s", b.config.SSHCertificateTTL)
+	}
+
+	// Test with skip_keygen
+	config["skip_keygen"] = true
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with both a key and a command
+	delete(config, "skip_keygen")
+	config["ssh_ca_private_key_file"] = "/dev/null"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test principals without an authority
+	config = testConfig()
+	config["ssh_certificate_principals"] = []string{"root"}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_ImageTTL(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["image_ttl"] = "720h"
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ImageTTL != 720*time.Hour {
+		t.Errorf("invalid: %s", b.config.ImageTTL)
+	}
+
+	// Test shorter than a day
+	config["image_ttl"] = "12h"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with a droplet artifact
+	config["image_ttl"] = "720h"
+	config["artifact_type"] = "droplet"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_ImageRelease(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["image_release"] = map[string]interface{}{
+		"version": "1.4.0",
+		"extra":   map[string]string{"BUILD_URL": "https://ci.example.com/builds/42"},
+	}
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ImageRelease.Path != "/etc/image-release" {
+		t.Errorf("invalid: %s", b.config.ImageRelease.Path)
+	}
+
+	// Test with an invalid extra key
+	config["image_release"] = map[string]interface{}{
+		"extra": map[string]string{"build-url": "https://ci.example.com/builds/42"},
+	}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with a relative path
+	config["image_release"] = map[string]interface{}{"path": "image-release"}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_EnvExpansion(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	t.Setenv("PACKER_TEST_DO_REGION", "ams3")
+	t.Setenv("PACKER_TEST_DO_SIZE", "s-2vcpu-2gb")
+
+	config["region"] = `env("PACKER_TEST_DO_REGION")`
+	config["size"] = `env("PACKER_TEST_DO_SIZE")`
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.Region != "ams3" {
+		t.Errorf("invalid: %s", b.config.Region)
+	}
+	if b.config.Size != "s-2vcpu-2gb" {
+		t.Errorf("invalid: %s", b.config.Size)
+	}
+
+	// Test with an unset variable
+	config["image"] = `env("PACKER_TEST_DO_UNSET_IMAGE")`
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_Webhooks(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["webhook"] = []map[string]interface{}{
+		{"url": "https://hooks.example.com/packer"},
+	}
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(b.config.Webhooks[0].Events) != 4 {
+		t.Errorf("invalid: %v", b.config.Webhooks[0].Events)
+	}
+
+	// The default events are a copy, which the webhook can't change for
+	// the others.
+	b.config.Webhooks[0].Events[0] = "changed"
+	if validWebhookEvents[0] == "changed" {
+		t.Fatal("the default events should not be shared")
+	}
+
+	// Test with an invalid event
+	config["webhook"] = []map[string]interface{}{
+		{"url": "https://hooks.example.com/packer", "events": []string{"droplet_deleted"}},
+	}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test without a url
+	config["webhook"] = []map[string]interface{}{
+		{"events": []string{WebhookBuildFailed}},
+	}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_ArtifactType(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test default
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ArtifactType != ArtifactTypeSnapshot {
+		t.Errorf("invalid: %s", b.config.ArtifactType)
+	}
+
+	// Test droplet
+	config["artifact_type"] = "droplet"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	// Test droplet with snapshot regions
+	config["snapshot_regions"] = []string{"ams3"}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test invalid
+	delete(config, "snapshot_regions")
+	config["artifact_type"] = "image"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_GeneratedData(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	generatedData, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	for _, key := range []string{"GPUDriverVersion", "CUDAVersion", "DropletID", "DropletName", "DropletIP", "Region", "Size"} {
+		found := false
+		for _, k := range generatedData {
+			if k == key {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("generated data should declare %s: %v", key, generatedData)
+		}
+	}
+}
+
+func TestBuilderPrepare_BackupPolicy(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test without backups
+	config["backup_policy"] = map[string]interface{}{"plan": "weekly", "weekday": "SUN", "hour": 4}
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with backups
+	config["backups"] = true
+	b = Builder{}
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.BackupPolicy.Hour != 4 {
+		t.Errorf("invalid: %d", b.config.BackupPolicy.Hour)
+	}
+}
+
+func TestBuilderPrepare_SnapshotNameRegistry(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["snapshot_name_registry"] = map[string]interface{}{"url": "registry.example.com"}
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	config["snapshot_name_registry"] = map[string]interface{}{"url": "https://registry.example.com/names"}
+	b = Builder{}
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.SnapshotNameRegistry.Timeout != 10*time.Second {
+		t.Errorf("invalid: %s", b.config.SnapshotNameRegistry.Timeout)
+	}
+
+	// Test with a droplet artifact
+	config["artifact_type"] = "droplet"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_MinimalAPIMode(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["minimal_api_mode"] = true
+	config["snapshot_regions"] = []string{"ams3"}
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	// Test with options needing account-wide reads
+	for _, option := range []string{"install_account_keys", "catalog_warnings", "force"} {
+		config := testConfig()
+		config["minimal_api_mode"] = true
+		config[option] = true
+		b = Builder{}
+		_, _, err = b.Prepare(config)
+		if err == nil {
+			t.Fatalf("%s should have error", option)
+		}
+	}
+
+	config["project_name"] = "platform-images"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_ReservedIP(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["reserved_ip"] = "45.55.96.47"
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	// Test with both ways of getting a reserved IP
+	config["assign_reserved_ip"] = true
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with an invalid address
+	for _, ip := range []string{"45.55.96", "2604:a880:800:14::4"} {
+		config := testConfig()
+		config["reserved_ip"] = ip
+		b = Builder{}
+		_, _, err = b.Prepare(config)
+		if err == nil {
+			t.Fatalf("%s should have error", ip)
+		}
+	}
+}
+
+func TestBuilderPrepare_FirewallTag(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["firewall_ids"] = []string{"bb4b2611-3d72-467b-8602-280330ecd65c"}
+	config["firewall_tag"] = "build-firewall"
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	// Test with an invalid tag
+	config["firewall_tag"] = "build firewall"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_TemporaryFirewall(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["temporary_firewall"] = true
+	config["temporary_firewall_source_cidrs"] = []string{"203.0.113.0/24", "198.51.100.7"}
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	// Test with an invalid source
+	config["temporary_firewall_source_cidrs"] = []string{"203.0.113.0/33"}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with a bastion and no sources
+	config = testConfig()
+	config["temporary_firewall"] = true
+	config["ssh_bastion_host"] = "bastion.example.com"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_PublicIPURL(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	// Left empty so the HTTP server address is only looked up on request.
+	if b.config.PublicIPURL != "" {
+		t.Errorf("invalid: %s", b.config.PublicIPURL)
+	}
+
+	config["public_ip_url"] = "https://ip.example.com/v4"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.PublicIPURL != "https://ip.example.com/v4" {
+		t.Errorf("invalid: %s", b.config.PublicIPURL)
+	}
+
+	config["public_ip_url"] = "ip.example.com"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_OutboundLockdown(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["outbound_lockdown"] = true
+	config["temporary_firewall_source_cidrs"] = []string{"198.51.100.7"}
+	config["outbound_allow"] = []map[string]interface{}{
+		{"addresses": []string{"10.0.0.0/8"}, "ports": "443"},
+		{"addresses": []string{"67.207.67.2", "67.207.67.3"}, "protocol": "udp", "ports": "53"},
+	}
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !b.config.TemporaryFirewall {
+		t.Fatal("outbound_lockdown should create a temporary firewall")
+	}
+	if b.config.OutboundAllow[0].Protocol != "tcp" {
+		t.Errorf("invalid: %s", b.config.OutboundAllow[0].Protocol)
+	}
+
+	// Test with firewalls that may allow more
+	config["firewall_tag"] = "build-firewall"
+	b = Builder{}
+	_, warnings, err = b.Prepare(config)
+	if len(warnings) == 0 {
+		t.Fatal("should have warning")
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	// Test with an invalid rule
+	config = testConfig()
+	config["outbound_lockdown"] = true
+	config["outbound_allow"] = []map[string]interface{}{
+		{"addresses": []string{"mirror.example.com"}, "protocol": "sctp"},
+	}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test without outbound_lockdown
+	delete(config, "outbound_lockdown")
+	config["outbound_allow"] = []map[string]interface{}{
+		{"addresses": []string{"10.0.0.0/8"}},
+	}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_ConcurrencyPolicy(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["concurrency_policy"] = "wait"
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ConcurrencyTimeout != 30*time.Minute {
+		t.Errorf("invalid: %s", b.config.ConcurrencyTimeout)
+	}
+
+	// Test with an invalid policy
+	config["concurrency_policy"] = "queue"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with minimal_api_mode
+	config["concurrency_policy"] = "fail"
+	config["minimal_api_mode"] = true
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_TemporaryVPC(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["temporary_vpc"] = true
+	config["temporary_vpc_ip_range"] = "10.200.0.0/24"
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !b.config.PrivateNetworking {
+		t.Error("temporary_vpc should enable private networking")
+	}
+
+	for key, value := range map[string]interface{}{
+		"vpc_uuid":                "5a4981aa-9653-4bd1-bef5-d6bff52042e4",
+		"connect_with_private_ip": true,
+		"artifact_type":           "droplet",
+		"temporary_vpc_ip_range":  "10.200.0.0",
+	} {
+		config := testConfig()
+		config["temporary_vpc"] = true
+		config["private_networking"] = true
+		config[key] = value
+		b = Builder{}
+		_, _, err = b.Prepare(config)
+		if err == nil {
+			t.Fatalf("%s should have error", key)
+		}
+	}
+}
+
+func TestBuilderPrepare_VPCName(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["vpc_name"] = "build-nyc3"
+	config["private_networking"] = true
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	// Test with a UUID too
+	config["vpc_uuid"] = "5a4981aa-9653-4bd1-bef5-d6bff52042e4"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test without private networking
+	delete(config, "vpc_uuid")
+	delete(config, "private_networking")
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_DefunctOptions(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test a renamed option
+	config["floating_ip"] = "192.0.2.10"
+	_, warnings, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "reserved_ip") {
+		t.Fatalf("bad warnings: %#v", warnings)
+	}
+	if b.config.ReservedIP != "192.0.2.10" {
+		t.Errorf("invalid: %s", b.config.ReservedIP)
+	}
+
+	// Test a renamed option along with its replacement
+	config["reserved_ip"] = "192.0.2.11"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+	delete(config, "floating_ip")
+	delete(config, "reserved_ip")
+
+	// Test a deprecated option
+	config["http_retry_max"] = 3
+	b = Builder{}
+	_, warnings, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "max_retries") {
+		t.Fatalf("bad warnings: %#v", warnings)
+	}
+	if *b.config.Retry.MaxRetries != 3 {
+		t.Errorf("invalid: %d", *b.config.Retry.MaxRetries)
+	}
+	delete(config, "http_retry_max")
+
+	// Test the options of other builders
+	config["ami_name"] = "app-{{timestamp}}"
+	config["instance_type"] = "t3.micro"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+	for _, hint := range []string{"ami_name: this is an Amazon builder option, use snapshot_name instead",
+		"instance_type: this is an Amazon builder option, use size instead"} {
+		if !strings.Contains(err.Error(), hint) {
+			t.Errorf("error should contain %q: %s", hint, err)
+		}
+	}
+}
+
+func TestBuilderPrepare_TemporaryKeyPair(t *testing.T) {
+	tests := []struct {
+		keyType  string
+		bits     int
+		valid    bool
+		warnings int
+	}{
+		{keyType: "", bits: 0, valid: true},
+		{keyType: "", bits: 3072, valid: true},
+		{keyType: "rsa", bits: 512, valid: false},
+		{keyType: "ecdsa", bits: 384, valid: true},
+		{keyType: "ecdsa", bits: 512, valid: false},
+		{keyType: "ed25519", bits: 0, valid: true},
+		{keyType: "ed25519", bits: 4096, valid: true, warnings: 1},
+		{keyType: "dsa", bits: 0, valid: false},
+		{keyType: "ssh-rsa", bits: 0, valid: false},
+	}
+
+	for _, tt := range tests {
+		var b Builder
+		config := testConfig()
+		if tt.keyType != "" {
+			config["temporary_key_pair_type"] = tt.keyType
+		}
+		if tt.bits != 0 {
+			config["temporary_key_pair_bits"] = tt.bits
+		}
+
+		_, warnings, err := b.Prepare(config)
+		if len(warnings) != tt.warnings {
+			t.Errorf("%s/%d: bad warnings: %#v", tt.keyType, tt.bits, warnings)
+		}
+		if tt.valid && err != nil {
+			t.Errorf("%s/%d: should not have error: %s", tt.keyType, tt.bits, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s/%d: should have error", tt.keyType, tt.bits)
+		}
+	}
+}
+
+func TestBuilderPrepare_SampleMetrics(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test without monitoring
+	config["sample_metrics"] = true
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	config["monitoring"] = true
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
+
+func TestBuilderPrepare_UserDataSize(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["user_data"] = "#cloud-config\n" + strings.Repeat("# padding\n", 7000)
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	config["compress_user_data"] = true
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
+
+func TestBuilderPrepare_ValidateUserData(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["user_data"] = "#cloud-config\nruncmd: echo hello\n"
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	config["validate_user_data"] = true
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil || !strings.Contains(err.Error(), "line 2, column 9: runcmd must be a list") {
+		t.Fatalf("bad error: %v", err)
+	}
+
+	config["user_data"] = "#cloud-config\nruncmd:\n  - echo hello\n"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
+
+func TestBuilderPrepare_WaitForCloudInit(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["wait_for_cloud_init"] = true
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.CloudInitTimeout != 10*time.Minute {
+		t.Errorf("invalid: %s", b.config.CloudInitTimeout)
+	}
+
+	config["image_init"] = "none"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	delete(config, "image_init")
+	config["communicator"] = "none"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_CheckServiceStatus(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["service_status_wait"] = "30m"
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	config["check_service_status"] = true
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ServiceStatusURL != "https://status.digitalocean.com" {
+		t.Errorf("invalid: %s", b.config.ServiceStatusURL)
+	}
+}
+
+func TestBuilderPrepare_HCPImageIDFormat(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.HCPImageIDFormat != "{{ .ID }}" {
+		t.Errorf("invalid: %s", b.config.HCPImageIDFormat)
+	}
+
+	config["hcp_image_id_format"] = "{{ .Region }}:{{ .ID }}"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.HCPImageIDFormat != "{{ .Region }}:{{ .ID }}" {
+		t.Errorf("invalid: %s", b.config.HCPImageIDFormat)
+	}
+
+	config["hcp_image_id_format"] = "{{ .Zone }}:{{ .ID }}"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_ShutdownTimeout(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["state_timeout"] = "10m"
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ShutdownTimeout != 10*time.Minute {
+		t.Errorf("invalid: %s", b.config.ShutdownTimeout)
+	}
+	if !*b.config.PowerOffFallback {
+		t.Error("power_off_fallback should default to true")
+	}
+
+	config["shutdown_timeout"] = "2m"
+	config["power_off_fallback"] = false
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ShutdownTimeout != 2*time.Minute || *b.config.PowerOffFallback {
+		t.Errorf("invalid: %s %t", b.config.ShutdownTimeout, *b.config.PowerOffFallback)
+	}
+}
+
+func TestBuilderPrepare_RequiredVPCPeerings(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test without a VPC
+	config["required_vpc_peerings"] = []string{"e0fe0f4d-596a-465e-a902-571ce57b79fa"}
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	config["private_networking"] = true
+	config["vpc_uuid"] = "5a4981aa-9653-4bd1-bef5-d6bff52042e4"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	// Test a peering with the build VPC itself
+	config["required_vpc_peerings"] = []string{"5a4981aa-9653-4bd1-bef5-d6bff52042e4"}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_UserDataVariables(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["snapshot_name"] = "app-1.4.0"
+	config["user_data"] = "#cloud-config\nhostname: {{ .DropletName }}\n" +
+		"# {{ .SnapshotName }} {{ .Region }} {{ .Size }} {{ .Image }}\n"
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	if !strings.HasPrefix(b.config.DropletName, "packer-") {
+		t.Fatalf("bad droplet name: %s", b.config.DropletName)
+	}
+	expected := "#cloud-config\nhostname: " + b.config.DropletName + "\n" +
+		"# app-1.4.0 nyc2 s-1vcpu-1gb foo\n"
+	if b.config.UserData != expected {
+		t.Errorf("bad user data: %q", b.config.UserData)
+	}
+
+	// Test the build UUID, which names the droplet by default
+	config["user_data"] = "{{ .BuildUUID }}"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.DropletName != "packer-"+b.config.UserData {
+		t.Errorf("bad build UUID: %q for droplet %s", b.config.UserData, b.config.DropletName)
+	}
+
+	// Test an invalid template
+	config["user_data"] = "{{ .Unknown }}"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_APIRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.json")
+	if err := new(apiRecorder).Save(path); err != nil {
+		t.Fatalf("save: %s", err)
+	}
+
+	var b Builder
+	config := testConfig()
+	delete(config, "api_token")
+	config["api_replay_file"] = path
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("replaying should not need a token: %s", err)
+	}
+
+	config["api_record_file"] = filepath.Join(t.TempDir(), "new.json")
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	delete(config, "api_record_file")
+	config["api_replay_file"] = filepath.Join(t.TempDir(), "missing.json")
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_PauseBeforeSnapshot(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["pause_before_snapshot"] = "30s"
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.PauseBeforeSnapshot != 30*time.Second {
+		t.Errorf("invalid: %s", b.config.PauseBeforeSnapshot)
+	}
+
+	config["pause_before_snapshot"] = "-1s"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_SnapshotTags(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["snapshot_tags"] = []string{"team:web", "base"}
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !reflect.DeepEqual(b.config.SnapshotTags, []string{"team:web", "base"}) {
+		t.Errorf("invalid: %v", b.config.SnapshotTags)
+	}
+
+	config["snapshot_tags"] = []string{"not a tag"}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_DeprecatePrevious(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["snapshot_name"] = "web-{{timestamp}}"
+	config["deprecate_previous"] = true
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	config["deprecate_prefix"] = "web-"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.OnDeprecatedImage != OnDeprecatedImageWarn {
+		t.Errorf("invalid: %s", b.config.OnDeprecatedImage)
+	}
+
+	config["artifact_type"] = "droplet"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	delete(config, "artifact_type")
+	config["on_deprecated_image"] = "ignore"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	delete(config, "on_deprecated_image")
+	config["minimal_api_mode"] = true
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_SnapshotRegionsAll(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["snapshot_regions"] = []string{"all"}
+	config["snapshot_regions_exclude"] = []string{"sgp1"}
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	config["snapshot_regions"] = []string{"all", "ams3"}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	config["snapshot_regions"] = []string{"ams3"}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("snapshot_regions_exclude without all should have error")
+	}
+
+	config["snapshot_regions"] = []string{"all"}
+	config["minimal_api_mode"] = true
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestAllSnapshotRegions(t *testing.T) {
+	regions := []godo.Region{
+		{Slug: "sgp1", Available: true},
+		{Slug: "nyc2", Available: true},
+		{Slug: "ams3", Available: true},
+		{Slug: "sfo1", Available: false},
+		{Slug: "fra1", Available: true},
+	}
+	c := &Config{Region: "nyc2", SnapshotRegionsExclude: []string{"sgp1"}}
+
+	got := allSnapshotRegions(c, regions)
+	if want := []string{"ams3", "fra1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad regions: %v, want %v", got, want)
+	}
+}
+
+func TestBuilderPrepare_StatePollInterval(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.StatePollInterval != 3*time.Second {
+		t.Errorf("invalid: %s", b.config.StatePollInterval)
+	}
+
+	config["state_poll_interval"] = "15s"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.StatePollInterval != 15*time.Second {
+		t.Errorf("invalid: %s", b.config.StatePollInterval)
+	}
+
+	config["state_poll_interval"] = "-1s"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_SnapshotRetryTimeout(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.SnapshotRetryTimeout != 5*time.Minute {
+		t.Errorf("invalid: %s", b.config.SnapshotRetryTimeout)
+	}
+
+	config["snapshot_retry_timeout"] = "15m"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.SnapshotRetryTimeout != 15*time.Minute {
+		t.Errorf("invalid: %s", b.config.SnapshotRetryTimeout)
+	}
+
+	config["snapshot_retry_timeout"] = "-1m"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_RegionFallbacks(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["region_fallbacks"] = []string{"sfo3", "ams3"}
+
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !reflect.DeepEqual(b.config.RegionFallbacks, []string{"sfo3", "ams3"}) {
+		t.Errorf("invalid: %v", b.config.RegionFallbacks)
+	}
+
+	config["region_fallbacks"] = []string{config["region"].(string)}
+	b = Builder{}
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error for the build region as a fallback")
+	}
+
+	config["region_fallbacks"] = []string{"sfo3"}
+	config["temporary_vpc"] = true
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil || !strings.Contains(err.Error(), "temporary_vpc can not be used with region_fallbacks") {
+		t.Fatalf("should have error: %v", err)
+	}
+}
+
+func TestBuilderPrepare_SizeFallbacks(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["size_fallbacks"] = []string{"s-4vcpu-8gb-amd"}
+
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !reflect.DeepEqual(b.config.SizeFallbacks, []string{"s-4vcpu-8gb-amd"}) {
+		t.Errorf("invalid: %v", b.config.SizeFallbacks)
+	}
+
+	config["size_fallbacks"] = []string{config["size"].(string)}
+	b = Builder{}
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error for the size as a fallback")
+	}
+
+	config["size_fallbacks"] = []string{"gpu-h100x1-80gb"}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil || !strings.Contains(err.Error(), "can't mix GPU and other sizes") {
+		t.Fatalf("should have error: %v", err)
+	}
+}
+
+func TestBuilderPrepare_DropletID(t *testing.T) {
+	config := map[string]interface{}{
+		"api_token":    "bar",
+		"droplet_id":   3164444,
+		"ssh_username": "root",
+		"ssh_password": "secret",
+	}
+
+	var b Builder
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.DropletID != 3164444 {
+		t.Errorf("invalid: %d", b.config.DropletID)
+	}
+
+	config["region"] = "nyc3"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil || !strings.Contains(err.Error(), "region can not be used with droplet_id") {
+		t.Fatalf("should have error: %v", err)
+	}
+
+	delete(config, "region")
+	delete(config, "ssh_password")
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil || !strings.Contains(err.Error(), "droplet_id requires ssh_private_key_file") {
+		t.Fatalf("should have error: %v", err)
+	}
+
+	config = testConfig()
+	config["leave_droplet_running"] = true
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil || !strings.Contains(err.Error(), "leave_droplet_running requires droplet_id") {
+		t.Fatalf("should have error: %v", err)
+	}
+}
+
+func TestBuilderPrepare_SkipSnapshot(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["skip_snapshot"] = true
+
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ArtifactType != ArtifactTypeDroplet {
+		t.Errorf("skip_snapshot should keep the droplet: %s", b.config.ArtifactType)
+	}
+
+	config["artifact_type"] = ArtifactTypeSnapshot
+	b = Builder{}
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_UserDataCompletion(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test default
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.UserDataCompletionTag != "packer-user-data-done" {
+		t.Errorf("invalid: %s", b.config.UserDataCompletionTag)
+	}
+	if b.config.UserDataTimeout != 30*time.Minute {
+		t.Errorf("invalid: %s", b.config.UserDataTimeout)
+	}
+
+	// Test with the none communicator and user data
+	config["communicator"] = "none"
+	config["user_data"] = "#!/bin/sh\ndoctl compute droplet tag ..."
+	config["user_data_completion"] = UserDataCompletionTag
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	// Test with an invalid value
+	config["user_data_completion"] = "agent"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test without user data
+	config["user_data_completion"] = UserDataCompletionPowerOff
+	delete(config, "user_data")
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with the SSH communicator
+	config["user_data"] = "#!/bin/sh\npoweroff"
+	config["communicator"] = "ssh"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_Generalize(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["generalize"] = true
+
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	config["communicator"] = "none"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_ReclaimFreeSpace(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["reclaim_free_space"] = ReclaimFreeSpaceTrim
+
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	config["reclaim_free_space"] = "discard"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_SourceImageURL(t *testing.T) {
+	t.Setenv("DIGITALOCEAN_SPACES_ACCESS_KEY", "")
+	t.Setenv("DIGITALOCEAN_SPACES_SECRET_KEY", "")
+
+	var b Builder
+	config := testConfig()
+	delete(config, "image")
+	config["source_image_url"] = "https://cloud-images.ubuntu.com/jammy/current/jammy-server-cloudimg-amd64.img"
+
+	// Test default
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.SourceImageDistribution != "Unknown" {
+		t.Errorf("invalid: %s", b.config.SourceImageDistribution)
+	}
+	if b.config.SourceImageTimeout != 30*time.Minute {
+		t.Errorf("invalid: %s", b.config.SourceImageTimeout)
+	}
+
+	// Test bad
+	for _, set := range []map[string]interface{}{
+		{"image": "foo"},
+		{"region_fallbacks": []string{"nyc3"}},
+		{"source_image_url": "ftp://example.com/disk.img"},
+		{"source_image_url": "spaces://images/disk.img"},
+		{"source_image_url": "spaces://images", "spaces_key": "DO00SPACESKEY", "spaces_secret": "spaces-secret-key"},
+		{"spaces_region": "nyc3"},
+	} {
+		bad := testConfig()
+		delete(bad, "image")
+		bad["source_image_url"] = config["source_image_url"]
+		for k, v := range set {
+			bad[k] = v
+		}
+		b = Builder{}
+		if _, _, err := b.Prepare(bad); err == nil {
+			t.Errorf("should have error: %v", set)
+		}
+	}
+
+	// Test Spaces
+	config["source_image_url"] = "spaces://images/disk.img"
+	config["spaces_key"] = "DO00SPACESKEY"
+	config["spaces_secret"] = "spaces-secret-key"
+	b = Builder{}
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.SpacesRegion != b.config.Region {
+		t.Errorf("invalid: %s", b.config.SpacesRegion)
+	}
+	config["spaces_region"] = "ams3"
+	b = Builder{}
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.SpacesRegion != "ams3" {
+		t.Errorf("invalid: %s", b.config.SpacesRegion)
+	}
+
+	// Test keep_source_image without source_image_url
+	config = testConfig()
+	config["keep_source_image"] = true
+	b = Builder{}
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error")
+	}
+}
@@ -0,0 +1,144 @@
+package digitalocean
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/digitalocean/godo"
+)
+
+// catalogWarnings checks the configured image, size and region against the
+// live catalog and returns a warning for each one that is retired or no
+// longer available. Failures to query the catalog are reported as warnings
+// too, since the check is advisory.
+func catalogWarnings(client *godo.Client, c *Config) []string {
+	// An image imported from source_image_url doesn't exist yet.
+	var image *godo.Image
+	var imageErr error
+	if c.Image != "" {
+		image, imageErr = getImage(client, c.Image)
+	}
+	sizes, sizesErr := listSizes(client)
+	regions, regionsErr := listRegions(client)
+
+	var warns []string
+	for _, err := range []error{imageErr, sizesErr, regionsErr} {
+		if err != nil {
+			warns = append(warns, fmt.Sprintf("Unable to check the DigitalOcean catalog: %s", err))
+		}
+	}
+
+	if imageErr != nil {
+		image = nil
+	}
+	if sizesErr != nil {
+		sizes = nil
+	}
+	if regionsErr != nil {
+		regions = nil
+	}
+
+	return append(warns, compareCatalog(c, image, sizes, regions)...)
+}
+
+// compareCatalog returns the deprecation warnings for the configuration.
+// A nil image, sizes or regions skips the corresponding checks.
+func compareCatalog(c *Config, image *godo.Image, sizes []godo.Size, regions []godo.Region) []string {
+	var warns []string
+
+	if image != nil {
+		if image.Status != "" && image.Status != "available" {
+			warns = append(warns, fmt.Sprintf(
+				"The image %s has status %q and may be removed; builds using it may start failing.",
+				c.Image, image.Status))
+		}
+		if len(image.Regions) > 0 && !containsString(image.Regions, c.Region) {
+			warns = append(warns, fmt.Sprintf(
+				"The image %s is no longer offered in region %s.", c.Image, c.Region))
+		}
+	}
+
+	if sizes != nil {
+		var size *godo.Size
+		for i := range sizes {
+			if sizes[i].Slug == c.Size {
+				size = &sizes[i]
+			}
+		}
+		if size == nil {
+			warns = append(warns, fmt.Sprintf(
+				"The size %s is not in the DigitalOcean catalog; it may have been retired.", c.Size))
+		} else if !size.Available {
+			warns = append(warns, fmt.Sprintf(
+				"The size %s is no longer available for new droplets.", c.Size))
+		}
+	}
+
+	if regions != nil {
+		for _, r := range regions {
+			if r.Slug == c.Region && !r.Available {
+				warns = append(warns, fmt.Sprintf(
+					"The region %s is not accepting new droplets.", c.Region))
+			}
+		}
+	}
+
+	return warns
+}
+
+// checkPlacements checks that the droplet can be created with its size in
+// its region, according to the sizes each region offers. With
+// size_fallbacks or region_fallbacks, it returns a warning for each
+// unavailable combination, and an error only if none is available.
+func checkPlacements(c *Config, regions []godo.Region) ([]string, error) {
+	var unavailable []string
+	placements := dropletPlacements(c)
+	for _, p := range placements {
+		if reason := placementUnavailable(p, regions); reason != "" {
+			unavailable = append(unavailable, reason)
+		}
+	}
+
+	switch {
+	case len(unavailable) < len(placements):
+		return unavailable, nil
+	case len(placements) == 1:
+		return nil, errors.New(unavailable[0])
+	default:
+		return nil, fmt.Errorf("no size and region the droplet can be created with is available:\n  %s",
+			strings.Join(unavailable, "\n  "))
+	}
+}
+
+// placementUnavailable returns why the droplet can't be created with p, or
+// "" if it can.
+func placementUnavailable(p dropletPlacement, regions []godo.Region) string {
+	for _, r := range regions {
+		if r.Slug != p.region {
+			continue
+		}
+		if !r.Available {
+			return fmt.Sprintf("the region %s is not accepting new droplets", p.region)
+		}
+		if !containsString(r.Sizes, p.size) {
+			return fmt.Sprintf("the size %s is not available in %s; the sizes available there are %s",
+				p.size, p.region, strings.Join(r.Sizes, ", "))
+		}
+		return ""
+	}
+	return fmt.Sprintf("the region %s does not exist", p.region)
+}
+
+func listSizes(client *godo.Client) ([]godo.Size, error) {
+	return ListAll(context.TODO(), client.Sizes.List)
+}
+
+func listUserImages(client *godo.Client) ([]godo.Image, error) {
+	return ListAll(context.TODO(), client.Images.ListUser)
+}
+
+func listRegions(client *godo.Client) ([]godo.Region, error) {
+	return ListAll(context.TODO(), client.Regions.List)
+}
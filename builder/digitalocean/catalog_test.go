@@ -0,0 +1,99 @@
+package digitalocean
+
+import (
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareCatalog(t *testing.T) {
+	c := &Config{Image: "ubuntu-18-04-x64", Size: "s-1vcpu-1gb", Region: "nyc3"}
+
+	tests := []struct {
+		name    string
+		image   *godo.Image
+		sizes   []godo.Size
+		regions []godo.Region
+		warns   int
+	}{
+		{
+			name:    "current",
+			image:   &godo.Image{Status: "available", Regions: []string{"nyc3"}},
+			sizes:   []godo.Size{{Slug: "s-1vcpu-1gb", Available: true}},
+			regions: []godo.Region{{Slug: "nyc3", Available: true}},
+		},
+		{
+			name:  "retired image",
+			image: &godo.Image{Status: "retired", Regions: []string{"nyc3"}},
+			warns: 1,
+		},
+		{
+			name:  "image not in region",
+			image: &godo.Image{Status: "available", Regions: []string{"sfo3"}},
+			warns: 1,
+		},
+		{
+			name:  "retired size",
+			sizes: []godo.Size{{Slug: "s-1vcpu-2gb", Available: true}},
+			warns: 1,
+		},
+		{
+			name:  "unavailable size",
+			sizes: []godo.Size{{Slug: "s-1vcpu-1gb", Available: false}},
+			warns: 1,
+		},
+		{
+			name:    "unavailable region",
+			regions: []godo.Region{{Slug: "nyc3", Available: false}},
+			warns:   1,
+		},
+		{
+			name: "nothing to check",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warns := compareCatalog(c, tt.image, tt.sizes, tt.regions)
+			require.Len(t, warns, tt.warns, "warnings: %v", warns)
+		})
+	}
+}
+
+func TestCheckPlacements(t *testing.T) {
+	regions := []godo.Region{
+		{Slug: "nyc3", Available: true, Sizes: []string{"s-1vcpu-1gb", "s-4vcpu-8gb"}},
+		{Slug: "sfo3", Available: true, Sizes: []string{"s-4vcpu-8gb-amd"}},
+		{Slug: "nyc1", Available: false, Sizes: []string{"s-4vcpu-8gb"}},
+	}
+
+	warns, err := checkPlacements(&Config{Region: "nyc3", Size: "s-4vcpu-8gb"}, regions)
+	require.NoError(t, err)
+	require.Empty(t, warns)
+
+	_, err = checkPlacements(&Config{Region: "sfo3", Size: "s-4vcpu-8gb"}, regions)
+	require.EqualError(t, err, "the size s-4vcpu-8gb is not available in sfo3; the sizes available there are s-4vcpu-8gb-amd")
+
+	_, err = checkPlacements(&Config{Region: "nyc1", Size: "s-4vcpu-8gb"}, regions)
+	require.EqualError(t, err, "the region nyc1 is not accepting new droplets")
+
+	_, err = checkPlacements(&Config{Region: "xyz1", Size: "s-4vcpu-8gb"}, regions)
+	require.EqualError(t, err, "the region xyz1 does not exist")
+
+	// A fallback is available.
+	warns, err = checkPlacements(&Config{
+		Region:          "sfo3",
+		RegionFallbacks: []string{"nyc3"},
+		Size:            "s-4vcpu-8gb",
+	}, regions)
+	require.NoError(t, err)
+	require.Len(t, warns, 1)
+
+	_, err = checkPlacements(&Config{
+		Region:        "sfo3",
+		Size:          "s-4vcpu-8gb",
+		SizeFallbacks: []string{"s-1vcpu-1gb"},
+	}, regions)
+	require.ErrorContains(t, err, "no size and region the droplet can be created with is available")
+}
@@ -0,0 +1,70 @@
+package digitalocean
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+// cleanupAttempts bounds how often a failed cleanup API call is made.
+const cleanupAttempts = 5
+
+// cleanupRetryWait is the wait before the first retry of a cleanup call. It
+// doubles with every retry.
+const cleanupRetryWait = 2 * time.Second
+
+// retryCleanup makes the API call op that deletes resource, retrying it with
+// backoff while it fails with a transient error. A 404 response means the
+// resource is already gone and counts as success. A failure that remains is
+// recorded in the state so that it is reported with the other leftover
+// resources at the end of the build.
+func retryCleanup(state multistep.StateBag, resource string, op func() (*godo.Response, error)) error {
+	wait := cleanupRetryWait
+	var err error
+	for attempt := 1; ; attempt++ {
+		var resp *godo.Response
+		resp, err = op()
+		if err == nil || isNotFound(resp) {
+			return nil
+		}
+		if attempt >= cleanupAttempts || !isTransientCleanupError(resp) {
+			break
+		}
+
+		log.Printf("[DEBUG] Error deleting %s (attempt %d/%d), retrying in %s: %s",
+			resource, attempt, cleanupAttempts, wait, err)
+		<-waitClock.After(wait)
+		wait *= 2
+	}
+
+	cleanupFailures(state)[resource] = err
+	return err
+}
+
+func isNotFound(resp *godo.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotFound
+}
+
+// isTransientCleanupError reports whether a deletion that failed with resp
+// may succeed when made again: the API couldn't be reached, rate limited the
+// request or failed itself. Other errors, such as a bad token, won't go away.
+func isTransientCleanupError(resp *godo.Response) bool {
+	if resp == nil || resp.Response == nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// cleanupFailures returns the resources that could not be deleted, with
+// the last error for each.
+func cleanupFailures(state multistep.StateBag) map[string]error {
+	failures, ok := stateCleanupFailures.GetOk(state)
+	if !ok {
+		failures = make(map[string]error)
+		stateCleanupFailures.Put(state, failures)
+	}
+	return failures
+}
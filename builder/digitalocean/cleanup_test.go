@@ -0,0 +1,68 @@
+package digitalocean
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestRetryCleanup(t *testing.T) {
+	useFakeClock(t)
+
+	serverError := &godo.Response{Response: &http.Response{StatusCode: http.StatusInternalServerError}}
+	notFound := &godo.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
+
+	// Succeeds after a transient failure
+	state := new(multistep.BasicStateBag)
+	calls := 0
+	err := retryCleanup(state, "droplet 1", func() (*godo.Response, error) {
+		calls++
+		if calls < 2 {
+			return serverError, errors.New("500 Internal Server Error")
+		}
+		return nil, nil
+	})
+	if err != nil || calls != 2 {
+		t.Fatalf("got err %v after %d calls", err, calls)
+	}
+	if len(cleanupFailures(state)) != 0 {
+		t.Fatalf("bad: %#v", cleanupFailures(state))
+	}
+
+	// A resource that is already gone
+	calls = 0
+	err = retryCleanup(state, "droplet 2", func() (*godo.Response, error) {
+		calls++
+		return notFound, errors.New("404 Not Found")
+	})
+	if err != nil || calls != 1 {
+		t.Fatalf("got err %v after %d calls", err, calls)
+	}
+
+	// Gives up and records the failure
+	calls = 0
+	err = retryCleanup(state, "droplet 3", func() (*godo.Response, error) {
+		calls++
+		return serverError, errors.New("500 Internal Server Error")
+	})
+	if err == nil || calls != cleanupAttempts {
+		t.Fatalf("got err %v after %d calls", err, calls)
+	}
+	if _, ok := cleanupFailures(state)["droplet 3"]; !ok {
+		t.Fatalf("failure not recorded: %#v", cleanupFailures(state))
+	}
+
+	// Doesn't retry an error that won't go away
+	calls = 0
+	err = retryCleanup(state, "droplet 4", func() (*godo.Response, error) {
+		calls++
+		return &godo.Response{Response: &http.Response{StatusCode: http.StatusUnauthorized}},
+			errors.New("401 Unable to authenticate you")
+	})
+	if err == nil || calls != 1 {
+		t.Fatalf("got err %v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,65 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/digitalocean/godo"
+	"github.com/digitalocean/packer-plugin-digitalocean/version"
+	"github.com/hashicorp/packer-plugin-sdk/useragent"
+)
+
+// listPerPage is the number of items requested per page by ListAll.
+const listPerPage = 200
+
+// NewClient returns a client for the DigitalOcean API at apiURL, or for the
+// public API when apiURL is empty. It authenticates with token and retries
+// failed requests following retry. The builder, the data sources and the
+// post-processors all create their clients with it.
+func NewClient(token, apiURL string, retry *RetryConfig) (*godo.Client, error) {
+	return newClientWithTransport(token, apiURL, retry, http.DefaultTransport)
+}
+
+// newClientWithTransport is NewClient sending the requests through base.
+func newClientWithTransport(token, apiURL string, retry *RetryConfig, base http.RoundTripper) (*godo.Client, error) {
+	ua := useragent.String(version.PluginVersion.FormattedVersion())
+	opts := []godo.ClientOpt{godo.SetUserAgent(ua)}
+	if apiURL != "" {
+		if _, err := url.Parse(apiURL); err != nil {
+			return nil, fmt.Errorf("DigitalOcean: Invalid API URL, %s.", err)
+		}
+		opts = append(opts, godo.SetBaseURL(apiURL))
+	}
+
+	client, err := godo.New(retry.httpClient(token, base), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("DigitalOcean: could not create client, %s", err)
+	}
+
+	return client, nil
+}
+
+// ListAll calls list for every page of a paginated listing, such as
+// client.Sizes.List, and returns the items of all the pages.
+func ListAll[T any](ctx context.Context, list func(context.Context, *godo.ListOptions) ([]T, *godo.Response, error)) ([]T, error) {
+	var items []T
+	opt := &godo.ListOptions{Page: 1, PerPage: listPerPage}
+	for {
+		page, resp, err := list(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			return items, nil
+		}
+		current, err := resp.Links.CurrentPage()
+		if err != nil {
+			return nil, err
+		}
+		opt.Page = current + 1
+	}
+}
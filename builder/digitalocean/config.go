@@ -6,14 +6,17 @@ package digitalocean
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"regexp"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/digitalocean/godo"
 	"github.com/hashicorp/packer-plugin-sdk/common"
 	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
@@ -21,24 +24,108 @@ import (
 	"github.com/mitchellh/mapstructure"
 )
 
+const (
+	ArtifactTypeSnapshot = "snapshot"
+	ArtifactTypeDroplet  = "droplet"
+
+	ConcurrencyPolicyFail = "fail"
+	ConcurrencyPolicyWait = "wait"
+
+	// SnapshotRegionsAll in snapshot_regions stands for every available
+	// region.
+	SnapshotRegionsAll = "all"
+
+	OnDeprecatedImageWarn  = "warn"
+	OnDeprecatedImageError = "error"
+
+	SSHInterfacePublicIP  = "public_ip"
+	SSHInterfacePrivateIP = "private_ip"
+	SSHInterfaceIPv6      = "ipv6"
+
+	ReclaimFreeSpaceTrim = "trim"
+	ReclaimFreeSpaceZero = "zero"
+
+	UserDataCompletionTag      = "tag"
+	UserDataCompletionPowerOff = "power_off"
+	UserDataCompletionTimeout  = "timeout"
+)
+
 type Config struct {
-	common.PackerConfig `mapstructure:",squash"`
-	Comm                communicator.Config `mapstructure:",squash"`
+	common.PackerConfig    `mapstructure:",squash"`
+	Comm                   communicator.Config `mapstructure:",squash"`
+	commonsteps.HTTPConfig `mapstructure:",squash"`
 	// The client TOKEN to use to access your account. It
 	// can also be specified via environment variable DIGITALOCEAN_TOKEN, DIGITALOCEAN_ACCESS_TOKEN, or DIGITALOCEAN_API_TOKEN if
 	// set. DIGITALOCEAN_API_TOKEN will be deprecated in a future release in favor of DIGITALOCEAN_TOKEN or DIGITALOCEAN_ACCESS_TOKEN.
 	APIToken string `mapstructure:"api_token" required:"true"`
 	// Non standard api endpoint URL. Set this if you are
 	// using a DigitalOcean API compatible service. It can also be specified via
-	// environment variable DIGITALOCEAN_API_URL.
+	// environment variable DIGITALOCEAN_API_URL. Before creating anything,
+	// the builder checks that the service supports the optional API features
+	// the configuration uses. It fails early when one it needs, such as
+	// projects, reserved IPs, firewalls or VPCs, is missing, and builds
+	// without backup policies and snapshot tags when those are missing.
 	APIURL string `mapstructure:"api_url" required:"false"`
 	// The maximum number of retries for requests that fail with a 429 or 500-level error.
 	// The default value is 5. Set to 0 to disable reties.
+	// Deprecated: use `max_retries` in the `retry` block instead.
 	HTTPRetryMax *int `mapstructure:"http_retry_max" required:"false"`
 	// The maximum wait time (in seconds) between failed API requests. Default: 30.0
+	// Deprecated: use `wait_max` in the `retry` block instead.
 	HTTPRetryWaitMax *float64 `mapstructure:"http_retry_wait_max" required:"false"`
 	// The minimum wait time (in seconds) between failed API requests. Default: 1.0
+	// Deprecated: use `wait_min` in the `retry` block instead.
 	HTTPRetryWaitMin *float64 `mapstructure:"http_retry_wait_min" required:"false"`
+	// Controls how failed API requests are retried. See the
+	// [retry configuration](#retry-configuration) section below.
+	Retry RetryConfig `mapstructure:"retry" required:"false"`
+	// Set to true to skip the account-wide reads the build otherwise makes,
+	// such as listing regions to validate `snapshot_regions` and the size,
+	// and checking the account status, trusting the configuration instead. This lets builds
+	// run with tokens scoped to droplet and image operations. Existing
+	// snapshots named `snapshot_name` aren't looked for either, so they are
+	// neither reported nor replaced. Options that need account-wide reads
+	// (`install_account_keys`, `catalog_warnings`, `team_uuid`, `team_name`,
+	// `project_name`, `vpc_name`, `concurrency_policy`, `report_cost`,
+	// `force`, `deprecate_previous` and `snapshot_regions = ["all"]`) can't
+	// be used with it.
+	// Defaults to `false`.
+	MinimalAPIMode bool `mapstructure:"minimal_api_mode" required:"false"`
+	// Write the API requests the build makes and their responses to this
+	// file, so that a failing build can be attached to a bug report. Values
+	// of fields naming tokens, passwords, secrets, keys, certificates, user
+	// data and email addresses are redacted, as are the signatures of
+	// presigned Spaces URLs, and no request headers are written, but review the file before sharing it: it still holds the
+	// names, IDs and IP addresses of your resources. The file is written
+	// when the build ends, whether it succeeds or not.
+	APIRecordFile string `mapstructure:"api_record_file" required:"false"`
+	// Answer the API requests the build makes from a file written by
+	// `api_record_file` instead of sending them to the API. This is meant
+	// for maintainers reproducing a reported build without access to the
+	// account it ran in: `api_token` isn't required, requests the recording
+	// has no response to fail, and nothing on the droplet can be reached,
+	// so use it with `communicator = "none"` or to reproduce failures that
+	// happen before connecting.
+	APIReplayFile string `mapstructure:"api_replay_file" required:"false"`
+	// The UUID of the team to build in. The build fails if the API token
+	// belongs to a different team, to avoid building into the wrong team
+	// when the token's account is a member of several.
+	TeamUUID string `mapstructure:"team_uuid" required:"false"`
+	// The name of the team to build in. Like `team_uuid`, but matched against
+	// the name of the token's team.
+	TeamName string `mapstructure:"team_name" required:"false"`
+	// What to do when the account has no room for another droplet, as
+	// computed from its droplet limit and the droplets it already has. With
+	// `fail`, the build fails before anything is created. With `wait`, the
+	// build waits for room, up to `concurrency_timeout`. By default the
+	// account isn't checked, and the droplet creation fails instead, with an
+	// error pointing to this option. The
+	// check is advisory: builds starting at the same moment can all see the
+	// same free room.
+	ConcurrencyPolicy string `mapstructure:"concurrency_policy" required:"false"`
+	// How long to wait for room for the droplet with `concurrency_policy`
+	// `wait`. Defaults to "30m".
+	ConcurrencyTimeout time.Duration `mapstructure:"concurrency_timeout" required:"false"`
 	// The name (or slug) of the region to launch the droplet
 	// in. Consequently, this is the region where the snapshot will be available.
 	// See
@@ -49,17 +136,85 @@ type Config struct {
 	// https://docs.digitalocean.com/reference/api/api-reference/#operation/list_all_sizes
 	// for the accepted size names/slugs.
 	Size string `mapstructure:"size" required:"true"`
+	// Regions to create the droplet in, in order, when the API rejects it
+	// in `region` because the size is out of capacity or not available
+	// there. The snapshot is then available in the region the droplet was
+	// built in, which is recorded in the artifact as `build_region`. Can't
+	// be used with options tied to the region: `vpc_uuid`, `vpc_name`,
+	// `temporary_vpc`, `volumes` and `reserved_ip`.
+	RegionFallbacks []string `mapstructure:"region_fallbacks" required:"false"`
+	// Sizes to create the droplet with, in order, when the API rejects
+	// `size` because it is out of capacity or not available in the region.
+	// They are all tried in a region before moving on to the next of
+	// `region_fallbacks`. The snapshot can only be used with droplets whose
+	// disk is at least as large as the one it was built on, so list sizes
+	// with the same disk. GPU and other sizes can't be mixed.
+	SizeFallbacks []string `mapstructure:"size_fallbacks" required:"false"`
+	// The ID of an existing droplet to provision and snapshot instead of
+	// creating one. The droplet is powered on if it is off, and it is never
+	// destroyed. Its region and size are used, so `region`, `size` and
+	// `image` must not be set, nor any option that only applies when
+	// creating a droplet. The communicator must authenticate with
+	// `ssh_private_key_file`, `ssh_password` or `ssh_agent_auth`, since no
+	// SSH key can be injected into an existing droplet.
+	DropletID int `mapstructure:"droplet_id" required:"false"`
+	// Set to true to power the droplet given with `droplet_id` back on once
+	// its snapshot is taken. Defaults to `false`, which leaves it off.
+	LeaveDropletRunning bool `mapstructure:"leave_droplet_running" required:"false"`
 	// The name (or slug) of the base image to use. This is the
 	// image that will be used to launch a new droplet and provision it. See
 	// https://docs.digitalocean.com/reference/api/api-reference/#operation/get_images_list
 	// for details on how to get a list of the accepted image names/slugs.
+	// AI/ML images (slugs beginning with `gpu-`) may only be used with GPU
+	// droplet sizes; their driver and CUDA versions are exposed as the
+	// `GPUDriverVersion` and `CUDAVersion` build variables.
 	Image string `mapstructure:"image" required:"true"`
+	// The URL of a Linux virtual machine image to import as a custom image
+	// in `region` and build from, instead of `image`. Use
+	// `spaces://<space>/<key>` for an object in a Space of `spaces_region`,
+	// which is read with `spaces_key` and `spaces_secret`. See
+	// https://docs.digitalocean.com/products/custom-images/details/features/
+	// for the supported formats. The custom image is deleted once the build
+	// is over unless `keep_source_image` is set.
+	SourceImageURL string `mapstructure:"source_image_url" required:"false"`
+	// The region of the Space a `spaces://` `source_image_url` is in, such as
+	// `nyc3`. Defaults to `region`.
+	SpacesRegion string `mapstructure:"spaces_region" required:"false"`
+	// The distribution of `source_image_url`, such as `Ubuntu`. See
+	// https://docs.digitalocean.com/reference/api/api-reference/#operation/images_create_custom
+	// for the accepted values. Defaults to `Unknown`.
+	SourceImageDistribution string `mapstructure:"source_image_distribution" required:"false"`
+	// Set to true to keep the custom image imported from `source_image_url`
+	// once the build is over, so that it can be used as `image` by later
+	// builds. Defaults to `false`.
+	KeepSourceImage bool `mapstructure:"keep_source_image" required:"false"`
+	// The time to wait, as a duration string, for the import of
+	// `source_image_url` to finish. Defaults to `30m`.
+	SourceImageTimeout time.Duration `mapstructure:"source_image_timeout" required:"false"`
 	// Set to true to enable private networking
 	// for the droplet being created. This defaults to false, or not enabled.
 	PrivateNetworking bool `mapstructure:"private_networking" required:"false"`
 	// Set to true to enable monitoring for the droplet
 	// being created. This defaults to false, or not enabled.
 	Monitoring bool `mapstructure:"monitoring" required:"false"`
+	// Set to true to read the droplet's CPU and memory usage from the
+	// Monitoring API once provisioning finishes, and report their peaks in
+	// the build output and the artifact state. Use it to pick the smallest
+	// `size` the build fits in. Requires `monitoring`. Defaults to `false`.
+	SampleMetrics bool `mapstructure:"sample_metrics" required:"false"`
+	// Set to true to report the hourly list price of the droplet size when
+	// the build starts, and the estimated cost of the droplet once it is
+	// destroyed, in the build output and the artifact state as
+	// `build_cost`. Defaults to `false`.
+	ReportCost bool `mapstructure:"report_cost" required:"false"`
+	// Set to true to keep the droplet when the build fails, so that it can be
+	// inspected, for example after a provisioner failure. The build output
+	// shows its ID and IP address, and how to destroy it. When Packer
+	// generated the SSH key pair, the private key is written to
+	// `do_<droplet ID>.pem` in the current directory. A temporary VPC is kept
+	// along with the droplet. Cancelled builds still destroy the droplet.
+	// Defaults to `false`.
+	KeepDropletOnError bool `mapstructure:"keep_droplet_on_error" required:"false"`
 	// A boolean indicating whether to install the DigitalOcean agent used for
 	// providing access to the Droplet web console in the control panel. By
 	// default, the agent is installed on new Droplets but installation errors
@@ -69,12 +224,65 @@ type Config struct {
 	// Set to true to enable ipv6 for the droplet being
 	// created. This defaults to false, or not enabled.
 	IPv6 bool `mapstructure:"ipv6" required:"false"`
+	// What the build produces: `snapshot` to snapshot the droplet, or
+	// `droplet` to skip the snapshot and keep the powered-off droplet as the
+	// artifact, for workflows that hand it to another system to capture.
+	// Destroying a `droplet` artifact destroys the droplet. Defaults to
+	// `snapshot`.
+	ArtifactType string `mapstructure:"artifact_type" required:"false"`
+	// Set to true to stop once provisioning is done and keep the droplet
+	// running as the artifact, which gives its ID, IP addresses and region,
+	// for example for test environments. It implies `artifact_type`
+	// `droplet`, without powering the droplet off. Defaults to `false`.
+	SkipSnapshot bool `mapstructure:"skip_snapshot" required:"false"`
+	// Set to true to enable backups of the build droplet, for compliance
+	// tooling that flags droplets without them. This defaults to false.
+	Backups bool `mapstructure:"backups" required:"false"`
+	// When the backups enabled by `backups` are taken. See the
+	// [backup policy](#backup-policy) section below.
+	BackupPolicy *BackupPolicy `mapstructure:"backup_policy" required:"false"`
 	// The name of the resulting snapshot that will
 	// appear in your account. Defaults to `packer-{{timestamp}}` (see
 	// configuration templates for more info).
 	SnapshotName string `mapstructure:"snapshot_name" required:"false"`
+	// An HTTP registry to check the `snapshot_name` against before the
+	// snapshot is taken, to keep snapshot names unique across accounts. See
+	// the [snapshot name registry](#snapshot-name-registry) section below.
+	SnapshotNameRegistry *NameRegistry `mapstructure:"snapshot_name_registry" required:"false"`
+	// How long the snapshot should be kept, as a duration string such as
+	// "720h". The snapshot is tagged with the date it expires on, such as
+	// `expires:2025-06-01`, and the `digitalocean-prune` post-processor
+	// deletes it from that date on. Must be at least "24h".
+	ImageTTL time.Duration `mapstructure:"image_ttl" required:"false"`
+	// Set to true to tag the earlier snapshots the new one supersedes with
+	// the date they were superseded on, such as `deprecated:2025-06-01`, once
+	// the new snapshot is created. Earlier snapshots are those whose names
+	// start with `deprecate_prefix`. Builds using a deprecated snapshot as
+	// their `image` then warn or fail, following `on_deprecated_image`.
+	// Defaults to `false`.
+	DeprecatePrevious bool `mapstructure:"deprecate_previous" required:"false"`
+	// The prefix of the names of the snapshots `deprecate_previous` tags,
+	// such as `web-` for snapshots named `web-{{timestamp}}`. Required with
+	// `deprecate_previous`.
+	DeprecatePrefix string `mapstructure:"deprecate_prefix" required:"false"`
+	// What to do when the `image` is a snapshot tagged as deprecated by
+	// `deprecate_previous`: `warn` to carry on with a warning, or `error` to
+	// fail the build before creating anything. Defaults to `warn`.
+	OnDeprecatedImage string `mapstructure:"on_deprecated_image" required:"false"`
+	// The format of the image IDs reported to HCP Packer for each region of
+	// the snapshot, as a template with the `{{ .ID }}`, `{{ .Name }}` and
+	// `{{ .Region }}` variables. For example, `{{ .Region }}:{{ .ID }}`
+	// qualifies the IDs with their region, which keeps the images of a
+	// multi-region snapshot apart in HCP Packer channels. Defaults to
+	// `{{ .ID }}`, the snapshot ID.
+	HCPImageIDFormat string `mapstructure:"hcp_image_id_format" required:"false"`
 	// Additional regions that resulting snapshot should be distributed to.
+	// Set to `["all"]` to distribute it to every region accepting new
+	// resources when the build runs, less those in
+	// `snapshot_regions_exclude`.
 	SnapshotRegions []string `mapstructure:"snapshot_regions" required:"false"`
+	// Regions to leave out when `snapshot_regions` is `["all"]`.
+	SnapshotRegionsExclude []string `mapstructure:"snapshot_regions_exclude" required:"false"`
 	// When true, Packer will block until all snapshot transfers have been completed
 	// and report errors. When false, Packer will initiate the snapshot transfers
 	// and exit successfully without waiting for completion. Defaults to true.
@@ -83,40 +291,380 @@ type Config struct {
 	// before timing out. The default transfer timeout is "30m" (valid time units
 	// include `s` for seconds, `m` for minutes, and `h` for hours).
 	TransferTimeout time.Duration `mapstructure:"transfer_timeout" required:"false"`
+	// The number of regions in `snapshot_regions` to transfer the snapshot
+	// to at once. The transfers to the other regions start as these finish.
+	// Defaults to `8`.
+	TransferConcurrency int `mapstructure:"transfer_concurrency" required:"false"`
+	// The number of times to start a snapshot transfer over when it fails
+	// or times out, waiting 30 seconds before the first retry and twice as
+	// long before each next one. The build fails, naming the regions, only
+	// once a transfer has failed after all its retries. Set to `0` to fail
+	// on the first failure. Defaults to `2`.
+	TransferRetries *int `mapstructure:"transfer_retries" required:"false"`
 	// The time to wait, as a duration string, for a
 	// droplet to enter a desired state (such as "active") before timing out. The
-	// default state timeout is "6m".
+	// default state timeout is "6m", or "20m" for GPU droplet sizes.
 	StateTimeout time.Duration `mapstructure:"state_timeout" required:"false"`
+	// How long to wait, as a duration string, between checks of the state of
+	// the droplet, its actions and the snapshot transfers. Poll less often to
+	// stay within the API rate limits of accounts running many builds.
+	// Defaults to "3s".
+	StatePollInterval time.Duration `mapstructure:"state_poll_interval" required:"false"`
+	// The time to wait, as a duration string, for the droplet to shut down
+	// gracefully before the snapshot. Defaults to the `state_timeout`.
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" required:"false"`
+	// Whether to power the droplet off when it doesn't shut down gracefully
+	// within the `shutdown_timeout`, as when a stuck service blocks the
+	// shutdown, rather than failing the build. Set to false to fail the
+	// build instead. Defaults to `true`.
+	PowerOffFallback *bool `mapstructure:"power_off_fallback" required:"false"`
+	// How long to pause, as a duration string, between powering the
+	// droplet off and snapshotting it, for storage that needs to settle
+	// once the droplet is off. Defaults to "0s", snapshotting right away.
+	PauseBeforeSnapshot time.Duration `mapstructure:"pause_before_snapshot" required:"false"`
+	// The time to wait, as a duration string, for the GPU stack of an AI/ML
+	// image to become ready on a GPU droplet before provisioning. Readiness
+	// is checked by running `nvidia-smi` or `rocm-smi`. The default GPU ready
+	// timeout is "10m".
+	GPUReadyTimeout time.Duration `mapstructure:"gpu_ready_timeout" required:"false"`
+	// Set to true to wait for cloud-init to finish before provisioning, so
+	// that provisioners don't race with the `user_data` for package manager
+	// locks or users it hasn't created yet. The build fails if cloud-init
+	// reports errors. Waiting uses `cloud-init status --wait`, or polls for
+	// `/run/cloud-init/result.json` on releases without it. Requires the
+	// `ssh` communicator. Defaults to `false`.
+	WaitForCloudInit bool `mapstructure:"wait_for_cloud_init" required:"false"`
+	// The time to wait, as a duration string, for cloud-init to finish with
+	// `wait_for_cloud_init`. Defaults to "10m".
+	CloudInitTimeout time.Duration `mapstructure:"cloud_init_timeout" required:"false"`
+	// The time to wait, as a duration string, for a newly created droplet to
+	// be unlocked by DigitalOcean before waiting for it to become active. The
+	// default unlock timeout is "6m", or "20m" for GPU droplet sizes.
+	UnlockTimeout time.Duration `mapstructure:"unlock_timeout" required:"false"`
 	// How long to wait for the Droplet snapshot to complete before timing out.
 	// The default snapshot timeout is "60m" (valid time units include `s` for
 	// seconds, `m` for minutes, and `h` for hours).
 	SnapshotTimeout time.Duration `mapstructure:"snapshot_timeout" required:"false"`
+	// How long to keep retrying the snapshot request while the API rejects
+	// it because another event of the droplet is still pending, waiting 5
+	// seconds before the first retry and up to a minute between the next
+	// ones. Defaults to "5m".
+	SnapshotRetryTimeout time.Duration `mapstructure:"snapshot_retry_timeout" required:"false"`
 	// The name assigned to the droplet. DigitalOcean
 	// sets the hostname of the machine to this value.
 	DropletName string `mapstructure:"droplet_name" required:"false"`
 	// User data to launch with the Droplet. Packer will
 	// not automatically wait for a user script to finish before shutting down the
-	// instance this must be handled in a provisioner.
+	// instance this must be handled in a provisioner, or with
+	// `user_data_completion` when there is no communicator. The SHA-256 of the user
+	// data is logged and recorded as a `user-data-sha256:<checksum>` tag on
+	// the droplet and the snapshot. The build's values are available to it
+	// as template variables, see [user data variables](#user-data-variables).
 	UserData string `mapstructure:"user_data" required:"false"`
 	// Path to a file that will be used for the user
 	// data when launching the Droplet.
 	UserDataFile string `mapstructure:"user_data_file" required:"false"`
+	// Set to true to gzip the user data, for cloud-init payloads over
+	// DigitalOcean's 64 KiB limit. The user data is sent as a MIME
+	// multi-part message holding the compressed payload, which cloud-init
+	// decompresses; other consumers of the user data must support that
+	// format. The `user-data-sha256` tag still records the checksum of the
+	// uncompressed user data. Defaults to `false`.
+	CompressUserData bool `mapstructure:"compress_user_data" required:"false"`
+	// Set to true to check user data starting with `#cloud-config` before
+	// the droplet is created: it must parse as YAML, must not repeat keys,
+	// and modules configured with a list, such as `runcmd` and
+	// `write_files`, must be given one. cloud-init skips invalid
+	// cloud-config without failing, so the mistakes otherwise only show once
+	// the build is over. Defaults to `false`.
+	ValidateUserData bool `mapstructure:"validate_user_data" required:"false"`
+	// How a build with the `none` communicator knows that the user data has
+	// finished provisioning the droplet, for images that can't run an SSH
+	// server. `tag` waits for the script to add `user_data_completion_tag`
+	// to the droplet, which it can find its ID for at
+	// `http://169.254.169.254/metadata/v1/id`; `power_off` waits for the
+	// script to power the droplet off; `timeout` waits for
+	// `user_data_timeout` to elapse. The build then powers the droplet off
+	// and snapshots it. Unset by default, when the build doesn't wait for
+	// the user data.
+	UserDataCompletion string `mapstructure:"user_data_completion" required:"false"`
+	// The tag the user data adds to the droplet when it has finished, with
+	// `user_data_completion` `tag`. Defaults to `packer-user-data-done`.
+	UserDataCompletionTag string `mapstructure:"user_data_completion_tag" required:"false"`
+	// The time to wait, as a duration string, for the user data to finish
+	// with `user_data_completion`. With `tag` and `power_off` the build
+	// fails if it hasn't finished by then. Defaults to "30m".
+	UserDataTimeout time.Duration `mapstructure:"user_data_timeout" required:"false"`
 	// Tags to apply to the droplet when it is created
 	Tags []string `mapstructure:"tags" required:"false"`
+	// Set to true to replace the snapshots already named `snapshot_name`,
+	// deleting them once the build has created the new one. Otherwise the
+	// build fails before creating anything when such a snapshot exists;
+	// `packer build -force` alone doesn't delete snapshots. Snapshots tagged
+	// `locked` are never replaced. Defaults to `false`.
+	Force bool `mapstructure:"force" required:"false"`
+	// Tags to apply to the snapshot once it is created. They are recorded
+	// in the artifact.
+	SnapshotTags []string `mapstructure:"snapshot_tags" required:"false"`
+	// The IDs of existing block storage volumes to attach to the droplet. The
+	// volumes must be in the same region as the droplet.
+	Volumes []string `mapstructure:"volumes" required:"false"`
+	// Set to true to snapshot the volumes in `volumes` after the droplet is
+	// powered off, alongside the droplet snapshot. The volume snapshots are
+	// included in the artifact, and their IDs in the HCP Packer registry
+	// labels as `volume_snapshot_ids`. Defaults to `false`.
+	SnapshotVolumes bool `mapstructure:"snapshot_volumes" required:"false"`
+	// The prefix of the names of the volume snapshots; each is named after
+	// the prefix and the name of its volume. Defaults to the `snapshot_name`.
+	VolumeSnapshotName string `mapstructure:"volume_snapshot_name" required:"false"`
+	// Tags to apply to the volume snapshots.
+	VolumeSnapshotTags []string `mapstructure:"volume_snapshot_tags" required:"false"`
+	// How long the API request creating each volume snapshot may take before
+	// timing out. It bounds that single request: the snapshot isn't polled
+	// afterwards. The default volume snapshot timeout is "10m".
+	VolumeSnapshotTimeout time.Duration `mapstructure:"volume_snapshot_timeout" required:"false"`
+	// Objects in Spaces to download onto the droplet before it is
+	// provisioned. See the [Spaces assets](#spaces-assets) section below.
+	SpacesAssets []SpacesAsset `mapstructure:"spaces_assets" required:"false"`
+	// Set to true to check the DigitalOcean status page for unresolved
+	// incidents and maintenance in progress in the build's `region` and
+	// `snapshot_regions`, at the start of the build and before the
+	// snapshot. They are reported as warnings, and again if the build
+	// fails. An unreachable status page is ignored. Defaults to `false`.
+	CheckServiceStatus bool `mapstructure:"check_service_status" required:"false"`
+	// How long to pause the build, as a duration string, while
+	// `check_service_status` finds incidents in the build's regions. The
+	// build carries on once they are over or the time is up. Defaults to
+	// "0s", reporting the incidents without pausing.
+	ServiceStatusWait time.Duration `mapstructure:"service_status_wait" required:"false"`
+	// The URL of the status page `check_service_status` reads, which must
+	// serve the Statuspage API. Defaults to
+	// `https://status.digitalocean.com`.
+	ServiceStatusURL string `mapstructure:"service_status_url" required:"false"`
+	// HTTP endpoints to notify as the build reaches its milestones. See the
+	// [webhooks](#webhooks) section below.
+	Webhooks []Webhook `mapstructure:"webhook" required:"false"`
+	// The access key used to read `spaces_assets`. This may also be set using
+	// the `DIGITALOCEAN_SPACES_ACCESS_KEY` environmental variable.
+	SpacesKey string `mapstructure:"spaces_key" required:"false"`
+	// The secret key used to read `spaces_assets`. This may also be set using
+	// the `DIGITALOCEAN_SPACES_SECRET_KEY` environmental variable.
+	SpacesSecret string `mapstructure:"spaces_secret" required:"false"`
+	// How long the presigned URLs the droplet downloads `spaces_assets`
+	// through remain valid. Defaults to "1h".
+	SpacesURLTTL time.Duration `mapstructure:"spaces_url_ttl" required:"false"`
+	// The ID of the project to move the droplet into once it is created, for
+	// cost attribution. By default the droplet stays in the account's default
+	// project. Only one of `project_id` or `project_name` may be set.
+	ProjectID string `mapstructure:"project_id" required:"false"`
+	// The name of the project to move the droplet into, looked up before the
+	// droplet is created. Only one of `project_id` or `project_name` may be
+	// set.
+	ProjectName string `mapstructure:"project_name" required:"false"`
+	// An existing reserved IP in the build region to assign to the droplet
+	// for the duration of the build, so that it has a known public address,
+	// for example to reach IP-allowlisted mirrors. It is unassigned when the
+	// build finishes. Only one of `reserved_ip` or `assign_reserved_ip` may be
+	// set.
+	ReservedIP string `mapstructure:"reserved_ip" required:"false"`
+	// Set to true to create a new reserved IP in the build region, assign it
+	// to the droplet for the duration of the build, and release it when the
+	// build finishes. Defaults to `false`.
+	AssignReservedIP bool `mapstructure:"assign_reserved_ip" required:"false"`
+	// The IDs of existing cloud firewalls to add the droplet to before Packer
+	// connects to it. The droplet is removed from them when the build
+	// finishes. The firewalls must allow the communicator to connect from the
+	// machine running Packer.
+	FirewallIDs []string `mapstructure:"firewall_ids" required:"false"`
+	// A tag to add to the droplet before Packer connects to it, placing it
+	// behind every cloud firewall that applies to that tag. The tag is
+	// removed from the droplet when the build finishes.
+	FirewallTag string `mapstructure:"firewall_tag" required:"false"`
+	// Set to true to create a temporary cloud firewall for the droplet that
+	// only lets the communicator in from the machine running Packer, or from
+	// `temporary_firewall_source_cidrs`, and allows all outbound traffic. It
+	// is deleted when the build finishes. The rules of all the firewalls
+	// applied to a droplet add up, so `firewall_ids` and `firewall_tag` can
+	// still let other traffic in. Defaults to `false`.
+	TemporaryFirewall bool `mapstructure:"temporary_firewall" required:"false"`
+	// The addresses or CIDR blocks allowed to reach the communicator through
+	// the temporary firewall. Defaults to the public IPv4 address of the
+	// machine running Packer, which is looked up from `public_ip_url` and
+	// can't be used with `connect_with_private_ip` or when connecting through
+	// a bastion or proxy.
+	TemporaryFirewallSourceCIDRs []string `mapstructure:"temporary_firewall_source_cidrs" required:"false"`
+	// The URL of a service that returns the public IPv4 address requests to
+	// it come from, as plain text. It is queried to find the address of the
+	// machine running Packer for `temporary_firewall` without
+	// `temporary_firewall_source_cidrs`, where it defaults to the external
+	// service `https://ipv4.icanhazip.com`. When set, it is also queried for
+	// `PACKER_HTTP_ADDR` without `http_reverse_tunnel` or
+	// `http_bind_address`; otherwise `PACKER_HTTP_ADDR` isn't set then.
+	PublicIPURL string `mapstructure:"public_ip_url" required:"false"`
+	// Set to true to block all outbound traffic from the droplet, except what
+	// the `outbound_allow` blocks allow, for the duration of the build. This
+	// sets `temporary_firewall`, whose outbound rules are replaced with the
+	// allowed traffic. DNS lookups are blocked unless they are allowed too.
+	// Defaults to `false`.
+	OutboundLockdown bool `mapstructure:"outbound_lockdown" required:"false"`
+	// Traffic the droplet may still send when `outbound_lockdown` is set. See
+	// the [outbound lockdown](#outbound-lockdown) section below.
+	OutboundAllow []OutboundAllow `mapstructure:"outbound_allow" required:"false"`
 	// UUID of the VPC which the droplet will be created in. Before using this,
 	// private_networking should be enabled.
 	VPCUUID string `mapstructure:"vpc_uuid" required:"false"`
+	// The name of the VPC which the droplet will be created in, looked up in
+	// the build region when the build starts. Only one of `vpc_uuid` or
+	// `vpc_name` may be set. Before using this, private_networking should be
+	// enabled.
+	VPCName string `mapstructure:"vpc_name" required:"false"`
+	// The UUIDs of VPCs the build VPC, set with `vpc_uuid` or `vpc_name`,
+	// must be peered with, for provisioning that reaches internal services
+	// over VPC peering. The build fails before creating the droplet when
+	// one of the peerings is missing or not active yet.
+	RequiredVPCPeerings []string `mapstructure:"required_vpc_peerings" required:"false"`
+	// Set to true to create a VPC in the build region for the droplet, and
+	// delete it once the droplet is gone, isolating the build from other
+	// VPCs. This enables `private_networking`. Defaults to `false`.
+	TemporaryVPC bool `mapstructure:"temporary_vpc" required:"false"`
+	// The private IP range of the temporary VPC, in CIDR notation (e.g.
+	// `10.200.0.0/24`). By default a free range is picked.
+	TemporaryVPCIPRange string `mapstructure:"temporary_vpc_ip_range" required:"false"`
 	// Wheter the communicators should use private IP or not (public IP in that case).
 	// If the droplet is or going to be accessible only from the local network because
 	// it is at behind a firewall, then communicators should use the private IP
 	// instead of the public IP. Before using this, private_networking should be enabled.
+	// This is an alias of `ssh_interface = "private_ip"`.
 	ConnectWithPrivateIP bool `mapstructure:"connect_with_private_ip" required:"false"`
+	// The address of the droplet the communicator connects to: `public_ip`
+	// for its public IPv4 address, `private_ip` for its private IPv4 address,
+	// which requires `private_networking`, or `ipv6` for its public IPv6
+	// address, which enables `ipv6`. Also works for WinRM. Defaults to
+	// `public_ip`.
+	SSHInterface string `mapstructure:"ssh_interface" required:"false"`
 	// The ID of an existing SSH key on the DigitalOcean account. This should be
 	// used in conjunction with `ssh_private_key_file`.
 	SSHKeyID int `mapstructure:"ssh_key_id" required:"false"`
+	// The fingerprint of an existing SSH key on the DigitalOcean account, as
+	// an alternative to `ssh_key_id`. Fingerprints are the same in every
+	// account holding the key, unlike IDs. This should be used in conjunction
+	// with `ssh_private_key_file`.
+	SSHKeyFingerprint string `mapstructure:"ssh_key_fingerprint" required:"false"`
+	// The name of an existing SSH key on the DigitalOcean account, as an
+	// alternative to `ssh_key_id`. The build fails when no key, or more than
+	// one key, has that name. This should be used in conjunction with
+	// `ssh_private_key_file`.
+	SSHKeyName string `mapstructure:"ssh_key_name" required:"false"`
+	// The IDs of existing SSH keys on the DigitalOcean account to install on
+	// the droplet, in addition to the temporary key generated by Packer and
+	// the key set with `ssh_key_id`, `ssh_key_fingerprint` or `ssh_key_name`.
+	// Use this for keys that must be present on every build, such as
+	// break-glass keys. Set `skip_keygen` to install them instead of the
+	// temporary key.
+	SSHKeyIDs []int `mapstructure:"ssh_key_ids" required:"false"`
+	// Set to true to also install every SSH key on the DigitalOcean account on
+	// the droplet. When false, only the temporary key generated by Packer and
+	// the keys set with `ssh_key_id` and `ssh_key_ids` are installed. Defaults
+	// to `false`.
+	InstallAccountKeys bool `mapstructure:"install_account_keys" required:"false"`
 	// Set to true if you are connecting as a non-root user whose public key is
 	// already available on the base image.
 	SkipKeygen bool `mapstructure:"skip_keygen" required:"false"`
+	// The private key of an SSH certificate authority to sign the temporary
+	// key generated by Packer with. The droplet must trust the authority
+	// with `TrustedUserCAKeys`. The temporary key is then not installed on
+	// the droplet, for environments where static `authorized_keys` entries
+	// are forbidden.
+	SSHCAPrivateKeyFile string `mapstructure:"ssh_ca_private_key_file" required:"false"`
+	// A command that signs the temporary key generated by Packer, as an
+	// alternative to `ssh_ca_private_key_file` for authorities whose key
+	// isn't available locally. The public key is written to the command's
+	// standard input in `authorized_keys` format, and the command must print
+	// the certificate, in the same format, to its standard output. For
+	// example `["vault", "write", "-field=signed_key",
+	// "ssh-client-signer/sign/packer", "public_key=-"]`.
+	SSHCASignerCommand []string `mapstructure:"ssh_ca_signer_command" required:"false"`
+	// The principals the certificate signed with `ssh_ca_private_key_file`
+	// is valid for. Defaults to the `ssh_username`.
+	SSHCertificatePrincipals []string `mapstructure:"ssh_certificate_principals" required:"false"`
+	// How long the certificate signed with `ssh_ca_private_key_file` is
+	// valid for. Defaults to "2h".
+	SSHCertificateTTL time.Duration `mapstructure:"ssh_certificate_ttl" required:"false"`
+	// How long to keep retrying when the droplet accepts SSH connections but
+	// rejects the injected SSH key, which can happen while cloud-init is
+	// still installing it. The time starts when the key is first rejected;
+	// until then, connection failures such as a droplet that is still
+	// booting are retried for `ssh_timeout`. The default is "2m".
+	SSHKeyPropagationTimeout time.Duration `mapstructure:"ssh_key_propagation_timeout" required:"false"`
+	// The number of times to reconnect and retry a provisioner operation,
+	// such as a file upload or starting a command, that failed because the
+	// SSH connection to the droplet dropped. Commands that were already
+	// running when the connection dropped are not run again, but the next
+	// operation reconnects before it starts. The number of
+	// reconnects is recorded in the artifact as `provision_reconnects`.
+	// Defaults to 0, which disables reconnecting.
+	ProvisionReconnectAttempts int `mapstructure:"provision_reconnect_attempts" required:"false"`
+	// Set to true to power cycle the droplet and try connecting once more
+	// when the communicator can't connect to it before its timeout, such as
+	// when the droplet hangs while booting. Defaults to `false`.
+	ConnectPowerCycle bool `mapstructure:"connect_power_cycle" required:"false"`
+	// The path of a file to write a JSON report to when the communicator
+	// can't connect to the droplet. The report holds the droplet's status and
+	// its recent actions, which are also shown in the build output.
+	ConnectFailureReportPath string `mapstructure:"connect_failure_report_path" required:"false"`
+	// Whether to make the HTTP server started for `http_directory` or
+	// `http_content` reachable from the droplet through an SSH reverse tunnel.
+	// `PACKER_HTTP_ADDR` then points to the tunnel on the droplet's loopback
+	// interface, which forwards to `http_bind_address` on the Packer host.
+	// Otherwise it points to `http_bind_address` or, when the server listens
+	// on every interface and `public_ip_url` is set, to the public IPv4
+	// address of the machine running Packer.
+	// Defaults to true when `connect_with_private_ip` or `ssh_bastion_host` is
+	// set, since the droplet usually can't reach the Packer host directly in
+	// those setups.
+	HTTPReverseTunnel *bool `mapstructure:"http_reverse_tunnel" required:"false"`
+	// Set to true to check the configured image, size and region against the
+	// live DigitalOcean catalog while validating the template, and warn when
+	// any of them is retired or no longer available. This makes API requests
+	// during `packer validate`. Defaults to `false`.
+	CatalogWarnings bool `mapstructure:"catalog_warnings" required:"false"`
+	// Set to true to record the droplet's network interfaces (type, address,
+	// netmask and gateway) and VPC (UUID, name and IP range) in the artifact
+	// before the snapshot is taken. They are available to post-processors as
+	// the `network_interfaces` and `network_vpc` artifact state. Defaults to
+	// `false`.
+	CaptureNetworkConfig bool `mapstructure:"capture_network_config" required:"false"`
+	// Writes a file describing where the image comes from into the droplet
+	// before the snapshot is taken. See the [image release](#image-release)
+	// section below.
+	ImageRelease *ImageRelease `mapstructure:"image_release" required:"false"`
+	// Set to true to generalize the droplet after provisioning, so that the
+	// droplets created from the snapshot don't share its identity: the build
+	// removes the SSH host keys, empties `/etc/machine-id`, runs
+	// `cloud-init clean --logs`, removes the shell history and removes the
+	// build's temporary SSH key from the `authorized_keys` files. The
+	// commands run with `sudo` unless `ssh_username` is `root`. Requires the
+	// `ssh` communicator. Defaults to `false`.
+	Generalize bool `mapstructure:"generalize" required:"false"`
+	// How to reclaim the free space of the droplet's filesystems before the
+	// shutdown, so that the snapshot is smaller: `trim` runs `fstrim` on
+	// every mounted filesystem that supports it, and `zero` fills the free
+	// space of the root filesystem with zeros and removes the fill file,
+	// which is slower but works without discard support. A failing `trim`
+	// only warns. Runs with `sudo` unless `ssh_username` is `root`, and
+	// requires the `ssh` communicator. Unset by default, when free space
+	// isn't reclaimed.
+	ReclaimFreeSpace string `mapstructure:"reclaim_free_space" required:"false"`
+	// Whether the base image runs cloud-init, which DigitalOcean uses to
+	// install SSH keys on the droplet. One of `auto`, `cloud-init` or `none`.
+	// With `auto`, SSH keys are injected into every image, except custom
+	// images imported without a known distribution, which may not run
+	// cloud-init: those use `ssh_password` when it is set, and otherwise get
+	// the keys with a warning. With `none`, no SSH keys are injected and
+	// `ssh_password` must be set to credentials baked into the image. The
+	// `KeyInjection` build variable reports the path used. Defaults to
+	// `auto`.
+	ImageInit string `mapstructure:"image_init" required:"false"`
 
 	ctx interpolate.Context
 }
@@ -127,6 +675,14 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 	var errs *packersdk.MultiError
 	var warns []string
 
+	// Catch deprecated, renamed and removed options, and options of other
+	// builders, before they fail decoding as unknown keys
+	raws, defunctWarns, defunctErrs := checkDefunctOptions(raws)
+	warns = append(warns, defunctWarns...)
+	if len(defunctErrs) > 0 {
+		errs = packersdk.MultiErrorAppend(errs, defunctErrs...)
+	}
+
 	var md mapstructure.Metadata
 	err := config.Decode(c, &config.DecodeOpts{
 		Metadata:           &md,
@@ -135,6 +691,8 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		InterpolateFilter: &interpolate.RenderFilter{
 			Exclude: []string{
 				"run_command",
+				"user_data",
+				"hcp_image_id_format",
 			},
 		},
 	}, raws...)
@@ -142,6 +700,21 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		return nil, err
 	}
 
+	// Expand env("NAME") references in the values that usually come from
+	// the CI environment
+	envFields := map[string]*string{
+		"region":   &c.Region,
+		"size":     &c.Size,
+		"image":    &c.Image,
+		"vpc_uuid": &c.VPCUUID,
+		"vpc_name": &c.VPCName,
+	}
+	for key, ptr := range envFields {
+		if err := expandEnv(ptr); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("%s: %s", key, err))
+		}
+	}
+
 	// Defaults
 	if c.APIToken == "" {
 		// Default to environment variable for api_token, if it exists
@@ -161,35 +734,8 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 	if c.APIURL == "" {
 		c.APIURL = os.Getenv("DIGITALOCEAN_API_URL")
 	}
-	if c.HTTPRetryMax == nil {
-		c.HTTPRetryMax = godo.PtrTo(5)
-		if max := os.Getenv("DIGITALOCEAN_HTTP_RETRY_MAX"); max != "" {
-			maxInt, err := strconv.Atoi(max)
-			if err != nil {
-				return nil, err
-			}
-			c.HTTPRetryMax = godo.PtrTo(maxInt)
-		}
-	}
-	if c.HTTPRetryWaitMax == nil {
-		c.HTTPRetryWaitMax = godo.PtrTo(30.0)
-		if waitMax := os.Getenv("DIGITALOCEAN_HTTP_RETRY_WAIT_MAX"); waitMax != "" {
-			waitMaxFloat, err := strconv.ParseFloat(waitMax, 64)
-			if err != nil {
-				return nil, err
-			}
-			c.HTTPRetryWaitMax = godo.PtrTo(waitMaxFloat)
-		}
-	}
-	if c.HTTPRetryWaitMin == nil {
-		c.HTTPRetryWaitMin = godo.PtrTo(1.0)
-		if waitMin := os.Getenv("DIGITALOCEAN_HTTP_RETRY_WAIT_MIN"); waitMin != "" {
-			waitMinFloat, err := strconv.ParseFloat(waitMin, 64)
-			if err != nil {
-				return nil, err
-			}
-			c.HTTPRetryWaitMin = godo.PtrTo(waitMinFloat)
-		}
+	if es := c.Retry.Prepare(c.HTTPRetryMax, c.HTTPRetryWaitMin, c.HTTPRetryWaitMax); len(es) > 0 {
+		errs = packersdk.MultiErrorAppend(errs, es...)
 	}
 
 	if c.SnapshotName == "" {
@@ -202,15 +748,49 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		c.SnapshotName = def
 	}
 
+	// The build UUID is available to user_data, and names the droplet by
+	// default
+	buildUUID := uuid.TimeOrderedUUID()
 	if c.DropletName == "" {
 		// Default to packer-[time-ordered-uuid]
-		c.DropletName = fmt.Sprintf("packer-%s", uuid.TimeOrderedUUID())
+		c.DropletName = fmt.Sprintf("packer-%s", buildUUID)
+	}
+
+	if c.StatePollInterval == 0 {
+		c.StatePollInterval = pollInterval
+	}
+	if c.StatePollInterval < 0 {
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("state_poll_interval must not be negative"))
 	}
 
 	if c.StateTimeout == 0 {
 		// Default to 6 minute timeouts waiting for
 		// desired state. i.e waiting for droplet to become active
 		c.StateTimeout = 6 * time.Minute
+		if isGPUSize(c.Size) {
+			// GPU droplets take much longer to provision
+			c.StateTimeout = 20 * time.Minute
+		}
+	}
+
+	if c.GPUReadyTimeout == 0 {
+		c.GPUReadyTimeout = 10 * time.Minute
+	}
+
+	if c.CloudInitTimeout == 0 {
+		c.CloudInitTimeout = 10 * time.Minute
+	}
+
+	if c.ConcurrencyTimeout == 0 {
+		c.ConcurrencyTimeout = 30 * time.Minute
+	}
+
+	if c.UnlockTimeout == 0 {
+		c.UnlockTimeout = 6 * time.Minute
+		if isGPUSize(c.Size) {
+			c.UnlockTimeout = 20 * time.Minute
+		}
 	}
 
 	if c.SnapshotTimeout == 0 {
@@ -218,45 +798,280 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		c.SnapshotTimeout = 60 * time.Minute
 	}
 
+	if c.SnapshotRetryTimeout == 0 {
+		c.SnapshotRetryTimeout = 5 * time.Minute
+	}
+	if c.SnapshotRetryTimeout < 0 {
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("snapshot_retry_timeout must not be negative"))
+	}
+
 	if c.TransferTimeout == 0 {
 		c.TransferTimeout = 30 * time.Minute
 	}
 
+	if c.TransferConcurrency == 0 {
+		c.TransferConcurrency = 8
+	}
+	if c.TransferConcurrency < 0 {
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("transfer_concurrency must not be negative"))
+	}
+	if c.TransferRetries == nil {
+		c.TransferRetries = godo.PtrTo(2)
+	}
+	if *c.TransferRetries < 0 {
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("transfer_retries must not be negative"))
+	}
+
+	if c.VolumeSnapshotName == "" {
+		c.VolumeSnapshotName = c.SnapshotName
+	}
+
+	if c.VolumeSnapshotTimeout == 0 {
+		c.VolumeSnapshotTimeout = 10 * time.Minute
+	}
+
+	if c.SpacesKey == "" {
+		c.SpacesKey = os.Getenv("DIGITALOCEAN_SPACES_ACCESS_KEY")
+	}
+
+	if c.SpacesSecret == "" {
+		c.SpacesSecret = os.Getenv("DIGITALOCEAN_SPACES_SECRET_KEY")
+	}
+
+	if c.SpacesURLTTL == 0 {
+		c.SpacesURLTTL = time.Hour
+	}
+
+	if c.SourceImageDistribution == "" {
+		c.SourceImageDistribution = "Unknown"
+	}
+
+	if c.SourceImageTimeout == 0 {
+		c.SourceImageTimeout = 30 * time.Minute
+	}
+
+	if c.SSHKeyPropagationTimeout == 0 {
+		c.SSHKeyPropagationTimeout = 2 * time.Minute
+	}
+
+	if c.SkipSnapshot {
+		if c.ArtifactType == ArtifactTypeSnapshot {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("skip_snapshot can not be used with artifact_type \"snapshot\""))
+		}
+		c.ArtifactType = ArtifactTypeDroplet
+	}
+	if c.ArtifactType == "" {
+		c.ArtifactType = ArtifactTypeSnapshot
+	}
+
+	if c.ImageInit == "" {
+		c.ImageInit = ImageInitAuto
+	}
+
+	if c.ShutdownTimeout == 0 {
+		c.ShutdownTimeout = c.StateTimeout
+	}
+	if c.PowerOffFallback == nil {
+		c.PowerOffFallback = godo.PtrTo(true)
+	}
+
 	if c.WaitSnapshotTransfer == nil {
 		c.WaitSnapshotTransfer = godo.PtrTo(true)
 	}
 
+	if es := c.HTTPConfig.Prepare(&c.ctx); len(es) > 0 {
+		errs = packersdk.MultiErrorAppend(errs, es...)
+	}
+
 	if es := c.Comm.Prepare(&c.ctx); len(es) > 0 {
 		errs = packersdk.MultiErrorAppend(errs, es...)
 	}
-	if c.APIToken == "" {
+	if c.APIToken == "" && c.APIReplayFile == "" {
 		// Required configurations that will display errors if not set
 		errs = packersdk.MultiErrorAppend(
 			errs, errors.New("api_token for auth must be specified"))
 	}
-
-	if c.Region == "" {
+	if c.APIRecordFile != "" && c.APIReplayFile != "" {
 		errs = packersdk.MultiErrorAppend(
-			errs, errors.New("region is required"))
+			errs, errors.New("api_record_file and api_replay_file can't be used together"))
+	}
+	if c.APIReplayFile != "" {
+		if _, err := readAPIRecording(c.APIReplayFile); err != nil {
+			errs = packersdk.MultiErrorAppend(
+				errs, fmt.Errorf("api_replay_file: %s", err))
+		}
 	}
 
-	if c.Size == "" {
+	if c.DropletID != 0 {
+		errs = packersdk.MultiErrorAppend(errs, c.prepareDropletID()...)
+	} else {
+		if c.Region == "" {
+			errs = packersdk.MultiErrorAppend(
+				errs, errors.New("region is required"))
+		}
+
+		if c.Size == "" {
+			errs = packersdk.MultiErrorAppend(
+				errs, errors.New("size is required"))
+		}
+
+		if c.Image == "" && c.SourceImageURL == "" {
+			errs = packersdk.MultiErrorAppend(
+				errs, errors.New("image is required"))
+		}
+	}
+	if c.LeaveDropletRunning && c.DropletID == 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("leave_droplet_running requires droplet_id"))
+	}
+
+	if len(c.RegionFallbacks) > 0 {
+		for key, set := range map[string]bool{
+			"vpc_uuid":      c.VPCUUID != "",
+			"vpc_name":      c.VPCName != "",
+			"temporary_vpc": c.TemporaryVPC,
+			"volumes":       len(c.Volumes) > 0,
+			"reserved_ip":   c.ReservedIP != "",
+		} {
+			if set {
+				errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("%s can not be used with region_fallbacks", key))
+			}
+		}
+	}
+	for _, size := range c.SizeFallbacks {
+		if size == "" || size == c.Size {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid size in size_fallbacks: %q", size))
+		} else if isGPUSize(size) != isGPUSize(c.Size) {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"size_fallbacks can't mix GPU and other sizes: %s and %s", c.Size, size))
+		}
+	}
+	for _, region := range c.RegionFallbacks {
+		if region == "" || region == c.Region {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid region in region_fallbacks: %q", region))
+		}
+	}
+
+	if isGPUImage(c.Image) && c.Size != "" && !isGPUSize(c.Size) {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+			"image %s is an AI/ML image and requires a GPU droplet size, got %s", c.Image, c.Size))
+	}
+
+	if isGPUSize(c.Size) && !gpuSizeRe.MatchString(c.Size) {
+		warns = append(warns, fmt.Sprintf("size %s does not look like a GPU droplet size "+
+			"(gpu-<model>x<count>-<memory>gb); check it against the sizes available to the account", c.Size))
+	}
+
+	switch c.SSHInterface {
+	case "":
+		c.SSHInterface = SSHInterfacePublicIP
+		if c.ConnectWithPrivateIP {
+			c.SSHInterface = SSHInterfacePrivateIP
+		}
+	case SSHInterfacePublicIP, SSHInterfacePrivateIP, SSHInterfaceIPv6:
+		if c.ConnectWithPrivateIP && c.SSHInterface != SSHInterfacePrivateIP {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"connect_with_private_ip can not be used with ssh_interface %s", c.SSHInterface))
+		}
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+			"ssh_interface must be one of %s, %s or %s", SSHInterfacePublicIP, SSHInterfacePrivateIP, SSHInterfaceIPv6))
+	}
+	// The rest of the builder only looks at connect_with_private_ip.
+	c.ConnectWithPrivateIP = c.SSHInterface == SSHInterfacePrivateIP
+	if c.SSHInterface == SSHInterfaceIPv6 {
+		c.IPv6 = true
+	}
+
+	if c.HTTPReverseTunnel == nil {
+		c.HTTPReverseTunnel = godo.PtrTo(c.Comm.Type == "ssh" &&
+			(c.ConnectWithPrivateIP || c.Comm.SSHBastionHost != ""))
+	} else if *c.HTTPReverseTunnel && c.Comm.Type != "ssh" {
 		errs = packersdk.MultiErrorAppend(
-			errs, errors.New("size is required"))
+			errs, errors.New("http_reverse_tunnel requires the ssh communicator"))
 	}
 
-	if c.Image == "" {
+	if c.PauseBeforeSnapshot < 0 {
 		errs = packersdk.MultiErrorAppend(
-			errs, errors.New("image is required"))
+			errs, errors.New("pause_before_snapshot must not be negative"))
+	}
+	if c.ProvisionReconnectAttempts < 0 {
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("provision_reconnect_attempts must not be negative"))
+	}
+
+	switch c.ImageInit {
+	case ImageInitAuto, ImageInitCloudInit, ImageInitNone:
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+			"image_init must be one of %q, %q or %q", ImageInitAuto, ImageInitCloudInit, ImageInitNone))
+	}
+
+	switch c.ArtifactType {
+	case ArtifactTypeSnapshot:
+		if containsString(c.SnapshotRegions, SnapshotRegionsAll) && len(c.SnapshotRegions) > 1 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"snapshot_regions %q can not be combined with other regions", SnapshotRegionsAll))
+		}
+		if len(c.SnapshotRegionsExclude) > 0 && !containsString(c.SnapshotRegions, SnapshotRegionsAll) {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"snapshot_regions_exclude requires snapshot_regions to be [%q]", SnapshotRegionsAll))
+		}
+	case ArtifactTypeDroplet:
+		if len(c.SnapshotRegions) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("snapshot_regions can not be used with artifact_type \"droplet\""))
+		}
+		if c.SnapshotVolumes {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("snapshot_volumes can not be used with artifact_type \"droplet\""))
+		}
+		if c.SnapshotNameRegistry != nil {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("snapshot_name_registry can not be used with artifact_type \"droplet\""))
+		}
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+			"artifact_type must be one of %q or %q", ArtifactTypeSnapshot, ArtifactTypeDroplet))
+	}
+
+	if c.UserData != "" {
+		c.ctx.Data = &userDataTemplateData{
+			DropletName:  c.DropletName,
+			Region:       c.Region,
+			Size:         c.Size,
+			Image:        c.Image,
+			SnapshotName: c.SnapshotName,
+			BuildUUID:    buildUUID,
+		}
+		rendered, err := interpolate.Render(c.UserData, &c.ctx)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("Error rendering user_data: %s", err))
+		} else {
+			c.UserData = rendered
+		}
+		c.ctx.Data = nil
 	}
 
 	if c.UserData != "" && c.UserDataFile != "" {
 		errs = packersdk.MultiErrorAppend(
 			errs, errors.New("only one of user_data or user_data_file can be specified"))
-	} else if c.UserDataFile != "" {
-		if _, err := os.Stat(c.UserDataFile); err != nil {
-			errs = packersdk.MultiErrorAppend(
-				errs, fmt.Errorf("user_data_file not found: %s", c.UserDataFile))
+	} else {
+		userData := c.UserData
+		if c.UserDataFile != "" {
+			contents, err := os.ReadFile(c.UserDataFile)
+			if err != nil {
+				errs = packersdk.MultiErrorAppend(
+					errs, fmt.Errorf("user_data_file not found: %s", c.UserDataFile))
+			}
+			userData = string(contents)
+		}
+		if _, err := finalUserData(userData, c.CompressUserData); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+		if c.ValidateUserData {
+			if es := validateCloudConfig(userData); len(es) > 0 {
+				errs = packersdk.MultiErrorAppend(errs, es...)
+			}
 		}
 	}
 
@@ -265,12 +1080,377 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 	}
 	tagRe := regexp.MustCompile("^[[:alnum:]:_-]{1,255}$")
 
+	if c.UserDataCompletion != "" {
+		switch c.UserDataCompletion {
+		case UserDataCompletionTag, UserDataCompletionPowerOff, UserDataCompletionTimeout:
+		default:
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("user_data_completion must be one of %q, %q or %q",
+				UserDataCompletionTag, UserDataCompletionPowerOff, UserDataCompletionTimeout))
+		}
+		if c.Comm.Type != "none" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("user_data_completion requires the none communicator"))
+		}
+		if c.UserData == "" && c.UserDataFile == "" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("user_data_completion requires user_data or user_data_file"))
+		}
+		if c.UserDataCompletion == UserDataCompletionPowerOff && c.SkipSnapshot {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("user_data_completion \"power_off\" can not be used with skip_snapshot"))
+		}
+	}
+	if c.UserDataCompletionTag == "" {
+		c.UserDataCompletionTag = "packer-user-data-done"
+	} else if !tagRe.MatchString(c.UserDataCompletionTag) {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid user_data_completion_tag: %s", c.UserDataCompletionTag))
+	}
+	if c.UserDataTimeout == 0 {
+		c.UserDataTimeout = 30 * time.Minute
+	} else if c.UserDataTimeout < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("user_data_timeout must not be negative"))
+	}
+
 	for _, t := range c.Tags {
 		if !tagRe.MatchString(t) {
 			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid tag: %s", t))
 		}
 	}
 
+	for _, t := range c.SnapshotTags {
+		if !tagRe.MatchString(t) {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid snapshot tag: %s", t))
+		}
+	}
+
+	for _, t := range c.VolumeSnapshotTags {
+		if !tagRe.MatchString(t) {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid volume snapshot tag: %s", t))
+		}
+	}
+
+	if c.FirewallTag != "" && !tagRe.MatchString(c.FirewallTag) {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid firewall tag: %s", c.FirewallTag))
+	}
+
+	if c.OutboundLockdown {
+		c.TemporaryFirewall = true
+		if len(c.FirewallIDs) > 0 || c.FirewallTag != "" {
+			warns = append(warns, "The outbound rules of firewall_ids and firewall_tag still "+
+				"apply with outbound_lockdown, and can allow more outbound traffic.")
+		}
+	} else if len(c.OutboundAllow) > 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("outbound_allow requires outbound_lockdown"))
+	}
+	for i := range c.OutboundAllow {
+		if es := c.OutboundAllow[i].Prepare(); len(es) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, es...)
+		}
+	}
+
+	if c.TemporaryFirewall && len(c.TemporaryFirewallSourceCIDRs) == 0 &&
+		(c.SSHInterface != SSHInterfacePublicIP || c.Comm.SSHBastionHost != "" || c.Comm.SSHProxyHost != "") {
+		errs = packersdk.MultiErrorAppend(errs, errors.New(
+			"temporary_firewall_source_cidrs must be set to use temporary_firewall with ssh_interface private_ip or ipv6, a bastion or a proxy"))
+	}
+	for _, cidr := range c.TemporaryFirewallSourceCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil && net.ParseIP(cidr) == nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid temporary firewall source: %s", cidr))
+		}
+	}
+
+	for i := range c.SpacesAssets {
+		if es := c.SpacesAssets[i].Prepare(c.Region); len(es) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, es...)
+		}
+	}
+	if c.MinimalAPIMode {
+		for key, set := range map[string]bool{
+			"install_account_keys":       c.InstallAccountKeys,
+			"catalog_warnings":           c.CatalogWarnings,
+			"team_uuid":                  c.TeamUUID != "",
+			"team_name":                  c.TeamName != "",
+			"project_name":               c.ProjectName != "",
+			"concurrency_policy":         c.ConcurrencyPolicy != "",
+			"report_cost":                c.ReportCost,
+			"force":                      c.Force,
+			"deprecate_previous":         c.DeprecatePrevious,
+			"vpc_name":                   c.VPCName != "",
+			`snapshot_regions = ["all"]`: containsString(c.SnapshotRegions, SnapshotRegionsAll),
+		} {
+			if set {
+				errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("%s can not be used with minimal_api_mode", key))
+			}
+		}
+	}
+
+	switch c.ConcurrencyPolicy {
+	case "", ConcurrencyPolicyFail, ConcurrencyPolicyWait:
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("concurrency_policy must be %q or %q, got %q",
+			ConcurrencyPolicyFail, ConcurrencyPolicyWait, c.ConcurrencyPolicy))
+	}
+
+	if c.ProjectID != "" && c.ProjectName != "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("only one of project_id or project_name can be specified"))
+	}
+
+	if c.ReservedIP != "" {
+		if c.AssignReservedIP {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("only one of reserved_ip or assign_reserved_ip can be specified"))
+		}
+		if ip := net.ParseIP(c.ReservedIP); ip == nil || ip.To4() == nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("reserved_ip must be an IPv4 address, got %q", c.ReservedIP))
+		}
+	}
+
+	if c.BackupPolicy != nil {
+		if !c.Backups {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("backup_policy requires backups to be enabled"))
+		}
+		if es := c.BackupPolicy.Prepare(); len(es) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, es...)
+		}
+	}
+
+	if c.SnapshotNameRegistry != nil {
+		if es := c.SnapshotNameRegistry.Prepare(); len(es) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, es...)
+		}
+	}
+
+	for i := range c.Webhooks {
+		if es := c.Webhooks[i].Prepare(); len(es) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, es...)
+		}
+	}
+
+	if len(c.SpacesAssets) > 0 {
+		if c.SpacesKey == "" || c.SpacesSecret == "" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("spaces_key and spaces_secret must be set to use spaces_assets"))
+		}
+		if c.Comm.Type != "ssh" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("spaces_assets requires the ssh communicator"))
+		}
+	}
+
+	if c.SpacesRegion != "" && !strings.HasPrefix(c.SourceImageURL, "spaces://") {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("spaces_region requires a spaces:// source_image_url"))
+	}
+	if c.SourceImageURL != "" {
+		if c.Image != "" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("image can not be used with source_image_url"))
+		}
+		if len(c.RegionFallbacks) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("region_fallbacks can not be used with source_image_url"))
+		}
+		if u, err := url.Parse(c.SourceImageURL); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid source_image_url: %s", err))
+		} else if u.Scheme == "spaces" {
+			if u.Host == "" || strings.TrimPrefix(u.Path, "/") == "" {
+				errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+					"source_image_url must be of the form spaces://<space>/<key>, got %s", c.SourceImageURL))
+			}
+			if c.SpacesKey == "" || c.SpacesSecret == "" {
+				errs = packersdk.MultiErrorAppend(errs, errors.New("spaces_key and spaces_secret must be set to use a spaces:// source_image_url"))
+			}
+			if c.SpacesRegion == "" {
+				c.SpacesRegion = c.Region
+			}
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"source_image_url must be an http, https or spaces URL, got %s", c.SourceImageURL))
+		}
+	} else if c.KeepSourceImage {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("keep_source_image requires source_image_url"))
+	}
+	if c.SourceImageTimeout < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("source_image_timeout must not be negative"))
+	}
+
+	if c.WaitForCloudInit {
+		if c.Comm.Type != "ssh" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("wait_for_cloud_init requires the ssh communicator"))
+		}
+		if c.ImageInit == ImageInitNone {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("wait_for_cloud_init can not be used with image_init \"none\""))
+		}
+	}
+
+	if c.HCPImageIDFormat == "" {
+		c.HCPImageIDFormat = DefaultHCPImageIDFormat
+	}
+	if err := ValidateHCPImageIDFormat(c.HCPImageIDFormat); err != nil {
+		errs = packersdk.MultiErrorAppend(errs, err)
+	}
+
+	if c.ServiceStatusURL == "" {
+		c.ServiceStatusURL = defaultStatusPageURL
+	}
+	if c.PublicIPURL != "" {
+		if u, err := url.Parse(c.PublicIPURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("public_ip_url must be an http or https URL, got %s", c.PublicIPURL))
+		}
+	}
+	if (c.ServiceStatusWait != 0 || c.ServiceStatusURL != defaultStatusPageURL) && !c.CheckServiceStatus {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("service_status_wait and service_status_url require check_service_status"))
+	}
+
+	if c.SampleMetrics && !c.Monitoring {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("sample_metrics requires monitoring"))
+	}
+
+	if c.ImageRelease != nil {
+		if c.Comm.Type != "ssh" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("image_release requires the ssh communicator"))
+		}
+		if es := c.ImageRelease.Prepare(); len(es) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, es...)
+		}
+	}
+
+	if c.Generalize {
+		if c.Comm.Type != "ssh" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("generalize requires the ssh communicator"))
+		}
+		if c.SkipSnapshot {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("generalize can not be used with skip_snapshot"))
+		}
+	}
+
+	if c.ReclaimFreeSpace != "" {
+		if c.ReclaimFreeSpace != ReclaimFreeSpaceTrim && c.ReclaimFreeSpace != ReclaimFreeSpaceZero {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("reclaim_free_space must be %q or %q",
+				ReclaimFreeSpaceTrim, ReclaimFreeSpaceZero))
+		}
+		if c.Comm.Type != "ssh" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("reclaim_free_space requires the ssh communicator"))
+		}
+		if c.SkipSnapshot {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("reclaim_free_space can not be used with skip_snapshot"))
+		}
+	}
+
+	// Check the temporary key settings now rather than once the build
+	// reaches the key generation
+	keyType := c.Comm.SSHTemporaryKeyPairType
+	if keyType == "" {
+		keyType = "rsa"
+	}
+	keyBits := c.Comm.SSHTemporaryKeyPairBits
+	switch keyType {
+	case "rsa":
+		if keyBits != 0 && keyBits < 1024 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"temporary_key_pair_bits must be at least 1024 for rsa keys, got %d", keyBits))
+		}
+	case "ecdsa":
+		if keyBits != 0 && keyBits != 256 && keyBits != 384 && keyBits != 521 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"temporary_key_pair_bits must be 256, 384 or 521 for ecdsa keys, got %d", keyBits))
+		}
+	case "ed25519":
+		if keyBits != 0 {
+			warns = append(warns, "temporary_key_pair_bits is ignored for ed25519 keys, which have a fixed size.")
+		}
+	case "dsa":
+		errs = packersdk.MultiErrorAppend(errs, errors.New(
+			"temporary_key_pair_type dsa is not supported: OpenSSH disables DSA keys by default, use ed25519 or rsa"))
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+			"temporary_key_pair_type must be one of rsa, ecdsa or ed25519, got %q", keyType))
+	}
+
+	if c.SSHCAPrivateKeyFile != "" || len(c.SSHCASignerCommand) > 0 {
+		if c.SSHCAPrivateKeyFile != "" && len(c.SSHCASignerCommand) > 0 {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("only one of ssh_ca_private_key_file or ssh_ca_signer_command can be specified"))
+		}
+		if c.Comm.Type != "ssh" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("an SSH certificate authority requires the ssh communicator"))
+		}
+		if c.SkipKeygen || c.Comm.SSHPrivateKeyFile != "" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New(
+				"an SSH certificate authority signs the temporary key, so it can not be used with skip_keygen or ssh_private_key_file"))
+		}
+		if c.SSHCAPrivateKeyFile != "" {
+			if _, err := os.Stat(c.SSHCAPrivateKeyFile); err != nil {
+				errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("ssh_ca_private_key_file is invalid: %s", err))
+			}
+		}
+		if len(c.SSHCertificatePrincipals) == 0 {
+			c.SSHCertificatePrincipals = []string{c.Comm.SSHUsername}
+		}
+		if c.SSHCertificateTTL == 0 {
+			c.SSHCertificateTTL = 2 * time.Hour
+		}
+	} else if len(c.SSHCertificatePrincipals) > 0 || c.SSHCertificateTTL != 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New(
+			"ssh_certificate_principals and ssh_certificate_ttl require ssh_ca_private_key_file"))
+	}
+
+	accountKeys := 0
+	for _, set := range []bool{c.SSHKeyID != 0, c.SSHKeyFingerprint != "", c.SSHKeyName != ""} {
+		if set {
+			accountKeys++
+		}
+	}
+	if accountKeys > 1 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New(
+			"only one of ssh_key_id, ssh_key_fingerprint or ssh_key_name can be specified"))
+	}
+
+	for _, id := range c.SSHKeyIDs {
+		if id <= 0 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid SSH key ID in ssh_key_ids: %d", id))
+		}
+	}
+
+	if c.ImageTTL != 0 {
+		if c.ImageTTL < 24*time.Hour {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("image_ttl must be at least 24h"))
+		}
+		if c.ArtifactType == ArtifactTypeDroplet {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("image_ttl can not be used with artifact_type droplet"))
+		}
+	}
+
+	if c.DeprecatePrevious {
+		if c.DeprecatePrefix == "" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("deprecate_prefix must be set to use deprecate_previous"))
+		}
+		if c.ArtifactType == ArtifactTypeDroplet {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("deprecate_previous can not be used with artifact_type droplet"))
+		}
+	}
+	if c.OnDeprecatedImage == "" {
+		c.OnDeprecatedImage = OnDeprecatedImageWarn
+	}
+	if c.OnDeprecatedImage != OnDeprecatedImageWarn && c.OnDeprecatedImage != OnDeprecatedImageError {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("on_deprecated_image must be %q or %q",
+			OnDeprecatedImageWarn, OnDeprecatedImageError))
+	}
+
+	if c.SnapshotVolumes && len(c.Volumes) == 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("snapshot_volumes requires volumes to be set"))
+	}
+
+	if c.TemporaryVPC {
+		c.PrivateNetworking = true
+		if c.VPCUUID != "" || c.VPCName != "" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("only one of vpc_uuid, vpc_name or temporary_vpc can be specified"))
+		}
+		if c.ConnectWithPrivateIP {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("connect_with_private_ip can not be used with temporary_vpc"))
+		}
+		if c.ArtifactType == ArtifactTypeDroplet {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("temporary_vpc can not be used with artifact_type droplet"))
+		}
+	} else if c.TemporaryVPCIPRange != "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("temporary_vpc_ip_range requires temporary_vpc"))
+	}
+	if c.TemporaryVPCIPRange != "" {
+		if _, _, err := net.ParseCIDR(c.TemporaryVPCIPRange); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid temporary_vpc_ip_range: %s", err))
+		}
+	}
+
 	// Check if the PrivateNetworking is enabled by user before use VPC UUID
 	if c.VPCUUID != "" {
 		if !c.PrivateNetworking {
@@ -278,10 +1458,28 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		}
 	}
 
+	if c.VPCName != "" {
+		if c.VPCUUID != "" {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("only one of vpc_uuid or vpc_name can be specified"))
+		}
+		if !c.PrivateNetworking {
+			errs = packersdk.MultiErrorAppend(errs, errors.New("private networking should be enabled to use vpc_name"))
+		}
+	}
+
+	if len(c.RequiredVPCPeerings) > 0 && c.VPCUUID == "" && c.VPCName == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("required_vpc_peerings requires vpc_uuid or vpc_name"))
+	}
+	for _, id := range c.RequiredVPCPeerings {
+		if id == "" || (id == c.VPCUUID && c.VPCUUID != "") {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid VPC UUID in required_vpc_peerings: %q", id))
+		}
+	}
+
 	// Check if the PrivateNetworking is enabled by user before use ConnectWithPrivateIP
 	if c.ConnectWithPrivateIP {
 		if !c.PrivateNetworking {
-			errs = packersdk.MultiErrorAppend(errs, errors.New("private networking should be enabled to use connect_with_private_ip"))
+			errs = packersdk.MultiErrorAppend(errs, errors.New("private networking should be enabled to use ssh_interface private_ip or connect_with_private_ip"))
 		}
 	}
 
@@ -289,6 +1487,72 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		return warns, errs
 	}
 
-	packersdk.LogSecretFilter.Set(c.APIToken)
+	packersdk.LogSecretFilter.Set(c.APIToken, c.SpacesKey, c.SpacesSecret)
+	for _, w := range c.Webhooks {
+		for _, v := range w.Headers {
+			packersdk.LogSecretFilter.Set(v)
+		}
+	}
 	return warns, nil
 }
+
+var envRe = regexp.MustCompile(`^env\("([A-Za-z_][A-Za-z0-9_]*)"\)$`)
+
+// expandEnv replaces a value of the form env("NAME") with the value of the
+// environment variable NAME, which must be set.
+func expandEnv(value *string) error {
+	m := envRe.FindStringSubmatch(*value)
+	if m == nil {
+		return nil
+	}
+
+	v, ok := os.LookupEnv(m[1])
+	if !ok || v == "" {
+		return fmt.Errorf("environment variable %s is not set", m[1])
+	}
+	*value = v
+	return nil
+}
+
+// prepareDropletID validates the configuration of a build against an
+// existing droplet with droplet_id.
+func (c *Config) prepareDropletID() []error {
+	var errs []error
+	if c.DropletID < 0 {
+		errs = append(errs, fmt.Errorf("invalid droplet_id: %d", c.DropletID))
+	}
+
+	for key, set := range map[string]bool{
+		"region":                c.Region != "",
+		"size":                  c.Size != "",
+		"image":                 c.Image != "",
+		"source_image_url":      c.SourceImageURL != "",
+		"region_fallbacks":      len(c.RegionFallbacks) > 0,
+		"size_fallbacks":        len(c.SizeFallbacks) > 0,
+		"user_data":             c.UserData != "",
+		"user_data_file":        c.UserDataFile != "",
+		"vpc_uuid":              c.VPCUUID != "",
+		"vpc_name":              c.VPCName != "",
+		"temporary_vpc":         c.TemporaryVPC,
+		"volumes":               len(c.Volumes) > 0,
+		"backup_policy":         c.BackupPolicy != nil,
+		"ssh_key_id":            c.SSHKeyID != 0,
+		"ssh_key_ids":           len(c.SSHKeyIDs) > 0,
+		"ssh_key_name":          c.SSHKeyName != "",
+		"ssh_key_fingerprint":   c.SSHKeyFingerprint != "",
+		"install_account_keys":  c.InstallAccountKeys,
+		"concurrency_policy":    c.ConcurrencyPolicy != "",
+		"report_cost":           c.ReportCost,
+		"keep_droplet_on_error": c.KeepDropletOnError,
+	} {
+		if set {
+			errs = append(errs, fmt.Errorf("%s can not be used with droplet_id", key))
+		}
+	}
+
+	if c.Comm.Type == "ssh" && c.Comm.SSHPrivateKeyFile == "" && c.Comm.SSHPassword == "" && !c.Comm.SSHAgentAuth {
+		errs = append(errs, errors.New("droplet_id requires ssh_private_key_file, ssh_password "+
+			"or ssh_agent_auth, since no SSH key can be injected into an existing droplet"))
+	}
+	return errs
+}
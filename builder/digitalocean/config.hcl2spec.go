@@ -10,89 +10,192 @@ import (
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
-	PackerBuildName           *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
-	PackerBuilderType         *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
-	PackerCoreVersion         *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
-	PackerDebug               *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
-	PackerForce               *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
-	PackerOnError             *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
-	PackerUserVars            map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
-	PackerSensitiveVars       []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
-	Type                      *string           `mapstructure:"communicator" cty:"communicator" hcl:"communicator"`
-	PauseBeforeConnect        *string           `mapstructure:"pause_before_connecting" cty:"pause_before_connecting" hcl:"pause_before_connecting"`
-	SSHHost                   *string           `mapstructure:"ssh_host" cty:"ssh_host" hcl:"ssh_host"`
-	SSHPort                   *int              `mapstructure:"ssh_port" cty:"ssh_port" hcl:"ssh_port"`
-	SSHUsername               *string           `mapstructure:"ssh_username" cty:"ssh_username" hcl:"ssh_username"`
-	SSHPassword               *string           `mapstructure:"ssh_password" cty:"ssh_password" hcl:"ssh_password"`
-	SSHKeyPairName            *string           `mapstructure:"ssh_keypair_name" undocumented:"true" cty:"ssh_keypair_name" hcl:"ssh_keypair_name"`
-	SSHTemporaryKeyPairName   *string           `mapstructure:"temporary_key_pair_name" undocumented:"true" cty:"temporary_key_pair_name" hcl:"temporary_key_pair_name"`
-	SSHTemporaryKeyPairType   *string           `mapstructure:"temporary_key_pair_type" cty:"temporary_key_pair_type" hcl:"temporary_key_pair_type"`
-	SSHTemporaryKeyPairBits   *int              `mapstructure:"temporary_key_pair_bits" cty:"temporary_key_pair_bits" hcl:"temporary_key_pair_bits"`
-	SSHCiphers                []string          `mapstructure:"ssh_ciphers" cty:"ssh_ciphers" hcl:"ssh_ciphers"`
-	SSHClearAuthorizedKeys    *bool             `mapstructure:"ssh_clear_authorized_keys" cty:"ssh_clear_authorized_keys" hcl:"ssh_clear_authorized_keys"`
-	SSHKEXAlgos               []string          `mapstructure:"ssh_key_exchange_algorithms" cty:"ssh_key_exchange_algorithms" hcl:"ssh_key_exchange_algorithms"`
-	SSHPrivateKeyFile         *string           `mapstructure:"ssh_private_key_file" undocumented:"true" cty:"ssh_private_key_file" hcl:"ssh_private_key_file"`
-	SSHCertificateFile        *string           `mapstructure:"ssh_certificate_file" cty:"ssh_certificate_file" hcl:"ssh_certificate_file"`
-	SSHPty                    *bool             `mapstructure:"ssh_pty" cty:"ssh_pty" hcl:"ssh_pty"`
-	SSHTimeout                *string           `mapstructure:"ssh_timeout" cty:"ssh_timeout" hcl:"ssh_timeout"`
-	SSHWaitTimeout            *string           `mapstructure:"ssh_wait_timeout" undocumented:"true" cty:"ssh_wait_timeout" hcl:"ssh_wait_timeout"`
-	SSHAgentAuth              *bool             `mapstructure:"ssh_agent_auth" undocumented:"true" cty:"ssh_agent_auth" hcl:"ssh_agent_auth"`
-	SSHDisableAgentForwarding *bool             `mapstructure:"ssh_disable_agent_forwarding" cty:"ssh_disable_agent_forwarding" hcl:"ssh_disable_agent_forwarding"`
-	SSHHandshakeAttempts      *int              `mapstructure:"ssh_handshake_attempts" cty:"ssh_handshake_attempts" hcl:"ssh_handshake_attempts"`
-	SSHBastionHost            *string           `mapstructure:"ssh_bastion_host" cty:"ssh_bastion_host" hcl:"ssh_bastion_host"`
-	SSHBastionPort            *int              `mapstructure:"ssh_bastion_port" cty:"ssh_bastion_port" hcl:"ssh_bastion_port"`
-	SSHBastionAgentAuth       *bool             `mapstructure:"ssh_bastion_agent_auth" cty:"ssh_bastion_agent_auth" hcl:"ssh_bastion_agent_auth"`
-	SSHBastionUsername        *string           `mapstructure:"ssh_bastion_username" cty:"ssh_bastion_username" hcl:"ssh_bastion_username"`
-	SSHBastionPassword        *string           `mapstructure:"ssh_bastion_password" cty:"ssh_bastion_password" hcl:"ssh_bastion_password"`
-	SSHBastionInteractive     *bool             `mapstructure:"ssh_bastion_interactive" cty:"ssh_bastion_interactive" hcl:"ssh_bastion_interactive"`
-	SSHBastionPrivateKeyFile  *string           `mapstructure:"ssh_bastion_private_key_file" cty:"ssh_bastion_private_key_file" hcl:"ssh_bastion_private_key_file"`
-	SSHBastionCertificateFile *string           `mapstructure:"ssh_bastion_certificate_file" cty:"ssh_bastion_certificate_file" hcl:"ssh_bastion_certificate_file"`
-	SSHFileTransferMethod     *string           `mapstructure:"ssh_file_transfer_method" cty:"ssh_file_transfer_method" hcl:"ssh_file_transfer_method"`
-	SSHProxyHost              *string           `mapstructure:"ssh_proxy_host" cty:"ssh_proxy_host" hcl:"ssh_proxy_host"`
-	SSHProxyPort              *int              `mapstructure:"ssh_proxy_port" cty:"ssh_proxy_port" hcl:"ssh_proxy_port"`
-	SSHProxyUsername          *string           `mapstructure:"ssh_proxy_username" cty:"ssh_proxy_username" hcl:"ssh_proxy_username"`
-	SSHProxyPassword          *string           `mapstructure:"ssh_proxy_password" cty:"ssh_proxy_password" hcl:"ssh_proxy_password"`
-	SSHKeepAliveInterval      *string           `mapstructure:"ssh_keep_alive_interval" cty:"ssh_keep_alive_interval" hcl:"ssh_keep_alive_interval"`
-	SSHReadWriteTimeout       *string           `mapstructure:"ssh_read_write_timeout" cty:"ssh_read_write_timeout" hcl:"ssh_read_write_timeout"`
-	SSHRemoteTunnels          []string          `mapstructure:"ssh_remote_tunnels" cty:"ssh_remote_tunnels" hcl:"ssh_remote_tunnels"`
-	SSHLocalTunnels           []string          `mapstructure:"ssh_local_tunnels" cty:"ssh_local_tunnels" hcl:"ssh_local_tunnels"`
-	SSHPublicKey              []byte            `mapstructure:"ssh_public_key" undocumented:"true" cty:"ssh_public_key" hcl:"ssh_public_key"`
-	SSHPrivateKey             []byte            `mapstructure:"ssh_private_key" undocumented:"true" cty:"ssh_private_key" hcl:"ssh_private_key"`
-	WinRMUser                 *string           `mapstructure:"winrm_username" cty:"winrm_username" hcl:"winrm_username"`
-	WinRMPassword             *string           `mapstructure:"winrm_password" cty:"winrm_password" hcl:"winrm_password"`
-	WinRMHost                 *string           `mapstructure:"winrm_host" cty:"winrm_host" hcl:"winrm_host"`
-	WinRMNoProxy              *bool             `mapstructure:"winrm_no_proxy" cty:"winrm_no_proxy" hcl:"winrm_no_proxy"`
-	WinRMPort                 *int              `mapstructure:"winrm_port" cty:"winrm_port" hcl:"winrm_port"`
-	WinRMTimeout              *string           `mapstructure:"winrm_timeout" cty:"winrm_timeout" hcl:"winrm_timeout"`
-	WinRMUseSSL               *bool             `mapstructure:"winrm_use_ssl" cty:"winrm_use_ssl" hcl:"winrm_use_ssl"`
-	WinRMInsecure             *bool             `mapstructure:"winrm_insecure" cty:"winrm_insecure" hcl:"winrm_insecure"`
-	WinRMUseNTLM              *bool             `mapstructure:"winrm_use_ntlm" cty:"winrm_use_ntlm" hcl:"winrm_use_ntlm"`
-	APIToken                  *string           `mapstructure:"api_token" required:"true" cty:"api_token" hcl:"api_token"`
-	APIURL                    *string           `mapstructure:"api_url" required:"false" cty:"api_url" hcl:"api_url"`
-	HTTPRetryMax              *int              `mapstructure:"http_retry_max" required:"false" cty:"http_retry_max" hcl:"http_retry_max"`
-	HTTPRetryWaitMax          *float64          `mapstructure:"http_retry_wait_max" required:"false" cty:"http_retry_wait_max" hcl:"http_retry_wait_max"`
-	HTTPRetryWaitMin          *float64          `mapstructure:"http_retry_wait_min" required:"false" cty:"http_retry_wait_min" hcl:"http_retry_wait_min"`
-	Region                    *string           `mapstructure:"region" required:"true" cty:"region" hcl:"region"`
-	Size                      *string           `mapstructure:"size" required:"true" cty:"size" hcl:"size"`
-	Image                     *string           `mapstructure:"image" required:"true" cty:"image" hcl:"image"`
-	PrivateNetworking         *bool             `mapstructure:"private_networking" required:"false" cty:"private_networking" hcl:"private_networking"`
-	Monitoring                *bool             `mapstructure:"monitoring" required:"false" cty:"monitoring" hcl:"monitoring"`
-	DropletAgent              *bool             `mapstructure:"droplet_agent" required:"false" cty:"droplet_agent" hcl:"droplet_agent"`
-	IPv6                      *bool             `mapstructure:"ipv6" required:"false" cty:"ipv6" hcl:"ipv6"`
-	SnapshotName              *string           `mapstructure:"snapshot_name" required:"false" cty:"snapshot_name" hcl:"snapshot_name"`
-	SnapshotRegions           []string          `mapstructure:"snapshot_regions" required:"false" cty:"snapshot_regions" hcl:"snapshot_regions"`
-	WaitSnapshotTransfer      *bool             `mapstructure:"wait_snapshot_transfer" required:"false" cty:"wait_snapshot_transfer" hcl:"wait_snapshot_transfer"`
-	TransferTimeout           *string           `mapstructure:"transfer_timeout" required:"false" cty:"transfer_timeout" hcl:"transfer_timeout"`
-	StateTimeout              *string           `mapstructure:"state_timeout" required:"false" cty:"state_timeout" hcl:"state_timeout"`
-	SnapshotTimeout           *string           `mapstructure:"snapshot_timeout" required:"false" cty:"snapshot_timeout" hcl:"snapshot_timeout"`
-	DropletName               *string           `mapstructure:"droplet_name" required:"false" cty:"droplet_name" hcl:"droplet_name"`
-	UserData                  *string           `mapstructure:"user_data" required:"false" cty:"user_data" hcl:"user_data"`
-	UserDataFile              *string           `mapstructure:"user_data_file" required:"false" cty:"user_data_file" hcl:"user_data_file"`
-	Tags                      []string          `mapstructure:"tags" required:"false" cty:"tags" hcl:"tags"`
-	VPCUUID                   *string           `mapstructure:"vpc_uuid" required:"false" cty:"vpc_uuid" hcl:"vpc_uuid"`
-	ConnectWithPrivateIP      *bool             `mapstructure:"connect_with_private_ip" required:"false" cty:"connect_with_private_ip" hcl:"connect_with_private_ip"`
-	SSHKeyID                  *int              `mapstructure:"ssh_key_id" required:"false" cty:"ssh_key_id" hcl:"ssh_key_id"`
-	SkipKeygen                *bool             `mapstructure:"skip_keygen" required:"false" cty:"skip_keygen" hcl:"skip_keygen"`
+	PackerBuildName              *string             `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType            *string             `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion            *string             `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug                  *bool               `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce                  *bool               `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError                *string             `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerUserVars               map[string]string   `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars          []string            `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	Type                         *string             `mapstructure:"communicator" cty:"communicator" hcl:"communicator"`
+	PauseBeforeConnect           *string             `mapstructure:"pause_before_connecting" cty:"pause_before_connecting" hcl:"pause_before_connecting"`
+	SSHHost                      *string             `mapstructure:"ssh_host" cty:"ssh_host" hcl:"ssh_host"`
+	SSHPort                      *int                `mapstructure:"ssh_port" cty:"ssh_port" hcl:"ssh_port"`
+	SSHUsername                  *string             `mapstructure:"ssh_username" cty:"ssh_username" hcl:"ssh_username"`
+	SSHPassword                  *string             `mapstructure:"ssh_password" cty:"ssh_password" hcl:"ssh_password"`
+	SSHKeyPairName               *string             `mapstructure:"ssh_keypair_name" undocumented:"true" cty:"ssh_keypair_name" hcl:"ssh_keypair_name"`
+	SSHTemporaryKeyPairName      *string             `mapstructure:"temporary_key_pair_name" undocumented:"true" cty:"temporary_key_pair_name" hcl:"temporary_key_pair_name"`
+	SSHTemporaryKeyPairType      *string             `mapstructure:"temporary_key_pair_type" cty:"temporary_key_pair_type" hcl:"temporary_key_pair_type"`
+	SSHTemporaryKeyPairBits      *int                `mapstructure:"temporary_key_pair_bits" cty:"temporary_key_pair_bits" hcl:"temporary_key_pair_bits"`
+	SSHCiphers                   []string            `mapstructure:"ssh_ciphers" cty:"ssh_ciphers" hcl:"ssh_ciphers"`
+	SSHClearAuthorizedKeys       *bool               `mapstructure:"ssh_clear_authorized_keys" cty:"ssh_clear_authorized_keys" hcl:"ssh_clear_authorized_keys"`
+	SSHKEXAlgos                  []string            `mapstructure:"ssh_key_exchange_algorithms" cty:"ssh_key_exchange_algorithms" hcl:"ssh_key_exchange_algorithms"`
+	SSHPrivateKeyFile            *string             `mapstructure:"ssh_private_key_file" undocumented:"true" cty:"ssh_private_key_file" hcl:"ssh_private_key_file"`
+	SSHCertificateFile           *string             `mapstructure:"ssh_certificate_file" cty:"ssh_certificate_file" hcl:"ssh_certificate_file"`
+	SSHPty                       *bool               `mapstructure:"ssh_pty" cty:"ssh_pty" hcl:"ssh_pty"`
+	SSHTimeout                   *string             `mapstructure:"ssh_timeout" cty:"ssh_timeout" hcl:"ssh_timeout"`
+	SSHWaitTimeout               *string             `mapstructure:"ssh_wait_timeout" undocumented:"true" cty:"ssh_wait_timeout" hcl:"ssh_wait_timeout"`
+	SSHAgentAuth                 *bool               `mapstructure:"ssh_agent_auth" undocumented:"true" cty:"ssh_agent_auth" hcl:"ssh_agent_auth"`
+	SSHDisableAgentForwarding    *bool               `mapstructure:"ssh_disable_agent_forwarding" cty:"ssh_disable_agent_forwarding" hcl:"ssh_disable_agent_forwarding"`
+	SSHHandshakeAttempts         *int                `mapstructure:"ssh_handshake_attempts" cty:"ssh_handshake_attempts" hcl:"ssh_handshake_attempts"`
+	SSHBastionHost               *string             `mapstructure:"ssh_bastion_host" cty:"ssh_bastion_host" hcl:"ssh_bastion_host"`
+	SSHBastionPort               *int                `mapstructure:"ssh_bastion_port" cty:"ssh_bastion_port" hcl:"ssh_bastion_port"`
+	SSHBastionAgentAuth          *bool               `mapstructure:"ssh_bastion_agent_auth" cty:"ssh_bastion_agent_auth" hcl:"ssh_bastion_agent_auth"`
+	SSHBastionUsername           *string             `mapstructure:"ssh_bastion_username" cty:"ssh_bastion_username" hcl:"ssh_bastion_username"`
+	SSHBastionPassword           *string             `mapstructure:"ssh_bastion_password" cty:"ssh_bastion_password" hcl:"ssh_bastion_password"`
+	SSHBastionInteractive        *bool               `mapstructure:"ssh_bastion_interactive" cty:"ssh_bastion_interactive" hcl:"ssh_bastion_interactive"`
+	SSHBastionPrivateKeyFile     *string             `mapstructure:"ssh_bastion_private_key_file" cty:"ssh_bastion_private_key_file" hcl:"ssh_bastion_private_key_file"`
+	SSHBastionCertificateFile    *string             `mapstructure:"ssh_bastion_certificate_file" cty:"ssh_bastion_certificate_file" hcl:"ssh_bastion_certificate_file"`
+	SSHFileTransferMethod        *string             `mapstructure:"ssh_file_transfer_method" cty:"ssh_file_transfer_method" hcl:"ssh_file_transfer_method"`
+	SSHProxyHost                 *string             `mapstructure:"ssh_proxy_host" cty:"ssh_proxy_host" hcl:"ssh_proxy_host"`
+	SSHProxyPort                 *int                `mapstructure:"ssh_proxy_port" cty:"ssh_proxy_port" hcl:"ssh_proxy_port"`
+	SSHProxyUsername             *string             `mapstructure:"ssh_proxy_username" cty:"ssh_proxy_username" hcl:"ssh_proxy_username"`
+	SSHProxyPassword             *string             `mapstructure:"ssh_proxy_password" cty:"ssh_proxy_password" hcl:"ssh_proxy_password"`
+	SSHKeepAliveInterval         *string             `mapstructure:"ssh_keep_alive_interval" cty:"ssh_keep_alive_interval" hcl:"ssh_keep_alive_interval"`
+	SSHReadWriteTimeout          *string             `mapstructure:"ssh_read_write_timeout" cty:"ssh_read_write_timeout" hcl:"ssh_read_write_timeout"`
+	SSHRemoteTunnels             []string            `mapstructure:"ssh_remote_tunnels" cty:"ssh_remote_tunnels" hcl:"ssh_remote_tunnels"`
+	SSHLocalTunnels              []string            `mapstructure:"ssh_local_tunnels" cty:"ssh_local_tunnels" hcl:"ssh_local_tunnels"`
+	SSHPublicKey                 []byte              `mapstructure:"ssh_public_key" undocumented:"true" cty:"ssh_public_key" hcl:"ssh_public_key"`
+	SSHPrivateKey                []byte              `mapstructure:"ssh_private_key" undocumented:"true" cty:"ssh_private_key" hcl:"ssh_private_key"`
+	WinRMUser                    *string             `mapstructure:"winrm_username" cty:"winrm_username" hcl:"winrm_username"`
+	WinRMPassword                *string             `mapstructure:"winrm_password" cty:"winrm_password" hcl:"winrm_password"`
+	WinRMHost                    *string             `mapstructure:"winrm_host" cty:"winrm_host" hcl:"winrm_host"`
+	WinRMNoProxy                 *bool               `mapstructure:"winrm_no_proxy" cty:"winrm_no_proxy" hcl:"winrm_no_proxy"`
+	WinRMPort                    *int                `mapstructure:"winrm_port" cty:"winrm_port" hcl:"winrm_port"`
+	WinRMTimeout                 *string             `mapstructure:"winrm_timeout" cty:"winrm_timeout" hcl:"winrm_timeout"`
+	WinRMUseSSL                  *bool               `mapstructure:"winrm_use_ssl" cty:"winrm_use_ssl" hcl:"winrm_use_ssl"`
+	WinRMInsecure                *bool               `mapstructure:"winrm_insecure" cty:"winrm_insecure" hcl:"winrm_insecure"`
+	WinRMUseNTLM                 *bool               `mapstructure:"winrm_use_ntlm" cty:"winrm_use_ntlm" hcl:"winrm_use_ntlm"`
+	HTTPDir                      *string             `mapstructure:"http_directory" cty:"http_directory" hcl:"http_directory"`
+	HTTPContent                  map[string]string   `mapstructure:"http_content" cty:"http_content" hcl:"http_content"`
+	HTTPPortMin                  *int                `mapstructure:"http_port_min" cty:"http_port_min" hcl:"http_port_min"`
+	HTTPPortMax                  *int                `mapstructure:"http_port_max" cty:"http_port_max" hcl:"http_port_max"`
+	HTTPAddress                  *string             `mapstructure:"http_bind_address" cty:"http_bind_address" hcl:"http_bind_address"`
+	HTTPInterface                *string             `mapstructure:"http_interface" undocumented:"true" cty:"http_interface" hcl:"http_interface"`
+	APIToken                     *string             `mapstructure:"api_token" required:"true" cty:"api_token" hcl:"api_token"`
+	APIURL                       *string             `mapstructure:"api_url" required:"false" cty:"api_url" hcl:"api_url"`
+	HTTPRetryMax                 *int                `mapstructure:"http_retry_max" required:"false" cty:"http_retry_max" hcl:"http_retry_max"`
+	HTTPRetryWaitMax             *float64            `mapstructure:"http_retry_wait_max" required:"false" cty:"http_retry_wait_max" hcl:"http_retry_wait_max"`
+	HTTPRetryWaitMin             *float64            `mapstructure:"http_retry_wait_min" required:"false" cty:"http_retry_wait_min" hcl:"http_retry_wait_min"`
+	Retry                        *FlatRetryConfig    `mapstructure:"retry" required:"false" cty:"retry" hcl:"retry"`
+	MinimalAPIMode               *bool               `mapstructure:"minimal_api_mode" required:"false" cty:"minimal_api_mode" hcl:"minimal_api_mode"`
+	APIRecordFile                *string             `mapstructure:"api_record_file" required:"false" cty:"api_record_file" hcl:"api_record_file"`
+	APIReplayFile                *string             `mapstructure:"api_replay_file" required:"false" cty:"api_replay_file" hcl:"api_replay_file"`
+	TeamUUID                     *string             `mapstructure:"team_uuid" required:"false" cty:"team_uuid" hcl:"team_uuid"`
+	TeamName                     *string             `mapstructure:"team_name" required:"false" cty:"team_name" hcl:"team_name"`
+	ConcurrencyPolicy            *string             `mapstructure:"concurrency_policy" required:"false" cty:"concurrency_policy" hcl:"concurrency_policy"`
+	ConcurrencyTimeout           *string             `mapstructure:"concurrency_timeout" required:"false" cty:"concurrency_timeout" hcl:"concurrency_timeout"`
+	Region                       *string             `mapstructure:"region" required:"true" cty:"region" hcl:"region"`
+	Size                         *string             `mapstructure:"size" required:"true" cty:"size" hcl:"size"`
+	RegionFallbacks              []string            `mapstructure:"region_fallbacks" required:"false" cty:"region_fallbacks" hcl:"region_fallbacks"`
+	SizeFallbacks                []string            `mapstructure:"size_fallbacks" required:"false" cty:"size_fallbacks" hcl:"size_fallbacks"`
+	DropletID                    *int                `mapstructure:"droplet_id" required:"false" cty:"droplet_id" hcl:"droplet_id"`
+	LeaveDropletRunning          *bool               `mapstructure:"leave_droplet_running" required:"false" cty:"leave_droplet_running" hcl:"leave_droplet_running"`
+	Image                        *string             `mapstructure:"image" required:"true" cty:"image" hcl:"image"`
+	SourceImageURL               *string             `mapstructure:"source_image_url" required:"false" cty:"source_image_url" hcl:"source_image_url"`
+	SpacesRegion                 *string             `mapstructure:"spaces_region" required:"false" cty:"spaces_region" hcl:"spaces_region"`
+	SourceImageDistribution      *string             `mapstructure:"source_image_distribution" required:"false" cty:"source_image_distribution" hcl:"source_image_distribution"`
+	KeepSourceImage              *bool               `mapstructure:"keep_source_image" required:"false" cty:"keep_source_image" hcl:"keep_source_image"`
+	SourceImageTimeout           *string             `mapstructure:"source_image_timeout" required:"false" cty:"source_image_timeout" hcl:"source_image_timeout"`
+	PrivateNetworking            *bool               `mapstructure:"private_networking" required:"false" cty:"private_networking" hcl:"private_networking"`
+	Monitoring                   *bool               `mapstructure:"monitoring" required:"false" cty:"monitoring" hcl:"monitoring"`
+	SampleMetrics                *bool               `mapstructure:"sample_metrics" required:"false" cty:"sample_metrics" hcl:"sample_metrics"`
+	ReportCost                   *bool               `mapstructure:"report_cost" required:"false" cty:"report_cost" hcl:"report_cost"`
+	KeepDropletOnError           *bool               `mapstructure:"keep_droplet_on_error" required:"false" cty:"keep_droplet_on_error" hcl:"keep_droplet_on_error"`
+	DropletAgent                 *bool               `mapstructure:"droplet_agent" required:"false" cty:"droplet_agent" hcl:"droplet_agent"`
+	IPv6                         *bool               `mapstructure:"ipv6" required:"false" cty:"ipv6" hcl:"ipv6"`
+	ArtifactType                 *string             `mapstructure:"artifact_type" required:"false" cty:"artifact_type" hcl:"artifact_type"`
+	SkipSnapshot                 *bool               `mapstructure:"skip_snapshot" required:"false" cty:"skip_snapshot" hcl:"skip_snapshot"`
+	Backups                      *bool               `mapstructure:"backups" required:"false" cty:"backups" hcl:"backups"`
+	BackupPolicy                 *FlatBackupPolicy   `mapstructure:"backup_policy" required:"false" cty:"backup_policy" hcl:"backup_policy"`
+	SnapshotName                 *string             `mapstructure:"snapshot_name" required:"false" cty:"snapshot_name" hcl:"snapshot_name"`
+	SnapshotNameRegistry         *FlatNameRegistry   `mapstructure:"snapshot_name_registry" required:"false" cty:"snapshot_name_registry" hcl:"snapshot_name_registry"`
+	ImageTTL                     *string             `mapstructure:"image_ttl" required:"false" cty:"image_ttl" hcl:"image_ttl"`
+	DeprecatePrevious            *bool               `mapstructure:"deprecate_previous" required:"false" cty:"deprecate_previous" hcl:"deprecate_previous"`
+	DeprecatePrefix              *string             `mapstructure:"deprecate_prefix" required:"false" cty:"deprecate_prefix" hcl:"deprecate_prefix"`
+	OnDeprecatedImage            *string             `mapstructure:"on_deprecated_image" required:"false" cty:"on_deprecated_image" hcl:"on_deprecated_image"`
+	HCPImageIDFormat             *string             `mapstructure:"hcp_image_id_format" required:"false" cty:"hcp_image_id_format" hcl:"hcp_image_id_format"`
+	SnapshotRegions              []string            `mapstructure:"snapshot_regions" required:"false" cty:"snapshot_regions" hcl:"snapshot_regions"`
+	SnapshotRegionsExclude       []string            `mapstructure:"snapshot_regions_exclude" required:"false" cty:"snapshot_regions_exclude" hcl:"snapshot_regions_exclude"`
+	WaitSnapshotTransfer         *bool               `mapstructure:"wait_snapshot_transfer" required:"false" cty:"wait_snapshot_transfer" hcl:"wait_snapshot_transfer"`
+	TransferTimeout              *string             `mapstructure:"transfer_timeout" required:"false" cty:"transfer_timeout" hcl:"transfer_timeout"`
+	TransferConcurrency          *int                `mapstructure:"transfer_concurrency" required:"false" cty:"transfer_concurrency" hcl:"transfer_concurrency"`
+	TransferRetries              *int                `mapstructure:"transfer_retries" required:"false" cty:"transfer_retries" hcl:"transfer_retries"`
+	StateTimeout                 *string             `mapstructure:"state_timeout" required:"false" cty:"state_timeout" hcl:"state_timeout"`
+	StatePollInterval            *string             `mapstructure:"state_poll_interval" required:"false" cty:"state_poll_interval" hcl:"state_poll_interval"`
+	ShutdownTimeout              *string             `mapstructure:"shutdown_timeout" required:"false" cty:"shutdown_timeout" hcl:"shutdown_timeout"`
+	PowerOffFallback             *bool               `mapstructure:"power_off_fallback" required:"false" cty:"power_off_fallback" hcl:"power_off_fallback"`
+	PauseBeforeSnapshot          *string             `mapstructure:"pause_before_snapshot" required:"false" cty:"pause_before_snapshot" hcl:"pause_before_snapshot"`
+	GPUReadyTimeout              *string             `mapstructure:"gpu_ready_timeout" required:"false" cty:"gpu_ready_timeout" hcl:"gpu_ready_timeout"`
+	WaitForCloudInit             *bool               `mapstructure:"wait_for_cloud_init" required:"false" cty:"wait_for_cloud_init" hcl:"wait_for_cloud_init"`
+	CloudInitTimeout             *string             `mapstructure:"cloud_init_timeout" required:"false" cty:"cloud_init_timeout" hcl:"cloud_init_timeout"`
+	UnlockTimeout                *string             `mapstructure:"unlock_timeout" required:"false" cty:"unlock_timeout" hcl:"unlock_timeout"`
+	SnapshotTimeout              *string             `mapstructure:"snapshot_timeout" required:"false" cty:"snapshot_timeout" hcl:"snapshot_timeout"`
+	SnapshotRetryTimeout         *string             `mapstructure:"snapshot_retry_timeout" required:"false" cty:"snapshot_retry_timeout" hcl:"snapshot_retry_timeout"`
+	DropletName                  *string             `mapstructure:"droplet_name" required:"false" cty:"droplet_name" hcl:"droplet_name"`
+	UserData                     *string             `mapstructure:"user_data" required:"false" cty:"user_data" hcl:"user_data"`
+	UserDataFile                 *string             `mapstructure:"user_data_file" required:"false" cty:"user_data_file" hcl:"user_data_file"`
+	CompressUserData             *bool               `mapstructure:"compress_user_data" required:"false" cty:"compress_user_data" hcl:"compress_user_data"`
+	ValidateUserData             *bool               `mapstructure:"validate_user_data" required:"false" cty:"validate_user_data" hcl:"validate_user_data"`
+	UserDataCompletion           *string             `mapstructure:"user_data_completion" required:"false" cty:"user_data_completion" hcl:"user_data_completion"`
+	UserDataCompletionTag        *string             `mapstructure:"user_data_completion_tag" required:"false" cty:"user_data_completion_tag" hcl:"user_data_completion_tag"`
+	UserDataTimeout              *string             `mapstructure:"user_data_timeout" required:"false" cty:"user_data_timeout" hcl:"user_data_timeout"`
+	Tags                         []string            `mapstructure:"tags" required:"false" cty:"tags" hcl:"tags"`
+	Force                        *bool               `mapstructure:"force" required:"false" cty:"force" hcl:"force"`
+	SnapshotTags                 []string            `mapstructure:"snapshot_tags" required:"false" cty:"snapshot_tags" hcl:"snapshot_tags"`
+	Volumes                      []string            `mapstructure:"volumes" required:"false" cty:"volumes" hcl:"volumes"`
+	SnapshotVolumes              *bool               `mapstructure:"snapshot_volumes" required:"false" cty:"snapshot_volumes" hcl:"snapshot_volumes"`
+	VolumeSnapshotName           *string             `mapstructure:"volume_snapshot_name" required:"false" cty:"volume_snapshot_name" hcl:"volume_snapshot_name"`
+	VolumeSnapshotTags           []string            `mapstructure:"volume_snapshot_tags" required:"false" cty:"volume_snapshot_tags" hcl:"volume_snapshot_tags"`
+	VolumeSnapshotTimeout        *string             `mapstructure:"volume_snapshot_timeout" required:"false" cty:"volume_snapshot_timeout" hcl:"volume_snapshot_timeout"`
+	SpacesAssets                 []FlatSpacesAsset   `mapstructure:"spaces_assets" required:"false" cty:"spaces_assets" hcl:"spaces_assets"`
+	CheckServiceStatus           *bool               `mapstructure:"check_service_status" required:"false" cty:"check_service_status" hcl:"check_service_status"`
+	ServiceStatusWait            *string             `mapstructure:"service_status_wait" required:"false" cty:"service_status_wait" hcl:"service_status_wait"`
+	ServiceStatusURL             *string             `mapstructure:"service_status_url" required:"false" cty:"service_status_url" hcl:"service_status_url"`
+	Webhooks                     []FlatWebhook       `mapstructure:"webhook" required:"false" cty:"webhook" hcl:"webhook"`
+	SpacesKey                    *string             `mapstructure:"spaces_key" required:"false" cty:"spaces_key" hcl:"spaces_key"`
+	SpacesSecret                 *string             `mapstructure:"spaces_secret" required:"false" cty:"spaces_secret" hcl:"spaces_secret"`
+	SpacesURLTTL                 *string             `mapstructure:"spaces_url_ttl" required:"false" cty:"spaces_url_ttl" hcl:"spaces_url_ttl"`
+	ProjectID                    *string             `mapstructure:"project_id" required:"false" cty:"project_id" hcl:"project_id"`
+	ProjectName                  *string             `mapstructure:"project_name" required:"false" cty:"project_name" hcl:"project_name"`
+	ReservedIP                   *string             `mapstructure:"reserved_ip" required:"false" cty:"reserved_ip" hcl:"reserved_ip"`
+	AssignReservedIP             *bool               `mapstructure:"assign_reserved_ip" required:"false" cty:"assign_reserved_ip" hcl:"assign_reserved_ip"`
+	FirewallIDs                  []string            `mapstructure:"firewall_ids" required:"false" cty:"firewall_ids" hcl:"firewall_ids"`
+	FirewallTag                  *string             `mapstructure:"firewall_tag" required:"false" cty:"firewall_tag" hcl:"firewall_tag"`
+	TemporaryFirewall            *bool               `mapstructure:"temporary_firewall" required:"false" cty:"temporary_firewall" hcl:"temporary_firewall"`
+	TemporaryFirewallSourceCIDRs []string            `mapstructure:"temporary_firewall_source_cidrs" required:"false" cty:"temporary_firewall_source_cidrs" hcl:"temporary_firewall_source_cidrs"`
+	PublicIPURL                  *string             `mapstructure:"public_ip_url" required:"false" cty:"public_ip_url" hcl:"public_ip_url"`
+	OutboundLockdown             *bool               `mapstructure:"outbound_lockdown" required:"false" cty:"outbound_lockdown" hcl:"outbound_lockdown"`
+	OutboundAllow                []FlatOutboundAllow `mapstructure:"outbound_allow" required:"false" cty:"outbound_allow" hcl:"outbound_allow"`
+	VPCUUID                      *string             `mapstructure:"vpc_uuid" required:"false" cty:"vpc_uuid" hcl:"vpc_uuid"`
+	VPCName                      *string             `mapstructure:"vpc_name" required:"false" cty:"vpc_name" hcl:"vpc_name"`
+	RequiredVPCPeerings          []string            `mapstructure:"required_vpc_peerings" required:"false" cty:"required_vpc_peerings" hcl:"required_vpc_peerings"`
+	TemporaryVPC                 *bool               `mapstructure:"temporary_vpc" required:"false" cty:"temporary_vpc" hcl:"temporary_vpc"`
+	TemporaryVPCIPRange          *string             `mapstructure:"temporary_vpc_ip_range" required:"false" cty:"temporary_vpc_ip_range" hcl:"temporary_vpc_ip_range"`
+	ConnectWithPrivateIP         *bool               `mapstructure:"connect_with_private_ip" required:"false" cty:"connect_with_private_ip" hcl:"connect_with_private_ip"`
+	SSHInterface                 *string             `mapstructure:"ssh_interface" required:"false" cty:"ssh_interface" hcl:"ssh_interface"`
+	SSHKeyID                     *int                `mapstructure:"ssh_key_id" required:"false" cty:"ssh_key_id" hcl:"ssh_key_id"`
+	SSHKeyFingerprint            *string             `mapstructure:"ssh_key_fingerprint" required:"false" cty:"ssh_key_fingerprint" hcl:"ssh_key_fingerprint"`
+	SSHKeyName                   *string             `mapstructure:"ssh_key_name" required:"false" cty:"ssh_key_name" hcl:"ssh_key_name"`
+	SSHKeyIDs                    []int               `mapstructure:"ssh_key_ids" required:"false" cty:"ssh_key_ids" hcl:"ssh_key_ids"`
+	InstallAccountKeys           *bool               `mapstructure:"install_account_keys" required:"false" cty:"install_account_keys" hcl:"install_account_keys"`
+	SkipKeygen                   *bool               `mapstructure:"skip_keygen" required:"false" cty:"skip_keygen" hcl:"skip_keygen"`
+	SSHCAPrivateKeyFile          *string             `mapstructure:"ssh_ca_private_key_file" required:"false" cty:"ssh_ca_private_key_file" hcl:"ssh_ca_private_key_file"`
+	SSHCASignerCommand           []string            `mapstructure:"ssh_ca_signer_command" required:"false" cty:"ssh_ca_signer_command" hcl:"ssh_ca_signer_command"`
+	SSHCertificatePrincipals     []string            `mapstructure:"ssh_certificate_principals" required:"false" cty:"ssh_certificate_principals" hcl:"ssh_certificate_principals"`
+	SSHCertificateTTL            *string             `mapstructure:"ssh_certificate_ttl" required:"false" cty:"ssh_certificate_ttl" hcl:"ssh_certificate_ttl"`
+	SSHKeyPropagationTimeout     *string             `mapstructure:"ssh_key_propagation_timeout" required:"false" cty:"ssh_key_propagation_timeout" hcl:"ssh_key_propagation_timeout"`
+	ProvisionReconnectAttempts   *int                `mapstructure:"provision_reconnect_attempts" required:"false" cty:"provision_reconnect_attempts" hcl:"provision_reconnect_attempts"`
+	ConnectPowerCycle            *bool               `mapstructure:"connect_power_cycle" required:"false" cty:"connect_power_cycle" hcl:"connect_power_cycle"`
+	ConnectFailureReportPath     *string             `mapstructure:"connect_failure_report_path" required:"false" cty:"connect_failure_report_path" hcl:"connect_failure_report_path"`
+	HTTPReverseTunnel            *bool               `mapstructure:"http_reverse_tunnel" required:"false" cty:"http_reverse_tunnel" hcl:"http_reverse_tunnel"`
+	CatalogWarnings              *bool               `mapstructure:"catalog_warnings" required:"false" cty:"catalog_warnings" hcl:"catalog_warnings"`
+	CaptureNetworkConfig         *bool               `mapstructure:"capture_network_config" required:"false" cty:"capture_network_config" hcl:"capture_network_config"`
+	ImageRelease                 *FlatImageRelease   `mapstructure:"image_release" required:"false" cty:"image_release" hcl:"image_release"`
+	Generalize                   *bool               `mapstructure:"generalize" required:"false" cty:"generalize" hcl:"generalize"`
+	ReclaimFreeSpace             *string             `mapstructure:"reclaim_free_space" required:"false" cty:"reclaim_free_space" hcl:"reclaim_free_space"`
+	ImageInit                    *string             `mapstructure:"image_init" required:"false" cty:"image_init" hcl:"image_init"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -107,89 +210,192 @@ func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec }
 // The decoded values from this spec will then be applied to a FlatConfig.
 func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"packer_build_name":            &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
-		"packer_builder_type":          &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
-		"packer_core_version":          &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
-		"packer_debug":                 &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
-		"packer_force":                 &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
-		"packer_on_error":              &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
-		"packer_user_variables":        &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
-		"packer_sensitive_variables":   &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
-		"communicator":                 &hcldec.AttrSpec{Name: "communicator", Type: cty.String, Required: false},
-		"pause_before_connecting":      &hcldec.AttrSpec{Name: "pause_before_connecting", Type: cty.String, Required: false},
-		"ssh_host":                     &hcldec.AttrSpec{Name: "ssh_host", Type: cty.String, Required: false},
-		"ssh_port":                     &hcldec.AttrSpec{Name: "ssh_port", Type: cty.Number, Required: false},
-		"ssh_username":                 &hcldec.AttrSpec{Name: "ssh_username", Type: cty.String, Required: false},
-		"ssh_password":                 &hcldec.AttrSpec{Name: "ssh_password", Type: cty.String, Required: false},
-		"ssh_keypair_name":             &hcldec.AttrSpec{Name: "ssh_keypair_name", Type: cty.String, Required: false},
-		"temporary_key_pair_name":      &hcldec.AttrSpec{Name: "temporary_key_pair_name", Type: cty.String, Required: false},
-		"temporary_key_pair_type":      &hcldec.AttrSpec{Name: "temporary_key_pair_type", Type: cty.String, Required: false},
-		"temporary_key_pair_bits":      &hcldec.AttrSpec{Name: "temporary_key_pair_bits", Type: cty.Number, Required: false},
-		"ssh_ciphers":                  &hcldec.AttrSpec{Name: "ssh_ciphers", Type: cty.List(cty.String), Required: false},
-		"ssh_clear_authorized_keys":    &hcldec.AttrSpec{Name: "ssh_clear_authorized_keys", Type: cty.Bool, Required: false},
-		"ssh_key_exchange_algorithms":  &hcldec.AttrSpec{Name: "ssh_key_exchange_algorithms", Type: cty.List(cty.String), Required: false},
-		"ssh_private_key_file":         &hcldec.AttrSpec{Name: "ssh_private_key_file", Type: cty.String, Required: false},
-		"ssh_certificate_file":         &hcldec.AttrSpec{Name: "ssh_certificate_file", Type: cty.String, Required: false},
-		"ssh_pty":                      &hcldec.AttrSpec{Name: "ssh_pty", Type: cty.Bool, Required: false},
-		"ssh_timeout":                  &hcldec.AttrSpec{Name: "ssh_timeout", Type: cty.String, Required: false},
-		"ssh_wait_timeout":             &hcldec.AttrSpec{Name: "ssh_wait_timeout", Type: cty.String, Required: false},
-		"ssh_agent_auth":               &hcldec.AttrSpec{Name: "ssh_agent_auth", Type: cty.Bool, Required: false},
-		"ssh_disable_agent_forwarding": &hcldec.AttrSpec{Name: "ssh_disable_agent_forwarding", Type: cty.Bool, Required: false},
-		"ssh_handshake_attempts":       &hcldec.AttrSpec{Name: "ssh_handshake_attempts", Type: cty.Number, Required: false},
-		"ssh_bastion_host":             &hcldec.AttrSpec{Name: "ssh_bastion_host", Type: cty.String, Required: false},
-		"ssh_bastion_port":             &hcldec.AttrSpec{Name: "ssh_bastion_port", Type: cty.Number, Required: false},
-		"ssh_bastion_agent_auth":       &hcldec.AttrSpec{Name: "ssh_bastion_agent_auth", Type: cty.Bool, Required: false},
-		"ssh_bastion_username":         &hcldec.AttrSpec{Name: "ssh_bastion_username", Type: cty.String, Required: false},
-		"ssh_bastion_password":         &hcldec.AttrSpec{Name: "ssh_bastion_password", Type: cty.String, Required: false},
-		"ssh_bastion_interactive":      &hcldec.AttrSpec{Name: "ssh_bastion_interactive", Type: cty.Bool, Required: false},
-		"ssh_bastion_private_key_file": &hcldec.AttrSpec{Name: "ssh_bastion_private_key_file", Type: cty.String, Required: false},
-		"ssh_bastion_certificate_file": &hcldec.AttrSpec{Name: "ssh_bastion_certificate_file", Type: cty.String, Required: false},
-		"ssh_file_transfer_method":     &hcldec.AttrSpec{Name: "ssh_file_transfer_method", Type: cty.String, Required: false},
-		"ssh_proxy_host":               &hcldec.AttrSpec{Name: "ssh_proxy_host", Type: cty.String, Required: false},
-		"ssh_proxy_port":               &hcldec.AttrSpec{Name: "ssh_proxy_port", Type: cty.Number, Required: false},
-		"ssh_proxy_username":           &hcldec.AttrSpec{Name: "ssh_proxy_username", Type: cty.String, Required: false},
-		"ssh_proxy_password":           &hcldec.AttrSpec{Name: "ssh_proxy_password", Type: cty.String, Required: false},
-		"ssh_keep_alive_interval":      &hcldec.AttrSpec{Name: "ssh_keep_alive_interval", Type: cty.String, Required: false},
-		"ssh_read_write_timeout":       &hcldec.AttrSpec{Name: "ssh_read_write_timeout", Type: cty.String, Required: false},
-		"ssh_remote_tunnels":           &hcldec.AttrSpec{Name: "ssh_remote_tunnels", Type: cty.List(cty.String), Required: false},
-		"ssh_local_tunnels":            &hcldec.AttrSpec{Name: "ssh_local_tunnels", Type: cty.List(cty.String), Required: false},
-		"ssh_public_key":               &hcldec.AttrSpec{Name: "ssh_public_key", Type: cty.List(cty.Number), Required: false},
-		"ssh_private_key":              &hcldec.AttrSpec{Name: "ssh_private_key", Type: cty.List(cty.Number), Required: false},
-		"winrm_username":               &hcldec.AttrSpec{Name: "winrm_username", Type: cty.String, Required: false},
-		"winrm_password":               &hcldec.AttrSpec{Name: "winrm_password", Type: cty.String, Required: false},
-		"winrm_host":                   &hcldec.AttrSpec{Name: "winrm_host", Type: cty.String, Required: false},
-		"winrm_no_proxy":               &hcldec.AttrSpec{Name: "winrm_no_proxy", Type: cty.Bool, Required: false},
-		"winrm_port":                   &hcldec.AttrSpec{Name: "winrm_port", Type: cty.Number, Required: false},
-		"winrm_timeout":                &hcldec.AttrSpec{Name: "winrm_timeout", Type: cty.String, Required: false},
-		"winrm_use_ssl":                &hcldec.AttrSpec{Name: "winrm_use_ssl", Type: cty.Bool, Required: false},
-		"winrm_insecure":               &hcldec.AttrSpec{Name: "winrm_insecure", Type: cty.Bool, Required: false},
-		"winrm_use_ntlm":               &hcldec.AttrSpec{Name: "winrm_use_ntlm", Type: cty.Bool, Required: false},
-		"api_token":                    &hcldec.AttrSpec{Name: "api_token", Type: cty.String, Required: false},
-		"api_url":                      &hcldec.AttrSpec{Name: "api_url", Type: cty.String, Required: false},
-		"http_retry_max":               &hcldec.AttrSpec{Name: "http_retry_max", Type: cty.Number, Required: false},
-		"http_retry_wait_max":          &hcldec.AttrSpec{Name: "http_retry_wait_max", Type: cty.Number, Required: false},
-		"http_retry_wait_min":          &hcldec.AttrSpec{Name: "http_retry_wait_min", Type: cty.Number, Required: false},
-		"region":                       &hcldec.AttrSpec{Name: "region", Type: cty.String, Required: false},
-		"size":                         &hcldec.AttrSpec{Name: "size", Type: cty.String, Required: false},
-		"image":                        &hcldec.AttrSpec{Name: "image", Type: cty.String, Required: false},
-		"private_networking":           &hcldec.AttrSpec{Name: "private_networking", Type: cty.Bool, Required: false},
-		"monitoring":                   &hcldec.AttrSpec{Name: "monitoring", Type: cty.Bool, Required: false},
-		"droplet_agent":                &hcldec.AttrSpec{Name: "droplet_agent", Type: cty.Bool, Required: false},
-		"ipv6":                         &hcldec.AttrSpec{Name: "ipv6", Type: cty.Bool, Required: false},
-		"snapshot_name":                &hcldec.AttrSpec{Name: "snapshot_name", Type: cty.String, Required: false},
-		"snapshot_regions":             &hcldec.AttrSpec{Name: "snapshot_regions", Type: cty.List(cty.String), Required: false},
-		"wait_snapshot_transfer":       &hcldec.AttrSpec{Name: "wait_snapshot_transfer", Type: cty.Bool, Required: false},
-		"transfer_timeout":             &hcldec.AttrSpec{Name: "transfer_timeout", Type: cty.String, Required: false},
-		"state_timeout":                &hcldec.AttrSpec{Name: "state_timeout", Type: cty.String, Required: false},
-		"snapshot_timeout":             &hcldec.AttrSpec{Name: "snapshot_timeout", Type: cty.String, Required: false},
-		"droplet_name":                 &hcldec.AttrSpec{Name: "droplet_name", Type: cty.String, Required: false},
-		"user_data":                    &hcldec.AttrSpec{Name: "user_data", Type: cty.String, Required: false},
-		"user_data_file":               &hcldec.AttrSpec{Name: "user_data_file", Type: cty.String, Required: false},
-		"tags":                         &hcldec.AttrSpec{Name: "tags", Type: cty.List(cty.String), Required: false},
-		"vpc_uuid":                     &hcldec.AttrSpec{Name: "vpc_uuid", Type: cty.String, Required: false},
-		"connect_with_private_ip":      &hcldec.AttrSpec{Name: "connect_with_private_ip", Type: cty.Bool, Required: false},
-		"ssh_key_id":                   &hcldec.AttrSpec{Name: "ssh_key_id", Type: cty.Number, Required: false},
-		"skip_keygen":                  &hcldec.AttrSpec{Name: "skip_keygen", Type: cty.Bool, Required: false},
+		"packer_build_name":               &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
+		"packer_builder_type":             &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
+		"packer_core_version":             &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
+		"packer_debug":                    &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
+		"packer_force":                    &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
+		"packer_on_error":                 &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_user_variables":           &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
+		"packer_sensitive_variables":      &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
+		"communicator":                    &hcldec.AttrSpec{Name: "communicator", Type: cty.String, Required: false},
+		"pause_before_connecting":         &hcldec.AttrSpec{Name: "pause_before_connecting", Type: cty.String, Required: false},
+		"ssh_host":                        &hcldec.AttrSpec{Name: "ssh_host", Type: cty.String, Required: false},
+		"ssh_port":                        &hcldec.AttrSpec{Name: "ssh_port", Type: cty.Number, Required: false},
+		"ssh_username":                    &hcldec.AttrSpec{Name: "ssh_username", Type: cty.String, Required: false},
+		"ssh_password":                    &hcldec.AttrSpec{Name: "ssh_password", Type: cty.String, Required: false},
+		"ssh_keypair_name":                &hcldec.AttrSpec{Name: "ssh_keypair_name", Type: cty.String, Required: false},
+		"temporary_key_pair_name":         &hcldec.AttrSpec{Name: "temporary_key_pair_name", Type: cty.String, Required: false},
+		"temporary_key_pair_type":         &hcldec.AttrSpec{Name: "temporary_key_pair_type", Type: cty.String, Required: false},
+		"temporary_key_pair_bits":         &hcldec.AttrSpec{Name: "temporary_key_pair_bits", Type: cty.Number, Required: false},
+		"ssh_ciphers":                     &hcldec.AttrSpec{Name: "ssh_ciphers", Type: cty.List(cty.String), Required: false},
+		"ssh_clear_authorized_keys":       &hcldec.AttrSpec{Name: "ssh_clear_authorized_keys", Type: cty.Bool, Required: false},
+		"ssh_key_exchange_algorithms":     &hcldec.AttrSpec{Name: "ssh_key_exchange_algorithms", Type: cty.List(cty.String), Required: false},
+		"ssh_private_key_file":            &hcldec.AttrSpec{Name: "ssh_private_key_file", Type: cty.String, Required: false},
+		"ssh_certificate_file":            &hcldec.AttrSpec{Name: "ssh_certificate_file", Type: cty.String, Required: false},
+		"ssh_pty":                         &hcldec.AttrSpec{Name: "ssh_pty", Type: cty.Bool, Required: false},
+		"ssh_timeout":                     &hcldec.AttrSpec{Name: "ssh_timeout", Type: cty.String, Required: false},
+		"ssh_wait_timeout":                &hcldec.AttrSpec{Name: "ssh_wait_timeout", Type: cty.String, Required: false},
+		"ssh_agent_auth":                  &hcldec.AttrSpec{Name: "ssh_agent_auth", Type: cty.Bool, Required: false},
+		"ssh_disable_agent_forwarding":    &hcldec.AttrSpec{Name: "ssh_disable_agent_forwarding", Type: cty.Bool, Required: false},
+		"ssh_handshake_attempts":          &hcldec.AttrSpec{Name: "ssh_handshake_attempts", Type: cty.Number, Required: false},
+		"ssh_bastion_host":                &hcldec.AttrSpec{Name: "ssh_bastion_host", Type: cty.String, Required: false},
+		"ssh_bastion_port":                &hcldec.AttrSpec{Name: "ssh_bastion_port", Type: cty.Number, Required: false},
+		"ssh_bastion_agent_auth":          &hcldec.AttrSpec{Name: "ssh_bastion_agent_auth", Type: cty.Bool, Required: false},
+		"ssh_bastion_username":            &hcldec.AttrSpec{Name: "ssh_bastion_username", Type: cty.String, Required: false},
+		"ssh_bastion_password":            &hcldec.AttrSpec{Name: "ssh_bastion_password", Type: cty.String, Required: false},
+		"ssh_bastion_interactive":         &hcldec.AttrSpec{Name: "ssh_bastion_interactive", Type: cty.Bool, Required: false},
+		"ssh_bastion_private_key_file":    &hcldec.AttrSpec{Name: "ssh_bastion_private_key_file", Type: cty.String, Required: false},
+		"ssh_bastion_certificate_file":    &hcldec.AttrSpec{Name: "ssh_bastion_certificate_file", Type: cty.String, Required: false},
+		"ssh_file_transfer_method":        &hcldec.AttrSpec{Name: "ssh_file_transfer_method", Type: cty.String, Required: false},
+		"ssh_proxy_host":                  &hcldec.AttrSpec{Name: "ssh_proxy_host", Type: cty.String, Required: false},
+		"ssh_proxy_port":                  &hcldec.AttrSpec{Name: "ssh_proxy_port", Type: cty.Number, Required: false},
+		"ssh_proxy_username":              &hcldec.AttrSpec{Name: "ssh_proxy_username", Type: cty.String, Required: false},
+		"ssh_proxy_password":              &hcldec.AttrSpec{Name: "ssh_proxy_password", Type: cty.String, Required: false},
+		"ssh_keep_alive_interval":         &hcldec.AttrSpec{Name: "ssh_keep_alive_interval", Type: cty.String, Required: false},
+		"ssh_read_write_timeout":          &hcldec.AttrSpec{Name: "ssh_read_write_timeout", Type: cty.String, Required: false},
+		"ssh_remote_tunnels":              &hcldec.AttrSpec{Name: "ssh_remote_tunnels", Type: cty.List(cty.String), Required: false},
+		"ssh_local_tunnels":               &hcldec.AttrSpec{Name: "ssh_local_tunnels", Type: cty.List(cty.String), Required: false},
+		"ssh_public_key":                  &hcldec.AttrSpec{Name: "ssh_public_key", Type: cty.List(cty.Number), Required: false},
+		"ssh_private_key":                 &hcldec.AttrSpec{Name: "ssh_private_key", Type: cty.List(cty.Number), Required: false},
+		"winrm_username":                  &hcldec.AttrSpec{Name: "winrm_username", Type: cty.String, Required: false},
+		"winrm_password":                  &hcldec.AttrSpec{Name: "winrm_password", Type: cty.String, Required: false},
+		"winrm_host":                      &hcldec.AttrSpec{Name: "winrm_host", Type: cty.String, Required: false},
+		"winrm_no_proxy":                  &hcldec.AttrSpec{Name: "winrm_no_proxy", Type: cty.Bool, Required: false},
+		"winrm_port":                      &hcldec.AttrSpec{Name: "winrm_port", Type: cty.Number, Required: false},
+		"winrm_timeout":                   &hcldec.AttrSpec{Name: "winrm_timeout", Type: cty.String, Required: false},
+		"winrm_use_ssl":                   &hcldec.AttrSpec{Name: "winrm_use_ssl", Type: cty.Bool, Required: false},
+		"winrm_insecure":                  &hcldec.AttrSpec{Name: "winrm_insecure", Type: cty.Bool, Required: false},
+		"winrm_use_ntlm":                  &hcldec.AttrSpec{Name: "winrm_use_ntlm", Type: cty.Bool, Required: false},
+		"http_directory":                  &hcldec.AttrSpec{Name: "http_directory", Type: cty.String, Required: false},
+		"http_content":                    &hcldec.AttrSpec{Name: "http_content", Type: cty.Map(cty.String), Required: false},
+		"http_port_min":                   &hcldec.AttrSpec{Name: "http_port_min", Type: cty.Number, Required: false},
+		"http_port_max":                   &hcldec.AttrSpec{Name: "http_port_max", Type: cty.Number, Required: false},
+		"http_bind_address":               &hcldec.AttrSpec{Name: "http_bind_address", Type: cty.String, Required: false},
+		"http_interface":                  &hcldec.AttrSpec{Name: "http_interface", Type: cty.String, Required: false},
+		"api_token":                       &hcldec.AttrSpec{Name: "api_token", Type: cty.String, Required: false},
+		"api_url":                         &hcldec.AttrSpec{Name: "api_url", Type: cty.String, Required: false},
+		"http_retry_max":                  &hcldec.AttrSpec{Name: "http_retry_max", Type: cty.Number, Required: false},
+		"http_retry_wait_max":             &hcldec.AttrSpec{Name: "http_retry_wait_max", Type: cty.Number, Required: false},
+		"http_retry_wait_min":             &hcldec.AttrSpec{Name: "http_retry_wait_min", Type: cty.Number, Required: false},
+		"retry":                           &hcldec.BlockSpec{TypeName: "retry", Nested: hcldec.ObjectSpec((*FlatRetryConfig)(nil).HCL2Spec())},
+		"minimal_api_mode":                &hcldec.AttrSpec{Name: "minimal_api_mode", Type: cty.Bool, Required: false},
+		"api_record_file":                 &hcldec.AttrSpec{Name: "api_record_file", Type: cty.String, Required: false},
+		"api_replay_file":                 &hcldec.AttrSpec{Name: "api_replay_file", Type: cty.String, Required: false},
+		"team_uuid":                       &hcldec.AttrSpec{Name: "team_uuid", Type: cty.String, Required: false},
+		"team_name":                       &hcldec.AttrSpec{Name: "team_name", Type: cty.String, Required: false},
+		"concurrency_policy":              &hcldec.AttrSpec{Name: "concurrency_policy", Type: cty.String, Required: false},
+		"concurrency_timeout":             &hcldec.AttrSpec{Name: "concurrency_timeout", Type: cty.String, Required: false},
+		"region":                          &hcldec.AttrSpec{Name: "region", Type: cty.String, Required: false},
+		"size":                            &hcldec.AttrSpec{Name: "size", Type: cty.String, Required: false},
+		"region_fallbacks":                &hcldec.AttrSpec{Name: "region_fallbacks", Type: cty.List(cty.String), Required: false},
+		"size_fallbacks":                  &hcldec.AttrSpec{Name: "size_fallbacks", Type: cty.List(cty.String), Required: false},
+		"droplet_id":                      &hcldec.AttrSpec{Name: "droplet_id", Type: cty.Number, Required: false},
+		"leave_droplet_running":           &hcldec.AttrSpec{Name: "leave_droplet_running", Type: cty.Bool, Required: false},
+		"image":                           &hcldec.AttrSpec{Name: "image", Type: cty.String, Required: false},
+		"source_image_url":                &hcldec.AttrSpec{Name: "source_image_url", Type: cty.String, Required: false},
+		"spaces_region":                   &hcldec.AttrSpec{Name: "spaces_region", Type: cty.String, Required: false},
+		"source_image_distribution":       &hcldec.AttrSpec{Name: "source_image_distribution", Type: cty.String, Required: false},
+		"keep_source_image":               &hcldec.AttrSpec{Name: "keep_source_image", Type: cty.Bool, Required: false},
+		"source_image_timeout":            &hcldec.AttrSpec{Name: "source_image_timeout", Type: cty.String, Required: false},
+		"private_networking":              &hcldec.AttrSpec{Name: "private_networking", Type: cty.Bool, Required: false},
+		"monitoring":                      &hcldec.AttrSpec{Name: "monitoring", Type: cty.Bool, Required: false},
+		"sample_metrics":                  &hcldec.AttrSpec{Name: "sample_metrics", Type: cty.Bool, Required: false},
+		"report_cost":                     &hcldec.AttrSpec{Name: "report_cost", Type: cty.Bool, Required: false},
+		"keep_droplet_on_error":           &hcldec.AttrSpec{Name: "keep_droplet_on_error", Type: cty.Bool, Required: false},
+		"droplet_agent":                   &hcldec.AttrSpec{Name: "droplet_agent", Type: cty.Bool, Required: false},
+		"ipv6":                            &hcldec.AttrSpec{Name: "ipv6", Type: cty.Bool, Required: false},
+		"artifact_type":                   &hcldec.AttrSpec{Name: "artifact_type", Type: cty.String, Required: false},
+		"skip_snapshot":                   &hcldec.AttrSpec{Name: "skip_snapshot", Type: cty.Bool, Required: false},
+		"backups":                         &hcldec.AttrSpec{Name: "backups", Type: cty.Bool, Required: false},
+		"backup_policy":                   &hcldec.BlockSpec{TypeName: "backup_policy", Nested: hcldec.ObjectSpec((*FlatBackupPolicy)(nil).HCL2Spec())},
+		"snapshot_name":                   &hcldec.AttrSpec{Name: "snapshot_name", Type: cty.String, Required: false},
+		"snapshot_name_registry":          &hcldec.BlockSpec{TypeName: "snapshot_name_registry", Nested: hcldec.ObjectSpec((*FlatNameRegistry)(nil).HCL2Spec())},
+		"image_ttl":                       &hcldec.AttrSpec{Name: "image_ttl", Type: cty.String, Required: false},
+		"deprecate_previous":              &hcldec.AttrSpec{Name: "deprecate_previous", Type: cty.Bool, Required: false},
+		"deprecate_prefix":                &hcldec.AttrSpec{Name: "deprecate_prefix", Type: cty.String, Required: false},
+		"on_deprecated_image":             &hcldec.AttrSpec{Name: "on_deprecated_image", Type: cty.String, Required: false},
+		"hcp_image_id_format":             &hcldec.AttrSpec{Name: "hcp_image_id_format", Type: cty.String, Required: false},
+		"snapshot_regions":                &hcldec.AttrSpec{Name: "snapshot_regions", Type: cty.List(cty.String), Required: false},
+		"snapshot_regions_exclude":        &hcldec.AttrSpec{Name: "snapshot_regions_exclude", Type: cty.List(cty.String), Required: false},
+		"wait_snapshot_transfer":          &hcldec.AttrSpec{Name: "wait_snapshot_transfer", Type: cty.Bool, Required: false},
+		"transfer_timeout":                &hcldec.AttrSpec{Name: "transfer_timeout", Type: cty.String, Required: false},
+		"transfer_concurrency":            &hcldec.AttrSpec{Name: "transfer_concurrency", Type: cty.Number, Required: false},
+		"transfer_retries":                &hcldec.AttrSpec{Name: "transfer_retries", Type: cty.Number, Required: false},
+		"state_timeout":                   &hcldec.AttrSpec{Name: "state_timeout", Type: cty.String, Required: false},
+		"state_poll_interval":             &hcldec.AttrSpec{Name: "state_poll_interval", Type: cty.String, Required: false},
+		"shutdown_timeout":                &hcldec.AttrSpec{Name: "shutdown_timeout", Type: cty.String, Required: false},
+		"power_off_fallback":              &hcldec.AttrSpec{Name: "power_off_fallback", Type: cty.Bool, Required: false},
+		"pause_before_snapshot":           &hcldec.AttrSpec{Name: "pause_before_snapshot", Type: cty.String, Required: false},
+		"gpu_ready_timeout":               &hcldec.AttrSpec{Name: "gpu_ready_timeout", Type: cty.String, Required: false},
+		"wait_for_cloud_init":             &hcldec.AttrSpec{Name: "wait_for_cloud_init", Type: cty.Bool, Required: false},
+		"cloud_init_timeout":              &hcldec.AttrSpec{Name: "cloud_init_timeout", Type: cty.String, Required: false},
+		"unlock_timeout":                  &hcldec.AttrSpec{Name: "unlock_timeout", Type: cty.String, Required: false},
+		"snapshot_timeout":                &hcldec.AttrSpec{Name: "snapshot_timeout", Type: cty.String, Required: false},
+		"snapshot_retry_timeout":          &hcldec.AttrSpec{Name: "snapshot_retry_timeout", Type: cty.String, Required: false},
+		"droplet_name":                    &hcldec.AttrSpec{Name: "droplet_name", Type: cty.String, Required: false},
+		"user_data":                       &hcldec.AttrSpec{Name: "user_data", Type: cty.String, Required: false},
+		"user_data_file":                  &hcldec.AttrSpec{Name: "user_data_file", Type: cty.String, Required: false},
+		"compress_user_data":              &hcldec.AttrSpec{Name: "compress_user_data", Type: cty.Bool, Required: false},
+		"validate_user_data":              &hcldec.AttrSpec{Name: "validate_user_data", Type: cty.Bool, Required: false},
+		"user_data_completion":            &hcldec.AttrSpec{Name: "user_data_completion", Type: cty.String, Required: false},
+		"user_data_completion_tag":        &hcldec.AttrSpec{Name: "user_data_completion_tag", Type: cty.String, Required: false},
+		"user_data_timeout":               &hcldec.AttrSpec{Name: "user_data_timeout", Type: cty.String, Required: false},
+		"tags":                            &hcldec.AttrSpec{Name: "tags", Type: cty.List(cty.String), Required: false},
+		"force":                           &hcldec.AttrSpec{Name: "force", Type: cty.Bool, Required: false},
+		"snapshot_tags":                   &hcldec.AttrSpec{Name: "snapshot_tags", Type: cty.List(cty.String), Required: false},
+		"volumes":                         &hcldec.AttrSpec{Name: "volumes", Type: cty.List(cty.String), Required: false},
+		"snapshot_volumes":                &hcldec.AttrSpec{Name: "snapshot_volumes", Type: cty.Bool, Required: false},
+		"volume_snapshot_name":            &hcldec.AttrSpec{Name: "volume_snapshot_name", Type: cty.String, Required: false},
+		"volume_snapshot_tags":            &hcldec.AttrSpec{Name: "volume_snapshot_tags", Type: cty.List(cty.String), Required: false},
+		"volume_snapshot_timeout":         &hcldec.AttrSpec{Name: "volume_snapshot_timeout", Type: cty.String, Required: false},
+		"spaces_assets":                   &hcldec.BlockListSpec{TypeName: "spaces_assets", Nested: hcldec.ObjectSpec((*FlatSpacesAsset)(nil).HCL2Spec())},
+		"check_service_status":            &hcldec.AttrSpec{Name: "check_service_status", Type: cty.Bool, Required: false},
+		"service_status_wait":             &hcldec.AttrSpec{Name: "service_status_wait", Type: cty.String, Required: false},
+		"service_status_url":              &hcldec.AttrSpec{Name: "service_status_url", Type: cty.String, Required: false},
+		"webhook":                         &hcldec.BlockListSpec{TypeName: "webhook", Nested: hcldec.ObjectSpec((*FlatWebhook)(nil).HCL2Spec())},
+		"spaces_key":                      &hcldec.AttrSpec{Name: "spaces_key", Type: cty.String, Required: false},
+		"spaces_secret":                   &hcldec.AttrSpec{Name: "spaces_secret", Type: cty.String, Required: false},
+		"spaces_url_ttl":                  &hcldec.AttrSpec{Name: "spaces_url_ttl", Type: cty.String, Required: false},
+		"project_id":                      &hcldec.AttrSpec{Name: "project_id", Type: cty.String, Required: false},
+		"project_name":                    &hcldec.AttrSpec{Name: "project_name", Type: cty.String, Required: false},
+		"reserved_ip":                     &hcldec.AttrSpec{Name: "reserved_ip", Type: cty.String, Required: false},
+		"assign_reserved_ip":              &hcldec.AttrSpec{Name: "assign_reserved_ip", Type: cty.Bool, Required: false},
+		"firewall_ids":                    &hcldec.AttrSpec{Name: "firewall_ids", Type: cty.List(cty.String), Required: false},
+		"firewall_tag":                    &hcldec.AttrSpec{Name: "firewall_tag", Type: cty.String, Required: false},
+		"temporary_firewall":              &hcldec.AttrSpec{Name: "temporary_firewall", Type: cty.Bool, Required: false},
+		"temporary_firewall_source_cidrs": &hcldec.AttrSpec{Name: "temporary_firewall_source_cidrs", Type: cty.List(cty.String), Required: false},
+		"public_ip_url":                   &hcldec.AttrSpec{Name: "public_ip_url", Type: cty.String, Required: false},
+		"outbound_lockdown":               &hcldec.AttrSpec{Name: "outbound_lockdown", Type: cty.Bool, Required: false},
+		"outbound_allow":                  &hcldec.BlockListSpec{TypeName: "outbound_allow", Nested: hcldec.ObjectSpec((*FlatOutboundAllow)(nil).HCL2Spec())},
+		"vpc_uuid":                        &hcldec.AttrSpec{Name: "vpc_uuid", Type: cty.String, Required: false},
+		"vpc_name":                        &hcldec.AttrSpec{Name: "vpc_name", Type: cty.String, Required: false},
+		"required_vpc_peerings":           &hcldec.AttrSpec{Name: "required_vpc_peerings", Type: cty.List(cty.String), Required: false},
+		"temporary_vpc":                   &hcldec.AttrSpec{Name: "temporary_vpc", Type: cty.Bool, Required: false},
+		"temporary_vpc_ip_range":          &hcldec.AttrSpec{Name: "temporary_vpc_ip_range", Type: cty.String, Required: false},
+		"connect_with_private_ip":         &hcldec.AttrSpec{Name: "connect_with_private_ip", Type: cty.Bool, Required: false},
+		"ssh_interface":                   &hcldec.AttrSpec{Name: "ssh_interface", Type: cty.String, Required: false},
+		"ssh_key_id":                      &hcldec.AttrSpec{Name: "ssh_key_id", Type: cty.Number, Required: false},
+		"ssh_key_fingerprint":             &hcldec.AttrSpec{Name: "ssh_key_fingerprint", Type: cty.String, Required: false},
+		"ssh_key_name":                    &hcldec.AttrSpec{Name: "ssh_key_name", Type: cty.String, Required: false},
+		"ssh_key_ids":                     &hcldec.AttrSpec{Name: "ssh_key_ids", Type: cty.List(cty.Number), Required: false},
+		"install_account_keys":            &hcldec.AttrSpec{Name: "install_account_keys", Type: cty.Bool, Required: false},
+		"skip_keygen":                     &hcldec.AttrSpec{Name: "skip_keygen", Type: cty.Bool, Required: false},
+		"ssh_ca_private_key_file":         &hcldec.AttrSpec{Name: "ssh_ca_private_key_file", Type: cty.String, Required: false},
+		"ssh_ca_signer_command":           &hcldec.AttrSpec{Name: "ssh_ca_signer_command", Type: cty.List(cty.String), Required: false},
+		"ssh_certificate_principals":      &hcldec.AttrSpec{Name: "ssh_certificate_principals", Type: cty.List(cty.String), Required: false},
+		"ssh_certificate_ttl":             &hcldec.AttrSpec{Name: "ssh_certificate_ttl", Type: cty.String, Required: false},
+		"ssh_key_propagation_timeout":     &hcldec.AttrSpec{Name: "ssh_key_propagation_timeout", Type: cty.String, Required: false},
+		"provision_reconnect_attempts":    &hcldec.AttrSpec{Name: "provision_reconnect_attempts", Type: cty.Number, Required: false},
+		"connect_power_cycle":             &hcldec.AttrSpec{Name: "connect_power_cycle", Type: cty.Bool, Required: false},
+		"connect_failure_report_path":     &hcldec.AttrSpec{Name: "connect_failure_report_path", Type: cty.String, Required: false},
+		"http_reverse_tunnel":             &hcldec.AttrSpec{Name: "http_reverse_tunnel", Type: cty.Bool, Required: false},
+		"catalog_warnings":                &hcldec.AttrSpec{Name: "catalog_warnings", Type: cty.Bool, Required: false},
+		"capture_network_config":          &hcldec.AttrSpec{Name: "capture_network_config", Type: cty.Bool, Required: false},
+		"image_release":                   &hcldec.BlockSpec{TypeName: "image_release", Nested: hcldec.ObjectSpec((*FlatImageRelease)(nil).HCL2Spec())},
+		"generalize":                      &hcldec.AttrSpec{Name: "generalize", Type: cty.Bool, Required: false},
+		"reclaim_free_space":              &hcldec.AttrSpec{Name: "reclaim_free_space", Type: cty.String, Required: false},
+		"image_init":                      &hcldec.AttrSpec{Name: "image_init", Type: cty.String, Required: false},
 	}
 	return s
 }
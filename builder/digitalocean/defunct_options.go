@@ -0,0 +1,128 @@
+package digitalocean
+
+import (
+	"fmt"
+	"sort"
+)
+
+// defunctOption is a configuration key the builder doesn't accept as is:
+// a deprecated option, an option that was renamed or removed, or the name
+// of an option of another builder.
+type defunctOption struct {
+	// Replacement is the option to use instead.
+	Replacement string
+	// Deprecated options still work, with a warning.
+	Deprecated bool
+	// Renamed options still work, with a warning, by using their value for
+	// Replacement.
+	Renamed bool
+	// Reason explains why the option isn't accepted, when it isn't
+	// deprecated or renamed.
+	Reason string
+}
+
+// defunctOptions are the keys checkDefunctOptions recognizes.
+var defunctOptions = map[string]defunctOption{
+	// Deprecated options
+	"http_retry_max":      {Replacement: "max_retries in the retry block", Deprecated: true},
+	"http_retry_wait_min": {Replacement: "wait_min in the retry block", Deprecated: true},
+	"http_retry_wait_max": {Replacement: "wait_max in the retry block", Deprecated: true},
+
+	// Renamed options. DigitalOcean renamed floating IPs to reserved IPs.
+	"floating_ip":        {Replacement: "reserved_ip", Renamed: true},
+	"assign_floating_ip": {Replacement: "assign_reserved_ip", Renamed: true},
+
+	// Options of the v1 API
+	"api_key":   {Replacement: "api_token", Reason: "this option was removed with the v1 API"},
+	"client_id": {Replacement: "api_token", Reason: "this option was removed with the v1 API"},
+	"region_id": {Replacement: "region", Reason: "this option was removed with the v1 API"},
+	"size_id":   {Replacement: "size", Reason: "this option was removed with the v1 API"},
+	"image_id":  {Replacement: "image", Reason: "this option was removed with the v1 API"},
+
+	// Options of the Amazon builders
+	"ami_name":           {Replacement: "snapshot_name", Reason: "this is an Amazon builder option"},
+	"ami_regions":        {Replacement: "snapshot_regions", Reason: "this is an Amazon builder option"},
+	"source_ami":         {Replacement: "image", Reason: "this is an Amazon builder option"},
+	"source_ami_filter":  {Replacement: "image, or the digitalocean-image data source", Reason: "this is an Amazon builder option"},
+	"instance_type":      {Replacement: "size", Reason: "this is an Amazon builder option"},
+	"availability_zone":  {Replacement: "region", Reason: "this is an Amazon builder option"},
+	"run_tags":           {Replacement: "tags", Reason: "this is an Amazon builder option"},
+	"vpc_id":             {Replacement: "vpc_uuid", Reason: "this is an Amazon builder option"},
+	"subnet_id":          {Replacement: "vpc_uuid", Reason: "this is an Amazon builder option"},
+	"security_group_ids": {Replacement: "firewall_ids", Reason: "this is an Amazon builder option"},
+	"ssh_keypair_name":   {Replacement: "ssh_key_name", Reason: "this is an Amazon builder option"},
+
+	// Options of the Google Compute and Azure builders
+	"source_image": {Replacement: "image", Reason: "this is a Google Compute builder option"},
+	"image_name":   {Replacement: "snapshot_name", Reason: "this is a Google Compute builder option"},
+	"machine_type": {Replacement: "size", Reason: "this is a Google Compute builder option"},
+	"zone":         {Replacement: "region", Reason: "this is a Google Compute builder option"},
+	"vm_size":      {Replacement: "size", Reason: "this is an Azure builder option"},
+	"location":     {Replacement: "region", Reason: "this is an Azure builder option"},
+}
+
+// checkDefunctOptions looks for the defunctOptions in the raw
+// configuration, before it is decoded. It returns copies of the raw
+// configurations without the renamed and removed keys, which would fail
+// decoding as unknown keys, along with a warning for each deprecated or
+// renamed key and an error for each other one. The value of a renamed key
+// is used for its replacement, unless that is set too.
+func checkDefunctOptions(raws []interface{}) ([]interface{}, []string, []error) {
+	var warns []string
+	var errs []error
+
+	checked := make([]interface{}, len(raws))
+	for i, raw := range raws {
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			checked[i] = raw
+			continue
+		}
+
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		copied := make(map[string]interface{}, len(m))
+		for _, key := range keys {
+			value := m[key]
+			opt, ok := defunctOptions[key]
+			if !ok {
+				copied[key] = value
+				continue
+			}
+			if value == nil {
+				// Unset attributes of HCL2 templates
+				if opt.Deprecated {
+					copied[key] = value
+				}
+				continue
+			}
+
+			switch {
+			case opt.Deprecated:
+				copied[key] = value
+				warns = append(warns, fmt.Sprintf(
+					"%s is deprecated and will be removed in a future version, use %s instead.",
+					key, opt.Replacement))
+			case opt.Renamed:
+				if _, ok := m[opt.Replacement]; ok {
+					errs = append(errs, fmt.Errorf(
+						"%s was renamed to %s, only set %s", key, opt.Replacement, opt.Replacement))
+					continue
+				}
+				copied[opt.Replacement] = value
+				warns = append(warns, fmt.Sprintf(
+					"%s was renamed to %s. Please update your template, %s will be removed in a future version.",
+					key, opt.Replacement, key))
+			default:
+				errs = append(errs, fmt.Errorf("%s: %s, use %s instead", key, opt.Reason, opt.Replacement))
+			}
+		}
+		checked[i] = copied
+	}
+
+	return checked, warns, errs
+}
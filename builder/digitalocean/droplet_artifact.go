@@ -0,0 +1,85 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// DropletBuilderId identifies the artifacts of builds with artifact_type
+// "droplet". It differs from BuilderId so that post-processors expecting an
+// image don't mistake the droplet for one.
+const DropletBuilderId = "pearkes.digitalocean.droplet"
+
+// DropletArtifact is the droplet retained by a build with artifact_type
+// "droplet", for systems that capture it themselves, or left running with
+// skip_snapshot.
+type DropletArtifact struct {
+	// The ID of the droplet
+	DropletId int
+
+	// The name of the droplet
+	DropletName string
+
+	// The name of the region the droplet is in
+	RegionName string
+
+	// Whether the droplet was left running, with skip_snapshot
+	Running bool
+
+	// The IP addresses of a running droplet, empty if it has none
+	PublicIPv4  string
+	PrivateIPv4 string
+	PublicIPv6  string
+
+	// The client for making API calls
+	Client *godo.Client
+
+	// StateData should store data such as GeneratedData
+	// to be shared with post-processors
+	StateData map[string]interface{}
+}
+
+var _ packersdk.Artifact = new(DropletArtifact)
+
+func (*DropletArtifact) BuilderId() string {
+	return DropletBuilderId
+}
+
+func (*DropletArtifact) Files() []string {
+	return nil
+}
+
+func (a *DropletArtifact) Id() string {
+	return fmt.Sprintf("%s:%d", a.RegionName, a.DropletId)
+}
+
+func (a *DropletArtifact) String() string {
+	if !a.Running {
+		return fmt.Sprintf("A powered-off droplet was retained: '%v' (ID: %v) in region '%v'",
+			a.DropletName, a.DropletId, a.RegionName)
+	}
+
+	var ips []string
+	for _, ip := range []string{a.PublicIPv4, a.PrivateIPv4, a.PublicIPv6} {
+		if ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+	return fmt.Sprintf("A running droplet was kept: '%v' (ID: %v) in region '%v' with IPs %s",
+		a.DropletName, a.DropletId, a.RegionName, strings.Join(ips, ", "))
+}
+
+func (a *DropletArtifact) State(name string) interface{} {
+	return a.StateData[name]
+}
+
+func (a *DropletArtifact) Destroy() error {
+	log.Printf("Destroying droplet: %d (%s)", a.DropletId, a.DropletName)
+	_, err := a.Client.Droplets.Delete(context.TODO(), a.DropletId)
+	return err
+}
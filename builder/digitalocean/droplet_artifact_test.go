@@ -0,0 +1,48 @@
+package digitalocean
+
+import (
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestDropletArtifact_Impl(t *testing.T) {
+	var raw interface{} = &DropletArtifact{}
+	if _, ok := raw.(packersdk.Artifact); !ok {
+		t.Fatalf("DropletArtifact should be artifact")
+	}
+}
+
+func TestDropletArtifactId(t *testing.T) {
+	a := &DropletArtifact{DropletId: 3164444, DropletName: "packer-build", RegionName: "nyc3"}
+	expected := "nyc3:3164444"
+
+	if a.Id() != expected {
+		t.Fatalf("artifact ID should match: %v", expected)
+	}
+}
+
+func TestDropletArtifactString(t *testing.T) {
+	a := &DropletArtifact{DropletId: 3164444, DropletName: "packer-build", RegionName: "nyc3"}
+	expected := "A powered-off droplet was retained: 'packer-build' (ID: 3164444) in region 'nyc3'"
+
+	if a.String() != expected {
+		t.Fatalf("artifact string should match: %v", expected)
+	}
+}
+
+func TestDropletArtifactString_running(t *testing.T) {
+	a := &DropletArtifact{
+		DropletId:   3164444,
+		DropletName: "packer-build",
+		RegionName:  "nyc3",
+		Running:     true,
+		PublicIPv4:  "192.0.2.10",
+		PrivateIPv4: "10.116.0.2",
+	}
+	expected := "A running droplet was kept: 'packer-build' (ID: 3164444) in region 'nyc3' with IPs 192.0.2.10, 10.116.0.2"
+
+	if a.String() != expected {
+		t.Fatalf("artifact string should match: %v, got %v", expected, a.String())
+	}
+}
@@ -0,0 +1,44 @@
+package digitalocean
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+// DefaultHCPImageIDFormat is the format of the image IDs reported to HCP
+// Packer by default: the snapshot ID alone.
+const DefaultHCPImageIDFormat = "{{ .ID }}"
+
+// hcpImageIDData is the data hcp_image_id_format is rendered with.
+type hcpImageIDData struct {
+	ID     int
+	Name   string
+	Region string
+}
+
+// RenderHCPImageID renders the image ID reported to HCP Packer for the
+// snapshot in region with format. An empty format is the
+// DefaultHCPImageIDFormat.
+func RenderHCPImageID(format string, id int, name, region string) (string, error) {
+	if format == "" {
+		format = DefaultHCPImageIDFormat
+	}
+	ctx := &interpolate.Context{Data: &hcpImageIDData{ID: id, Name: name, Region: region}}
+	rendered, err := interpolate.Render(format, ctx)
+	if err != nil {
+		return "", err
+	}
+	if rendered == "" {
+		return "", fmt.Errorf("the image ID is empty")
+	}
+	return rendered, nil
+}
+
+// ValidateHCPImageIDFormat checks that format renders image IDs.
+func ValidateHCPImageIDFormat(format string) error {
+	if _, err := RenderHCPImageID(format, 12345, "packer-1700000000", "nyc3"); err != nil {
+		return fmt.Errorf("invalid hcp_image_id_format: %s", err)
+	}
+	return nil
+}
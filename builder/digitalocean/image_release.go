@@ -0,0 +1,96 @@
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type ImageRelease
+
+package digitalocean
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// imageReleaseKeyRe matches the keys of the extra image release fields,
+// which are shell variable names like those of os-release(5).
+var imageReleaseKeyRe = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// ImageRelease writes a file describing where the image comes from into the
+// droplet before the snapshot is taken, so that droplets created from the
+// image can report their provenance. It is set with an `image_release`
+// block and requires the ssh communicator. The file is written with `sudo`
+// unless `ssh_username` is `root`.
+type ImageRelease struct {
+	// Where to write the file. Defaults to `/etc/image-release`.
+	Path string `mapstructure:"path" required:"false"`
+	// The version of the image, written as `IMAGE_VERSION`.
+	Version string `mapstructure:"version" required:"false"`
+	// The repository the image is built from, written as `GIT_REPOSITORY`.
+	GitRepository string `mapstructure:"git_repository" required:"false"`
+	// The commit the image is built from, written as `GIT_COMMIT`.
+	GitCommit string `mapstructure:"git_commit" required:"false"`
+	// The branch the image is built from, written as `GIT_BRANCH`.
+	GitBranch string `mapstructure:"git_branch" required:"false"`
+	// Additional fields to write. Keys must be upper case shell variable
+	// names, such as `BUILD_URL`.
+	Extra map[string]string `mapstructure:"extra" required:"false"`
+}
+
+// Prepare sets the defaults for the image release file and validates it.
+func (r *ImageRelease) Prepare() []error {
+	var errs []error
+
+	if r.Path == "" {
+		r.Path = "/etc/image-release"
+	}
+	if !path.IsAbs(r.Path) {
+		errs = append(errs, fmt.Errorf("image_release: path must be absolute"))
+	}
+	for key := range r.Extra {
+		if !imageReleaseKeyRe.MatchString(key) {
+			errs = append(errs, fmt.Errorf("image_release: invalid extra key %q", key))
+		}
+	}
+
+	return errs
+}
+
+// fields returns the configured fields of the file, after the ones the
+// builder records, leaving out the unset ones.
+func (r *ImageRelease) fields(builderFields []imageReleaseField) []imageReleaseField {
+	fields := append([]imageReleaseField{}, builderFields...)
+	fields = append(fields,
+		imageReleaseField{"IMAGE_VERSION", r.Version},
+		imageReleaseField{"GIT_REPOSITORY", r.GitRepository},
+		imageReleaseField{"GIT_COMMIT", r.GitCommit},
+		imageReleaseField{"GIT_BRANCH", r.GitBranch},
+	)
+
+	keys := make([]string, 0, len(r.Extra))
+	for key := range r.Extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fields = append(fields, imageReleaseField{key, r.Extra[key]})
+	}
+
+	set := fields[:0]
+	for _, f := range fields {
+		if f.Value != "" {
+			set = append(set, f)
+		}
+	}
+	return set
+}
+
+// imageReleaseContents formats the fields in the os-release(5) format, so
+// that the file can be sourced by shell scripts.
+func imageReleaseContents(fields []imageReleaseField) string {
+	var b strings.Builder
+	for _, f := range fields {
+		v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`", "\n", " ").Replace(f.Value)
+		fmt.Fprintf(&b, "%s=\"%s\"\n", f.Key, v)
+	}
+	return b.String()
+}
@@ -0,0 +1,41 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package digitalocean
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatImageRelease is an auto-generated flat version of ImageRelease.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatImageRelease struct {
+	Path          *string           `mapstructure:"path" required:"false" cty:"path" hcl:"path"`
+	Version       *string           `mapstructure:"version" required:"false" cty:"version" hcl:"version"`
+	GitRepository *string           `mapstructure:"git_repository" required:"false" cty:"git_repository" hcl:"git_repository"`
+	GitCommit     *string           `mapstructure:"git_commit" required:"false" cty:"git_commit" hcl:"git_commit"`
+	GitBranch     *string           `mapstructure:"git_branch" required:"false" cty:"git_branch" hcl:"git_branch"`
+	Extra         map[string]string `mapstructure:"extra" required:"false" cty:"extra" hcl:"extra"`
+}
+
+// FlatMapstructure returns a new FlatImageRelease.
+// FlatImageRelease is an auto-generated flat version of ImageRelease.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*ImageRelease) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatImageRelease)
+}
+
+// HCL2Spec returns the hcl spec of a ImageRelease.
+// This spec is used by HCL to read the fields of ImageRelease.
+// The decoded values from this spec will then be applied to a FlatImageRelease.
+func (*FlatImageRelease) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"path":           &hcldec.AttrSpec{Name: "path", Type: cty.String, Required: false},
+		"version":        &hcldec.AttrSpec{Name: "version", Type: cty.String, Required: false},
+		"git_repository": &hcldec.AttrSpec{Name: "git_repository", Type: cty.String, Required: false},
+		"git_commit":     &hcldec.AttrSpec{Name: "git_commit", Type: cty.String, Required: false},
+		"git_branch":     &hcldec.AttrSpec{Name: "git_branch", Type: cty.String, Required: false},
+		"extra":          &hcldec.AttrSpec{Name: "extra", Type: cty.Map(cty.String), Required: false},
+	}
+	return s
+}
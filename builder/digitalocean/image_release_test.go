@@ -0,0 +1,29 @@
+package digitalocean
+
+import "testing"
+
+func TestImageReleaseContents(t *testing.T) {
+	r := &ImageRelease{
+		Version:   "1.4.0",
+		GitCommit: "9f2c1e7",
+		Extra: map[string]string{
+			"BUILD_URL": "https://ci.example.com/builds/42",
+			"NOTES":     `say "hi" for $5`,
+		},
+	}
+
+	contents := imageReleaseContents(r.fields([]imageReleaseField{
+		{"IMAGE_NAME", "packer-1704207845"},
+		{"BASE_IMAGE", ""},
+	}))
+
+	expected := `IMAGE_NAME="packer-1704207845"
+IMAGE_VERSION="1.4.0"
+GIT_COMMIT="9f2c1e7"
+BUILD_URL="https://ci.example.com/builds/42"
+NOTES="say \"hi\" for \$5"
+`
+	if contents != expected {
+		t.Fatalf("bad contents:\n%s", contents)
+	}
+}
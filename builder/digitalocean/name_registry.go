@@ -0,0 +1,87 @@
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type NameRegistry
+
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// NameRegistry is an HTTP endpoint that keeps track of the snapshot names
+// in use, for teams that share a naming convention across several
+// DigitalOcean accounts. It is set with a `snapshot_name_registry` block.
+// Before the snapshot is taken, the registry is asked whether the
+// `snapshot_name` is taken with a GET request to the URL with the name in
+// the `name` query parameter, which is answered with a JSON body such as
+// `{"taken": true, "owner": "team-a"}`. The build fails if the name is
+// taken or the registry can't be reached.
+type NameRegistry struct {
+	// The URL of the registry.
+	URL string `mapstructure:"url" required:"true"`
+	// Additional HTTP headers to send, for example to authenticate.
+	Headers map[string]string `mapstructure:"headers" required:"false"`
+	// How long to wait for the registry to answer. Defaults to "10s".
+	Timeout time.Duration `mapstructure:"timeout" required:"false"`
+}
+
+// Prepare sets the defaults for the registry and validates it.
+func (r *NameRegistry) Prepare() []error {
+	var errs []error
+
+	if r.URL == "" {
+		errs = append(errs, fmt.Errorf("snapshot_name_registry: url must be set"))
+	} else if u, err := url.Parse(r.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("snapshot_name_registry: url must be an http or https URL, got %q", r.URL))
+	}
+	if r.Timeout == 0 {
+		r.Timeout = 10 * time.Second
+	}
+
+	return errs
+}
+
+// NameRegistryAnswer is the JSON body the registry answers with.
+type NameRegistryAnswer struct {
+	Taken bool   `json:"taken"`
+	Owner string `json:"owner,omitempty"`
+}
+
+// lookup asks the registry whether name is taken.
+func (r *NameRegistry) lookup(ctx context.Context, client *http.Client, name string) (*NameRegistryAnswer, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("name", name)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range r.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	answer := new(NameRegistryAnswer)
+	if err := json.NewDecoder(resp.Body).Decode(answer); err != nil {
+		return nil, fmt.Errorf("invalid response: %s", err)
+	}
+	return answer, nil
+}
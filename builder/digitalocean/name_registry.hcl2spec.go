@@ -0,0 +1,35 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package digitalocean
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatNameRegistry is an auto-generated flat version of NameRegistry.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatNameRegistry struct {
+	URL     *string           `mapstructure:"url" required:"true" cty:"url" hcl:"url"`
+	Headers map[string]string `mapstructure:"headers" required:"false" cty:"headers" hcl:"headers"`
+	Timeout *string           `mapstructure:"timeout" required:"false" cty:"timeout" hcl:"timeout"`
+}
+
+// FlatMapstructure returns a new FlatNameRegistry.
+// FlatNameRegistry is an auto-generated flat version of NameRegistry.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*NameRegistry) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatNameRegistry)
+}
+
+// HCL2Spec returns the hcl spec of a NameRegistry.
+// This spec is used by HCL to read the fields of NameRegistry.
+// The decoded values from this spec will then be applied to a FlatNameRegistry.
+func (*FlatNameRegistry) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"url":     &hcldec.AttrSpec{Name: "url", Type: cty.String, Required: false},
+		"headers": &hcldec.AttrSpec{Name: "headers", Type: cty.Map(cty.String), Required: false},
+		"timeout": &hcldec.AttrSpec{Name: "timeout", Type: cty.String, Required: false},
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type OutboundAllow
+
+package digitalocean
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/digitalocean/godo"
+)
+
+var validOutboundProtocols = []string{"tcp", "udp", "icmp"}
+
+// OutboundAllow lets the droplet reach some destinations while
+// `outbound_lockdown` blocks all other outbound traffic. It is set with one
+// or more `outbound_allow` blocks.
+type OutboundAllow struct {
+	// The addresses or CIDR blocks the droplet may connect to.
+	Addresses []string `mapstructure:"addresses" required:"true"`
+	// The protocol of the allowed traffic: `tcp`, `udp` or `icmp`. Defaults
+	// to `tcp`.
+	Protocol string `mapstructure:"protocol" required:"false"`
+	// The ports or port range (e.g. `443` or `8000-8080`) of the allowed
+	// traffic, or `all`. Ignored for `icmp`. Defaults to `all`.
+	Ports string `mapstructure:"ports" required:"false"`
+}
+
+// Prepare sets the defaults for the rule and validates it.
+func (a *OutboundAllow) Prepare() []error {
+	var errs []error
+
+	if len(a.Addresses) == 0 {
+		errs = append(errs, fmt.Errorf("outbound_allow: addresses must be set"))
+	}
+	for _, addr := range a.Addresses {
+		if _, _, err := net.ParseCIDR(addr); err != nil && net.ParseIP(addr) == nil {
+			errs = append(errs, fmt.Errorf("outbound_allow: invalid address %q", addr))
+		}
+	}
+
+	if a.Protocol == "" {
+		a.Protocol = "tcp"
+	}
+	if !containsString(validOutboundProtocols, a.Protocol) {
+		errs = append(errs, fmt.Errorf("outbound_allow: invalid protocol %q, must be one of %v", a.Protocol, validOutboundProtocols))
+	}
+	if a.Ports == "" || a.Protocol == "icmp" {
+		a.Ports = "all"
+	}
+
+	return errs
+}
+
+// rule returns the firewall rule allowing the traffic.
+func (a *OutboundAllow) rule() godo.OutboundRule {
+	rule := godo.OutboundRule{
+		Protocol:     a.Protocol,
+		Destinations: &godo.Destinations{Addresses: a.Addresses},
+	}
+	if a.Protocol != "icmp" {
+		rule.PortRange = a.Ports
+	}
+	return rule
+}
+
+// String describes the allowed traffic, e.g. "tcp/443 to 10.0.0.0/8".
+func (a *OutboundAllow) String() string {
+	return fmt.Sprintf("%s/%s to %s", a.Protocol, a.Ports, strings.Join(a.Addresses, ","))
+}
@@ -0,0 +1,35 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package digitalocean
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatOutboundAllow is an auto-generated flat version of OutboundAllow.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatOutboundAllow struct {
+	Addresses []string `mapstructure:"addresses" required:"true" cty:"addresses" hcl:"addresses"`
+	Protocol  *string  `mapstructure:"protocol" required:"false" cty:"protocol" hcl:"protocol"`
+	Ports     *string  `mapstructure:"ports" required:"false" cty:"ports" hcl:"ports"`
+}
+
+// FlatMapstructure returns a new FlatOutboundAllow.
+// FlatOutboundAllow is an auto-generated flat version of OutboundAllow.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*OutboundAllow) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatOutboundAllow)
+}
+
+// HCL2Spec returns the hcl spec of a OutboundAllow.
+// This spec is used by HCL to read the fields of OutboundAllow.
+// The decoded values from this spec will then be applied to a FlatOutboundAllow.
+func (*FlatOutboundAllow) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"addresses": &hcldec.AttrSpec{Name: "addresses", Type: cty.List(cty.String), Required: false},
+		"protocol":  &hcldec.AttrSpec{Name: "protocol", Type: cty.String, Required: false},
+		"ports":     &hcldec.AttrSpec{Name: "ports", Type: cty.String, Required: false},
+	}
+	return s
+}
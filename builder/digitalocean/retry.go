@@ -0,0 +1,295 @@
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type RetryConfig
+
+package digitalocean
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"golang.org/x/oauth2"
+)
+
+const (
+	RetryOnRateLimit   = "rate_limit"
+	RetryOnServerError = "server_error"
+	RetryOnNetwork     = "network"
+)
+
+var validRetryOn = []string{RetryOnRateLimit, RetryOnServerError, RetryOnNetwork}
+
+// maxRateLimitWait caps how long a rate-limited request waits for the limit
+// to reset before it is retried.
+const maxRateLimitWait = 5 * time.Minute
+
+// RetryConfig controls how failed DigitalOcean API requests are retried. It
+// is set with a `retry` block and is shared by the builder, the data sources
+// and the post-processors. Values not set in the block fall back to the
+// deprecated `http_retry_*` options and `DIGITALOCEAN_HTTP_RETRY_*`
+// environment variables.
+type RetryConfig struct {
+	// The maximum number of times a failed request is retried. Set to 0 to
+	// disable retries. Defaults to the value of `http_retry_max`, the
+	// `DIGITALOCEAN_HTTP_RETRY_MAX` environment variable, or 5.
+	MaxRetries *int `mapstructure:"max_retries" required:"false"`
+	// The minimum time to wait before retrying a request. Defaults to the
+	// value of `http_retry_wait_min`, the `DIGITALOCEAN_HTTP_RETRY_WAIT_MIN`
+	// environment variable, or "1s".
+	WaitMin time.Duration `mapstructure:"wait_min" required:"false"`
+	// The maximum time to wait before retrying a request. Defaults to the
+	// value of `http_retry_wait_max`, the `DIGITALOCEAN_HTTP_RETRY_WAIT_MAX`
+	// environment variable, or "30s".
+	WaitMax time.Duration `mapstructure:"wait_max" required:"false"`
+	// Randomize the wait between retries so that concurrent builds don't
+	// retry in lockstep. Defaults to false.
+	Jitter bool `mapstructure:"jitter" required:"false"`
+	// The classes of failures to retry. Any of `rate_limit` (429 responses),
+	// `server_error` (500-level responses) and `network` (connection errors).
+	// Rate-limited requests are retried once the `Retry-After` or
+	// `Ratelimit-Reset` header of the response allows, when that is later
+	// than the backoff, waiting 5 minutes at most. Requests that aren't
+	// idempotent, such as creating a droplet, are only retried when rate
+	// limited or when they couldn't be sent. Defaults to all of them.
+	RetryOn []string `mapstructure:"retry_on" required:"false"`
+}
+
+// Prepare sets the defaults for the retry configuration. Values not set in
+// the retry block are taken from the deprecated http_retry_* options, then
+// from the DIGITALOCEAN_HTTP_RETRY_* environment variables.
+func (c *RetryConfig) Prepare(legacyMax *int, legacyWaitMin, legacyWaitMax *float64) []error {
+	var errs []error
+
+	if c.MaxRetries == nil {
+		c.MaxRetries = legacyMax
+	}
+	if c.MaxRetries == nil {
+		c.MaxRetries = godo.PtrTo(5)
+		if max := os.Getenv("DIGITALOCEAN_HTTP_RETRY_MAX"); max != "" {
+			maxInt, err := strconv.Atoi(max)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid DIGITALOCEAN_HTTP_RETRY_MAX: %s", err))
+			}
+			c.MaxRetries = godo.PtrTo(maxInt)
+		}
+	}
+
+	if c.WaitMin == 0 {
+		wait, err := retryWaitDefault(legacyWaitMin, "DIGITALOCEAN_HTTP_RETRY_WAIT_MIN", 1.0)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		c.WaitMin = wait
+	}
+	if c.WaitMax == 0 {
+		wait, err := retryWaitDefault(legacyWaitMax, "DIGITALOCEAN_HTTP_RETRY_WAIT_MAX", 30.0)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		c.WaitMax = wait
+	}
+
+	if len(c.RetryOn) == 0 {
+		c.RetryOn = append([]string{}, validRetryOn...)
+	}
+
+	if *c.MaxRetries < 0 {
+		errs = append(errs, errors.New("retry max_retries must not be negative"))
+	}
+	if c.WaitMin > c.WaitMax {
+		errs = append(errs, errors.New("retry wait_min must not be greater than wait_max"))
+	}
+	for _, r := range c.RetryOn {
+		if !containsString(validRetryOn, r) {
+			errs = append(errs, fmt.Errorf("invalid retry_on value %q; must be one of: %v", r, validRetryOn))
+		}
+	}
+
+	return errs
+}
+
+// HTTPClient returns an HTTP client authenticating with the given API token
+// that retries failed requests according to the retry configuration.
+func (c *RetryConfig) HTTPClient(token string) *http.Client {
+	return c.httpClient(token, http.DefaultTransport)
+}
+
+// httpClient is HTTPClient sending the requests through base.
+func (c *RetryConfig) httpClient(token string, base http.RoundTripper) *http.Client {
+	if c.MaxRetries != nil && *c.MaxRetries > 0 {
+		base = &retryTransport{config: c, base: base}
+	}
+
+	return &http.Client{
+		Transport: &oauth2.Transport{
+			Source: &APITokenSource{AccessToken: token},
+			Base:   base,
+		},
+	}
+}
+
+// Backoff returns how long to wait before the given retry attempt, starting
+// at 1.
+func (c *RetryConfig) Backoff(attempt int) time.Duration {
+	wait := c.WaitMin
+	for i := 1; i < attempt && wait < c.WaitMax; i++ {
+		wait *= 2
+	}
+	if wait > c.WaitMax {
+		wait = c.WaitMax
+	}
+
+	if c.Jitter && wait > 1 {
+		wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)))
+	}
+
+	return wait
+}
+
+// shouldRetry reports whether req should be made again after it got resp or
+// failed with err. Requests that aren't idempotent may have taken effect
+// unless they were rate limited or never sent, so they are retried in those
+// cases only.
+func (c *RetryConfig) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	idempotent := req.Method != http.MethodPost && req.Method != http.MethodPatch
+	if err != nil {
+		return containsString(c.RetryOn, RetryOnNetwork) && (idempotent || requestNotSent(err))
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return containsString(c.RetryOn, RetryOnRateLimit)
+	}
+	if !idempotent {
+		return false
+	}
+	if resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented {
+		return containsString(c.RetryOn, RetryOnServerError)
+	}
+	return false
+}
+
+// requestNotSent reports whether err kept a request from reaching the API:
+// its address couldn't be resolved or connected to.
+func requestNotSent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+type retryTransport struct {
+	config *RetryConfig
+	base   http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, errors.New("unable to retry request with a non-replayable body")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(r)
+		if attempt >= *t.config.MaxRetries || !t.config.shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		wait := t.config.Backoff(attempt + 1)
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			if limit := rateLimitWait(resp, waitClock.Now()); limit > wait {
+				if limit > maxRateLimitWait {
+					limit = maxRateLimitWait
+				}
+				log.Printf("[DEBUG] %s %s was rate limited, waiting %s as the API asks", req.Method, req.URL, limit)
+				wait = limit
+			}
+		}
+
+		if err != nil {
+			log.Printf("[DEBUG] %s %s failed, retrying: %s", req.Method, req.URL, err)
+		} else {
+			log.Printf("[DEBUG] %s %s returned %d, retrying", req.Method, req.URL, resp.StatusCode)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := sleepContext(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// rateLimitWait returns how long a rate-limited response asks to wait
+// before retrying: the delay or date of its Retry-After header or, failing
+// that, the time until its Ratelimit-Reset header, a Unix time. It returns
+// 0 when the response has neither.
+func rateLimitWait(resp *http.Response, now time.Time) time.Duration {
+	var wait time.Duration
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if date, err := http.ParseTime(v); err == nil {
+			wait = date.Sub(now)
+		}
+	} else if v := resp.Header.Get("Ratelimit-Reset"); v != "" {
+		if reset, err := strconv.ParseInt(v, 10, 64); err == nil {
+			wait = time.Unix(reset, 0).Sub(now)
+		}
+	}
+
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+func retryWaitDefault(legacy *float64, env string, def float64) (time.Duration, error) {
+	seconds := def
+	if legacy != nil {
+		seconds = *legacy
+	} else if v := os.Getenv(env); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return time.Duration(def * float64(time.Second)), fmt.Errorf("invalid %s: %s", env, err)
+		}
+		seconds = f
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-waitClock.After(d):
+		return nil
+	}
+}
+
+func containsString(list []string, term string) bool {
+	for _, t := range list {
+		if t == term {
+			return true
+		}
+	}
+	return false
+}
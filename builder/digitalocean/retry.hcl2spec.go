@@ -0,0 +1,39 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package digitalocean
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatRetryConfig is an auto-generated flat version of RetryConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatRetryConfig struct {
+	MaxRetries *int     `mapstructure:"max_retries" required:"false" cty:"max_retries" hcl:"max_retries"`
+	WaitMin    *string  `mapstructure:"wait_min" required:"false" cty:"wait_min" hcl:"wait_min"`
+	WaitMax    *string  `mapstructure:"wait_max" required:"false" cty:"wait_max" hcl:"wait_max"`
+	Jitter     *bool    `mapstructure:"jitter" required:"false" cty:"jitter" hcl:"jitter"`
+	RetryOn    []string `mapstructure:"retry_on" required:"false" cty:"retry_on" hcl:"retry_on"`
+}
+
+// FlatMapstructure returns a new FlatRetryConfig.
+// FlatRetryConfig is an auto-generated flat version of RetryConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*RetryConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatRetryConfig)
+}
+
+// HCL2Spec returns the hcl spec of a RetryConfig.
+// This spec is used by HCL to read the fields of RetryConfig.
+// The decoded values from this spec will then be applied to a FlatRetryConfig.
+func (*FlatRetryConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"max_retries": &hcldec.AttrSpec{Name: "max_retries", Type: cty.Number, Required: false},
+		"wait_min":    &hcldec.AttrSpec{Name: "wait_min", Type: cty.String, Required: false},
+		"wait_max":    &hcldec.AttrSpec{Name: "wait_max", Type: cty.String, Required: false},
+		"jitter":      &hcldec.AttrSpec{Name: "jitter", Type: cty.Bool, Required: false},
+		"retry_on":    &hcldec.AttrSpec{Name: "retry_on", Type: cty.List(cty.String), Required: false},
+	}
+	return s
+}
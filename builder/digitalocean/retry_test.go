@@ -0,0 +1,247 @@
+package digitalocean
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+func TestRetryConfig_Prepare(t *testing.T) {
+	// Test defaults
+	c := RetryConfig{}
+	if errs := c.Prepare(nil, nil, nil); len(errs) > 0 {
+		t.Fatalf("should not have error: %v", errs)
+	}
+	if *c.MaxRetries != 5 || c.WaitMin != time.Second || c.WaitMax != 30*time.Second {
+		t.Errorf("bad defaults: %#v", c)
+	}
+	if len(c.RetryOn) != 3 {
+		t.Errorf("bad retry_on default: %v", c.RetryOn)
+	}
+
+	// Test the deprecated options are used when the block is empty
+	c = RetryConfig{}
+	if errs := c.Prepare(godo.PtrTo(2), godo.PtrTo(0.5), godo.PtrTo(10.0)); len(errs) > 0 {
+		t.Fatalf("should not have error: %v", errs)
+	}
+	if *c.MaxRetries != 2 || c.WaitMin != 500*time.Millisecond || c.WaitMax != 10*time.Second {
+		t.Errorf("bad values from deprecated options: %#v", c)
+	}
+
+	// Test the block takes precedence
+	c = RetryConfig{MaxRetries: godo.PtrTo(0), WaitMin: 2 * time.Second}
+	if errs := c.Prepare(godo.PtrTo(2), godo.PtrTo(0.5), nil); len(errs) > 0 {
+		t.Fatalf("should not have error: %v", errs)
+	}
+	if *c.MaxRetries != 0 || c.WaitMin != 2*time.Second {
+		t.Errorf("bad values: %#v", c)
+	}
+
+	// Test bad values
+	c = RetryConfig{WaitMin: time.Minute, WaitMax: time.Second, RetryOn: []string{"teapot"}}
+	if errs := c.Prepare(nil, nil, nil); len(errs) != 2 {
+		t.Fatalf("should have two errors: %v", errs)
+	}
+}
+
+func TestRetryConfig_HTTPClient(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("bad authorization header: %q", r.Header.Get("Authorization"))
+		}
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	c := RetryConfig{WaitMin: time.Millisecond, WaitMax: time.Millisecond}
+	if errs := c.Prepare(nil, nil, nil); len(errs) > 0 {
+		t.Fatalf("should not have error: %v", errs)
+	}
+
+	resp, err := c.HTTPClient("token").Get(ts.URL)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK || requests != 3 {
+		t.Errorf("expected success after 3 requests, got %d after %d", resp.StatusCode, requests)
+	}
+
+	// Server errors are not retried unless requested
+	requests = 0
+	c.RetryOn = []string{RetryOnRateLimit}
+	resp, err = c.HTTPClient("token").Get(ts.URL)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || requests != 1 {
+		t.Errorf("expected a single failed request, got %d after %d", resp.StatusCode, requests)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"none", http.Header{}, 0},
+		{"retry after seconds", http.Header{"Retry-After": {"42"}}, 42 * time.Second},
+		{"retry after date", http.Header{"Retry-After": {"Sun, 01 Jun 2025 12:01:30 GMT"}}, 90 * time.Second},
+		{"ratelimit reset", http.Header{"Ratelimit-Reset": {fmt.Sprint(now.Add(time.Minute).Unix())}}, time.Minute},
+		{"retry after first", http.Header{
+			"Retry-After":     {"5"},
+			"Ratelimit-Reset": {fmt.Sprint(now.Add(time.Hour).Unix())},
+		}, 5 * time.Second},
+		{"past reset", http.Header{"Ratelimit-Reset": {fmt.Sprint(now.Add(-time.Minute).Unix())}}, 0},
+		{"invalid", http.Header{"Retry-After": {"soon"}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: tt.header}
+			if got := rateLimitWait(resp, now); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryConfig_HTTPClientRateLimit(t *testing.T) {
+	useFakeClock(t)
+
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 2 {
+			// The limit reset a second ago by the fake clock, so the
+			// retry doesn't wait longer than the backoff.
+			w.Header().Set("Ratelimit-Reset", fmt.Sprint(waitClock.Now().Add(-time.Second).Unix()))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	c := RetryConfig{WaitMin: time.Millisecond, WaitMax: time.Millisecond}
+	if errs := c.Prepare(nil, nil, nil); len(errs) > 0 {
+		t.Fatalf("should not have error: %v", errs)
+	}
+
+	resp, err := c.HTTPClient("token").Get(ts.URL)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK || requests != 2 {
+		t.Errorf("expected success after 2 requests, got %d after %d", resp.StatusCode, requests)
+	}
+}
+
+func TestRetryConfig_HTTPClientRateLimitCap(t *testing.T) {
+	clock := useFakeClock(t)
+	start := clock.now
+
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 2 {
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	c := RetryConfig{WaitMin: time.Millisecond, WaitMax: time.Millisecond}
+	if errs := c.Prepare(nil, nil, nil); len(errs) > 0 {
+		t.Fatalf("should not have error: %v", errs)
+	}
+
+	resp, err := c.HTTPClient("token").Get(ts.URL)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK || requests != 2 {
+		t.Errorf("expected success after 2 requests, got %d after %d", resp.StatusCode, requests)
+	}
+	if waited := clock.now.Sub(start); waited != maxRateLimitWait {
+		t.Errorf("waited %s, want %s", waited, maxRateLimitWait)
+	}
+}
+
+func TestRetryConfig_HTTPClientPost(t *testing.T) {
+	useFakeClock(t)
+
+	tests := []struct {
+		name     string
+		status   int
+		requests int
+	}{
+		{"server error", http.StatusServiceUnavailable, 1},
+		{"rate limited", http.StatusTooManyRequests, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests < 2 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer ts.Close()
+
+			c := RetryConfig{WaitMin: time.Millisecond, WaitMax: time.Millisecond}
+			if errs := c.Prepare(nil, nil, nil); len(errs) > 0 {
+				t.Fatalf("should not have error: %v", errs)
+			}
+
+			resp, err := c.HTTPClient("token").Post(ts.URL, "application/json", strings.NewReader(`{"name": "web"}`))
+			if err != nil {
+				t.Fatalf("should not have error: %s", err)
+			}
+			resp.Body.Close()
+			if requests != tt.requests {
+				t.Errorf("got %d requests, want %d", requests, tt.requests)
+			}
+		})
+	}
+}
+
+func TestRequestNotSent(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{&net.DNSError{Err: "no such host", Name: "api.digitalocean.com"}, true},
+		{&net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, false},
+		{io.ErrUnexpectedEOF, false},
+	}
+
+	for _, tt := range tests {
+		if got := requestNotSent(tt.err); got != tt.want {
+			t.Errorf("%q: got %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
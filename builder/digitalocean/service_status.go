@@ -0,0 +1,118 @@
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultStatusPageURL is DigitalOcean's status page, which serves the
+// Statuspage API.
+const defaultStatusPageURL = "https://status.digitalocean.com"
+
+// statusPageTimeout bounds each request to the status page.
+const statusPageTimeout = 10 * time.Second
+
+// statusPollInterval is how often a build paused by service_status_wait
+// checks the status page again.
+const statusPollInterval = time.Minute
+
+// serviceIncident is an unresolved incident or a maintenance in progress on
+// the status page.
+type serviceIncident struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Impact     string `json:"impact"`
+	Shortlink  string `json:"shortlink"`
+	Components []struct {
+		Name string `json:"name"`
+	} `json:"components"`
+}
+
+func (i *serviceIncident) String() string {
+	s := fmt.Sprintf("%s (%s)", i.Name, i.Status)
+	if i.Shortlink != "" {
+		s += " " + i.Shortlink
+	}
+	return s
+}
+
+// affects reports whether the incident names region, in its own name or
+// in the name of one of the components it affects.
+func (i *serviceIncident) affects(region string) bool {
+	region = strings.ToLower(region)
+	if containsWord(strings.ToLower(i.Name), region) {
+		return true
+	}
+	for _, c := range i.Components {
+		if containsWord(strings.ToLower(c.Name), region) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether s contains word, not as part of a longer
+// word, so that nyc1 doesn't match nyc10.
+func containsWord(s, word string) bool {
+	isWordChar := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= '0' && r <= '9'
+	}
+	return strings.Contains(" "+strings.Map(func(r rune) rune {
+		if isWordChar(r) {
+			return r
+		}
+		return ' '
+	}, s)+" ", " "+word+" ")
+}
+
+// statusSummary is the part of the status page summary the builder reads.
+type statusSummary struct {
+	Incidents             []serviceIncident `json:"incidents"`
+	ScheduledMaintenances []serviceIncident `json:"scheduled_maintenances"`
+}
+
+// regionIncidents returns the unresolved incidents and the maintenances in
+// progress on the status page at baseURL that affect any of regions.
+func regionIncidents(ctx context.Context, client *http.Client, baseURL string, regions []string) ([]serviceIncident, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(baseURL, "/")+"/api/v2/summary.json", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	var summary statusSummary
+	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
+		return nil, fmt.Errorf("invalid response: %s", err)
+	}
+
+	var incidents []serviceIncident
+	candidates := summary.Incidents
+	for _, m := range summary.ScheduledMaintenances {
+		if m.Status == "in_progress" || m.Status == "verifying" {
+			candidates = append(candidates, m)
+		}
+	}
+	for _, i := range candidates {
+		for _, region := range regions {
+			if i.affects(region) {
+				incidents = append(incidents, i)
+				break
+			}
+		}
+	}
+	return incidents, nil
+}
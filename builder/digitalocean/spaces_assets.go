@@ -0,0 +1,105 @@
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type SpacesAsset
+
+package digitalocean
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// SpacesAsset selects objects in a Space to download onto the droplet before
+// it is provisioned. The droplet fetches the objects itself through presigned
+// URLs, so large build inputs don't have to pass through the machine running
+// Packer. It is set with one or more `spaces_assets` blocks and requires the
+// `spaces_key` and `spaces_secret` options.
+type SpacesAsset struct {
+	// The name of the Space holding the objects.
+	Bucket string `mapstructure:"bucket" required:"true"`
+	// The key prefix of the objects to download. Use a trailing `/` to
+	// download the contents of a directory. Defaults to every object in the
+	// Space.
+	Prefix string `mapstructure:"prefix" required:"false"`
+	// The directory on the droplet to download the objects to. Objects keep
+	// their path relative to `prefix` beneath it.
+	Destination string `mapstructure:"destination" required:"true"`
+	// The region of the Space, such as `nyc3`. Defaults to the `region` of
+	// the droplet.
+	Region string `mapstructure:"region" required:"false"`
+}
+
+// Prepare sets the defaults for the asset and validates it.
+func (a *SpacesAsset) Prepare(region string) []error {
+	var errs []error
+
+	if a.Region == "" {
+		a.Region = region
+	}
+	if a.Bucket == "" {
+		errs = append(errs, fmt.Errorf("spaces_assets: bucket must be set"))
+	}
+	if a.Destination == "" {
+		errs = append(errs, fmt.Errorf("spaces_assets: destination must be set"))
+	}
+
+	return errs
+}
+
+// SpacesClient returns an S3 client for the Spaces endpoint of region.
+func SpacesClient(key, secret, region string) (s3iface.S3API, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials(key, secret, ""),
+		Endpoint:    aws.String(fmt.Sprintf("https://%s.digitaloceanspaces.com", region)),
+		Region:      aws.String(region),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s3.New(sess), nil
+}
+
+// listSpacesObjects returns the keys of the objects in bucket beginning with
+// prefix. Directory placeholder objects are skipped.
+func listSpacesObjects(svc s3iface.S3API, bucket, prefix string) ([]string, error) {
+	var keys []string
+	err := svc.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			if key := aws.StringValue(obj.Key); !strings.HasSuffix(key, "/") {
+				keys = append(keys, key)
+			}
+		}
+		return true
+	})
+	return keys, err
+}
+
+// assetPath returns where the object key selected by prefix is downloaded to
+// beneath destination.
+func assetPath(destination, prefix, key string) string {
+	rel := strings.TrimLeft(strings.TrimPrefix(key, prefix), "/")
+	if rel == "" {
+		rel = path.Base(key)
+	}
+	return path.Join(destination, rel)
+}
+
+// assetDownloadCommand returns the shell command that downloads url to dst
+// on the droplet, with curl or, failing that, wget.
+func assetDownloadCommand(dst, url string) string {
+	return fmt.Sprintf("mkdir -p %s && { curl -fsSL -o %s %s || wget -q -O %s %s; }",
+		shellQuote(path.Dir(dst)), shellQuote(dst), shellQuote(url), shellQuote(dst), shellQuote(url))
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
@@ -0,0 +1,37 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package digitalocean
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatSpacesAsset is an auto-generated flat version of SpacesAsset.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatSpacesAsset struct {
+	Bucket      *string `mapstructure:"bucket" required:"true" cty:"bucket" hcl:"bucket"`
+	Prefix      *string `mapstructure:"prefix" required:"false" cty:"prefix" hcl:"prefix"`
+	Destination *string `mapstructure:"destination" required:"true" cty:"destination" hcl:"destination"`
+	Region      *string `mapstructure:"region" required:"false" cty:"region" hcl:"region"`
+}
+
+// FlatMapstructure returns a new FlatSpacesAsset.
+// FlatSpacesAsset is an auto-generated flat version of SpacesAsset.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*SpacesAsset) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatSpacesAsset)
+}
+
+// HCL2Spec returns the hcl spec of a SpacesAsset.
+// This spec is used by HCL to read the fields of SpacesAsset.
+// The decoded values from this spec will then be applied to a FlatSpacesAsset.
+func (*FlatSpacesAsset) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"bucket":      &hcldec.AttrSpec{Name: "bucket", Type: cty.String, Required: false},
+		"prefix":      &hcldec.AttrSpec{Name: "prefix", Type: cty.String, Required: false},
+		"destination": &hcldec.AttrSpec{Name: "destination", Type: cty.String, Required: false},
+		"region":      &hcldec.AttrSpec{Name: "region", Type: cty.String, Required: false},
+	}
+	return s
+}
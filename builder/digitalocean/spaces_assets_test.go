@@ -0,0 +1,35 @@
+package digitalocean
+
+import (
+	"testing"
+)
+
+func TestAssetPath(t *testing.T) {
+	tests := []struct {
+		prefix string
+		key    string
+		want   string
+	}{
+		{"models/", "models/weights.bin", "/opt/assets/weights.bin"},
+		{"models/", "models/v2/weights.bin", "/opt/assets/v2/weights.bin"},
+		{"models", "models/weights.bin", "/opt/assets/weights.bin"},
+		{"models/weights.bin", "models/weights.bin", "/opt/assets/weights.bin"},
+		{"", "models/weights.bin", "/opt/assets/models/weights.bin"},
+	}
+
+	for _, tt := range tests {
+		if got := assetPath("/opt/assets", tt.prefix, tt.key); got != tt.want {
+			t.Errorf("assetPath(%q, %q) = %q, want %q", tt.prefix, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestAssetDownloadCommand(t *testing.T) {
+	got := assetDownloadCommand("/opt/it's here/a.bin", "https://example.com/a.bin?X-Amz-Signature=abc&b=c")
+	want := `mkdir -p '/opt/it'"'"'s here' && ` +
+		`{ curl -fsSL -o '/opt/it'"'"'s here/a.bin' 'https://example.com/a.bin?X-Amz-Signature=abc&b=c' || ` +
+		`wget -q -O '/opt/it'"'"'s here/a.bin' 'https://example.com/a.bin?X-Amz-Signature=abc&b=c'; }`
+	if got != want {
+		t.Errorf("bad command:\n got: %s\nwant: %s", got, want)
+	}
+}
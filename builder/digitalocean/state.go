@@ -0,0 +1,117 @@
+package digitalocean
+
+import (
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"golang.org/x/crypto/ssh"
+)
+
+// stateKey is a key of the state bag holding a value of type T. The steps
+// share what they find and create through these keys rather than through
+// bare strings, so that the type of each value is stated once and a step
+// reading a value no earlier step put fails with a message naming it.
+// The keys the SDK and its steps use, such as "ui", "config", "client",
+// "communicator" and "error", are read directly.
+type stateKey[T any] string
+
+// Get returns the value of the key. It panics, naming the key, if no
+// earlier step put it or a step put a value of another type: either is a
+// bug in the steps rather than something a build can recover from.
+func (k stateKey[T]) Get(state multistep.StateBag) T {
+	raw, ok := state.GetOk(string(k))
+	if !ok {
+		var zero T
+		panic(fmt.Sprintf("state %q is not set: it must be put as a %T by an earlier step", string(k), zero))
+	}
+	v, ok := raw.(T)
+	if !ok {
+		panic(fmt.Sprintf("state %q is a %T, not a %T", string(k), raw, v))
+	}
+	return v
+}
+
+// GetOk returns the value of the key and whether it is set to a value of
+// its type.
+func (k stateKey[T]) GetOk(state multistep.StateBag) (T, bool) {
+	raw, ok := state.GetOk(string(k))
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	v, ok := raw.(T)
+	return v, ok
+}
+
+// Put sets the value of the key.
+func (k stateKey[T]) Put(state multistep.StateBag, v T) {
+	state.Put(string(k), v)
+}
+
+// The droplet
+var (
+	stateDropletID         = stateKey[int]("droplet_id")
+	stateDropletIP         = stateKey[string]("droplet_ip")
+	stateDropletName       = stateKey[string]("droplet_name")
+	stateDropletSize       = stateKey[string]("droplet_size")
+	stateDropletRetained   = stateKey[bool]("droplet_retained")
+	stateDropletKept       = stateKey[bool]("droplet_kept")
+	stateDropletMetrics    = stateKey[*DropletMetrics]("droplet_metrics")
+	stateBuildRegion       = stateKey[string]("build_region")
+	stateRegionFeatures    = stateKey[[]string]("region_features")
+	stateSourceImageID     = stateKey[string]("source_image_id")
+	stateCloudInit         = stateKey[bool]("cloud_init")
+	stateUserDataSHA256    = stateKey[string]("user_data_sha256")
+	stateNetworkInterfaces = stateKey[[]interface{}]("network_interfaces")
+	stateNetworkVPC        = stateKey[map[string]string]("network_vpc")
+)
+
+// SSH keys and certificates
+var (
+	stateSSHKeyID            = stateKey[int]("ssh_key_id")
+	stateFingerprintSSHKeyID = stateKey[int]("fingerprint_ssh_key_id")
+	stateNamedSSHKeyID       = stateKey[int]("named_ssh_key_id")
+	stateAccountSSHKeyIDs    = stateKey[[]int]("account_ssh_key_ids")
+	stateInstalledSSHKeyIDs  = stateKey[[]int]("installed_ssh_key_ids")
+	stateSSHCertificate      = stateKey[*ssh.Certificate]("ssh_certificate")
+)
+
+// Networking
+var (
+	stateVPCUUID             = stateKey[string]("vpc_uuid")
+	stateTemporaryVPCUUID    = stateKey[string]("temporary_vpc_uuid")
+	stateTemporaryFirewallID = stateKey[string]("temporary_firewall_id")
+	stateOutboundAllowed     = stateKey[[]string]("outbound_allowed")
+	stateReservedIP          = stateKey[string]("reserved_ip")
+	stateReservedIPCreated   = stateKey[string]("reserved_ip_created")
+)
+
+// The account and the API
+var (
+	stateTeamUUID           = stateKey[string]("team_uuid")
+	stateTeamName           = stateKey[string]("team_name")
+	stateProjectID          = stateKey[string]("project_id")
+	stateNamedProjectID     = stateKey[string]("named_project_id")
+	stateAPIMissingFeatures = stateKey[map[string]bool]("api_missing_features")
+	stateServiceIncidents   = stateKey[[]serviceIncident]("service_incidents")
+)
+
+// The build
+var (
+	stateProvisionReconnects  = stateKey[int]("provision_reconnects")
+	stateBuildCost            = stateKey[*BuildCost]("build_cost")
+	stateConnectFailureReport = stateKey[*ConnectFailureReport]("connect_failure_report")
+	stateCleanupFailures      = stateKey[map[string]error]("cleanup_failures")
+	stateHCPImageIDFormat     = stateKey[string]("hcp_image_id_format")
+)
+
+// The snapshots
+var (
+	stateSnapshotImageID = stateKey[int]("snapshot_image_id")
+	stateSnapshotName    = stateKey[string]("snapshot_name")
+	stateSnapshotTags    = stateKey[[]string]("snapshot_tags")
+	stateReplacedImages  = stateKey[[]godo.Image]("replaced_images")
+	stateRegions         = stateKey[[]string]("regions")
+	stateVolumeSnapshots = stateKey[[]interface{}]("volume_snapshots")
+)
@@ -0,0 +1,52 @@
+package digitalocean
+
+import (
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStateKey(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+
+	if _, ok := stateDropletID.GetOk(state); ok {
+		t.Fatal("droplet_id should not be set")
+	}
+	stateDropletID.Put(state, 42)
+	if id := stateDropletID.Get(state); id != 42 {
+		t.Fatalf("bad droplet_id: %d", id)
+	}
+	if id, ok := state.Get("droplet_id").(int); !ok || id != 42 {
+		t.Fatalf("droplet_id should be stored under its name: %#v", state.Get("droplet_id"))
+	}
+
+	state.Put("snapshot_name", 7)
+	if _, ok := stateSnapshotName.GetOk(state); ok {
+		t.Fatal("snapshot_name of the wrong type should not be ok")
+	}
+}
+
+func TestStateKey_GetPanics(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"unset", nil, `state "snapshot_name" is not set: it must be put as a string by an earlier step`},
+		{"wrong type", 7, `state "snapshot_name" is a int, not a string`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := new(multistep.BasicStateBag)
+			if tc.value != nil {
+				state.Put("snapshot_name", tc.value)
+			}
+			defer func() {
+				if r := recover(); r != tc.want {
+					t.Fatalf("bad panic: %v", r)
+				}
+			}()
+			stateSnapshotName.Get(state)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepAccount checks the account before anything is created: that its
+// status allows creating droplets, and that the API token builds in the
+// team configured with team_uuid or team_name. The team is recorded for
+// the artifact.
+type stepAccount struct{}
+
+func (s *stepAccount) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	checkTeam := c.TeamUUID != "" || c.TeamName != ""
+
+	account, _, err := client.Account.Get(context.TODO())
+	if err != nil {
+		if !checkTeam {
+			log.Printf("[DEBUG] Error retrieving account, not checking it: %s", err)
+			return multistep.ActionContinue
+		}
+		err := fmt.Errorf("Error retrieving account to check the team: %s", explainAccountError(err))
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	if err := accountStatusError(account); err != nil {
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	if checkTeam {
+		if err := matchTeam(c, account.Team); err != nil {
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	if account.Team != nil {
+		ui.Say(fmt.Sprintf("Building in team %s (%s)", account.Team.Name, account.Team.UUID))
+		stateTeamUUID.Put(state, account.Team.UUID)
+		stateTeamName.Put(state, account.Team.Name)
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepAccount) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// matchTeam returns an error unless team is the team configured with
+// team_uuid and team_name.
+func matchTeam(c *Config, team *godo.TeamInfo) error {
+	if team == nil {
+		return fmt.Errorf("The API token is not associated with a team; " +
+			"team_uuid and team_name can only be used with team tokens")
+	}
+	if c.TeamUUID != "" && team.UUID != c.TeamUUID {
+		return fmt.Errorf("The API token belongs to team %s (%s), not to team_uuid %s",
+			team.Name, team.UUID, c.TeamUUID)
+	}
+	if c.TeamName != "" && team.Name != c.TeamName {
+		return fmt.Errorf("The API token belongs to team %s (%s), not to team_name %q",
+			team.Name, team.UUID, c.TeamName)
+	}
+	return nil
+}
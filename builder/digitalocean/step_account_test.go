@@ -0,0 +1,34 @@
+package digitalocean
+
+import (
+	"testing"
+
+	"github.com/digitalocean/godo"
+)
+
+func TestMatchTeam(t *testing.T) {
+	team := &godo.TeamInfo{UUID: "5df3e3004a17e242b7c20ca6c9fc25b701a47ece", Name: "Platform"}
+
+	tests := []struct {
+		name    string
+		config  Config
+		team    *godo.TeamInfo
+		wantErr bool
+	}{
+		{"matching uuid", Config{TeamUUID: team.UUID}, team, false},
+		{"matching name", Config{TeamName: "Platform"}, team, false},
+		{"matching uuid and name", Config{TeamUUID: team.UUID, TeamName: "Platform"}, team, false},
+		{"other uuid", Config{TeamUUID: "other"}, team, true},
+		{"other name", Config{TeamUUID: team.UUID, TeamName: "Data"}, team, true},
+		{"no team", Config{TeamName: "Platform"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := matchTeam(&tt.config, tt.team)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// apiFeature is an optional part of the DigitalOcean API that
+// DigitalOcean-compatible services may not implement.
+type apiFeature struct {
+	// name describes the feature in messages.
+	name string
+	// path is an endpoint listing resources of the feature, which answers
+	// 404 when the feature is missing.
+	path string
+	// required reports whether the configuration can't be built without
+	// the feature. When it is used but not required, the build goes on
+	// without it.
+	required func(c *Config) bool
+	// used reports whether the configuration uses the feature at all.
+	used func(c *Config) bool
+}
+
+const (
+	apiFeatureBackupPolicies = "backup_policies"
+	apiFeatureTags           = "tags"
+	apiFeatureProjects       = "projects"
+	apiFeatureReservedIPs    = "reserved_ips"
+	apiFeatureFirewalls      = "firewalls"
+	apiFeatureVPCs           = "vpcs"
+)
+
+func always(*Config) bool { return true }
+
+func never(*Config) bool { return false }
+
+var apiFeatures = map[string]apiFeature{
+	apiFeatureBackupPolicies: {
+		name:     "droplet backup policies",
+		path:     "v2/droplets/backups/policies",
+		used:     func(c *Config) bool { return c.BackupPolicy != nil },
+		required: never,
+	},
+	apiFeatureTags: {
+		name: "tags",
+		path: "v2/tags",
+		used: func(c *Config) bool {
+			return c.UserData != "" || c.UserDataFile != "" || c.FirewallTag != "" || c.ImageTTL > 0 ||
+				len(c.SnapshotTags) > 0 || c.DeprecatePrevious
+		},
+		required: func(c *Config) bool {
+			return c.FirewallTag != "" || c.ImageTTL > 0 || len(c.SnapshotTags) > 0 || c.DeprecatePrevious
+		},
+	},
+	apiFeatureProjects: {
+		name:     "projects",
+		path:     "v2/projects",
+		used:     func(c *Config) bool { return c.ProjectID != "" || c.ProjectName != "" },
+		required: always,
+	},
+	apiFeatureReservedIPs: {
+		name:     "reserved IPs",
+		path:     "v2/reserved_ips",
+		used:     func(c *Config) bool { return c.ReservedIP != "" || c.AssignReservedIP },
+		required: always,
+	},
+	apiFeatureFirewalls: {
+		name:     "cloud firewalls",
+		path:     "v2/firewalls",
+		used:     func(c *Config) bool { return len(c.FirewallIDs) > 0 || c.TemporaryFirewall },
+		required: always,
+	},
+	apiFeatureVPCs: {
+		name:     "VPCs",
+		path:     "v2/vpcs",
+		used:     func(c *Config) bool { return c.VPCName != "" || c.TemporaryVPC },
+		required: always,
+	},
+}
+
+// stepAPICapabilities probes a DigitalOcean-compatible API set with api_url
+// for the optional features the configuration uses, before anything is
+// created. The build fails early when a feature it can't do without is
+// missing, and otherwise goes on without the missing features rather than
+// failing with a 404 halfway through.
+type stepAPICapabilities struct{}
+
+func (s *stepAPICapabilities) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if c.APIURL == "" || c.MinimalAPIMode {
+		return multistep.ActionContinue
+	}
+
+	keys := make([]string, 0, len(apiFeatures))
+	for key := range apiFeatures {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	missing := make(map[string]bool)
+	var requiredMissing []string
+	for _, key := range keys {
+		feature := apiFeatures[key]
+		if !feature.used(c) {
+			continue
+		}
+
+		supported, err := probeAPIFeature(ctx, client, feature.path)
+		if err != nil {
+			log.Printf("[DEBUG] Error probing the API for %s, assuming it is supported: %s", feature.name, err)
+			continue
+		}
+		if supported {
+			continue
+		}
+
+		missing[key] = true
+		if feature.required(c) {
+			requiredMissing = append(requiredMissing, feature.name)
+		} else {
+			ui.Message(fmt.Sprintf("The API at %s doesn't support %s, building without them", c.APIURL, feature.name))
+		}
+	}
+	stateAPIMissingFeatures.Put(state, missing)
+
+	if len(requiredMissing) > 0 {
+		err := fmt.Errorf("The API at %s doesn't support %s, which the configuration needs",
+			c.APIURL, strings.Join(requiredMissing, ", "))
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepAPICapabilities) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// probeAPIFeature reports whether the API serves path. The request is made
+// directly, so that probing doesn't depend on what the godo version in use
+// knows about.
+func probeAPIFeature(ctx context.Context, client *godo.Client, path string) (bool, error) {
+	req, err := client.NewRequest(ctx, http.MethodGet, path+"?per_page=1", nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.Do(ctx, req, nil)
+	if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// apiSupports reports whether the API supports the feature, which it is
+// assumed to unless probing found otherwise.
+func apiSupports(state multistep.StateBag, feature string) bool {
+	missing, ok := stateAPIMissingFeatures.GetOk(state)
+	if !ok {
+		return true
+	}
+	return !missing[feature]
+}
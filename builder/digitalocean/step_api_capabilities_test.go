@@ -0,0 +1,101 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepAPICapabilities(t *testing.T) {
+	var probed []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probed = append(probed, r.URL.Path)
+		switch r.URL.Path {
+		case "/v2/droplets/backups/policies", "/v2/reserved_ips":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"id": "not_found", "message": "The resource you requested could not be found."}`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		config         Config
+		expectedAction multistep.StepAction
+		expectedProbes []string
+		unsupported    []string
+	}{
+		{
+			name:           "default API",
+			config:         Config{BackupPolicy: &BackupPolicy{}},
+			expectedAction: multistep.ActionContinue,
+		},
+		{
+			name:           "minimal API mode",
+			config:         Config{APIURL: ts.URL, MinimalAPIMode: true, BackupPolicy: &BackupPolicy{}},
+			expectedAction: multistep.ActionContinue,
+		},
+		{
+			name:           "nothing to probe",
+			config:         Config{APIURL: ts.URL},
+			expectedAction: multistep.ActionContinue,
+		},
+		{
+			name:           "optional feature missing",
+			config:         Config{APIURL: ts.URL, BackupPolicy: &BackupPolicy{}, UserData: "#!/bin/sh"},
+			expectedAction: multistep.ActionContinue,
+			expectedProbes: []string{"/v2/droplets/backups/policies", "/v2/tags"},
+			unsupported:    []string{apiFeatureBackupPolicies},
+		},
+		{
+			name:           "required feature missing",
+			config:         Config{APIURL: ts.URL, AssignReservedIP: true, FirewallIDs: []string{"fw"}},
+			expectedAction: multistep.ActionHalt,
+			expectedProbes: []string{"/v2/firewalls", "/v2/reserved_ips"},
+			unsupported:    []string{apiFeatureReservedIPs},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probed = nil
+
+			var out bytes.Buffer
+			state := new(multistep.BasicStateBag)
+			state.Put("client", client)
+			state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+			state.Put("config", &tt.config)
+
+			action := new(stepAPICapabilities).Run(context.Background(), state)
+			if action != tt.expectedAction {
+				t.Fatalf("bad action: %v: %s", action, out.String())
+			}
+			if strings.Join(probed, ",") != strings.Join(tt.expectedProbes, ",") {
+				t.Fatalf("bad probes: %v", probed)
+			}
+			for _, feature := range tt.unsupported {
+				if apiSupports(state, feature) {
+					t.Fatalf("%s should be unsupported", feature)
+				}
+			}
+			if !apiSupports(state, apiFeatureProjects) {
+				t.Fatal("unprobed features should be supported")
+			}
+		})
+	}
+}
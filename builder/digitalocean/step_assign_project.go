@@ -0,0 +1,49 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepAssignProject moves the droplet into the project set with project_id
+// or project_name, so that it is attributed to that project instead of the
+// default one. stepProjectName looks up project_name before the droplet is
+// created.
+type stepAssignProject struct{}
+
+func (s *stepAssignProject) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	dropletID := stateDropletID.Get(state)
+
+	projectID := c.ProjectID
+	if id, ok := stateNamedProjectID.GetOk(state); ok {
+		projectID = id
+	}
+	if projectID == "" {
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Assigning droplet to project %s...", projectID))
+	urn := fmt.Sprintf("do:droplet:%d", dropletID)
+	_, _, err := client.Projects.AssignResources(context.TODO(), projectID, urn)
+	if err != nil {
+		err := fmt.Errorf("Error assigning droplet to project: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	stateProjectID.Put(state, projectID)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepAssignProject) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
@@ -0,0 +1,57 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepAssignProject(t *testing.T) {
+	var assigned []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/projects/b7cd4ac4-3a1a-4c36-9e2b-3cc4ce1e8d55/resources":
+			var body struct {
+				Resources []string `json:"resources"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			assigned = body.Resources
+			w.Write([]byte(`{"resources": []}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{ProjectName: "platform-images"})
+	state.Put("droplet_id", 3164444)
+	stateNamedProjectID.Put(state, "b7cd4ac4-3a1a-4c36-9e2b-3cc4ce1e8d55")
+
+	if action := new(stepAssignProject).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	if len(assigned) != 1 || assigned[0] != "do:droplet:3164444" {
+		t.Fatalf("bad resources: %v", assigned)
+	}
+	if id := stateProjectID.Get(state); id != "b7cd4ac4-3a1a-4c36-9e2b-3cc4ce1e8d55" {
+		t.Fatalf("bad project ID: %s", id)
+	}
+}
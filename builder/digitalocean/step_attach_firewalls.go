@@ -0,0 +1,97 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepAttachFirewalls places the droplet behind the cloud firewalls set with
+// firewall_ids and firewall_tag before the communicator connects, and takes
+// it out of them again during cleanup.
+type stepAttachFirewalls struct {
+	firewallIDs []string
+	tagged      bool
+}
+
+func (s *stepAttachFirewalls) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	dropletID := stateDropletID.Get(state)
+
+	for _, id := range c.FirewallIDs {
+		ui.Say(fmt.Sprintf("Adding droplet to firewall %s...", id))
+		if _, err := client.Firewalls.AddDroplets(context.TODO(), id, dropletID); err != nil {
+			err := fmt.Errorf("Error adding droplet to firewall %s: %s", id, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		s.firewallIDs = append(s.firewallIDs, id)
+	}
+
+	if c.FirewallTag != "" {
+		ui.Say(fmt.Sprintf("Tagging droplet with firewall tag %s...", c.FirewallTag))
+		if err := tagDroplet(client, dropletID, c.FirewallTag); err != nil {
+			err := fmt.Errorf("Error tagging droplet with %s: %s", c.FirewallTag, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		s.tagged = true
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepAttachFirewalls) Cleanup(state multistep.StateBag) {
+	if len(s.firewallIDs) == 0 && !s.tagged {
+		return
+	}
+
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	dropletID := stateDropletID.Get(state)
+
+	for _, id := range s.firewallIDs {
+		ui.Say(fmt.Sprintf("Removing droplet from firewall %s...", id))
+		err := retryCleanup(state, "firewall "+id+" membership", func() (*godo.Response, error) {
+			return client.Firewalls.RemoveDroplets(context.TODO(), id, dropletID)
+		})
+		if err != nil {
+			ui.Error(fmt.Sprintf(
+				"Error removing droplet from firewall %s: %s", id, err))
+		}
+	}
+
+	if s.tagged {
+		ui.Say(fmt.Sprintf("Removing firewall tag %s from droplet...", c.FirewallTag))
+		err := retryCleanup(state, "firewall tag "+c.FirewallTag, func() (*godo.Response, error) {
+			return client.Tags.UntagResources(context.TODO(), c.FirewallTag, &godo.UntagResourcesRequest{
+				Resources: []godo.Resource{{ID: strconv.Itoa(dropletID), Type: godo.DropletResourceType}},
+			})
+		})
+		if err != nil {
+			ui.Error(fmt.Sprintf(
+				"Error removing tag %s from droplet: %s", c.FirewallTag, err))
+		}
+	}
+}
+
+// tagDroplet tags the droplet, creating the tag if it doesn't exist yet.
+func tagDroplet(client *godo.Client, dropletID int, tag string) error {
+	if _, _, err := client.Tags.Create(context.TODO(), &godo.TagCreateRequest{Name: tag}); err != nil {
+		return err
+	}
+
+	_, err := client.Tags.TagResources(context.TODO(), tag, &godo.TagResourcesRequest{
+		Resources: []godo.Resource{{ID: strconv.Itoa(dropletID), Type: godo.DropletResourceType}},
+	})
+	return err
+}
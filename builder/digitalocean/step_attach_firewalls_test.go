@@ -0,0 +1,71 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepAttachFirewalls(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v2/firewalls/bb4b2611-3d72-467b-8602-280330ecd65c/droplets":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/tags":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"tag": {"name": "build-firewall"}}`))
+		case r.URL.Path == "/v2/tags/build-firewall/resources":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{
+		FirewallIDs: []string{"bb4b2611-3d72-467b-8602-280330ecd65c"},
+		FirewallTag: "build-firewall",
+	})
+	state.Put("droplet_id", 3164444)
+
+	step := new(stepAttachFirewalls)
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	step.Cleanup(state)
+
+	expected := []string{
+		"POST /v2/firewalls/bb4b2611-3d72-467b-8602-280330ecd65c/droplets",
+		"POST /v2/tags",
+		"POST /v2/tags/build-firewall/resources",
+		"DELETE /v2/firewalls/bb4b2611-3d72-467b-8602-280330ecd65c/droplets",
+		"DELETE /v2/tags/build-firewall/resources",
+	}
+	if len(requests) != len(expected) {
+		t.Fatalf("bad requests: %v", requests)
+	}
+	for i := range expected {
+		if requests[i] != expected[i] {
+			t.Fatalf("bad requests: %v", requests)
+		}
+	}
+}
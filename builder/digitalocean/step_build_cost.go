@@ -0,0 +1,95 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepBuildCost reports, with report_cost, the hourly price of the droplet
+// before creating it, and what the droplet cost once it is destroyed. The
+// cost is estimated from the list price of the size and the time the
+// droplet existed, so the invoice can differ by its rounding and discounts.
+type stepBuildCost struct {
+	prices  map[string]float64
+	created time.Time
+}
+
+// BuildCost is the estimated cost of the build droplet, in US dollars.
+type BuildCost struct {
+	Size                string  `json:"size"`
+	EstimatedHourlyCost float64 `json:"estimated_hourly_cost"`
+	DropletHours        float64 `json:"droplet_hours"`
+	AccruedCost         float64 `json:"accrued_cost"`
+}
+
+func (s *stepBuildCost) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if !c.ReportCost {
+		return multistep.ActionContinue
+	}
+
+	sizes, err := listSizes(client)
+	if err != nil {
+		ui.Message(fmt.Sprintf("Unable to estimate the build cost: %s", err))
+		return multistep.ActionContinue
+	}
+	s.prices = make(map[string]float64, len(sizes))
+	for _, size := range sizes {
+		s.prices[size.Slug] = size.PriceHourly
+	}
+
+	price, ok := s.prices[c.Size]
+	if !ok {
+		ui.Message(fmt.Sprintf("Unable to estimate the build cost: the size %s has no price", c.Size))
+		return multistep.ActionContinue
+	}
+	ui.Say(fmt.Sprintf("Estimated cost: $%.5f per hour of %s droplet", price, c.Size))
+	stateBuildCost.Put(state, &BuildCost{Size: c.Size, EstimatedHourlyCost: price})
+	s.created = waitClock.Now()
+
+	return multistep.ActionContinue
+}
+
+// Cleanup runs once the droplet is destroyed, so the time it existed is
+// known.
+func (s *stepBuildCost) Cleanup(state multistep.StateBag) {
+	cost, ok := stateBuildCost.GetOk(state)
+	if !ok {
+		return
+	}
+	if _, ok := stateDropletID.GetOk(state); !ok {
+		// The droplet was never created
+		return
+	}
+	ui := state.Get("ui").(packersdk.Ui)
+
+	// A fallback size may have been used
+	if size, ok := stateDropletSize.GetOk(state); ok {
+		cost.Size = size
+	}
+	price, ok := s.prices[cost.Size]
+	if !ok {
+		log.Printf("[DEBUG] No price for size %s, not reporting the build cost", cost.Size)
+		return
+	}
+
+	cost.DropletHours = waitClock.Now().Sub(s.created).Hours()
+	cost.AccruedCost = price * cost.DropletHours
+	msg := fmt.Sprintf("Droplet cost: about $%.4f for %.2f hours of %s",
+		cost.AccruedCost, cost.DropletHours, cost.Size)
+	retained, _ := stateDropletRetained.GetOk(state)
+	kept, _ := stateDropletKept.GetOk(state)
+	if retained || kept {
+		msg += ", and the droplet keeps accruing costs"
+	}
+	ui.Say(msg)
+}
@@ -0,0 +1,73 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepBuildCost(t *testing.T) {
+	clock := useFakeClock(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/sizes" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"sizes": [
+			{"slug": "s-4vcpu-8gb", "price_hourly": 0.07143},
+			{"slug": "s-4vcpu-8gb-amd", "price_hourly": 0.08333}
+		]}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{Size: "s-4vcpu-8gb", ReportCost: true})
+
+	step := new(stepBuildCost)
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	if !strings.Contains(out.String(), "Estimated cost: $0.07143 per hour of s-4vcpu-8gb droplet") {
+		t.Fatalf("missing estimate in output: %s", out.String())
+	}
+
+	// The droplet is created with a fallback size and lives for 30 minutes.
+	stateDropletID.Put(state, 3164444)
+	stateDropletSize.Put(state, "s-4vcpu-8gb-amd")
+	<-clock.After(30 * time.Minute)
+	step.Cleanup(state)
+
+	cost := newArtifactState(state).BuildCost
+	if cost == nil {
+		t.Fatal("the cost should be recorded in the artifact state")
+	}
+	if cost.Size != "s-4vcpu-8gb-amd" || cost.EstimatedHourlyCost != 0.07143 || cost.DropletHours != 0.5 {
+		t.Fatalf("bad cost: %#v", cost)
+	}
+	if math.Abs(cost.AccruedCost-0.041665) > 1e-9 {
+		t.Fatalf("bad accrued cost: %v", cost.AccruedCost)
+	}
+	if !strings.Contains(out.String(), "Droplet cost: about $0.0417 for 0.50 hours of s-4vcpu-8gb-amd") {
+		t.Fatalf("missing cost in output: %s", out.String())
+	}
+}
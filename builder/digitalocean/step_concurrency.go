@@ -0,0 +1,93 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// concurrencyPollInterval is how often a build queued by concurrency_policy
+// "wait" checks for room again.
+const concurrencyPollInterval = 30 * time.Second
+
+// stepConcurrency checks, with concurrency_policy, that the account's
+// droplet limit leaves room for the build droplet, and fails or waits when
+// it doesn't.
+type stepConcurrency struct{}
+
+func (s *stepConcurrency) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if c.ConcurrencyPolicy == "" {
+		return multistep.ActionContinue
+	}
+
+	available, err := dropletRoom(ctx, client)
+	if err != nil {
+		err := fmt.Errorf("Error checking the account's droplet limit: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if available > 0 {
+		ui.Message(fmt.Sprintf("The account has room for %d more droplets", available))
+		return multistep.ActionContinue
+	}
+
+	if c.ConcurrencyPolicy == ConcurrencyPolicyFail {
+		err := fmt.Errorf("The account has reached its droplet limit, " +
+			"so the build droplet can't be created")
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say(fmt.Sprintf("The account has reached its droplet limit, waiting up to %s for room...", c.ConcurrencyTimeout))
+	err = PollEvery(ctx, concurrencyPollInterval, c.ConcurrencyTimeout, func(ctx context.Context, attempt int) (bool, error) {
+		available, err := dropletRoom(ctx, client)
+		if err != nil {
+			return false, err
+		}
+		return available > 0, nil
+	})
+	if err == ErrPollTimeout {
+		err = fmt.Errorf("no room after %s", c.ConcurrencyTimeout)
+	}
+	if err != nil {
+		err := fmt.Errorf("Error waiting for room under the account's droplet limit: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepConcurrency) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// dropletRoom returns how many more droplets the account can create: its
+// droplet limit minus the droplets it has.
+func dropletRoom(ctx context.Context, client *godo.Client) (int, error) {
+	account, _, err := client.Account.Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	_, resp, err := client.Droplets.List(ctx, &godo.ListOptions{Page: 1, PerPage: 1})
+	if err != nil {
+		return 0, err
+	}
+	if resp.Meta == nil {
+		return 0, fmt.Errorf("the droplet count is missing from the response")
+	}
+
+	return account.DropletLimit - resp.Meta.Total, nil
+}
@@ -0,0 +1,75 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepConcurrency(t *testing.T) {
+	useFakeClock(t)
+
+	droplets := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v2/account":
+			w.Write([]byte(`{"account": {"droplet_limit": 10, "status": "active"}}`))
+		case "/v2/droplets":
+			fmt.Fprintf(w, `{"droplets": [], "meta": {"total": %d}}`, droplets)
+			// Droplets of other builds are deleted while this one waits
+			if droplets > 0 {
+				droplets--
+			}
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(c *Config) multistep.StepAction {
+		var out bytes.Buffer
+		state := new(multistep.BasicStateBag)
+		state.Put("client", client)
+		state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+		state.Put("config", c)
+		return new(stepConcurrency).Run(context.Background(), state)
+	}
+
+	droplets = 9
+	if action := run(&Config{ConcurrencyPolicy: ConcurrencyPolicyFail}); action != multistep.ActionContinue {
+		t.Fatalf("bad action with room: %v", action)
+	}
+
+	droplets = 10
+	if action := run(&Config{ConcurrencyPolicy: ConcurrencyPolicyFail}); action != multistep.ActionHalt {
+		t.Fatalf("bad action without room: %v", action)
+	}
+
+	droplets = 12
+	if action := run(&Config{ConcurrencyPolicy: ConcurrencyPolicyWait, ConcurrencyTimeout: time.Hour}); action != multistep.ActionContinue {
+		t.Fatalf("bad action after waiting: %v", action)
+	}
+	if droplets != 8 {
+		t.Fatalf("should have checked until there was room: %d", droplets)
+	}
+
+	droplets = 100
+	if action := run(&Config{ConcurrencyPolicy: ConcurrencyPolicyWait, ConcurrencyTimeout: time.Minute}); action != multistep.ActionHalt {
+		t.Fatalf("bad action after timing out: %v", action)
+	}
+}
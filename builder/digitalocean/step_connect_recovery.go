@@ -0,0 +1,152 @@
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepConnectRecovery runs the communicator's connect step and, when it
+// never manages to connect, power cycles the droplet and tries once more
+// with connect_power_cycle. When connecting still fails, it reports what
+// the API knows about the droplet.
+type stepConnectRecovery struct {
+	Connect multistep.Step
+}
+
+// ConnectFailureReport describes a droplet Packer could not connect to.
+type ConnectFailureReport struct {
+	DropletID   int                   `json:"droplet_id"`
+	Error       string                `json:"error"`
+	PowerCycled bool                  `json:"power_cycled"`
+	Status      string                `json:"status,omitempty"`
+	Locked      bool                  `json:"locked"`
+	Actions     []ConnectFailureEvent `json:"actions,omitempty"`
+	ReportedAt  time.Time             `json:"reported_at"`
+}
+
+// ConnectFailureEvent is an action performed on the droplet.
+type ConnectFailureEvent struct {
+	ID          int    `json:"id"`
+	Type        string `json:"type"`
+	Status      string `json:"status"`
+	StartedAt   string `json:"started_at,omitempty"`
+	CompletedAt string `json:"completed_at,omitempty"`
+}
+
+func (s *stepConnectRecovery) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if action := s.Connect.Run(ctx, state); action == multistep.ActionContinue || ctx.Err() != nil {
+		return action
+	}
+
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	dropletID := stateDropletID.Get(state)
+
+	powerCycled := false
+	if c.ConnectPowerCycle {
+		ui.Say("Could not connect to the droplet, power cycling it and trying again...")
+		if err := powerCycleDroplet(ctx, client, dropletID, c.StateTimeout); err != nil {
+			ui.Error(fmt.Sprintf("Error power cycling droplet: %s", err))
+		} else {
+			powerCycled = true
+			state.Remove("error")
+			if action := s.Connect.Run(ctx, state); action == multistep.ActionContinue || ctx.Err() != nil {
+				return action
+			}
+		}
+	}
+
+	connectErr := fmt.Errorf("could not connect to the droplet")
+	if err, ok := state.GetOk("error"); ok {
+		connectErr = err.(error)
+	}
+
+	ui.Say("Gathering diagnostics for the droplet...")
+	report := connectFailureReport(ctx, client, dropletID, connectErr, powerCycled)
+	ui.Message(fmt.Sprintf("Droplet %d: status %q, locked %t", report.DropletID, report.Status, report.Locked))
+	for _, a := range report.Actions {
+		ui.Message(fmt.Sprintf("Action %d: %s %s (started %s, completed %s)",
+			a.ID, a.Type, a.Status, a.StartedAt, a.CompletedAt))
+	}
+	stateConnectFailureReport.Put(state, report)
+
+	if c.ConnectFailureReportPath != "" {
+		if err := writeConnectFailureReport(c.ConnectFailureReportPath, report); err != nil {
+			ui.Error(fmt.Sprintf("Error writing connect failure report: %s", err))
+		} else {
+			ui.Message(fmt.Sprintf("Wrote connect failure report to %s", c.ConnectFailureReportPath))
+		}
+	}
+
+	return multistep.ActionHalt
+}
+
+func (s *stepConnectRecovery) Cleanup(state multistep.StateBag) {
+	s.Connect.Cleanup(state)
+}
+
+// powerCycleDroplet power cycles the droplet and waits for it to be active
+// again.
+func powerCycleDroplet(ctx context.Context, client *godo.Client, dropletID int, timeout time.Duration) error {
+	action, _, err := client.DropletActions.PowerCycle(ctx, dropletID)
+	if err != nil {
+		return err
+	}
+	if err := waitForActionState(ctx, godo.ActionCompleted, dropletID, action.ID, client, timeout); err != nil {
+		return err
+	}
+	return waitForDropletState(ctx, "active", dropletID, client, timeout)
+}
+
+// connectFailureReport gathers what the API knows about the droplet. Errors
+// reading it are logged, leaving the corresponding fields empty.
+func connectFailureReport(ctx context.Context, client *godo.Client, dropletID int, connectErr error, powerCycled bool) *ConnectFailureReport {
+	report := &ConnectFailureReport{
+		DropletID:   dropletID,
+		Error:       connectErr.Error(),
+		PowerCycled: powerCycled,
+		ReportedAt:  time.Now().UTC(),
+	}
+
+	droplet, _, err := client.Droplets.Get(ctx, dropletID)
+	if err != nil {
+		log.Printf("[DEBUG] Error retrieving droplet %d for the report: %s", dropletID, err)
+	} else {
+		report.Status = droplet.Status
+		report.Locked = droplet.Locked
+	}
+
+	actions, _, err := client.Droplets.Actions(ctx, dropletID, &godo.ListOptions{PerPage: 20})
+	if err != nil {
+		log.Printf("[DEBUG] Error listing actions of droplet %d for the report: %s", dropletID, err)
+	}
+	for _, a := range actions {
+		e := ConnectFailureEvent{ID: a.ID, Type: a.Type, Status: a.Status}
+		if a.StartedAt != nil {
+			e.StartedAt = a.StartedAt.UTC().Format(time.RFC3339)
+		}
+		if a.CompletedAt != nil {
+			e.CompletedAt = a.CompletedAt.UTC().Format(time.RFC3339)
+		}
+		report.Actions = append(report.Actions, e)
+	}
+
+	return report
+}
+
+func writeConnectFailureReport(path string, report *ConnectFailureReport) error {
+	encoded, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(encoded, '\n'), 0644)
+}
@@ -0,0 +1,128 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// flakyConnect fails to connect the first failures times it is run.
+type flakyConnect struct {
+	failures int
+	runs     int
+}
+
+func (s *flakyConnect) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	s.runs++
+	if s.runs <= s.failures {
+		state.Put("error", errors.New("Timeout waiting for SSH."))
+		return multistep.ActionHalt
+	}
+	state.Put("communicator", new(packersdk.MockCommunicator))
+	return multistep.ActionContinue
+}
+
+func (s *flakyConnect) Cleanup(state multistep.StateBag) {}
+
+func TestStepConnectRecovery(t *testing.T) {
+	useFakeClock(t)
+
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/droplets/3164444/actions":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"action": {"id": 36804745, "status": "in-progress", "type": "power_cycle"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/droplets/3164444/actions/36804745":
+			w.Write([]byte(`{"action": {"id": 36804745, "status": "completed", "type": "power_cycle"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/droplets/3164444/actions":
+			w.Write([]byte(`{"actions": [
+				{"id": 36804745, "status": "completed", "type": "power_cycle", "started_at": "2024-01-02T15:04:05Z"},
+				{"id": 36804636, "status": "completed", "type": "create"}
+			]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/droplets/3164444":
+			w.Write([]byte(`{"droplet": {"id": 3164444, "status": "active", "locked": false}}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		powerCycle     bool
+		failures       int
+		expectedAction multistep.StepAction
+		expectedRuns   int
+	}{
+		{name: "connected", powerCycle: true, failures: 0, expectedAction: multistep.ActionContinue, expectedRuns: 1},
+		{name: "connected after power cycle", powerCycle: true, failures: 1, expectedAction: multistep.ActionContinue, expectedRuns: 2},
+		{name: "never connected", powerCycle: true, failures: 2, expectedAction: multistep.ActionHalt, expectedRuns: 2},
+		{name: "no power cycle", failures: 1, expectedAction: multistep.ActionHalt, expectedRuns: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reportPath := filepath.Join(t.TempDir(), "report.json")
+
+			var out bytes.Buffer
+			state := new(multistep.BasicStateBag)
+			state.Put("client", client)
+			state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+			state.Put("config", &Config{
+				ConnectPowerCycle:        tt.powerCycle,
+				ConnectFailureReportPath: reportPath,
+				StateTimeout:             time.Minute,
+			})
+			state.Put("droplet_id", 3164444)
+
+			connect := &flakyConnect{failures: tt.failures}
+			action := (&stepConnectRecovery{Connect: connect}).Run(context.Background(), state)
+			if action != tt.expectedAction {
+				t.Fatalf("bad action: %v: %s", action, out.String())
+			}
+			if connect.runs != tt.expectedRuns {
+				t.Fatalf("bad runs: %d", connect.runs)
+			}
+
+			if action == multistep.ActionContinue {
+				if _, err := os.Stat(reportPath); err == nil {
+					t.Fatal("should not write a report")
+				}
+				return
+			}
+
+			contents, err := os.ReadFile(reportPath)
+			if err != nil {
+				t.Fatalf("should write a report: %s", err)
+			}
+			var report ConnectFailureReport
+			if err := json.Unmarshal(contents, &report); err != nil {
+				t.Fatal(err)
+			}
+			if report.Status != "active" || report.PowerCycled != tt.powerCycle ||
+				report.Error != "Timeout waiting for SSH." || len(report.Actions) != 2 {
+				t.Fatalf("bad report: %s", contents)
+			}
+		})
+	}
+}
@@ -2,9 +2,13 @@ package digitalocean
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
 	"strconv"
+	"strings"
 
 	"io/ioutil"
 
@@ -22,12 +26,25 @@ func (s *stepCreateDroplet) Run(ctx context.Context, state multistep.StateBag) m
 	ui := state.Get("ui").(packersdk.Ui)
 	c := state.Get("config").(*Config)
 
+	if c.DropletID != 0 {
+		return s.useDroplet(state)
+	}
+
 	// Store the source image ID and
 	// other miscellaneous info for HCP Packer
-	state.Put("source_image_id", c.Image)
-	state.Put("droplet_size", c.Size)
-	state.Put("droplet_name", c.DropletName)
-	state.Put("build_region", c.Region)
+	stateSourceImageID.Put(state, c.Image)
+	stateDropletName.Put(state, c.DropletName)
+
+	if c.InstallAccountKeys {
+		keyIDs, err := listAccountKeyIDs(client)
+		if err != nil {
+			err := fmt.Errorf("Error listing account SSH keys: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		stateAccountSSHKeyIDs.Put(state, keyIDs)
+	}
 
 	// Create the droplet based on configuration
 	ui.Say("Creating droplet...")
@@ -39,19 +56,60 @@ func (s *stepCreateDroplet) Run(ctx context.Context, state multistep.StateBag) m
 
 	log.Printf("[DEBUG] Droplet create parameters: %s", godo.Stringify(dropletCreateReq))
 
-	droplet, _, err := client.Droplets.Create(context.TODO(), dropletCreateReq)
+	if checksum, ok := stateUserDataSHA256.GetOk(state); ok {
+		ui.Message(fmt.Sprintf("User data SHA-256: %s", checksum))
+	}
+
+	installedKeys := make([]int, 0, len(dropletCreateReq.SSHKeys))
+	for _, k := range dropletCreateReq.SSHKeys {
+		installedKeys = append(installedKeys, k.ID)
+	}
+	if len(installedKeys) > 0 {
+		ui.Message(fmt.Sprintf("Installing SSH keys: %v", installedKeys))
+	} else {
+		ui.Message("Not installing any SSH keys")
+	}
+	stateInstalledSSHKeyIDs.Put(state, installedKeys)
+
+	var droplet *godo.Droplet
+	placements := dropletPlacements(c)
+	for i, p := range placements {
+		dropletCreateReq.Region, dropletCreateReq.Size = p.region, p.size
+		if c.BackupPolicy != nil && apiSupports(state, apiFeatureBackupPolicies) {
+			droplet, _, err = createDropletWithBackupPolicy(context.TODO(), client, dropletCreateReq, c.BackupPolicy)
+		} else {
+			droplet, _, err = client.Droplets.Create(context.TODO(), dropletCreateReq)
+		}
+		if err == nil || i == len(placements)-1 || !isCapacityError(err) {
+			break
+		}
+		ui.Error(fmt.Sprintf("Warning: can't create a %s, trying a %s: %s", p, placements[i+1], err))
+	}
 	if err != nil {
-		err := fmt.Errorf("Error creating droplet: %s", err)
+		err := fmt.Errorf("Error creating droplet: %s", explainAccountError(err))
 		state.Put("error", err)
 		ui.Error(err.Error())
 		return multistep.ActionHalt
 	}
 
+	// The following steps, the snapshot transfers and the artifact use the
+	// region and size the droplet was built with.
+	if dropletCreateReq.Region != c.Region {
+		ui.Message(fmt.Sprintf("Building in %s instead of %s", dropletCreateReq.Region, c.Region))
+		c.Region = dropletCreateReq.Region
+	}
+	if dropletCreateReq.Size != c.Size {
+		ui.Message(fmt.Sprintf("Building with size %s instead of %s", dropletCreateReq.Size, c.Size))
+		c.Size = dropletCreateReq.Size
+	}
+	stateBuildRegion.Put(state, c.Region)
+	stateDropletSize.Put(state, c.Size)
+
 	// We use this in cleanup
 	s.dropletId = droplet.ID
 
 	// Store the droplet id for later
-	state.Put("droplet_id", droplet.ID)
+	stateDropletID.Put(state, droplet.ID)
 	// instance_id is the generic term used so that users can have access to the
 	// instance id inside of the provisioners, used in step_provision.
 	state.Put("instance_id", droplet.ID)
@@ -59,20 +117,89 @@ func (s *stepCreateDroplet) Run(ctx context.Context, state multistep.StateBag) m
 	return multistep.ActionContinue
 }
 
+// useDroplet uses the existing droplet given with droplet_id instead of
+// creating one, powering it on if it is off. The droplet isn't destroyed
+// in the cleanup.
+func (s *stepCreateDroplet) useDroplet(state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	ui.Say(fmt.Sprintf("Using existing droplet %d...", c.DropletID))
+	droplet, _, err := client.Droplets.Get(context.TODO(), c.DropletID)
+	if err != nil {
+		err := fmt.Errorf("Error retrieving droplet %d: %s", c.DropletID, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	if droplet.Image != nil {
+		stateSourceImageID.Put(state, strconv.Itoa(droplet.Image.ID))
+	}
+	stateDropletSize.Put(state, droplet.SizeSlug)
+	stateDropletName.Put(state, droplet.Name)
+	stateBuildRegion.Put(state, c.Region)
+
+	if droplet.Status == "off" {
+		ui.Say("Powering on the droplet...")
+		if _, _, err := client.DropletActions.PowerOn(context.TODO(), droplet.ID); err != nil {
+			err := fmt.Errorf("Error powering on droplet: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	stateDropletID.Put(state, droplet.ID)
+	state.Put("instance_id", droplet.ID)
+	// The droplet belongs to the user and must outlive the build.
+	stateDropletRetained.Put(state, true)
+
+	return multistep.ActionContinue
+}
+
 func (s *stepCreateDroplet) buildDropletCreateRequest(state multistep.StateBag) (*godo.DropletCreateRequest, error) {
 	c := state.Get("config").(*Config)
 
 	sshKeys := []godo.DropletCreateSSHKey{}
-	sshKeyID, hasSSHkey := state.GetOk("ssh_key_id")
-	if hasSSHkey {
-		sshKeys = append(sshKeys, godo.DropletCreateSSHKey{
-			ID: sshKeyID.(int),
-		})
-	}
-	if c.SSHKeyID != 0 {
-		sshKeys = append(sshKeys, godo.DropletCreateSSHKey{
-			ID: c.SSHKeyID,
-		})
+	if cloudInit, ok := stateCloudInit.GetOk(state); ok && !cloudInit {
+		// Keys would never be installed on the droplet
+		log.Println("[DEBUG] Image does not run cloud-init, not adding SSH keys to droplet")
+	} else {
+		sshKeyID, hasSSHkey := stateSSHKeyID.GetOk(state)
+		if hasSSHkey {
+			sshKeys = append(sshKeys, godo.DropletCreateSSHKey{
+				ID: sshKeyID,
+			})
+		}
+		if c.SSHKeyID != 0 {
+			sshKeys = append(sshKeys, godo.DropletCreateSSHKey{
+				ID: c.SSHKeyID,
+			})
+		}
+		if id, ok := stateFingerprintSSHKeyID.GetOk(state); ok {
+			sshKeys = append(sshKeys, godo.DropletCreateSSHKey{
+				ID: id,
+			})
+		}
+		if id, ok := stateNamedSSHKeyID.GetOk(state); ok {
+			sshKeys = append(sshKeys, godo.DropletCreateSSHKey{
+				ID: id,
+			})
+		}
+		for _, id := range c.SSHKeyIDs {
+			if !containsSSHKey(sshKeys, id) {
+				sshKeys = append(sshKeys, godo.DropletCreateSSHKey{ID: id})
+			}
+		}
+		if accountKeyIDs, ok := stateAccountSSHKeyIDs.GetOk(state); ok {
+			for _, id := range accountKeyIDs {
+				if !containsSSHKey(sshKeys, id) {
+					sshKeys = append(sshKeys, godo.DropletCreateSSHKey{ID: id})
+				}
+			}
+		}
 	}
 
 	userData := c.UserData
@@ -85,21 +212,47 @@ func (s *stepCreateDroplet) buildDropletCreateRequest(state multistep.StateBag)
 		userData = string(contents)
 	}
 
+	tags := c.Tags
+	if userData != "" {
+		checksum := userDataChecksum(userData)
+		stateUserDataSHA256.Put(state, checksum)
+		tags = append(append([]string{}, c.Tags...), userDataTag(checksum))
+	}
+	userData, err := finalUserData(userData, c.CompressUserData)
+	if err != nil {
+		return nil, err
+	}
+
 	createImage := getImageType(c.Image)
 
+	var volumes []godo.DropletCreateVolume
+	for _, id := range c.Volumes {
+		volumes = append(volumes, godo.DropletCreateVolume{ID: id})
+	}
+
+	vpcUUID := c.VPCUUID
+	if id, ok := stateVPCUUID.GetOk(state); ok {
+		vpcUUID = id
+	}
+	if id, ok := stateTemporaryVPCUUID.GetOk(state); ok {
+		vpcUUID = id
+	}
+
 	return &godo.DropletCreateRequest{
 		Name:              c.DropletName,
 		Region:            c.Region,
 		Size:              c.Size,
 		Image:             createImage,
 		SSHKeys:           sshKeys,
+		Volumes:           volumes,
+		Backups:           c.Backups,
 		PrivateNetworking: c.PrivateNetworking,
 		Monitoring:        c.Monitoring,
 		WithDropletAgent:  c.DropletAgent,
 		IPv6:              c.IPv6,
 		UserData:          userData,
-		Tags:              c.Tags,
-		VPCUUID:           c.VPCUUID,
+		Tags:              tags,
+		VPCUUID:           vpcUUID,
 	}, nil
 }
 
@@ -109,18 +262,113 @@ func (s *stepCreateDroplet) Cleanup(state multistep.StateBag) {
 		return
 	}
 
+	// The droplet is the artifact
+	if _, ok := stateDropletRetained.GetOk(state); ok {
+		return
+	}
+
 	client := state.Get("client").(*godo.Client)
 	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if _, halted := state.GetOk(multistep.StateHalted); halted && c.KeepDropletOnError {
+		s.keepDroplet(state)
+		return
+	}
 
 	// Destroy the droplet we just created
 	ui.Say("Destroying droplet...")
-	_, err := client.Droplets.Delete(context.TODO(), s.dropletId)
+	err := retryCleanup(state, fmt.Sprintf("droplet %d", s.dropletId), func() (*godo.Response, error) {
+		return client.Droplets.Delete(context.TODO(), s.dropletId)
+	})
 	if err != nil {
 		ui.Error(fmt.Sprintf(
 			"Error destroying droplet. Please destroy it manually: %s", err))
 	}
 }
 
+// dropletPlacement is a region and size to create the droplet with.
+type dropletPlacement struct {
+	region, size string
+}
+
+func (p dropletPlacement) String() string {
+	return fmt.Sprintf("%s droplet in %s", p.size, p.region)
+}
+
+// dropletPlacements returns the regions and sizes to try creating the
+// droplet with, in order: each size of size_fallbacks in a region of
+// region_fallbacks before the next region.
+func dropletPlacements(c *Config) []dropletPlacement {
+	var placements []dropletPlacement
+	for _, region := range append([]string{c.Region}, c.RegionFallbacks...) {
+		for _, size := range append([]string{c.Size}, c.SizeFallbacks...) {
+			placements = append(placements, dropletPlacement{region, size})
+		}
+	}
+	return placements
+}
+
+// keepDroplet keeps the droplet of a failed build for keep_droplet_on_error,
+// and tells how to reach and destroy it.
+func (s *stepCreateDroplet) keepDroplet(state multistep.StateBag) {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	stateDropletKept.Put(state, true)
+	ip, _ := stateDropletIP.GetOk(state)
+	ui.Say(fmt.Sprintf("Keeping droplet %d (IP: %s) after the failure, as keep_droplet_on_error is set.", s.dropletId, ip))
+
+	if c.Comm.SSHPrivateKeyFile == "" && len(c.Comm.SSHPrivateKey) > 0 {
+		path := fmt.Sprintf("do_%d.pem", s.dropletId)
+		if err := os.WriteFile(path, c.Comm.SSHPrivateKey, 0600); err != nil {
+			ui.Error(fmt.Sprintf("Error writing the temporary SSH private key: %s", err))
+		} else {
+			ui.Message(fmt.Sprintf("The temporary SSH private key is in %s", path))
+		}
+	}
+
+	ui.Message(fmt.Sprintf("Destroy it once done with: doctl compute droplet delete %d", s.dropletId))
+}
+
+// isCapacityError reports whether err is the API refusing to create a
+// droplet because its size is out of capacity or not available in the
+// region, so that it may be created elsewhere.
+func isCapacityError(err error) bool {
+	var errResp *godo.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return false
+	}
+	if errResp.Response.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+	msg := strings.ToLower(errResp.Message)
+	return strings.Contains(msg, "capacity") || strings.Contains(msg, "not available")
+}
+
+// listAccountKeyIDs returns the IDs of all SSH keys on the account.
+func listAccountKeyIDs(client *godo.Client) ([]int, error) {
+	keys, err := ListAll(context.TODO(), client.Keys.List)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, k := range keys {
+		ids = append(ids, k.ID)
+	}
+	return ids, nil
+}
+
+func containsSSHKey(keys []godo.DropletCreateSSHKey, id int) bool {
+	for _, k := range keys {
+		if k.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 func getImageType(image string) godo.DropletCreateImage {
 	createImage := godo.DropletCreateImage{Slug: image}
 
@@ -131,3 +379,44 @@ func getImageType(image string) godo.DropletCreateImage {
 
 	return createImage
 }
+
+// dropletCreateWithBackupPolicy is a droplet create request with a backup
+// policy, which godo's request doesn't support yet.
+type dropletCreateWithBackupPolicy struct {
+	*godo.DropletCreateRequest
+	BackupPolicy *backupPolicyRequest `json:"backup_policy,omitempty"`
+}
+
+type backupPolicyRequest struct {
+	Plan    string `json:"plan,omitempty"`
+	Weekday string `json:"weekday,omitempty"`
+	Hour    *int   `json:"hour,omitempty"`
+}
+
+// createDropletWithBackupPolicy creates a droplet like
+// client.Droplets.Create, with backups taken according to policy.
+func createDropletWithBackupPolicy(ctx context.Context, client *godo.Client, createRequest *godo.DropletCreateRequest, policy *BackupPolicy) (*godo.Droplet, *godo.Response, error) {
+	body := &dropletCreateWithBackupPolicy{
+		DropletCreateRequest: createRequest,
+		BackupPolicy: &backupPolicyRequest{
+			Plan:    policy.Plan,
+			Weekday: policy.Weekday,
+			Hour:    godo.PtrTo(policy.Hour),
+		},
+	}
+
+	req, err := client.NewRequest(ctx, http.MethodPost, "v2/droplets", body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := new(struct {
+		Droplet *godo.Droplet `json:"droplet"`
+	})
+	resp, err := client.Do(ctx, req, root)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return root.Droplet, resp, nil
+}
@@ -1,10 +1,19 @@
 package digitalocean
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/digitalocean/godo"
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/stretchr/testify/require"
 )
 
@@ -200,6 +209,135 @@ func TestBuilder_buildDropletCreateRequest(t *testing.T) {
 				VPCUUID:           "",
 			},
 		},
+		{
+			name: "Image without cloud-init",
+			in: &Config{
+				DropletName: "appliance-build",
+				Region:      "nyc3",
+				Size:        "s-1vcpu-1gb",
+				Image:       "789",
+				SSHKeyID:    12345,
+			},
+			addToState: map[string]interface{}{"cloud_init": false},
+			out: &godo.DropletCreateRequest{
+				Name:              "appliance-build",
+				Region:            "nyc3",
+				Size:              "s-1vcpu-1gb",
+				Image:             godo.DropletCreateImage{ID: 789, Slug: ""},
+				SSHKeys:           []godo.DropletCreateSSHKey{},
+				Backups:           false,
+				IPv6:              false,
+				PrivateNetworking: false,
+				Monitoring:        false,
+				UserData:          "",
+				VPCUUID:           "",
+			},
+		},
+		{
+			name: "Account keys",
+			in: &Config{
+				DropletName:        "ubuntu-20-04-x64-build",
+				Region:             "nyc3",
+				Size:               "s-1vcpu-1gb",
+				Image:              "ubuntu-20-04-x64",
+				InstallAccountKeys: true,
+			},
+			addToState: map[string]interface{}{
+				"ssh_key_id":          12345,
+				"account_ssh_key_ids": []int{111, 12345, 222},
+			},
+			out: &godo.DropletCreateRequest{
+				Name:              "ubuntu-20-04-x64-build",
+				Region:            "nyc3",
+				Size:              "s-1vcpu-1gb",
+				Image:             godo.DropletCreateImage{ID: 0, Slug: "ubuntu-20-04-x64"},
+				SSHKeys:           []godo.DropletCreateSSHKey{{ID: 12345}, {ID: 111}, {ID: 222}},
+				Backups:           false,
+				IPv6:              false,
+				PrivateNetworking: false,
+				Monitoring:        false,
+				UserData:          "",
+				VPCUUID:           "",
+			},
+		},
+		{
+			name: "User data",
+			in: &Config{
+				DropletName: "ubuntu-20-04-x64-build",
+				Region:      "nyc3",
+				Size:        "s-1vcpu-1gb",
+				Image:       "ubuntu-20-04-x64",
+				UserData:    "#cloud-config\npackages: [nginx]\n",
+				Tags:        []string{"packer"},
+			},
+			out: &godo.DropletCreateRequest{
+				Name:              "ubuntu-20-04-x64-build",
+				Region:            "nyc3",
+				Size:              "s-1vcpu-1gb",
+				Image:             godo.DropletCreateImage{ID: 0, Slug: "ubuntu-20-04-x64"},
+				SSHKeys:           []godo.DropletCreateSSHKey{},
+				Backups:           false,
+				IPv6:              false,
+				PrivateNetworking: false,
+				Monitoring:        false,
+				UserData:          "#cloud-config\npackages: [nginx]\n",
+				Tags:              []string{"packer", "user-data-sha256:" + userDataChecksum("#cloud-config\npackages: [nginx]\n")},
+				VPCUUID:           "",
+			},
+		},
+		{
+			name: "SSH key IDs",
+			in: &Config{
+				DropletName: "ubuntu-20-04-x64-build",
+				Region:      "nyc3",
+				Size:        "s-1vcpu-1gb",
+				Image:       "ubuntu-20-04-x64",
+				SSHKeyID:    512,
+				SSHKeyIDs:   []int{1024, 512},
+			},
+			out: &godo.DropletCreateRequest{
+				Name:              "ubuntu-20-04-x64-build",
+				Region:            "nyc3",
+				Size:              "s-1vcpu-1gb",
+				Image:             godo.DropletCreateImage{ID: 0, Slug: "ubuntu-20-04-x64"},
+				SSHKeys:           []godo.DropletCreateSSHKey{{ID: 256}, {ID: 512}, {ID: 768}, {ID: 896}, {ID: 1024}},
+				Backups:           false,
+				IPv6:              false,
+				PrivateNetworking: false,
+				Monitoring:        false,
+				UserData:          "",
+				VPCUUID:           "",
+			},
+			addToState: map[string]interface{}{
+				"ssh_key_id":             256,
+				"fingerprint_ssh_key_id": 768,
+				"named_ssh_key_id":       896,
+			},
+		},
+		{
+			name: "Volumes",
+			in: &Config{
+				DropletName: "ubuntu-20-04-x64-build",
+				Region:      "nyc3",
+				Size:        "s-1vcpu-1gb",
+				Image:       "ubuntu-20-04-x64",
+				Volumes:     []string{"506f78a4-e098-11e5-ad9f-000f53306ae1"},
+			},
+			out: &godo.DropletCreateRequest{
+				Name:              "ubuntu-20-04-x64-build",
+				Region:            "nyc3",
+				Size:              "s-1vcpu-1gb",
+				Image:             godo.DropletCreateImage{ID: 0, Slug: "ubuntu-20-04-x64"},
+				SSHKeys:           []godo.DropletCreateSSHKey{},
+				Volumes:           []godo.DropletCreateVolume{{ID: "506f78a4-e098-11e5-ad9f-000f53306ae1"}},
+				Backups:           false,
+				IPv6:              false,
+				PrivateNetworking: false,
+				Monitoring:        false,
+				UserData:          "",
+				VPCUUID:           "",
+			},
+		},
 	}
 
 	for _, tt := range imageTypeTests {
@@ -221,3 +359,219 @@ func TestBuilder_buildDropletCreateRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestStepCreateDroplet_RegionFallbacks(t *testing.T) {
+	// nyc3 and sfo3 are out of the size.
+	var tried []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v2/droplets" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var req struct {
+			Region string `json:"region"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("bad request: %s", err)
+		}
+		tried = append(tried, req.Region)
+		w.Header().Set("Content-Type", "application/json")
+		if req.Region != "ams3" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"id": "unprocessable_entity", "message": "Size is not available in this region."}`))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"droplet": {"id": 3164444}}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	c := &Config{
+		DropletName:     "packer-test",
+		Region:          "nyc3",
+		RegionFallbacks: []string{"sfo3", "ams3", "fra1"},
+		Size:            "s-1vcpu-1gb",
+		Image:           "ubuntu-20-04-x64",
+	}
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("config", c)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+
+	step := new(stepCreateDroplet)
+	action := step.Run(context.Background(), state)
+	require.Equal(t, multistep.ActionContinue, action, "%v", state.Get("error"))
+	require.Equal(t, []string{"nyc3", "sfo3", "ams3"}, tried)
+	require.Equal(t, "ams3", c.Region)
+	require.Equal(t, "ams3", stateBuildRegion.Get(state))
+	require.Equal(t, 3164444, stateDropletID.Get(state))
+	require.Contains(t, out.String(), "can't create a s-1vcpu-1gb droplet in nyc3, trying a s-1vcpu-1gb droplet in sfo3")
+
+	// Out of fallbacks.
+	tried = nil
+	c.Region = "nyc3"
+	c.RegionFallbacks = []string{"sfo3"}
+	state.Remove("error")
+	action = new(stepCreateDroplet).Run(context.Background(), state)
+	require.Equal(t, multistep.ActionHalt, action)
+	require.Equal(t, []string{"nyc3", "sfo3"}, tried)
+	require.True(t, strings.Contains(state.Get("error").(error).Error(), "not available in this region"))
+}
+
+func TestStepCreateDroplet_SizeFallbacks(t *testing.T) {
+	// Only the AMD size is available, and only in sfo3.
+	var tried []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Region string `json:"region"`
+			Size   string `json:"size"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("bad request: %s", err)
+		}
+		tried = append(tried, req.Size+"@"+req.Region)
+		w.Header().Set("Content-Type", "application/json")
+		if req.Region != "sfo3" || req.Size != "s-4vcpu-8gb-amd" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"id": "unprocessable_entity", "message": "The region is at capacity for this size."}`))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"droplet": {"id": 3164444}}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	c := &Config{
+		DropletName:     "packer-test",
+		Region:          "nyc3",
+		RegionFallbacks: []string{"sfo3"},
+		Size:            "s-4vcpu-8gb",
+		SizeFallbacks:   []string{"s-4vcpu-8gb-amd"},
+		Image:           "ubuntu-20-04-x64",
+	}
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("config", c)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+
+	action := new(stepCreateDroplet).Run(context.Background(), state)
+	require.Equal(t, multistep.ActionContinue, action, "%v", state.Get("error"))
+	require.Equal(t, []string{
+		"s-4vcpu-8gb@nyc3", "s-4vcpu-8gb-amd@nyc3", "s-4vcpu-8gb@sfo3", "s-4vcpu-8gb-amd@sfo3",
+	}, tried)
+	require.Equal(t, "s-4vcpu-8gb-amd", c.Size)
+	require.Equal(t, "s-4vcpu-8gb-amd", stateDropletSize.Get(state))
+	require.Equal(t, "sfo3", stateBuildRegion.Get(state))
+}
+
+func TestStepCreateDroplet_KeepDropletOnError(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	deleted := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/v2/droplets/3164444" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		deleted++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	require.NoError(t, err)
+
+	cleanup := func(c *Config, halted bool) (multistep.StateBag, string) {
+		var out bytes.Buffer
+		state := new(multistep.BasicStateBag)
+		state.Put("client", client)
+		state.Put("config", c)
+		state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+		stateDropletID.Put(state, 3164444)
+		stateDropletIP.Put(state, "192.0.2.10")
+		if halted {
+			state.Put(multistep.StateHalted, true)
+		}
+		(&stepCreateDroplet{dropletId: 3164444}).Cleanup(state)
+		return state, out.String()
+	}
+
+	c := &Config{KeepDropletOnError: true}
+	c.Comm.SSHPrivateKey = []byte("private key")
+	state, out := cleanup(c, true)
+	require.Equal(t, 0, deleted)
+	require.True(t, stateDropletKept.Get(state))
+	require.Contains(t, out, "Keeping droplet 3164444 (IP: 192.0.2.10)")
+	require.Contains(t, out, "doctl compute droplet delete 3164444")
+	key, err := os.ReadFile(filepath.Join(dir, "do_3164444.pem"))
+	require.NoError(t, err)
+	require.Equal(t, "private key", string(key))
+
+	// The kept droplet isn't deleted as a leftover either.
+	(&stepVerifyCleanup{Attempts: 3}).Cleanup(state)
+	require.Equal(t, 0, deleted)
+
+	// Successful builds and builds without the option destroy the droplet.
+	cleanup(c, false)
+	require.Equal(t, 1, deleted)
+	cleanup(&Config{}, true)
+	require.Equal(t, 2, deleted)
+}
+
+func TestStepCreateDroplet_DropletID(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/droplets/3164444":
+			w.Write([]byte(`{"droplet": {"id": 3164444, "name": "golden", "status": "off",
+				"size_slug": "s-2vcpu-4gb", "image": {"id": 7938206}}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/droplets/3164444/actions":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"action": {"id": 1, "status": "in-progress"}}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("config", &Config{DropletID: 3164444, Region: "nyc3"})
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+
+	step := new(stepCreateDroplet)
+	action := step.Run(context.Background(), state)
+	require.Equal(t, multistep.ActionContinue, action, "%v", state.Get("error"))
+	require.Equal(t, 3164444, stateDropletID.Get(state))
+	require.Equal(t, "7938206", stateSourceImageID.Get(state))
+	require.Equal(t, "s-2vcpu-4gb", stateDropletSize.Get(state))
+	require.Equal(t, "nyc3", stateBuildRegion.Get(state))
+
+	// The droplet was off, and is never destroyed, not even by the check
+	// that the temporary resources are gone.
+	state.Put(multistep.StateHalted, true)
+	step.Cleanup(state)
+	(&stepVerifyCleanup{Attempts: 3}).Cleanup(state)
+	require.Equal(t, []string{"GET /v2/droplets/3164444", "POST /v2/droplets/3164444/actions"}, requests)
+}
@@ -20,6 +20,11 @@ func (s *stepCreateSSHKey) Run(ctx context.Context, state multistep.StateBag) mu
 	ui := state.Get("ui").(packersdk.Ui)
 	c := state.Get("config").(*Config)
 
+	if cloudInit, ok := stateCloudInit.GetOk(state); ok && !cloudInit {
+		ui.Say("Image does not run cloud-init; skipping SSH public key import...")
+		return multistep.ActionContinue
+	}
+
 	if c.Comm.SSHPublicKey == nil {
 		ui.Say("No public SSH key found; skipping SSH public key import...")
 		return multistep.ActionContinue
@@ -48,7 +53,7 @@ func (s *stepCreateSSHKey) Run(ctx context.Context, state multistep.StateBag) mu
 	log.Printf("temporary ssh key name: %s", name)
 
 	// Remember some state for the future
-	state.Put("ssh_key_id", key.ID)
+	stateSSHKeyID.Put(state, key.ID)
 
 	return multistep.ActionContinue
 }
@@ -63,7 +68,9 @@ func (s *stepCreateSSHKey) Cleanup(state multistep.StateBag) {
 	ui := state.Get("ui").(packersdk.Ui)
 
 	ui.Say("Deleting temporary ssh key...")
-	_, err := client.Keys.DeleteByID(context.TODO(), s.keyId)
+	err := retryCleanup(state, fmt.Sprintf("ssh key %d", s.keyId), func() (*godo.Response, error) {
+		return client.Keys.DeleteByID(context.TODO(), s.keyId)
+	})
 	if err != nil {
 		log.Printf("Error cleaning up ssh key: %s", err)
 		ui.Error(fmt.Sprintf(
@@ -0,0 +1,69 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepDeprecateSnapshots tags the snapshots the new one supersedes, those
+// named with the deprecate_prefix, as deprecated. Failing to is only
+// reported: the new snapshot is good, and failing the build would lose
+// track of it.
+type stepDeprecateSnapshots struct{}
+
+func (s *stepDeprecateSnapshots) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if !c.DeprecatePrevious {
+		return multistep.ActionContinue
+	}
+
+	imageID := stateSnapshotImageID.Get(state)
+	replaced := make(map[int]bool)
+	if images, ok := stateReplacedImages.GetOk(state); ok {
+		for _, image := range images {
+			replaced[image.ID] = true
+		}
+	}
+
+	ui.Say(fmt.Sprintf("Deprecating earlier snapshots named %s*...", c.DeprecatePrefix))
+	images, err := listUserImages(client)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error listing snapshots to deprecate: %s", err))
+		return multistep.ActionContinue
+	}
+
+	tag := DeprecatedTag(time.Now())
+	matched := false
+	for _, image := range images {
+		if image.ID == imageID || replaced[image.ID] || !strings.HasPrefix(image.Name, c.DeprecatePrefix) {
+			continue
+		}
+		matched = true
+		if _, ok := ImageDeprecation(&image); ok {
+			continue
+		}
+
+		ui.Message(fmt.Sprintf("Deprecating snapshot %d (%s)", image.ID, image.Name))
+		if err := TagImage(ctx, client, image.ID, tag); err != nil {
+			ui.Error(fmt.Sprintf("Error deprecating snapshot %d: %s", image.ID, err))
+		}
+	}
+	if !matched {
+		ui.Message(fmt.Sprintf("Warning: no earlier snapshots are named %s*; check deprecate_prefix", c.DeprecatePrefix))
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepDeprecateSnapshots) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
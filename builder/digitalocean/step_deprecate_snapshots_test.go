@@ -0,0 +1,110 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepDeprecateSnapshots(t *testing.T) {
+	var deprecated []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/images":
+			w.Write([]byte(`{"images": [
+				{"id": 1, "name": "web-2025-05-01", "tags": []},
+				{"id": 2, "name": "web-2025-04-01", "tags": ["deprecated:2025-05-01"]},
+				{"id": 3, "name": "web-2025-06-01", "tags": []},
+				{"id": 4, "name": "db-2025-05-01", "tags": []},
+				{"id": 5, "name": "web-2025-03-01", "tags": []}
+			]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/tags":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"tag": {"name": "deprecated"}}`))
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/resources"):
+			var req godo.TagResourcesRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if want := DeprecatedTag(time.Now()); !strings.Contains(r.URL.Path, want) {
+				t.Errorf("bad tag: %s, want %s", r.URL.Path, want)
+			}
+			for _, resource := range req.Resources {
+				deprecated = append(deprecated, resource.ID)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{DeprecatePrevious: true, DeprecatePrefix: "web-"})
+	stateSnapshotImageID.Put(state, 3)
+	stateReplacedImages.Put(state, []godo.Image{{ID: 5, Name: "web-2025-03-01"}})
+
+	step := new(stepDeprecateSnapshots)
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	sort.Strings(deprecated)
+	if !reflect.DeepEqual(deprecated, []string{"1"}) {
+		t.Errorf("bad deprecated snapshots: %v", deprecated)
+	}
+}
+
+func TestStepDeprecateSnapshots_NoMatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v2/images" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"images": [
+			{"id": 3, "name": "web-2025-06-01", "tags": []},
+			{"id": 4, "name": "db-2025-05-01", "tags": []}
+		]}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{DeprecatePrevious: true, DeprecatePrefix: "web-2025-06-01"})
+	stateSnapshotImageID.Put(state, 3)
+
+	step := new(stepDeprecateSnapshots)
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	if !strings.Contains(out.String(), "Warning: no earlier snapshots are named web-2025-06-01*") {
+		t.Errorf("missing warning: %s", out.String())
+	}
+}
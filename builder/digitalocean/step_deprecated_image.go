@@ -0,0 +1,56 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepDeprecatedImage checks whether the base image is a snapshot that
+// deprecate_previous tagged as superseded, and warns or fails following
+// on_deprecated_image. Only snapshots, which are referenced by ID, can be
+// deprecated.
+type stepDeprecatedImage struct{}
+
+func (s *stepDeprecatedImage) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	id, err := strconv.Atoi(c.Image)
+	if err != nil {
+		return multistep.ActionContinue
+	}
+
+	image, _, err := client.Images.GetByID(context.TODO(), id)
+	if err != nil {
+		err := fmt.Errorf("Error retrieving base image %s: %s", c.Image, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	deprecated, ok := ImageDeprecation(image)
+	if !ok {
+		return multistep.ActionContinue
+	}
+
+	msg := fmt.Sprintf("The base image %d (%s) was deprecated on %s; a newer snapshot supersedes it.",
+		image.ID, image.Name, deprecated.Format(expiresDateLayout))
+	if c.OnDeprecatedImage == OnDeprecatedImageError {
+		err := fmt.Errorf("%s Set on_deprecated_image to %q to build from it anyway.", msg, OnDeprecatedImageWarn)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	ui.Message(fmt.Sprintf("Warning: %s", msg))
+
+	return multistep.ActionContinue
+}
+
+func (s *stepDeprecatedImage) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
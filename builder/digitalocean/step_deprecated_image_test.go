@@ -0,0 +1,66 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepDeprecatedImage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v2/images/1":
+			w.Write([]byte(`{"image": {"id": 1, "name": "web-1", "tags": ["deprecated:2025-06-01"]}}`))
+		case "/v2/images/2":
+			w.Write([]byte(`{"image": {"id": 2, "name": "web-2", "tags": []}}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		config *Config
+		action multistep.StepAction
+		out    string
+	}{
+		{"slug", &Config{Image: "ubuntu-22-04-x64", OnDeprecatedImage: OnDeprecatedImageError}, multistep.ActionContinue, ""},
+		{"current", &Config{Image: "2", OnDeprecatedImage: OnDeprecatedImageError}, multistep.ActionContinue, ""},
+		{"warn", &Config{Image: "1", OnDeprecatedImage: OnDeprecatedImageWarn}, multistep.ActionContinue,
+			"Warning: The base image 1 (web-1) was deprecated on 2025-06-01"},
+		{"error", &Config{Image: "1", OnDeprecatedImage: OnDeprecatedImageError}, multistep.ActionHalt,
+			"The base image 1 (web-1) was deprecated on 2025-06-01"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			state := new(multistep.BasicStateBag)
+			state.Put("client", client)
+			state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+			state.Put("config", tc.config)
+
+			step := new(stepDeprecatedImage)
+			if action := step.Run(context.Background(), state); action != tc.action {
+				t.Fatalf("bad action: %v: %s", action, out.String())
+			}
+			if !strings.Contains(out.String(), tc.out) || (tc.out == "" && out.Len() > 0) {
+				t.Fatalf("bad output: %s", out.String())
+			}
+		})
+	}
+}
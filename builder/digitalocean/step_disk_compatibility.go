@@ -0,0 +1,87 @@
+package digitalocean
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepDiskCompatibility checks that the disk of the droplet size, and of
+// each size of size_fallbacks, is at least the base image's minimum disk
+// size, before any resources are created. The API otherwise only rejects
+// the droplet with a generic error. It is skipped in minimal_api_mode.
+type stepDiskCompatibility struct{}
+
+func (s *stepDiskCompatibility) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if c.MinimalAPIMode {
+		return multistep.ActionContinue
+	}
+
+	image, err := getImage(client, c.Image)
+	if err != nil {
+		err := fmt.Errorf("Error retrieving base image %s: %s", c.Image, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if image.MinDiskSize == 0 {
+		return multistep.ActionContinue
+	}
+
+	sizes, err := listSizes(client)
+	if err != nil {
+		err := fmt.Errorf("Error listing droplet sizes: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	for _, slug := range append([]string{c.Size}, c.SizeFallbacks...) {
+		for _, size := range sizes {
+			if size.Slug != slug || size.Disk >= image.MinDiskSize {
+				continue
+			}
+			msg := fmt.Sprintf("The base image %s needs a disk of at least %d GB, but size %s only has %d GB.",
+				c.Image, image.MinDiskSize, slug, size.Disk)
+			if fit := smallestSizeWithDisk(sizes, c.Region, image.MinDiskSize); fit != "" {
+				msg += fmt.Sprintf(" Use a size with a larger disk, such as %s.", fit)
+			}
+			err := errors.New(msg)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepDiskCompatibility) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// smallestSizeWithDisk returns the cheapest size available in region with
+// a disk of at least disk GB, or "" if there is none.
+func smallestSizeWithDisk(sizes []godo.Size, region string, disk int) string {
+	var fit *godo.Size
+	for i, size := range sizes {
+		if !size.Available || size.Disk < disk || !containsString(size.Regions, region) {
+			continue
+		}
+		if fit == nil || size.PriceMonthly < fit.PriceMonthly {
+			fit = &sizes[i]
+		}
+	}
+	if fit == nil {
+		return ""
+	}
+	return fit.Slug
+}
@@ -0,0 +1,74 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepDiskCompatibility(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v2/images/7938206":
+			w.Write([]byte(`{"image": {"id": 7938206, "min_disk_size": 50}}`))
+		case "/v2/sizes":
+			w.Write([]byte(`{"sizes": [
+				{"slug": "s-1vcpu-1gb", "disk": 25, "price_monthly": 6, "available": true, "regions": ["nyc3"]},
+				{"slug": "s-2vcpu-4gb", "disk": 80, "price_monthly": 24, "available": true, "regions": ["nyc3"]},
+				{"slug": "s-2vcpu-2gb", "disk": 60, "price_monthly": 18, "available": true, "regions": ["nyc3"]},
+				{"slug": "s-2vcpu-2gb-amd", "disk": 60, "price_monthly": 12, "available": true, "regions": ["sfo3"]}
+			]}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		config *Config
+		action multistep.StepAction
+		out    string
+	}{
+		{"fits", &Config{Image: "7938206", Region: "nyc3", Size: "s-2vcpu-4gb"}, multistep.ActionContinue, ""},
+		{"too small", &Config{Image: "7938206", Region: "nyc3", Size: "s-1vcpu-1gb"}, multistep.ActionHalt,
+			"The base image 7938206 needs a disk of at least 50 GB, but size s-1vcpu-1gb only has 25 GB. " +
+				"Use a size with a larger disk, such as s-2vcpu-2gb."},
+		{"fallback too small", &Config{
+			Image: "7938206", Region: "nyc3", Size: "s-2vcpu-4gb", SizeFallbacks: []string{"s-1vcpu-1gb"},
+		}, multistep.ActionHalt, "size s-1vcpu-1gb only has 25 GB"},
+		{"minimal api mode", &Config{Image: "7938206", Region: "nyc3", Size: "s-1vcpu-1gb", MinimalAPIMode: true},
+			multistep.ActionContinue, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			state := new(multistep.BasicStateBag)
+			state.Put("client", client)
+			state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+			state.Put("config", tc.config)
+
+			step := new(stepDiskCompatibility)
+			if action := step.Run(context.Background(), state); action != tc.action {
+				t.Fatalf("bad action: %v: %s", action, out.String())
+			}
+			if !strings.Contains(out.String(), tc.out) {
+				t.Fatalf("expected %q in output: %s", tc.out, out.String())
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/digitalocean/godo"
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
 )
 
 type stepDropletInfo struct{}
@@ -15,11 +16,21 @@ func (s *stepDropletInfo) Run(ctx context.Context, state multistep.StateBag) mul
 	client := state.Get("client").(*godo.Client)
 	ui := state.Get("ui").(packersdk.Ui)
 	c := state.Get("config").(*Config)
-	dropletID := state.Get("droplet_id").(int)
+	dropletID := stateDropletID.Get(state)
+
+	// A new droplet can stay locked while it is still being provisioned,
+	// so wait for that separately from it becoming active.
+	ui.Say("Waiting for droplet to unlock...")
+	if err := waitForDropletUnlocked(ctx, client, dropletID, c.UnlockTimeout); err != nil {
+		err := fmt.Errorf("Error waiting for droplet to unlock after %s: %s", c.UnlockTimeout, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
 
 	ui.Say("Waiting for droplet to become active...")
 
-	err := waitForDropletState("active", dropletID, client, c.StateTimeout)
+	err := waitForDropletState(ctx, "active", dropletID, client, c.StateTimeout)
 	if err != nil {
 		err := fmt.Errorf("Error waiting for droplet to become active: %s", err)
 		state.Put("error", err)
@@ -36,32 +47,29 @@ func (s *stepDropletInfo) Run(ctx context.Context, state multistep.StateBag) mul
 		return multistep.ActionHalt
 	}
 
-	// Verify we have an IPv4 address
-	invalid := droplet.Networks == nil ||
-		len(droplet.Networks.V4) == 0
-	if invalid {
-		err := fmt.Errorf("IPv4 address not found for droplet")
-		state.Put("error", err)
-		ui.Error(err.Error())
-		return multistep.ActionHalt
+	// Record the features of the region the droplet was built in, such as
+	// private_networking or ipv6, so consumers can check the build environment.
+	regionFeatures := make([]string, 0)
+	if droplet.Region != nil {
+		regionFeatures = append(regionFeatures, droplet.Region.Features...)
 	}
+	stateRegionFeatures.Put(state, regionFeatures)
 
 	// Find the ip address which will be used by communicator
-	foundNetwork := false
-	for _, network := range droplet.Networks.V4 {
-		if (c.ConnectWithPrivateIP && network.Type == "private") ||
-			(!(c.ConnectWithPrivateIP) && network.Type == "public") {
-			state.Put("droplet_ip", network.IPAddress)
-			foundNetwork = true
-			break
-		}
-	}
-	if !foundNetwork {
-		err := fmt.Errorf("Count not find a public IPv4 address for this droplet")
+	ip, err := dropletAddress(droplet, c.SSHInterface)
+	if err != nil {
 		state.Put("error", err)
 		ui.Error(err.Error())
 		return multistep.ActionHalt
 	}
+	stateDropletIP.Put(state, ip)
+
+	generatedData := &packerbuilderdata.GeneratedData{State: state}
+	generatedData.Put("DropletID", droplet.ID)
+	generatedData.Put("DropletName", droplet.Name)
+	generatedData.Put("DropletIP", stateDropletIP.Get(state))
+	generatedData.Put("Region", c.Region)
+	generatedData.Put("Size", c.Size)
 
 	return multistep.ActionContinue
 }
@@ -69,3 +77,42 @@ func (s *stepDropletInfo) Run(ctx context.Context, state multistep.StateBag) mul
 func (s *stepDropletInfo) Cleanup(state multistep.StateBag) {
 	// no cleanup
 }
+
+// dropletAddress returns the address of the droplet selected by
+// ssh_interface.
+func dropletAddress(droplet *godo.Droplet, sshInterface string) (string, error) {
+	if droplet.Networks != nil {
+		if sshInterface == SSHInterfaceIPv6 {
+			for _, network := range droplet.Networks.V6 {
+				if network.Type == "public" {
+					return network.IPAddress, nil
+				}
+			}
+		} else {
+			networkType := "public"
+			if sshInterface == SSHInterfacePrivateIP {
+				networkType = "private"
+			}
+			for _, network := range droplet.Networks.V4 {
+				if network.Type == networkType {
+					return network.IPAddress, nil
+				}
+			}
+		}
+	}
+
+	switch sshInterface {
+	case SSHInterfaceIPv6:
+		return "", fmt.Errorf("Could not find a public IPv6 address for this droplet")
+	case SSHInterfacePrivateIP:
+		return "", fmt.Errorf("Could not find a private IPv4 address for this droplet")
+	default:
+		// Droplets without a public IPv4 address can still be reached over
+		// their other addresses.
+		if droplet.Networks != nil && len(droplet.Networks.V6) > 0 {
+			return "", fmt.Errorf("Could not find a public IPv4 address for this droplet; " +
+				"set ssh_interface to ipv6 or private_ip to connect over its other addresses")
+		}
+		return "", fmt.Errorf("Could not find a public IPv4 address for this droplet")
+	}
+}
@@ -0,0 +1,80 @@
+package digitalocean
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestDropletAddress(t *testing.T) {
+	droplet := &godo.Droplet{
+		Networks: &godo.Networks{
+			V4: []godo.NetworkV4{
+				{IPAddress: "10.116.0.2", Type: "private"},
+				{IPAddress: "203.0.113.10", Type: "public"},
+			},
+			V6: []godo.NetworkV6{
+				{IPAddress: "2604:a880:400:d1::8a:5001", Type: "public"},
+			},
+		},
+	}
+
+	tests := []struct {
+		sshInterface string
+		expected     string
+	}{
+		{sshInterface: SSHInterfacePublicIP, expected: "203.0.113.10"},
+		{sshInterface: SSHInterfacePrivateIP, expected: "10.116.0.2"},
+		{sshInterface: SSHInterfaceIPv6, expected: "2604:a880:400:d1::8a:5001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sshInterface, func(t *testing.T) {
+			ip, err := dropletAddress(droplet, tt.sshInterface)
+			if err != nil {
+				t.Fatalf("should not have error: %s", err)
+			}
+			if ip != tt.expected {
+				t.Fatalf("bad address: %s", ip)
+			}
+		})
+	}
+
+	// Test a droplet without IPv4 addresses
+	v4 := droplet.Networks.V4
+	droplet.Networks.V4 = nil
+	if ip, err := dropletAddress(droplet, SSHInterfaceIPv6); err != nil || ip != "2604:a880:400:d1::8a:5001" {
+		t.Fatalf("bad address without IPv4: %s, %v", ip, err)
+	}
+	if _, err := dropletAddress(droplet, SSHInterfacePublicIP); err == nil ||
+		!strings.Contains(err.Error(), "ssh_interface") {
+		t.Fatalf("should have error suggesting ssh_interface: %v", err)
+	}
+	droplet.Networks.V4 = v4
+
+	droplet.Networks.V6 = nil
+	if _, err := dropletAddress(droplet, SSHInterfaceIPv6); err == nil {
+		t.Fatal("should have error without an IPv6 address")
+	}
+}
+
+func TestBracketIPv6(t *testing.T) {
+	for ip, expected := range map[string]string{
+		"203.0.113.10":              "203.0.113.10",
+		"2604:a880:400:d1::8a:5001": "[2604:a880:400:d1::8a:5001]",
+		"droplet.example.com":       "droplet.example.com",
+	} {
+		state := new(multistep.BasicStateBag)
+		state.Put("droplet_ip", ip)
+		host, err := bracketIPv6(communicator.CommHost("", "droplet_ip"))(state)
+		if err != nil {
+			t.Fatalf("should not have error: %s", err)
+		}
+		if host != expected {
+			t.Fatalf("bad host for %s: %s", ip, host)
+		}
+	}
+}
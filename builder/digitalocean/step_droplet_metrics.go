@@ -0,0 +1,163 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/digitalocean/godo/metrics"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepDropletMetrics reads the CPU and memory usage of the droplet from the
+// Monitoring API with sample_metrics, from its creation until provisioning
+// finished, and reports their peaks. The metrics are informational, so
+// failing to read them doesn't fail the build.
+type stepDropletMetrics struct{}
+
+// DropletMetrics is the peak resource usage of the droplet during the
+// build.
+type DropletMetrics struct {
+	PeakCPUPercent    float64 `json:"peak_cpu_percent"`
+	PeakMemoryPercent float64 `json:"peak_memory_percent"`
+	PeakMemoryBytes   float64 `json:"peak_memory_bytes"`
+	TotalMemoryBytes  float64 `json:"total_memory_bytes"`
+}
+
+func (s *stepDropletMetrics) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	dropletID := stateDropletID.Get(state)
+
+	if !c.SampleMetrics {
+		return multistep.ActionContinue
+	}
+
+	ui.Say("Reading droplet metrics...")
+	m, err := dropletMetrics(ctx, client, dropletID, time.Now())
+	if err != nil {
+		ui.Message(fmt.Sprintf("Unable to read droplet metrics: %s", err))
+		return multistep.ActionContinue
+	}
+
+	ui.Message(fmt.Sprintf("Peak CPU usage: %.1f%%", m.PeakCPUPercent))
+	ui.Message(fmt.Sprintf("Peak memory usage: %.1f%% (%.0f of %.0f MiB)",
+		m.PeakMemoryPercent, m.PeakMemoryBytes/(1<<20), m.TotalMemoryBytes/(1<<20)))
+	stateDropletMetrics.Put(state, m)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepDropletMetrics) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// dropletMetrics reads the peak CPU and memory usage of the droplet from
+// its creation until end.
+func dropletMetrics(ctx context.Context, client *godo.Client, dropletID int, end time.Time) (*DropletMetrics, error) {
+	droplet, _, err := client.Droplets.Get(ctx, dropletID)
+	if err != nil {
+		return nil, err
+	}
+	start, err := time.Parse(time.RFC3339, droplet.Created)
+	if err != nil {
+		return nil, fmt.Errorf("invalid droplet creation time %q: %s", droplet.Created, err)
+	}
+
+	req := &godo.DropletMetricsRequest{
+		HostID: strconv.Itoa(dropletID),
+		Start:  start,
+		End:    end,
+	}
+	cpu, _, err := client.Monitoring.GetDropletCPU(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	total, _, err := client.Monitoring.GetDropletTotalMemory(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	available, _, err := client.Monitoring.GetDropletAvailableMemory(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(DropletMetrics)
+	var ok bool
+	if m.PeakCPUPercent, ok = peakCPUPercent(cpu.Data.Result); !ok {
+		return nil, fmt.Errorf("the droplet hasn't reported CPU metrics yet")
+	}
+	if ok = peakMemory(m, total.Data.Result, available.Data.Result); !ok {
+		return nil, fmt.Errorf("the droplet hasn't reported memory metrics yet")
+	}
+	return m, nil
+}
+
+// peakCPUPercent returns the highest CPU usage between two samples of the
+// cumulative CPU time series, which have one series per mode and CPU.
+func peakCPUPercent(streams []metrics.SampleStream) (float64, bool) {
+	totals := make(map[metrics.Time]float64)
+	idle := make(map[metrics.Time]float64)
+	for _, stream := range streams {
+		for _, v := range stream.Values {
+			totals[v.Timestamp] += float64(v.Value)
+			if stream.Metric["mode"] == "idle" {
+				idle[v.Timestamp] += float64(v.Value)
+			}
+		}
+	}
+
+	times := make([]metrics.Time, 0, len(totals))
+	for t := range totals {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	peak, ok := 0.0, false
+	for i := 1; i < len(times); i++ {
+		prev, cur := times[i-1], times[i]
+		elapsed := totals[cur] - totals[prev]
+		if elapsed <= 0 {
+			continue
+		}
+		usage := 100 * (1 - (idle[cur]-idle[prev])/elapsed)
+		if !ok || usage > peak {
+			peak, ok = usage, true
+		}
+	}
+	return peak, ok
+}
+
+// peakMemory sets the highest memory usage, the total memory less the
+// available memory, from the two memory series.
+func peakMemory(m *DropletMetrics, total, available []metrics.SampleStream) bool {
+	totals := make(map[metrics.Time]float64)
+	for _, stream := range total {
+		for _, v := range stream.Values {
+			totals[v.Timestamp] = float64(v.Value)
+		}
+	}
+
+	ok := false
+	for _, stream := range available {
+		for _, v := range stream.Values {
+			t, found := totals[v.Timestamp]
+			if !found || t <= 0 {
+				continue
+			}
+			used := t - float64(v.Value)
+			if !ok || used > m.PeakMemoryBytes {
+				m.PeakMemoryBytes = used
+				m.TotalMemoryBytes = t
+				m.PeakMemoryPercent = 100 * used / t
+				ok = true
+			}
+		}
+	}
+	return ok
+}
@@ -0,0 +1,87 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepDropletMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		hostID := r.URL.Query().Get("host_id")
+		switch {
+		case r.URL.Path == "/v2/droplets/3164444" || r.URL.Path == "/v2/droplets/3164445":
+			w.Write([]byte(`{"droplet": {"id": 3164444, "created_at": "2024-01-02T15:04:05Z"}}`))
+		case hostID == "3164445":
+			// A droplet that hasn't reported metrics yet
+			w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": []}}`))
+		case r.URL.Path == "/v2/monitoring/metrics/droplet/cpu":
+			if hostID != "3164444" || r.URL.Query().Get("start") != "1704207845" {
+				t.Errorf("bad query: %s", r.URL.RawQuery)
+			}
+			// 60s of CPU time per sample: 45s then 15s of it idle
+			w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": [
+				{"metric": {"mode": "idle"}, "values": [[1704207900, "100"], [1704207960, "145"], [1704208020, "160"]]},
+				{"metric": {"mode": "user"}, "values": [[1704207900, "20"], [1704207960, "35"], [1704208020, "80"]]}
+			]}}`))
+		case r.URL.Path == "/v2/monitoring/metrics/droplet/memory_total":
+			w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": [
+				{"metric": {}, "values": [[1704207900, "2048"], [1704207960, "2048"]]}
+			]}}`))
+		case r.URL.Path == "/v2/monitoring/metrics/droplet/memory_available":
+			w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": [
+				{"metric": {}, "values": [[1704207900, "1536"], [1704207960, "512"]]}
+			]}}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{Monitoring: true, SampleMetrics: true})
+	state.Put("droplet_id", 3164444)
+
+	if action := new(stepDropletMetrics).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+
+	m, ok := state.GetOk("droplet_metrics")
+	if !ok {
+		t.Fatalf("should have metrics: %s", out.String())
+	}
+	metrics := m.(*DropletMetrics)
+	if math.Abs(metrics.PeakCPUPercent-75) > 0.001 {
+		t.Errorf("bad peak CPU: %f", metrics.PeakCPUPercent)
+	}
+	if metrics.PeakMemoryBytes != 1536 || metrics.TotalMemoryBytes != 2048 || metrics.PeakMemoryPercent != 75 {
+		t.Errorf("bad memory: %#v", metrics)
+	}
+
+	// Test a droplet that hasn't reported metrics, which isn't an error
+	state.Put("droplet_id", 3164445)
+	state.Remove("droplet_metrics")
+	if action := new(stepDropletMetrics).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v", action)
+	}
+	if _, ok := state.GetOk("droplet_metrics"); ok {
+		t.Fatal("should not have metrics")
+	}
+}
@@ -0,0 +1,93 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// generalizeCommands remove what identifies the droplet the image was built
+// on, so that the droplets created from it get their own on first boot.
+var generalizeCommands = []string{
+	// Regenerated by cloud-init, or by the SSH server package on boot
+	"rm -f /etc/ssh/ssh_host_*_key /etc/ssh/ssh_host_*_key.pub",
+	// Emptied rather than removed so that systemd generates a new one
+	"if [ -f /etc/machine-id ]; then truncate -s 0 /etc/machine-id; fi",
+	"rm -f /var/lib/dbus/machine-id",
+	// Without its state cloud-init runs again as on a new instance
+	"if command -v cloud-init >/dev/null 2>&1; then cloud-init clean --logs; fi",
+	"rm -f /root/.bash_history /home/*/.bash_history",
+}
+
+// removeAuthorizedKeyCommand removes the lines holding a public key from the
+// authorized_keys files, keeping the files and their permissions.
+const removeAuthorizedKeyCommand = `for f in /root/.ssh/authorized_keys /home/*/.ssh/authorized_keys; do ` +
+	`if [ -f "$f" ]; then grep -vF %s "$f" > "$f.packer" || true; cat "$f.packer" > "$f"; rm -f "$f.packer"; fi; done`
+
+// stepGeneralize removes the droplet's SSH host keys, machine ID,
+// cloud-init state, shell history and the temporary SSH key from
+// authorized_keys before the shutdown, with generalize.
+type stepGeneralize struct{}
+
+func (s *stepGeneralize) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if !c.Generalize {
+		return multistep.ActionContinue
+	}
+
+	comm := state.Get("communicator").(packersdk.Communicator)
+
+	// The temporary key is only in authorized_keys when the build imported
+	// it into the account to create the droplet with.
+	var tempKey string
+	if _, ok := stateSSHKeyID.GetOk(state); ok {
+		tempKey = string(c.Comm.SSHPublicKey)
+	}
+
+	ui.Say("Generalizing the droplet...")
+	cmd := &packersdk.RemoteCmd{Command: generalizeCommand(c.Comm.SSHUsername, tempKey)}
+	if err := cmd.RunWithUi(ctx, comm, ui); err != nil {
+		err := fmt.Errorf("Error generalizing the droplet: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if status := cmd.ExitStatus(); status != 0 {
+		err := fmt.Errorf("Error generalizing the droplet: exited with status %d", status)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepGeneralize) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// generalizeCommand returns the command generalizing the droplet, removing
+// tempKey from authorized_keys unless it's empty.
+func generalizeCommand(username, tempKey string) string {
+	commands := append([]string{"set -e"}, generalizeCommands...)
+	if fields := strings.Fields(tempKey); len(fields) >= 2 {
+		// Match the key itself, whatever comment it was installed with
+		commands = append(commands, fmt.Sprintf(removeAuthorizedKeyCommand, shellQuote(fields[1])))
+	}
+	return asRoot(username, strings.Join(commands, "\n"))
+}
+
+// asRoot returns the command running script as root, with sudo unless the
+// build connects as root.
+func asRoot(username, script string) string {
+	command := "sh -c " + shellQuote(script)
+	if username != "root" {
+		command = "sudo " + command
+	}
+	return command
+}
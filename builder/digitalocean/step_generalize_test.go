@@ -0,0 +1,69 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepGeneralize(t *testing.T) {
+	comm := new(packersdk.MockCommunicator)
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("communicator", comm)
+	state.Put("config", &Config{
+		Generalize: true,
+		Comm: communicator.Config{
+			SSH: communicator.SSH{
+				SSHUsername:  "ubuntu",
+				SSHPublicKey: []byte("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIK9/key+ packer_1234\n"),
+			},
+		},
+	})
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	stateSSHKeyID.Put(state, 42)
+
+	if action := new(stepGeneralize).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	command := comm.StartCmd.Command
+	if !strings.HasPrefix(command, "sudo sh -c ") {
+		t.Errorf("should run with sudo: %s", command)
+	}
+	for _, want := range []string{"ssh_host_", "/etc/machine-id", "cloud-init clean", ".bash_history", "grep -vF '\"'\"'AAAAC3NzaC1lZDI1NTE5AAAAIK9/key+'\"'\"'"} {
+		if !strings.Contains(command, want) {
+			t.Errorf("command should contain %q: %s", want, command)
+		}
+	}
+}
+
+func TestGeneralizeCommand(t *testing.T) {
+	command := generalizeCommand("root", "")
+	if strings.HasPrefix(command, "sudo") {
+		t.Errorf("root shouldn't use sudo: %s", command)
+	}
+	if strings.Contains(command, "authorized_keys") {
+		t.Errorf("should only remove a temporary key: %s", command)
+	}
+}
+
+func TestStepGeneralize_failure(t *testing.T) {
+	comm := &packersdk.MockCommunicator{StartExitStatus: 1}
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("communicator", comm)
+	state.Put("config", &Config{Generalize: true})
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+
+	if action := new(stepGeneralize).Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %v", action)
+	}
+	if err := state.Get("error").(error); !strings.Contains(err.Error(), "exited with status 1") {
+		t.Fatalf("bad error: %s", err)
+	}
+}
@@ -0,0 +1,88 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepHTTPTunnel makes the HTTP server reachable by provisioners. With
+// http_reverse_tunnel it forwards the server's port on the droplet's
+// loopback interface to the server's address on the Packer host over the SSH
+// connection, which must be set up before the communicator connects.
+// Otherwise the droplet connects to the Packer host directly, on
+// http_bind_address or, when the server listens on every interface and
+// public_ip_url is set, on the public IPv4 address that service returns.
+type stepHTTPTunnel struct{}
+
+// httpTunnelListenIP is where the SDK's remote tunnels listen on the droplet.
+const httpTunnelListenIP = "127.0.0.1"
+
+func (s *stepHTTPTunnel) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	port := state.Get("http_port").(int)
+	if port == 0 {
+		return multistep.ActionContinue
+	}
+
+	if !*c.HTTPReverseTunnel {
+		if !listensEverywhere(c.HTTPAddress) {
+			state.Put("http_ip", c.HTTPAddress)
+			return multistep.ActionContinue
+		}
+
+		if c.PublicIPURL == "" {
+			ui.Message("Warning: the HTTP server listens on every interface, PACKER_HTTP_ADDR " +
+				"won't be set. Set http_bind_address to the address the droplet can reach the " +
+				"HTTP server on, or public_ip_url to look up the public IP address of this machine.")
+			return multistep.ActionContinue
+		}
+		ip, err := runnerPublicIP(ctx, c.PublicIPURL)
+		if err != nil {
+			ui.Message(fmt.Sprintf("Warning: could not look up the public IP address of this machine, "+
+				"PACKER_HTTP_ADDR won't be set. Set http_bind_address to the address the droplet "+
+				"can reach the HTTP server on. %s", err))
+			return multistep.ActionContinue
+		}
+		log.Printf("[DEBUG] Serving HTTP to the droplet on %s", ip)
+		state.Put("http_ip", ip)
+		return multistep.ActionContinue
+	}
+
+	tunnel := httpTunnel(c.HTTPAddress, port)
+	log.Printf("[DEBUG] Forwarding HTTP server through SSH tunnel %s", tunnel)
+	ui.Say(fmt.Sprintf("Forwarding HTTP server to port %d on the droplet through SSH...", port))
+	c.Comm.SSHRemoteTunnels = append(c.Comm.SSHRemoteTunnels, tunnel)
+	state.Put("http_ip", httpTunnelListenIP)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepHTTPTunnel) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// httpTunnel returns the remote tunnel forwarding port on the droplet to the
+// HTTP server listening on the same port at bindAddress on the Packer host,
+// or on its loopback interface when it listens on every interface.
+func httpTunnel(bindAddress string, port int) string {
+	target := bindAddress
+	if listensEverywhere(target) {
+		target = "127.0.0.1"
+	}
+	return fmt.Sprintf("%d:%s", port, net.JoinHostPort(target, strconv.Itoa(port)))
+}
+
+// listensEverywhere reports whether http_bind_address makes the HTTP server
+// listen on every interface.
+func listensEverywhere(bindAddress string) bool {
+	ip := net.ParseIP(bindAddress)
+	return bindAddress == "" || (ip != nil && ip.IsUnspecified())
+}
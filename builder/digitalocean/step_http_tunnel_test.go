@@ -0,0 +1,99 @@
+package digitalocean
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepHTTPTunnel(t *testing.T) {
+	ipServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("203.0.113.7\n"))
+	}))
+	defer ipServer.Close()
+
+	tests := []struct {
+		name    string
+		tunnel  bool
+		port    int
+		address string
+		tunnels []string
+		httpIP  string
+	}{
+		{name: "no server", tunnel: true, port: 0},
+		{name: "tunnel", tunnel: true, port: 8123, tunnels: []string{"8123:127.0.0.1:8123"}, httpIP: "127.0.0.1"},
+		{name: "no tunnel", tunnel: false, port: 8123, httpIP: "203.0.113.7"},
+		{name: "no tunnel on every interface", tunnel: false, port: 8123, address: "0.0.0.0", httpIP: "203.0.113.7"},
+		{name: "no tunnel with bind address", tunnel: false, port: 8123, address: "10.0.0.5", httpIP: "10.0.0.5"},
+		{name: "tunnel with bind address", tunnel: true, port: 8123, address: "10.0.0.5", tunnels: []string{"8123:10.0.0.5:8123"}, httpIP: "127.0.0.1"},
+		{name: "tunnel with IPv6 bind address", tunnel: true, port: 8123, address: "::1", tunnels: []string{"8123:[::1]:8123"}, httpIP: "127.0.0.1"},
+		{name: "tunnel on every interface", tunnel: true, port: 8123, address: "0.0.0.0", tunnels: []string{"8123:127.0.0.1:8123"}, httpIP: "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{HTTPReverseTunnel: godo.PtrTo(tt.tunnel), PublicIPURL: ipServer.URL}
+			c.HTTPAddress = tt.address
+
+			state := new(multistep.BasicStateBag)
+			state.Put("ui", packersdk.TestUi(t))
+			state.Put("config", c)
+			state.Put("http_port", tt.port)
+
+			if action := new(stepHTTPTunnel).Run(context.Background(), state); action != multistep.ActionContinue {
+				t.Fatalf("bad action: %#v", action)
+			}
+
+			if len(c.Comm.SSHRemoteTunnels) != len(tt.tunnels) ||
+				(len(tt.tunnels) > 0 && c.Comm.SSHRemoteTunnels[0] != tt.tunnels[0]) {
+				t.Errorf("tunnels: got %v, want %v", c.Comm.SSHRemoteTunnels, tt.tunnels)
+			}
+			httpIP, _ := state.GetOk("http_ip")
+			if tt.httpIP == "" && httpIP != nil {
+				t.Errorf("http_ip should not be set, got %v", httpIP)
+			}
+			if tt.httpIP != "" && httpIP != tt.httpIP {
+				t.Errorf("http_ip: got %v, want %s", httpIP, tt.httpIP)
+			}
+		})
+	}
+}
+
+func TestStepHTTPTunnel_NoPublicIPURL(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", packersdk.TestUi(t))
+	state.Put("config", &Config{HTTPReverseTunnel: godo.PtrTo(false)})
+	state.Put("http_port", 8123)
+
+	if action := new(stepHTTPTunnel).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if httpIP, ok := state.GetOk("http_ip"); ok {
+		t.Errorf("http_ip should not be set, got %v", httpIP)
+	}
+}
+
+func TestStepHTTPTunnel_PublicIPFailure(t *testing.T) {
+	ipServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ipServer.Close()
+
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", packersdk.TestUi(t))
+	state.Put("config", &Config{HTTPReverseTunnel: godo.PtrTo(false), PublicIPURL: ipServer.URL})
+	state.Put("http_port", 8123)
+
+	// The build goes on; only provisioners using PACKER_HTTP_ADDR need it.
+	if action := new(stepHTTPTunnel).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if httpIP, ok := state.GetOk("http_ip"); ok {
+		t.Errorf("http_ip should not be set, got %v", httpIP)
+	}
+}
@@ -0,0 +1,65 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/digitalocean/packer-plugin-digitalocean/version"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepImageRelease writes the image release file set with image_release
+// into the droplet, as root.
+type stepImageRelease struct{}
+
+func (s *stepImageRelease) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if c.ImageRelease == nil {
+		return multistep.ActionContinue
+	}
+
+	comm := state.Get("communicator").(packersdk.Communicator)
+
+	ui.Say(fmt.Sprintf("Writing image release file %s...", c.ImageRelease.Path))
+	contents := imageReleaseContents(c.ImageRelease.fields([]imageReleaseField{
+		{"IMAGE_NAME", c.SnapshotName},
+		{"BUILD_TIME", time.Now().UTC().Format(time.RFC3339)},
+		{"BASE_IMAGE", c.Image},
+		{"BUILD_REGION", c.Region},
+		{"BUILD_SIZE", c.Size},
+		{"PACKER_PLUGIN_VERSION", version.PluginVersion.FormattedVersion()},
+	}))
+
+	p := shellQuote(c.ImageRelease.Path)
+	script := fmt.Sprintf("mkdir -p %s && printf '%%s' %s > %s && chmod 0644 %s",
+		shellQuote(path.Dir(c.ImageRelease.Path)), shellQuote(contents), p, p)
+	cmd := &packersdk.RemoteCmd{Command: asRoot(c.Comm.SSHUsername, script)}
+	if err := cmd.RunWithUi(ctx, comm, ui); err != nil {
+		err := fmt.Errorf("Error writing image release file: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if status := cmd.ExitStatus(); status != 0 {
+		err := fmt.Errorf("Error writing image release file: exited with status %d", status)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepImageRelease) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// imageReleaseField is a line of the image release file.
+type imageReleaseField struct {
+	Key, Value string
+}
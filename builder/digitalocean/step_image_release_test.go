@@ -0,0 +1,53 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepImageRelease(t *testing.T) {
+	tests := []struct {
+		username string
+		sudo     bool
+	}{
+		{username: "ubuntu", sudo: true},
+		{username: "root", sudo: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			comm := new(packersdk.MockCommunicator)
+			var out bytes.Buffer
+			state := new(multistep.BasicStateBag)
+			state.Put("communicator", comm)
+			state.Put("config", &Config{
+				SnapshotName: "packer-1704207845",
+				ImageRelease: &ImageRelease{Path: "/etc/image-release", Version: "1.4.0"},
+				Comm: communicator.Config{
+					SSH: communicator.SSH{SSHUsername: tt.username},
+				},
+			})
+			state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+
+			if action := new(stepImageRelease).Run(context.Background(), state); action != multistep.ActionContinue {
+				t.Fatalf("bad action: %v: %s", action, out.String())
+			}
+
+			command := comm.StartCmd.Command
+			if got := strings.HasPrefix(command, "sudo sh -c "); got != tt.sudo {
+				t.Errorf("sudo: got %t, want %t: %s", got, tt.sudo, command)
+			}
+			for _, want := range []string{"/etc/image-release", "IMAGE_VERSION", "chmod 0644"} {
+				if !strings.Contains(command, want) {
+					t.Errorf("command should contain %q: %s", want, command)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/uuid"
+)
+
+// stepImportSourceImage imports source_image_url as a custom image in the
+// droplet's region and builds from it in place of image. The custom image
+// is deleted once the build is over unless keep_source_image is set.
+type stepImportSourceImage struct {
+	imageID int
+}
+
+func (s *stepImportSourceImage) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if c.SourceImageURL == "" {
+		return multistep.ActionContinue
+	}
+
+	imageURL, err := sourceImageURL(c)
+	if err != nil {
+		err := fmt.Errorf("Error presigning source_image_url: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	name := fmt.Sprintf("packer-%s", uuid.TimeOrderedUUID())
+	ui.Say(fmt.Sprintf("Importing %s as custom image %s in %s...", c.SourceImageURL, name, c.Region))
+	image, _, err := client.Images.Create(ctx, &godo.CustomImageCreateRequest{
+		Name:         name,
+		Url:          imageURL,
+		Region:       c.Region,
+		Distribution: c.SourceImageDistribution,
+		Description:  fmt.Sprintf("Imported from %s", c.SourceImageURL),
+	})
+	if err != nil {
+		err := fmt.Errorf("Error importing source image: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	s.imageID = image.ID
+	log.Printf("Source image ID: %d", image.ID)
+
+	ui.Message("Waiting for the import to complete (may take a while)...")
+	if err := WaitForImageAvailable(ctx, client, image.ID, c.SourceImageTimeout); err != nil {
+		err := fmt.Errorf("Error importing source image: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	c.Image = strconv.Itoa(image.ID)
+	return multistep.ActionContinue
+}
+
+func (s *stepImportSourceImage) Cleanup(state multistep.StateBag) {
+	c := state.Get("config").(*Config)
+	if s.imageID == 0 || c.KeepSourceImage {
+		return
+	}
+
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	ui.Say(fmt.Sprintf("Deleting source image %d...", s.imageID))
+	if _, err := client.Images.Delete(context.TODO(), s.imageID); err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error deleting source image. Please delete it manually: %s", err))
+	}
+}
+
+// sourceImageURL returns the URL the import fetches source_image_url from.
+// Objects in Spaces are fetched through a presigned URL that expires after
+// spaces_url_ttl, so that they don't have to be public.
+func sourceImageURL(c *Config) (string, error) {
+	u, err := url.Parse(c.SourceImageURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "spaces" {
+		return c.SourceImageURL, nil
+	}
+
+	svc, err := SpacesClient(c.SpacesKey, c.SpacesSecret, c.SpacesRegion)
+	if err != nil {
+		return "", err
+	}
+	req, _ := svc.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(u.Host),
+		Key:    aws.String(strings.TrimPrefix(u.Path, "/")),
+	})
+	presigned, err := req.Presign(c.SpacesURLTTL)
+	if err != nil {
+		return "", err
+	}
+	packersdk.LogSecretFilter.Set(presigned)
+	return presigned, nil
+}
@@ -0,0 +1,124 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepImportSourceImage(t *testing.T) {
+	useFakeClock(t)
+
+	var created godo.CustomImageCreateRequest
+	deleted := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/images":
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Error(err)
+			}
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"image": {"id": 42, "status": "NEW"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/images/42":
+			w.Write([]byte(`{"image": {"id": 42, "status": "available"}}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/images/42":
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, keep := range []bool{false, true} {
+		created, deleted = godo.CustomImageCreateRequest{}, false
+
+		var out bytes.Buffer
+		c := &Config{
+			Region:                  "nyc3",
+			SourceImageURL:          "https://example.com/disk.img",
+			SourceImageDistribution: "Ubuntu",
+			SourceImageTimeout:      time.Hour,
+			KeepSourceImage:         keep,
+		}
+		state := new(multistep.BasicStateBag)
+		state.Put("client", client)
+		state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+		state.Put("config", c)
+
+		step := new(stepImportSourceImage)
+		if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+			t.Fatalf("bad action: %v: %s", action, out.String())
+		}
+		if created.Url != c.SourceImageURL || created.Region != "nyc3" || created.Distribution != "Ubuntu" {
+			t.Errorf("bad import request: %+v", created)
+		}
+		if !strings.HasPrefix(created.Name, "packer-") {
+			t.Errorf("bad image name: %s", created.Name)
+		}
+		if c.Image != "42" {
+			t.Errorf("should build from the imported image: %s", c.Image)
+		}
+
+		step.Cleanup(state)
+		if deleted == keep {
+			t.Errorf("keep_source_image %t: deleted %t", keep, deleted)
+		}
+	}
+}
+
+func TestStepImportSourceImage_Skip(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+	state.Put("client", (*godo.Client)(nil))
+	state.Put("ui", &packersdk.BasicUi{Writer: new(bytes.Buffer)})
+	state.Put("config", &Config{Image: "ubuntu-22-04-x64"})
+
+	step := new(stepImportSourceImage)
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v", action)
+	}
+	step.Cleanup(state)
+}
+
+func TestSourceImageURL(t *testing.T) {
+	c := &Config{
+		Region:         "sfo3",
+		SpacesRegion:   "nyc3",
+		SourceImageURL: "spaces://images/disks/base.img.gz",
+		SpacesKey:      "key",
+		SpacesSecret:   "secret",
+		SpacesURLTTL:   time.Hour,
+	}
+	u, err := sourceImageURL(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(u, "https://images.nyc3.digitaloceanspaces.com/disks/base.img.gz?") &&
+		!strings.HasPrefix(u, "https://nyc3.digitaloceanspaces.com/images/disks/base.img.gz?") {
+		t.Errorf("bad presigned URL: %s", u)
+	}
+	if !strings.Contains(u, "X-Amz-Signature=") {
+		t.Errorf("URL should be presigned: %s", u)
+	}
+
+	c.SourceImageURL = "https://example.com/disk.img"
+	if u, err := sourceImageURL(c); err != nil || u != c.SourceImageURL {
+		t.Errorf("bad URL: %s, %v", u, err)
+	}
+}
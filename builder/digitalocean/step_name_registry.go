@@ -0,0 +1,50 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepNameRegistry asks the snapshot_name_registry whether the snapshot
+// name is taken, right before the snapshot is taken.
+type stepNameRegistry struct{}
+
+func (s *stepNameRegistry) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	r := c.SnapshotNameRegistry
+	if r == nil {
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Checking snapshot name %s with the name registry...", c.SnapshotName))
+	client := &http.Client{Timeout: r.Timeout}
+	answer, err := r.lookup(ctx, client, c.SnapshotName)
+	if err != nil {
+		err := fmt.Errorf("Error checking snapshot name with the name registry at %s: %s", r.URL, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if answer.Taken {
+		msg := fmt.Sprintf("snapshot name %s is already taken", c.SnapshotName)
+		if answer.Owner != "" {
+			msg += fmt.Sprintf(" by %s", answer.Owner)
+		}
+		err := fmt.Errorf("Error checking snapshot name with the name registry: %s", msg)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepNameRegistry) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
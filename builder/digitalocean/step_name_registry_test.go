@@ -0,0 +1,83 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepNameRegistry(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch name := r.URL.Query().Get("name"); name {
+		case "web-1.0":
+			fmt.Fprint(w, `{"taken": true, "owner": "team-a"}`)
+		case "web-2.0":
+			fmt.Fprint(w, `{"taken": false}`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	cases := []struct {
+		name    string
+		headers map[string]string
+		err     string
+	}{
+		{"web-2.0", map[string]string{"Authorization": "Bearer secret"}, ""},
+		{"web-1.0", map[string]string{"Authorization": "Bearer secret"}, "snapshot name web-1.0 is already taken by team-a"},
+		{"web-2.0", nil, "401 Unauthorized"},
+		{"web-3.0", map[string]string{"Authorization": "Bearer secret"}, "500 Internal Server Error"},
+	}
+	for _, tc := range cases {
+		c := &Config{
+			SnapshotName: tc.name,
+			SnapshotNameRegistry: &NameRegistry{
+				URL:     ts.URL + "/names",
+				Headers: tc.headers,
+				Timeout: 5 * time.Second,
+			},
+		}
+
+		var out bytes.Buffer
+		state := new(multistep.BasicStateBag)
+		state.Put("config", c)
+		state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+
+		action := new(stepNameRegistry).Run(context.Background(), state)
+		if tc.err == "" {
+			if action != multistep.ActionContinue {
+				t.Fatalf("%s: unexpected halt: %v", tc.name, state.Get("error"))
+			}
+			continue
+		}
+		if action != multistep.ActionHalt {
+			t.Fatalf("%s: expected halt", tc.name)
+		}
+		if err := state.Get("error").(error); !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("%s: unexpected error: %s", tc.name, err)
+		}
+	}
+}
+
+func TestStepNameRegistry_NotSet(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+	state.Put("config", &Config{SnapshotName: "web-1.0"})
+	state.Put("ui", &packersdk.BasicUi{Writer: new(bytes.Buffer)})
+
+	if action := new(stepNameRegistry).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected halt: %v", state.Get("error"))
+	}
+}
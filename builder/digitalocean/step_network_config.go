@@ -0,0 +1,93 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepNetworkConfig records the droplet's network interfaces and VPC before
+// the snapshot is taken, so that consumers of the artifact know what the
+// network configuration baked into the image expects.
+type stepNetworkConfig struct{}
+
+func (s *stepNetworkConfig) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	dropletID := stateDropletID.Get(state)
+
+	if !c.CaptureNetworkConfig {
+		return multistep.ActionContinue
+	}
+
+	ui.Say("Recording droplet network configuration...")
+	droplet, _, err := client.Droplets.Get(context.TODO(), dropletID)
+	if err != nil {
+		err := fmt.Errorf("Error retrieving droplet: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	var vpc *godo.VPC
+	if droplet.VPCUUID != "" {
+		vpc, _, err = client.VPCs.Get(context.TODO(), droplet.VPCUUID)
+		if err != nil {
+			err := fmt.Errorf("Error retrieving VPC %s: %s", droplet.VPCUUID, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	interfaces, vpcInfo := networkConfig(droplet, vpc)
+	stateNetworkInterfaces.Put(state, interfaces)
+	stateNetworkVPC.Put(state, vpcInfo)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepNetworkConfig) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// networkConfig describes the droplet's interfaces and VPC using only types
+// that can be passed to post-processors.
+func networkConfig(droplet *godo.Droplet, vpc *godo.VPC) ([]interface{}, map[string]string) {
+	interfaces := make([]interface{}, 0)
+	if droplet.Networks != nil {
+		for _, n := range droplet.Networks.V4 {
+			interfaces = append(interfaces, map[string]string{
+				"type":       n.Type,
+				"version":    "4",
+				"ip_address": n.IPAddress,
+				"netmask":    n.Netmask,
+				"gateway":    n.Gateway,
+			})
+		}
+		for _, n := range droplet.Networks.V6 {
+			interfaces = append(interfaces, map[string]string{
+				"type":       n.Type,
+				"version":    "6",
+				"ip_address": n.IPAddress,
+				"netmask":    strconv.Itoa(n.Netmask),
+				"gateway":    n.Gateway,
+			})
+		}
+	}
+
+	vpcInfo := map[string]string{}
+	if vpc != nil {
+		vpcInfo["uuid"] = vpc.ID
+		vpcInfo["name"] = vpc.Name
+		vpcInfo["ip_range"] = vpc.IPRange
+		vpcInfo["region"] = vpc.RegionSlug
+	}
+
+	return interfaces, vpcInfo
+}
@@ -0,0 +1,38 @@
+package digitalocean
+
+import (
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkConfig(t *testing.T) {
+	droplet := &godo.Droplet{
+		Networks: &godo.Networks{
+			V4: []godo.NetworkV4{
+				{IPAddress: "203.0.113.10", Netmask: "255.255.240.0", Gateway: "203.0.113.1", Type: "public"},
+				{IPAddress: "10.116.0.2", Netmask: "255.255.240.0", Type: "private"},
+			},
+			V6: []godo.NetworkV6{
+				{IPAddress: "2001:db8::10", Netmask: 64, Gateway: "2001:db8::1", Type: "public"},
+			},
+		},
+	}
+	vpc := &godo.VPC{ID: "vpc-uuid", Name: "default-nyc3", IPRange: "10.116.0.0/20", RegionSlug: "nyc3"}
+
+	interfaces, vpcInfo := networkConfig(droplet, vpc)
+
+	require.Equal(t, []interface{}{
+		map[string]string{"type": "public", "version": "4", "ip_address": "203.0.113.10", "netmask": "255.255.240.0", "gateway": "203.0.113.1"},
+		map[string]string{"type": "private", "version": "4", "ip_address": "10.116.0.2", "netmask": "255.255.240.0", "gateway": ""},
+		map[string]string{"type": "public", "version": "6", "ip_address": "2001:db8::10", "netmask": "64", "gateway": "2001:db8::1"},
+	}, interfaces)
+	require.Equal(t, map[string]string{
+		"uuid": "vpc-uuid", "name": "default-nyc3", "ip_range": "10.116.0.0/20", "region": "nyc3",
+	}, vpcInfo)
+
+	interfaces, vpcInfo = networkConfig(&godo.Droplet{}, nil)
+	require.Empty(t, interfaces)
+	require.Empty(t, vpcInfo)
+}
@@ -0,0 +1,37 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepPauseBeforeSnapshot pauses between powering the droplet off and
+// snapshotting it, for pause_before_snapshot. A pause provisioner can't
+// cover this window, which starts after provisioning.
+type stepPauseBeforeSnapshot struct{}
+
+func (s *stepPauseBeforeSnapshot) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if c.PauseBeforeSnapshot == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Pausing %s before the snapshot...", c.PauseBeforeSnapshot))
+	if err := sleepContext(ctx, c.PauseBeforeSnapshot); err != nil {
+		err := fmt.Errorf("Cancelled pausing before the snapshot")
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepPauseBeforeSnapshot) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
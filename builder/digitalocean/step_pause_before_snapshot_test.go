@@ -0,0 +1,37 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepPauseBeforeSnapshot(t *testing.T) {
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{PauseBeforeSnapshot: 10 * time.Millisecond})
+
+	step := new(stepPauseBeforeSnapshot)
+	start := time.Now()
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("should have paused, took %s", elapsed)
+	}
+
+	state.Put("config", &Config{PauseBeforeSnapshot: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if action := step.Run(ctx, state); action != multistep.ActionHalt {
+		t.Fatalf("a cancelled pause should halt: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+}
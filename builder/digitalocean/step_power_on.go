@@ -0,0 +1,37 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepPowerOn powers the droplet given with droplet_id back on once the
+// snapshot is taken, for leave_droplet_running. The snapshot is already
+// taken by then, so failures are only warnings.
+type stepPowerOn struct{}
+
+func (s *stepPowerOn) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	dropletId := stateDropletID.Get(state)
+
+	ui.Say("Powering the droplet back on...")
+	_, _, err := client.DropletActions.PowerOn(ctx, dropletId)
+	if err == nil {
+		err = waitForDropletState(ctx, "active", dropletId, client, c.StateTimeout)
+	}
+	if err != nil {
+		ui.Error(fmt.Sprintf("Warning: Error powering droplet %d back on: %s", dropletId, err))
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepPowerOn) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
@@ -0,0 +1,57 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepPowerOn(t *testing.T) {
+	useFakeClock(t)
+
+	status := "off"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/droplets/3164444/actions":
+			status = "active"
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"action": {"id": 1, "status": "in-progress"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/droplets/3164444":
+			w.Write([]byte(`{"droplet": {"id": 3164444, "status": "` + status + `"}}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("config", &Config{StateTimeout: time.Minute})
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	stateDropletID.Put(state, 3164444)
+
+	if action := new(stepPowerOn).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v", action)
+	}
+	if status != "active" {
+		t.Fatal("should have powered the droplet on")
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatalf("should not have error: %s", out.String())
+	}
+}
@@ -0,0 +1,56 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepProjectName looks up the ID of the project named with project_name,
+// so that an unknown project fails the build before the droplet is created.
+type stepProjectName struct{}
+
+func (s *stepProjectName) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if c.ProjectName == "" {
+		return multistep.ActionContinue
+	}
+
+	project, err := findProject(client, c.ProjectName)
+	if err != nil {
+		err := fmt.Errorf("Error finding project %q: %s", c.ProjectName, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Message(fmt.Sprintf("Using project %s (%s)", project.Name, project.ID))
+	stateNamedProjectID.Put(state, project.ID)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepProjectName) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// findProject returns the project named name.
+func findProject(client *godo.Client, name string) (*godo.Project, error) {
+	projects, err := ListAll(context.TODO(), client.Projects.List)
+	if err != nil {
+		return nil, err
+	}
+	for i := range projects {
+		if projects[i].Name == name {
+			return &projects[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no project with that name")
+}
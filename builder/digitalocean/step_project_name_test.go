@@ -0,0 +1,53 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepProjectName(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v2/projects" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"projects": [
+			{"id": "4e1bfbc3-dc3e-41f2-a18f-1b4d7ba71679", "name": "default"},
+			{"id": "b7cd4ac4-3a1a-4c36-9e2b-3cc4ce1e8d55", "name": "platform-images"}
+		]}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{ProjectName: "platform-images"})
+
+	if action := new(stepProjectName).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	if id := stateNamedProjectID.Get(state); id != "b7cd4ac4-3a1a-4c36-9e2b-3cc4ce1e8d55" {
+		t.Fatalf("bad project ID: %s", id)
+	}
+
+	// An unknown project
+	state.Put("config", &Config{ProjectName: "data"})
+	if action := new(stepProjectName).Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %v", action)
+	}
+}
@@ -0,0 +1,273 @@
+package digitalocean
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"golang.org/x/crypto/ssh"
+)
+
+// stepProvisionReconnect wraps the communicator used by the provisioners so
+// that a connection dropped by a transient network event is re-established
+// and the failed operation retried, up to provision_reconnect_attempts
+// times per operation.
+type stepProvisionReconnect struct {
+	// Connect is run again to re-establish the connection. It must put a new
+	// communicator in the state, as communicator.StepConnect does.
+	Connect multistep.Step
+}
+
+func (s *stepProvisionReconnect) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	c := state.Get("config").(*Config)
+
+	stateProvisionReconnects.Put(state, 0)
+	if c.ProvisionReconnectAttempts == 0 {
+		return multistep.ActionContinue
+	}
+
+	comm, ok := state.GetOk("communicator")
+	if !ok {
+		return multistep.ActionContinue
+	}
+
+	r := &reconnectingCommunicator{
+		comm:        comm.(packersdk.Communicator),
+		maxAttempts: c.ProvisionReconnectAttempts,
+		ui:          state.Get("ui").(packersdk.Ui),
+		state:       state,
+	}
+	r.reconnect = func() (packersdk.Communicator, error) {
+		// The build context, so that cancelling the build stops waiting for
+		// the droplet.
+		action := s.Connect.Run(ctx, state)
+
+		// Connect replaces the communicator in the state, but the
+		// provisioners must keep going through this one.
+		comm, _ := state.GetOk("communicator")
+		state.Put("communicator", r)
+
+		if action != multistep.ActionContinue {
+			if err, ok := state.GetOk("error"); ok {
+				return nil, err.(error)
+			}
+			return nil, errors.New("connecting was cancelled")
+		}
+		return comm.(packersdk.Communicator), nil
+	}
+	state.Put("communicator", r)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepProvisionReconnect) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// reconnectingCommunicator retries operations that fail because the
+// connection to the droplet dropped. Commands that were already running
+// when the connection dropped are not run again, since they may not be
+// safe to repeat: they exit with packersdk.CmdDisconnect, as the SSH
+// communicator reports them, and the next operation reconnects first.
+type reconnectingCommunicator struct {
+	comm        packersdk.Communicator
+	maxAttempts int
+	reconnect   func() (packersdk.Communicator, error)
+	ui          packersdk.Ui
+	state       multistep.StateBag
+
+	mu sync.Mutex
+	// lost is the communicator a command was running on when the
+	// connection dropped.
+	lost packersdk.Communicator
+}
+
+var _ packersdk.Communicator = new(reconnectingCommunicator)
+
+func (r *reconnectingCommunicator) Start(ctx context.Context, cmd *packersdk.RemoteCmd) error {
+	return r.retry(func(comm packersdk.Communicator) error {
+		if err := comm.Start(ctx, cmd); err != nil {
+			return err
+		}
+		go func() {
+			if cmd.Wait() == packersdk.CmdDisconnect {
+				log.Printf("[DEBUG] Connection to droplet lost while running %q", cmd.Command)
+				r.mu.Lock()
+				r.lost = comm
+				r.mu.Unlock()
+			}
+		}()
+		return nil
+	}, nil)
+}
+
+func (r *reconnectingCommunicator) Upload(dst string, src io.Reader, fi *os.FileInfo) error {
+	seeker, canRewind := src.(io.Seeker)
+	return r.retry(func(comm packersdk.Communicator) error {
+		return comm.Upload(dst, src, fi)
+	}, func() bool {
+		if !canRewind {
+			return false
+		}
+		_, err := seeker.Seek(0, io.SeekStart)
+		return err == nil
+	})
+}
+
+func (r *reconnectingCommunicator) UploadDir(dst string, src string, exclude []string) error {
+	return r.retry(func(comm packersdk.Communicator) error {
+		return comm.UploadDir(dst, src, exclude)
+	}, nil)
+}
+
+func (r *reconnectingCommunicator) Download(src string, dst io.Writer) error {
+	f, canRewind := dst.(*os.File)
+	return r.retry(func(comm packersdk.Communicator) error {
+		return comm.Download(src, dst)
+	}, func() bool {
+		if !canRewind {
+			return false
+		}
+		if err := f.Truncate(0); err != nil {
+			return false
+		}
+		_, err := f.Seek(0, io.SeekStart)
+		return err == nil
+	})
+}
+
+func (r *reconnectingCommunicator) DownloadDir(src string, dst string, exclude []string) error {
+	return r.retry(func(comm packersdk.Communicator) error {
+		return comm.DownloadDir(src, dst, exclude)
+	}, nil)
+}
+
+// retry runs op, reconnecting and running it again while it fails with a
+// connection error. It reconnects first when a command lost the current
+// connection. rewind, if set, prepares op to be run again and reports
+// whether that is possible.
+func (r *reconnectingCommunicator) retry(op func(packersdk.Communicator) error, rewind func() bool) error {
+	r.mu.Lock()
+	lost := r.lost
+	lostCurrent := lost != nil && lost == r.comm
+	r.mu.Unlock()
+	if lostCurrent {
+		r.ui.Say("Connection to droplet lost while running a command; reconnecting...")
+		if err := r.reconnectOnce(lost); err != nil {
+			log.Printf("[DEBUG] Reconnect failed: %s", err)
+			return fmt.Errorf("Error reconnecting to droplet: %s", err)
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		r.mu.Lock()
+		comm := r.comm
+		r.mu.Unlock()
+
+		err := op(comm)
+		if err == nil || !isConnectionError(err) || attempt > r.maxAttempts {
+			return err
+		}
+		if rewind != nil && !rewind() {
+			return err
+		}
+
+		r.ui.Say(fmt.Sprintf("Connection to droplet lost (%s); reconnecting (attempt %d/%d)...",
+			err, attempt, r.maxAttempts))
+		if err := r.reconnectOnce(comm); err != nil {
+			log.Printf("[DEBUG] Reconnect failed: %s", err)
+			return fmt.Errorf("Error reconnecting to droplet: %s", err)
+		}
+	}
+}
+
+// reconnectOnce replaces the communicator unless another operation already
+// replaced the failed one.
+func (r *reconnectingCommunicator) reconnectOnce(failed packersdk.Communicator) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.comm != failed {
+		return nil
+	}
+
+	// The communicators of the SDK can't be closed, but don't leak the
+	// connection of those that can.
+	if closer, ok := failed.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("[DEBUG] Error closing the lost connection: %s", err)
+		}
+	}
+
+	comm, err := r.reconnect()
+	if err != nil {
+		return err
+	}
+	r.comm = comm
+	stateProvisionReconnects.Put(r.state, stateProvisionReconnects.Get(r.state)+1)
+	return nil
+}
+
+// connectionErrorMessages are the messages of connection errors the SSH
+// communicator of the SDK flattens into new errors with %s, such as
+// "sftpSession error: EOF".
+var connectionErrorMessages = []string{
+	"wait: remote command exited without exit status or exit signal",
+	"connection reset by peer",
+	"connection refused",
+	"broken pipe",
+	"use of closed network connection",
+	"no route to host",
+	"network is unreachable",
+	"i/o timeout",
+	"client not available",
+}
+
+// isConnectionError reports whether err was caused by the connection to the
+// droplet dropping rather than by the operation itself.
+func isConnectionError(err error) bool {
+	// A transfer cut off mid-way, whose session ends without an exit
+	// status.
+	var exitMissing *ssh.ExitMissingError
+	if errors.As(err, &exitMissing) {
+		return true
+	}
+
+	msg := err.Error()
+	if msg == "EOF" || strings.HasSuffix(msg, ": EOF") || strings.HasSuffix(msg, ": unexpected EOF") {
+		return true
+	}
+	for _, m := range connectionErrorMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+
+	for _, target := range []error{
+		io.EOF,
+		io.ErrUnexpectedEOF,
+		net.ErrClosed,
+		syscall.EPIPE,
+		syscall.ECONNRESET,
+		syscall.ECONNABORTED,
+		syscall.ECONNREFUSED,
+		syscall.EHOSTUNREACH,
+		syscall.ENETUNREACH,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
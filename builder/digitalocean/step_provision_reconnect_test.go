@@ -0,0 +1,319 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	sshcomm "github.com/hashicorp/packer-plugin-sdk/sdk-internals/communicator/ssh"
+	"golang.org/x/crypto/ssh"
+)
+
+// flakyCommunicator fails uploads with a connection error while the shared
+// failure count is positive.
+type flakyCommunicator struct {
+	packersdk.MockCommunicator
+	failures *int
+	closed   bool
+}
+
+func (c *flakyCommunicator) Upload(dst string, src io.Reader, fi *os.FileInfo) error {
+	if *c.failures > 0 {
+		*c.failures--
+		return &net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)}
+	}
+	return c.MockCommunicator.Upload(dst, src, fi)
+}
+
+func (c *flakyCommunicator) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestReconnectingCommunicator_Upload(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int
+		maxAttempts int
+		reconnects  int
+		wantErr     bool
+	}{
+		{name: "no failures", failures: 0, maxAttempts: 2, reconnects: 0},
+		{name: "recovers", failures: 2, maxAttempts: 2, reconnects: 2},
+		{name: "exhausted", failures: 3, maxAttempts: 2, reconnects: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := new(multistep.BasicStateBag)
+			state.Put("provision_reconnects", 0)
+
+			failures := tt.failures
+			r := &reconnectingCommunicator{
+				comm:        &flakyCommunicator{failures: &failures},
+				maxAttempts: tt.maxAttempts,
+				reconnect: func() (packersdk.Communicator, error) {
+					return &flakyCommunicator{failures: &failures}, nil
+				},
+				ui:    packersdk.TestUi(t),
+				state: state,
+			}
+
+			err := r.Upload("/tmp/file", bytes.NewReader([]byte("data")), nil)
+			if tt.wantErr && err == nil {
+				t.Fatal("should have error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("should not have error: %s", err)
+			}
+			if got := state.Get("provision_reconnects").(int); got != tt.reconnects {
+				t.Errorf("reconnects: got %d, want %d", got, tt.reconnects)
+			}
+		})
+	}
+}
+
+// stepFakeConnect puts a new communicator in the state, as
+// communicator.StepConnect does.
+type stepFakeConnect struct {
+	failures *int
+	ctx      context.Context
+	comms    []*flakyCommunicator
+}
+
+func (s *stepFakeConnect) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	s.ctx = ctx
+	comm := &flakyCommunicator{failures: s.failures}
+	s.comms = append(s.comms, comm)
+	state.Put("communicator", comm)
+	return multistep.ActionContinue
+}
+
+func (s *stepFakeConnect) Cleanup(state multistep.StateBag) {}
+
+func TestStepProvisionReconnect(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "build")
+
+	failures := 1
+	connect := &stepFakeConnect{failures: &failures}
+	first := &flakyCommunicator{failures: &failures}
+
+	state := new(multistep.BasicStateBag)
+	state.Put("config", &Config{ProvisionReconnectAttempts: 2})
+	state.Put("ui", packersdk.TestUi(t))
+	state.Put("communicator", first)
+
+	step := &stepProvisionReconnect{Connect: connect}
+	if action := step.Run(ctx, state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+
+	comm := state.Get("communicator").(packersdk.Communicator)
+	if err := comm.Upload("/tmp/file", bytes.NewReader([]byte("data")), nil); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	if connect.ctx.Value(ctxKey{}) != "build" {
+		t.Error("should reconnect with the build context")
+	}
+	if !first.closed {
+		t.Error("should close the lost connection")
+	}
+	if len(connect.comms) != 1 || connect.comms[0].closed {
+		t.Errorf("should use the new connection: %#v", connect.comms)
+	}
+	if state.Get("communicator") != comm {
+		t.Error("should keep the reconnecting communicator in the state")
+	}
+	if got := stateProvisionReconnects.Get(state); got != 1 {
+		t.Errorf("reconnects: got %d, want 1", got)
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{fmt.Errorf("error reading output: %w", io.EOF), true},
+		{net.ErrClosed, true},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}, true},
+		{&ssh.ExitMissingError{}, true},
+		{errors.New("sftpSession error: EOF"), true},
+		{errors.New("sftpSession error: dial tcp 203.0.113.7:22: connect: connection refused"), true},
+		{errors.New("scp: /etc/shadow: Permission denied"), false},
+		{&ssh.ExitError{Waitmsg: ssh.Waitmsg{}}, false},
+		{errors.New("script failed: unexpected EOF while looking for matching quote"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isConnectionError(tt.err); got != tt.want {
+			t.Errorf("%q: got %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
+
+// startCommandSSHServer starts an SSH server on the loopback interface that
+// runs commands and takes scp uploads, except that its n-th connection, from
+// 1, drops as soon as a command starts if drop(n). It returns the server's
+// address.
+func startCommandSSHServer(t *testing.T, drop func(n int) bool) string {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(hostSigner)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	var conns int32
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			n := int(atomic.AddInt32(&conns, 1))
+			go func() {
+				defer conn.Close()
+				sconn, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newCh := range chans {
+					ch, chReqs, err := newCh.Accept()
+					if err != nil {
+						return
+					}
+					go func() {
+						for req := range chReqs {
+							if req.Type != "exec" {
+								req.Reply(false, nil)
+								continue
+							}
+							req.Reply(true, nil)
+							if drop(n) {
+								conn.Close()
+								return
+							}
+							command := string(req.Payload[4:])
+							if strings.HasPrefix(command, "scp ") {
+								// Acknowledge every step of the upload.
+								ch.Write(make([]byte, 8))
+								io.Copy(io.Discard, ch)
+							}
+							status := make([]byte, 4)
+							binary.BigEndian.PutUint32(status, 0)
+							ch.SendRequest("exit-status", false, status)
+							ch.Close()
+						}
+					}()
+				}
+				sconn.Wait()
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func newTestSSHCommunicator(t *testing.T, addr string) packersdk.Communicator {
+	comm, err := sshcomm.New(addr, &sshcomm.Config{
+		SSHConfig: &ssh.ClientConfig{
+			User:            "root",
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		},
+		Connection: func() (net.Conn, error) {
+			return net.Dial("tcp", addr)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return comm
+}
+
+func TestIsConnectionError_SSHCommunicator(t *testing.T) {
+	addr := startCommandSSHServer(t, func(int) bool { return true })
+	comm := newTestSSHCommunicator(t, addr)
+
+	err := comm.Upload("/tmp/file", bytes.NewReader([]byte("data")), nil)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+	if !isConnectionError(err) {
+		t.Errorf("%q should be a connection error", err)
+	}
+}
+
+func TestReconnectingCommunicator_CommandDisconnect(t *testing.T) {
+	// The first connection drops while the command runs.
+	addr := startCommandSSHServer(t, func(n int) bool { return n == 1 })
+
+	state := new(multistep.BasicStateBag)
+	stateProvisionReconnects.Put(state, 0)
+	r := &reconnectingCommunicator{
+		comm:        newTestSSHCommunicator(t, addr),
+		maxAttempts: 1,
+		reconnect: func() (packersdk.Communicator, error) {
+			return newTestSSHCommunicator(t, addr), nil
+		},
+		ui:    packersdk.TestUi(t),
+		state: state,
+	}
+
+	cmd := &packersdk.RemoteCmd{Command: "reboot"}
+	if err := r.Start(context.Background(), cmd); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if status := cmd.Wait(); status != packersdk.CmdDisconnect {
+		t.Fatalf("exit status: got %d, want %d", status, packersdk.CmdDisconnect)
+	}
+	// The exit status reaches the provisioner and the communicator at once.
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		r.mu.Lock()
+		lost := r.lost != nil
+		r.mu.Unlock()
+		if lost {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the connection should be marked as lost")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The command isn't run again, but the next operation reconnects.
+	if err := r.Upload("/tmp/file", bytes.NewReader([]byte("data")), nil); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if got := stateProvisionReconnects.Get(state); got != 1 {
+		t.Errorf("reconnects: got %d, want 1", got)
+	}
+}
@@ -0,0 +1,64 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// trimCommand discards the unused blocks of every mounted filesystem that
+// supports it.
+const trimCommand = "fstrim --all --verbose"
+
+// zeroFillCommand fills the root filesystem's free space with zeros and
+// removes the fill file. dd fails once the filesystem is full, which is
+// how it's meant to stop.
+const zeroFillCommand = "dd if=/dev/zero of=/packer-zero.fill bs=1M 2>/dev/null; " +
+	"sync; rm -f /packer-zero.fill && sync"
+
+// stepReclaimFreeSpace reclaims the droplet's free space before the shutdown
+// with reclaim_free_space, so that the blocks deleted files leave behind
+// don't make the snapshot larger than its contents.
+type stepReclaimFreeSpace struct{}
+
+func (s *stepReclaimFreeSpace) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	var script string
+	switch c.ReclaimFreeSpace {
+	case ReclaimFreeSpaceTrim:
+		ui.Say("Trimming the droplet's filesystems...")
+		script = trimCommand
+	case ReclaimFreeSpaceZero:
+		ui.Say("Zeroing the free space of the droplet's root filesystem...")
+		script = zeroFillCommand
+	default:
+		return multistep.ActionContinue
+	}
+
+	comm := state.Get("communicator").(packersdk.Communicator)
+
+	cmd := &packersdk.RemoteCmd{Command: asRoot(c.Comm.SSHUsername, script)}
+	err := cmd.RunWithUi(ctx, comm, ui)
+	if err == nil && cmd.ExitStatus() != 0 {
+		err = fmt.Errorf("exited with status %d", cmd.ExitStatus())
+	}
+	if err != nil && c.ReclaimFreeSpace == ReclaimFreeSpaceTrim {
+		// The snapshot is only larger without it
+		ui.Error(fmt.Sprintf("Warning: Error trimming the droplet's filesystems: %s", err))
+	} else if err != nil {
+		err := fmt.Errorf("Error zeroing the droplet's free space: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepReclaimFreeSpace) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
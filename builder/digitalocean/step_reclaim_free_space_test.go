@@ -0,0 +1,52 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepReclaimFreeSpace(t *testing.T) {
+	cases := []struct {
+		name    string
+		reclaim string
+		status  int
+		command string
+		halt    bool
+	}{
+		{name: "trim", reclaim: ReclaimFreeSpaceTrim, command: "sh -c 'fstrim --all --verbose'"},
+		{name: "trim failure", reclaim: ReclaimFreeSpaceTrim, status: 1, command: "fstrim"},
+		{name: "zero", reclaim: ReclaimFreeSpaceZero, command: "dd if=/dev/zero"},
+		{name: "zero failure", reclaim: ReclaimFreeSpaceZero, status: 1, command: "dd if=/dev/zero", halt: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			comm := &packersdk.MockCommunicator{StartExitStatus: tc.status}
+			var out bytes.Buffer
+			state := new(multistep.BasicStateBag)
+			state.Put("communicator", comm)
+			state.Put("config", &Config{
+				ReclaimFreeSpace: tc.reclaim,
+				Comm:             communicator.Config{SSH: communicator.SSH{SSHUsername: "root"}},
+			})
+			state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+
+			action := new(stepReclaimFreeSpace).Run(context.Background(), state)
+			if tc.halt != (action == multistep.ActionHalt) {
+				t.Fatalf("bad action: %v: %s", action, out.String())
+			}
+			if _, ok := state.GetOk("error"); ok != tc.halt {
+				t.Fatalf("unexpected error state: %s", out.String())
+			}
+			if !strings.Contains(comm.StartCmd.Command, tc.command) {
+				t.Errorf("command should contain %q: %s", tc.command, comm.StartCmd.Command)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepReplaceSnapshots deletes the snapshots stepSnapshotNameCheck found
+// under the snapshot's name, now that the new one exists. Each is fetched
+// again first, so one locked or deleted during the build is left alone.
+// Failing to delete one is only reported: the new snapshot is good, and
+// failing the build would lose track of it.
+type stepReplaceSnapshots struct{}
+
+func (s *stepReplaceSnapshots) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	images, ok := stateReplacedImages.GetOk(state)
+	if !ok {
+		return multistep.ActionContinue
+	}
+
+	for _, image := range images {
+		current, resp, err := client.Images.GetByID(ctx, image.ID)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error looking up replaced snapshot %d, delete it manually: %s", image.ID, err))
+			continue
+		}
+		if IsLocked(current) {
+			ui.Message(fmt.Sprintf("Warning: not deleting replaced snapshot %d (%s): it is now tagged %q",
+				image.ID, image.Name, LockedTag))
+			continue
+		}
+
+		ui.Say(fmt.Sprintf("Deleting replaced snapshot %d (%s)...", image.ID, image.Name))
+		if _, err := client.Images.Delete(ctx, image.ID); err != nil {
+			ui.Error(fmt.Sprintf("Error deleting replaced snapshot %d, delete it manually: %s", image.ID, err))
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepReplaceSnapshots) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
@@ -0,0 +1,81 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepReplaceSnapshots(t *testing.T) {
+	var deleted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v2/images/") {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/v2/images/")
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			switch id {
+			case "5":
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"id": "not_found", "message": "The resource you were accessing could not be found."}`))
+			case "7":
+				w.Write([]byte(`{"image": {"id": 7, "name": "web", "tags": ["locked"]}}`))
+			default:
+				w.Write([]byte(`{"image": {"id": ` + id + `, "name": "web", "tags": []}}`))
+			}
+		case http.MethodDelete:
+			deleted = append(deleted, id)
+			if id == "3" {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"id": "server_error", "message": "Server was unable to give you a response."}`))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	// 5 was deleted and 7 locked since stepSnapshotNameCheck listed them.
+	stateReplacedImages.Put(state, []godo.Image{{ID: 3, Name: "web"}, {ID: 5, Name: "web"}, {ID: 7, Name: "web"}, {ID: 1, Name: "web"}})
+
+	step := new(stepReplaceSnapshots)
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	if !reflect.DeepEqual(deleted, []string{"3", "1"}) {
+		t.Errorf("bad deleted snapshots: %v", deleted)
+	}
+	if !strings.Contains(out.String(), "Error deleting replaced snapshot 3") {
+		t.Errorf("the failed deletion should be reported: %s", out.String())
+	}
+	if !strings.Contains(out.String(), `not deleting replaced snapshot 7 (web): it is now tagged "locked"`) {
+		t.Errorf("the locked snapshot should be reported: %s", out.String())
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Error("a failed deletion should not fail the build")
+	}
+}
@@ -0,0 +1,111 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+)
+
+// stepReservedIP assigns the reserved IP set with reserved_ip, or a new one
+// when assign_reserved_ip is set, to the droplet, so that it has a known
+// public address while it is provisioned.
+type stepReservedIP struct {
+	ip       string
+	assigned bool
+}
+
+func (s *stepReservedIP) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	dropletID := stateDropletID.Get(state)
+
+	if c.ReservedIP == "" && !c.AssignReservedIP {
+		return multistep.ActionContinue
+	}
+
+	s.ip = c.ReservedIP
+	if c.AssignReservedIP {
+		ui.Say(fmt.Sprintf("Creating reserved IP in %s...", c.Region))
+		reservedIP, _, err := client.ReservedIPs.Create(context.TODO(), &godo.ReservedIPCreateRequest{
+			Region: c.Region,
+		})
+		if err != nil {
+			err := fmt.Errorf("Error creating reserved IP: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		s.ip = reservedIP.IP
+		stateReservedIPCreated.Put(state, s.ip)
+	}
+
+	ui.Say(fmt.Sprintf("Assigning reserved IP %s to droplet...", s.ip))
+	action, _, err := client.ReservedIPActions.Assign(context.TODO(), s.ip, dropletID)
+	if err != nil {
+		err := fmt.Errorf("Error assigning reserved IP %s: %s", s.ip, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	s.assigned = true
+
+	if err := waitForReservedIPAction(ctx, godo.ActionCompleted, s.ip, action.ID, client, c.StateTimeout); err != nil {
+		err := fmt.Errorf("Error waiting for reserved IP %s to be assigned: %s", s.ip, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	stateReservedIP.Put(state, s.ip)
+	generatedData := &packerbuilderdata.GeneratedData{State: state}
+	generatedData.Put("ReservedIP", s.ip)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepReservedIP) Cleanup(state multistep.StateBag) {
+	if s.ip == "" {
+		return
+	}
+
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if s.assigned {
+		ui.Say(fmt.Sprintf("Unassigning reserved IP %s...", s.ip))
+		var action *godo.Action
+		err := retryCleanup(state, "reserved IP assignment "+s.ip, func() (*godo.Response, error) {
+			var resp *godo.Response
+			var err error
+			action, resp, err = client.ReservedIPActions.Unassign(context.TODO(), s.ip)
+			return resp, err
+		})
+		if err != nil {
+			ui.Error(fmt.Sprintf(
+				"Error unassigning reserved IP %s. Please unassign it manually: %s", s.ip, err))
+		} else if action != nil {
+			if err := waitForReservedIPAction(context.TODO(), godo.ActionCompleted, s.ip, action.ID, client, c.StateTimeout); err != nil {
+				ui.Error(fmt.Sprintf("Error waiting for reserved IP %s to be unassigned: %s", s.ip, err))
+			}
+		}
+	}
+
+	if _, ok := stateReservedIPCreated.GetOk(state); !ok {
+		return
+	}
+
+	ui.Say(fmt.Sprintf("Releasing reserved IP %s...", s.ip))
+	err := retryCleanup(state, "reserved IP "+s.ip, func() (*godo.Response, error) {
+		return client.ReservedIPs.Delete(context.TODO(), s.ip)
+	})
+	if err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error releasing reserved IP %s. Please release it manually: %s", s.ip, err))
+	}
+}
@@ -0,0 +1,77 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepReservedIP(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/reserved_ips":
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"reserved_ip": {"ip": "45.55.96.47", "region": {"slug": "nyc3"}}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/reserved_ips/45.55.96.47/actions":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"action": {"id": 68212728, "status": "in-progress"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/reserved_ips/45.55.96.47/actions/68212728":
+			w.Write([]byte(`{"action": {"id": 68212728, "status": "completed"}}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/reserved_ips/45.55.96.47":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{Region: "nyc3", AssignReservedIP: true, StateTimeout: time.Minute})
+	state.Put("droplet_id", 3164444)
+
+	step := new(stepReservedIP)
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	if ip := state.Get("reserved_ip"); ip != "45.55.96.47" {
+		t.Fatalf("bad reserved ip: %v", ip)
+	}
+
+	step.Cleanup(state)
+
+	expected := []string{
+		"POST /v2/reserved_ips",
+		"POST /v2/reserved_ips/45.55.96.47/actions",
+		"GET /v2/reserved_ips/45.55.96.47/actions/68212728",
+		"POST /v2/reserved_ips/45.55.96.47/actions",
+		"GET /v2/reserved_ips/45.55.96.47/actions/68212728",
+		"DELETE /v2/reserved_ips/45.55.96.47",
+	}
+	if len(requests) != len(expected) {
+		t.Fatalf("bad requests: %v", requests)
+	}
+	for i := range expected {
+		if requests[i] != expected[i] {
+			t.Fatalf("bad requests: %v", requests)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepRetainDroplet keeps the powered-off droplet instead of destroying it
+// when the build finishes, so that it can be the artifact.
+type stepRetainDroplet struct{}
+
+func (s *stepRetainDroplet) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	dropletId := stateDropletID.Get(state)
+
+	ui.Say(fmt.Sprintf("Retaining droplet %d as the artifact...", dropletId))
+	stateDropletRetained.Put(state, true)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepRetainDroplet) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
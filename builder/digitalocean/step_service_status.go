@@ -0,0 +1,117 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepServiceStatus checks the DigitalOcean status page for incidents and
+// maintenance in the build's regions with check_service_status, before
+// the build and before the snapshot. It reports them, and with
+// service_status_wait pauses until they are over. An unreachable status
+// page doesn't fail the build.
+type stepServiceStatus struct {
+	// Before describes what the check comes before, for the output.
+	Before string
+	// ReportFailure reports the incidents seen during the build if it
+	// fails, so that the failure can be put down to them.
+	ReportFailure bool
+}
+
+func (s *stepServiceStatus) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if !c.CheckServiceStatus {
+		return multistep.ActionContinue
+	}
+
+	regions := append([]string{c.Region}, c.SnapshotRegions...)
+	client := &http.Client{Timeout: statusPageTimeout}
+	incidents, err := regionIncidents(ctx, client, c.ServiceStatusURL, regions)
+	if err != nil {
+		log.Printf("[DEBUG] Error checking the DigitalOcean status page: %s", err)
+		return multistep.ActionContinue
+	}
+	if len(incidents) == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui.Error(fmt.Sprintf("Warning: DigitalOcean reports incidents in %v before %s:", regions, s.Before))
+	for _, i := range incidents {
+		ui.Error("  " + i.String())
+	}
+	recordIncidents(state, incidents)
+
+	if c.ServiceStatusWait == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Waiting up to %s for the incidents to be over...", c.ServiceStatusWait))
+	err = PollEvery(ctx, statusPollInterval, c.ServiceStatusWait, func(ctx context.Context, attempt int) (bool, error) {
+		incidents, err := regionIncidents(ctx, client, c.ServiceStatusURL, regions)
+		if err != nil {
+			log.Printf("[DEBUG] Error checking the DigitalOcean status page (attempt %d): %s", attempt, err)
+			return false, nil
+		}
+		recordIncidents(state, incidents)
+		return len(incidents) == 0, nil
+	})
+	switch err {
+	case nil:
+		ui.Message("The incidents are over")
+	case ErrPollTimeout:
+		ui.Error(fmt.Sprintf("Warning: the incidents are still ongoing after %s, continuing", c.ServiceStatusWait))
+	default:
+		err := fmt.Errorf("Error waiting for the incidents to be over: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepServiceStatus) Cleanup(state multistep.StateBag) {
+	if !s.ReportFailure {
+		return
+	}
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	incidents, ok := stateServiceIncidents.GetOk(state)
+	if cancelled || !halted || !ok {
+		return
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	ui.Error("The build failed while DigitalOcean reported incidents in its regions, which may have caused it:")
+	for _, i := range incidents {
+		ui.Error("  " + i.String())
+	}
+}
+
+// recordIncidents adds the incidents to the ones seen during the build.
+func recordIncidents(state multistep.StateBag, incidents []serviceIncident) {
+	var seen []serviceIncident
+	if v, ok := stateServiceIncidents.GetOk(state); ok {
+		seen = v
+	}
+	for _, i := range incidents {
+		known := false
+		for _, s := range seen {
+			if s.Name == i.Name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			seen = append(seen, i)
+		}
+	}
+	stateServiceIncidents.Put(state, seen)
+}
@@ -0,0 +1,125 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+const testStatusSummary = `{
+  "incidents": [
+    {"name": "Droplet creation delays in NYC3", "status": "investigating", "impact": "minor",
+     "shortlink": "https://stspg.io/abc", "components": [{"name": "Droplets"}]},
+    {"name": "Networking issues", "status": "monitoring", "impact": "major",
+     "components": [{"name": "NYC1"}]}
+  ],
+  "scheduled_maintenances": [
+    {"name": "Network maintenance in AMS3", "status": "scheduled", "components": []},
+    {"name": "Storage maintenance", "status": "in_progress", "components": [{"name": "SFO3"}]}
+  ]
+}`
+
+func TestRegionIncidents(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/summary.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, testStatusSummary)
+	}))
+	defer ts.Close()
+
+	cases := []struct {
+		regions  []string
+		expected []string
+	}{
+		{[]string{"nyc3"}, []string{"Droplet creation delays in NYC3"}},
+		{[]string{"nyc10"}, nil},
+		{[]string{"nyc1", "sfo3"}, []string{"Networking issues", "Storage maintenance"}},
+		{[]string{"ams3"}, nil},
+	}
+	for _, tc := range cases {
+		incidents, err := regionIncidents(context.Background(), http.DefaultClient, ts.URL, tc.regions)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %s", tc.regions, err)
+		}
+		var names []string
+		for _, i := range incidents {
+			names = append(names, i.Name)
+		}
+		if fmt.Sprint(names) != fmt.Sprint(tc.expected) {
+			t.Errorf("%v: got %v, want %v", tc.regions, names, tc.expected)
+		}
+	}
+}
+
+func TestStepServiceStatus(t *testing.T) {
+	clock := useFakeClock(t)
+	start := clock.now
+
+	resolved := start.Add(3 * time.Minute)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if clock.now.Before(resolved) {
+			fmt.Fprint(w, testStatusSummary)
+			return
+		}
+		fmt.Fprint(w, `{"incidents": [], "scheduled_maintenances": []}`)
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("config", &Config{
+		Region:             "nyc3",
+		CheckServiceStatus: true,
+		ServiceStatusWait:  30 * time.Minute,
+		ServiceStatusURL:   ts.URL,
+	})
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+
+	step := &stepServiceStatus{Before: "the build", ReportFailure: true}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected halt: %v", state.Get("error"))
+	}
+	if !strings.Contains(out.String(), "Droplet creation delays in NYC3 (investigating) https://stspg.io/abc") {
+		t.Fatalf("missing incident in output: %s", out.String())
+	}
+	if waited := clock.now.Sub(start); waited != 3*statusPollInterval {
+		t.Fatalf("bad wait: %s", waited)
+	}
+
+	// The incidents are reported again when the build fails
+	out.Reset()
+	state.Put(multistep.StateHalted, true)
+	step.Cleanup(state)
+	if !strings.Contains(out.String(), "The build failed while DigitalOcean reported incidents") {
+		t.Fatalf("missing report in output: %s", out.String())
+	}
+}
+
+func TestStepServiceStatus_Unreachable(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("config", &Config{Region: "nyc3", CheckServiceStatus: true, ServiceStatusURL: ts.URL})
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+
+	if action := new(stepServiceStatus).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected halt: %v", state.Get("error"))
+	}
+	if out.Len() != 0 {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+}
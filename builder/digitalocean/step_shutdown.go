@@ -11,13 +11,17 @@ import (
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 )
 
+// shutdownRetryInterval is how often the shutdown request is repeated,
+// since it sometimes completes without doing anything.
+const shutdownRetryInterval = 20 * time.Second
+
 type stepShutdown struct{}
 
 func (s *stepShutdown) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 	client := state.Get("client").(*godo.Client)
 	c := state.Get("config").(*Config)
 	ui := state.Get("ui").(packersdk.Ui)
-	dropletId := state.Get("droplet_id").(int)
+	dropletId := stateDropletID.Get(state)
 
 	// Gracefully power off the droplet. We have to retry this a number
 	// of times because sometimes it says it completed when it actually
@@ -33,39 +37,36 @@ func (s *stepShutdown) Run(ctx context.Context, state multistep.StateBag) multis
 		return multistep.ActionHalt
 	}
 
-	// A channel we use as a flag to end our goroutines
-	done := make(chan struct{})
-	shutdownRetryDone := make(chan struct{})
-
-	// Make sure we wait for the shutdown retry goroutine to end
-	// before moving on.
-	defer func() {
-		close(done)
-		<-shutdownRetryDone
-	}()
-
-	// Start a goroutine that just keeps trying to shut down the
-	// droplet.
-	go func() {
-		defer close(shutdownRetryDone)
+	// Keep asking the droplet to shut down while waiting for it to be off.
+	log.Printf("Waiting for up to %d seconds for droplet to become off", c.ShutdownTimeout/time.Second)
+	lastShutdown := waitClock.Now()
+	err = poll(ctx, c.ShutdownTimeout, func(ctx context.Context, attempt int) (bool, error) {
+		log.Printf("Checking droplet status... (attempt: %d)", attempt)
+		droplet, _, err := client.Droplets.Get(ctx, dropletId)
+		if err != nil {
+			return false, err
+		}
+		if droplet.Status == "off" {
+			return true, nil
+		}
 
-		for attempts := 2; attempts > 0; attempts++ {
-			log.Printf("ShutdownDroplet attempt #%d...", attempts)
-			_, _, err := client.DropletActions.Shutdown(context.TODO(), dropletId)
-			if err != nil {
+		if waitClock.Now().Sub(lastShutdown) >= shutdownRetryInterval {
+			log.Printf("Retrying droplet shutdown...")
+			if _, _, err := client.DropletActions.Shutdown(ctx, dropletId); err != nil {
 				log.Printf("Shutdown retry error: %s", err)
 			}
-
-			select {
-			case <-done:
-				return
-			case <-time.After(20 * time.Second):
-				// Retry!
-			}
+			lastShutdown = waitClock.Now()
 		}
-	}()
-
-	err = waitForDropletState("off", dropletId, client, c.StateTimeout)
+		return false, nil
+	})
+	if err == ErrPollTimeout && *c.PowerOffFallback {
+		// A stuck service can block the shutdown indefinitely; stepPowerOff
+		// powers the droplet off instead.
+		ui.Error(fmt.Sprintf("Warning: the droplet didn't shut down within %s, powering it off", c.ShutdownTimeout))
+		err = nil
+	} else if err == ErrPollTimeout {
+		err = fmt.Errorf("Timeout while waiting to for droplet to become 'off'")
+	}
 	if err != nil {
 		// If we get an error the first time, actually report it
 		err := fmt.Errorf("Error shutting down droplet: %s", err)
@@ -74,7 +75,7 @@ func (s *stepShutdown) Run(ctx context.Context, state multistep.StateBag) multis
 		return multistep.ActionHalt
 	}
 
-	if err := waitForDropletUnlocked(client, dropletId, c.StateTimeout); err != nil {
+	if err := waitForDropletUnlocked(ctx, client, dropletId, c.StateTimeout); err != nil {
 		// If we get an error the first time, actually report it
 		err := fmt.Errorf("Error shutting down droplet: %s", err)
 		state.Put("error", err)
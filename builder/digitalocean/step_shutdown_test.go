@@ -0,0 +1,87 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepShutdown_PowerOffFallback(t *testing.T) {
+	for _, fallback := range []bool{true, false} {
+		useFakeClock(t)
+
+		// The droplet ignores the shutdown, as when a service blocks it, and
+		// only turns off when powered off.
+		status := "active"
+		var actions []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/v2/droplets/3164444":
+				w.Write([]byte(`{"droplet": {"id": 3164444, "status": "` + status + `"}}`))
+			case r.Method == http.MethodPost && r.URL.Path == "/v2/droplets/3164444/actions":
+				var req godo.ActionRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("bad request: %s", err)
+				}
+				actions = append(actions, req["type"].(string))
+				if req["type"] == "power_off" {
+					status = "off"
+				}
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"action": {"id": 1, "status": "in-progress"}}`))
+			default:
+				t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		state := new(multistep.BasicStateBag)
+		state.Put("client", client)
+		state.Put("config", &Config{
+			StateTimeout:     time.Minute,
+			ShutdownTimeout:  time.Minute,
+			PowerOffFallback: godo.PtrTo(fallback),
+		})
+		state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+		state.Put("droplet_id", 3164444)
+
+		action := new(stepShutdown).Run(context.Background(), state)
+		if !fallback {
+			if action != multistep.ActionHalt {
+				t.Fatal("should halt without the fallback")
+			}
+			ts.Close()
+			continue
+		}
+		if action != multistep.ActionContinue {
+			t.Fatalf("unexpected halt: %v", state.Get("error"))
+		}
+		if !strings.Contains(out.String(), "didn't shut down within 1m0s, powering it off") {
+			t.Fatalf("missing warning in output: %s", out.String())
+		}
+
+		if action := new(stepPowerOff).Run(context.Background(), state); action != multistep.ActionContinue {
+			t.Fatalf("unexpected halt: %v", state.Get("error"))
+		}
+		if actions[len(actions)-1] != "power_off" || status != "off" {
+			t.Fatalf("droplet wasn't powered off: %v", actions)
+		}
+		ts.Close()
+	}
+}
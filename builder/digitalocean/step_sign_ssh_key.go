@@ -0,0 +1,158 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"golang.org/x/crypto/ssh"
+)
+
+// stepSignSSHKey signs the temporary key with the SSH certificate authority
+// set with ssh_ca_private_key_file or ssh_ca_signer_command. The
+// communicator presents the certificate, which it finds in the state as
+// ssh_certificate.
+type stepSignSSHKey struct{}
+
+func (s *stepSignSSHKey) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if c.SSHCAPrivateKeyFile == "" && len(c.SSHCASignerCommand) == 0 {
+		return multistep.ActionContinue
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(c.Comm.SSHPublicKey)
+	if err != nil {
+		err := fmt.Errorf("Error parsing the temporary SSH public key: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say("Signing the temporary SSH key...")
+	var cert *ssh.Certificate
+	if c.SSHCAPrivateKeyFile != "" {
+		cert, err = signSSHKey(c.SSHCAPrivateKeyFile, pub, c.SSHCertificatePrincipals, c.SSHCertificateTTL, time.Now())
+	} else {
+		cert, err = runSSHSigner(ctx, c.SSHCASignerCommand, c.Comm.SSHPublicKey)
+	}
+	if err == nil && !bytes.Equal(cert.Key.Marshal(), pub.Marshal()) {
+		err = fmt.Errorf("the certificate is for another key")
+	}
+	if err != nil {
+		err := fmt.Errorf("Error signing the temporary SSH key: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Message(fmt.Sprintf("Certificate valid for %s until %s",
+		strings.Join(cert.ValidPrincipals, ", "), formatCertExpiry(cert.ValidBefore)))
+	stateSSHCertificate.Put(state, cert)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepSignSSHKey) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// signSSHKey signs pub with the certificate authority key in caFile,
+// issuing a user certificate for principals that is valid for ttl.
+func signSSHKey(caFile string, pub ssh.PublicKey, principals []string, ttl time.Duration, now time.Time) (*ssh.Certificate, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := ssh.ParsePrivateKey(pem)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh_ca_private_key_file: %s", err)
+	}
+
+	cert := &ssh.Certificate{
+		Key:             pub,
+		CertType:        ssh.UserCert,
+		KeyId:           "packer",
+		ValidPrincipals: principals,
+		// Allow for clock skew between this machine and the droplet.
+		ValidAfter:  uint64(now.Add(-5 * time.Minute).Unix()),
+		ValidBefore: uint64(now.Add(ttl).Unix()),
+		Permissions: ssh.Permissions{
+			Extensions: map[string]string{
+				"permit-agent-forwarding": "",
+				"permit-port-forwarding":  "",
+				"permit-pty":              "",
+				"permit-user-rc":          "",
+			},
+		},
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// runSSHSigner signs the public key with an external command, which reads
+// the key from its standard input and prints the certificate.
+func runSSHSigner(ctx context.Context, command []string, pub []byte) (*ssh.Certificate, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(pub)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	key, _, _, _, err := ssh.ParseAuthorizedKey(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate from ssh_ca_signer_command: %s", err)
+	}
+	cert, ok := key.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("ssh_ca_signer_command printed a %s key, not a certificate", key.Type())
+	}
+	return cert, nil
+}
+
+func formatCertExpiry(validBefore uint64) string {
+	if validBefore == ssh.CertTimeInfinity {
+		return "forever"
+	}
+	return time.Unix(int64(validBefore), 0).UTC().Format(time.RFC3339)
+}
+
+// certificateSSHConfig makes the communicator authenticate with the
+// certificate of the temporary key, when there is one.
+func certificateSSHConfig(sshConfig func(multistep.StateBag) (*ssh.ClientConfig, error)) func(multistep.StateBag) (*ssh.ClientConfig, error) {
+	return func(state multistep.StateBag) (*ssh.ClientConfig, error) {
+		conf, err := sshConfig(state)
+		if err != nil {
+			return nil, err
+		}
+		cert, ok := stateSSHCertificate.GetOk(state)
+		if !ok {
+			return conf, nil
+		}
+
+		c := state.Get("config").(*Config)
+		signer, err := ssh.ParsePrivateKey(c.Comm.SSHPrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("Error on parsing SSH private key: %s", err)
+		}
+		certSigner, err := ssh.NewCertSigner(cert, signer)
+		if err != nil {
+			return nil, err
+		}
+		conf.Auth = append([]ssh.AuthMethod{ssh.PublicKeys(certSigner)}, conf.Auth...)
+		return conf, nil
+	}
+}
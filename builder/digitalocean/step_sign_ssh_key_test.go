@@ -0,0 +1,130 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"golang.org/x/crypto/ssh"
+)
+
+// testSSHKeyPair returns a new ed25519 key pair as an OpenSSH private key
+// and an authorized_keys line.
+func testSSHKeyPair(t *testing.T) ([]byte, []byte) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(block), ssh.MarshalAuthorizedKey(sshPub)
+}
+
+func TestStepSignSSHKey(t *testing.T) {
+	dir := t.TempDir()
+	caPriv, caPub := testSSHKeyPair(t)
+	caFile := filepath.Join(dir, "ca")
+	if err := os.WriteFile(caFile, caPriv, 0600); err != nil {
+		t.Fatal(err)
+	}
+	priv, pub := testSSHKeyPair(t)
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{
+		Comm: communicator.Config{
+			SSH: communicator.SSH{SSHPublicKey: pub, SSHPrivateKey: priv},
+		},
+		SSHCAPrivateKeyFile:      caFile,
+		SSHCertificatePrincipals: []string{"root"},
+		SSHCertificateTTL:        time.Hour,
+	})
+
+	if action := new(stepSignSSHKey).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	cert := state.Get("ssh_certificate").(*ssh.Certificate)
+
+	ca, _, _, _, err := ssh.ParseAuthorizedKey(caPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return bytes.Equal(auth.Marshal(), ca.Marshal())
+		},
+	}
+	if err := checker.CheckCert("root", cert); err != nil {
+		t.Fatalf("bad certificate: %s", err)
+	}
+	if err := checker.CheckCert("admin", cert); err == nil {
+		t.Fatal("certificate should only be valid for root")
+	}
+
+	conf, err := certificateSSHConfig(func(multistep.StateBag) (*ssh.ClientConfig, error) {
+		return &ssh.ClientConfig{}, nil
+	})(state)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(conf.Auth) != 1 {
+		t.Fatalf("bad auth methods: %d", len(conf.Auth))
+	}
+}
+
+func TestRunSSHSigner(t *testing.T) {
+	dir := t.TempDir()
+	caPriv, _ := testSSHKeyPair(t)
+	caFile := filepath.Join(dir, "ca")
+	if err := os.WriteFile(caFile, caPriv, 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, pub := testSSHKeyPair(t)
+	key, _, _, _, err := ssh.ParseAuthorizedKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := signSSHKey(caFile, key, []string{"root"}, time.Hour, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := filepath.Join(dir, "cert.pub")
+	if err := os.WriteFile(certFile, ssh.MarshalAuthorizedKey(signed), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := runSSHSigner(context.Background(), []string{"sh", "-c", "cat >/dev/null && cat " + certFile}, pub)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !bytes.Equal(cert.Key.Marshal(), key.Marshal()) {
+		t.Fatal("certificate should be for the public key")
+	}
+
+	// Test a signer printing a plain key
+	if _, err := runSSHSigner(context.Background(), []string{"sh", "-c", "cat"}, pub); err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test a failing signer
+	if _, err := runSSHSigner(context.Background(), []string{"false"}, pub); err == nil {
+		t.Fatal("should have error")
+	}
+}
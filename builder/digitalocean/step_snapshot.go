@@ -5,6 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/digitalocean/godo"
@@ -17,17 +21,42 @@ type stepSnapshot struct {
 	snapshotTimeout         time.Duration
 	transferTimeout         time.Duration
 	waitForSnapshotTransfer bool
+	// transferConcurrency bounds the number of regions the snapshot is
+	// transferred to at once.
+	transferConcurrency int
+	// transferRetries is the number of times a failed transfer is started
+	// over.
+	transferRetries int
+	// progressInterval is how often the progress of the snapshot and of
+	// the transfers is reported while waiting for them. Defaults to
+	// progressInterval.
+	progressInterval time.Duration
+	// snapshotRetryTimeout is how long to keep retrying the snapshot
+	// request while the droplet has a pending event.
+	snapshotRetryTimeout time.Duration
 }
 
+// transferRetryWait is how long to wait before retrying a failed transfer
+// the first time. It doubles with each retry.
+const transferRetryWait = 30 * time.Second
+
+// snapshotRetryWait is how long to wait before retrying a snapshot request
+// rejected because of a pending event the first time. It doubles with each
+// retry, up to snapshotRetryWaitMax.
+const (
+	snapshotRetryWait    = 5 * time.Second
+	snapshotRetryWaitMax = time.Minute
+)
+
 func (s *stepSnapshot) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 	client := state.Get("client").(*godo.Client)
 	ui := state.Get("ui").(packersdk.Ui)
 	c := state.Get("config").(*Config)
-	dropletId := state.Get("droplet_id").(int)
+	dropletId := stateDropletID.Get(state)
 	var snapshotRegions []string
 
 	ui.Say(fmt.Sprintf("Creating snapshot: %v", c.SnapshotName))
-	action, _, err := client.DropletActions.Snapshot(context.TODO(), dropletId, c.SnapshotName)
+	action, err := s.createSnapshot(ctx, ui, client, dropletId, c.SnapshotName)
 	if err != nil {
 		err := fmt.Errorf("Error creating snapshot: %s", err)
 		state.Put("error", err)
@@ -39,8 +68,14 @@ func (s *stepSnapshot) Run(ctx context.Context, state multistep.StateBag) multis
 	// because action can take a long time and may depend on the size of the final snapshot,
 	// the timeout is parameterized
 	ui.Say("Waiting for snapshot to complete...")
-	if err := waitForActionState(godo.ActionCompleted, dropletId, action.ID,
-		client, s.snapshotTimeout); err != nil {
+	started := time.Now()
+	stopReport := reportProgress(ui, s.reportInterval(), func() string {
+		return fmt.Sprintf("Snapshot in progress, %s elapsed...", time.Since(started).Round(time.Second))
+	})
+	err = waitForActionState(ctx, godo.ActionCompleted, dropletId, action.ID,
+		client, s.snapshotTimeout)
+	stopReport()
+	if err != nil {
 		// If we get an error the first time, actually report it
 		err := fmt.Errorf("Error waiting for snapshot: %s", err)
 		state.Put("error", err)
@@ -51,7 +86,7 @@ func (s *stepSnapshot) Run(ctx context.Context, state multistep.StateBag) multis
 	// Wait for the droplet to become unlocked first. For snapshots
 	// this can end up taking quite a long time, so we hardcode this to
 	// 20 minutes.
-	if err := waitForDropletUnlocked(client, dropletId, 20*time.Minute); err != nil {
+	if err := waitForDropletUnlocked(ctx, client, dropletId, 20*time.Minute); err != nil {
 		// If we get an error the first time, actually report it
 		err := fmt.Errorf("Error shutting down droplet: %s", err)
 		state.Put("error", err)
@@ -95,37 +130,50 @@ func (s *stepSnapshot) Run(ctx context.Context, state multistep.StateBag) multis
 			regions = append(regions, region)
 		}
 
-		eg, gCtx := errgroup.WithContext(ctx)
+		// Each transfer runs to the end, so that a failure in one region
+		// doesn't cancel the others and all the failed regions can be
+		// reported.
+		var eg errgroup.Group
+		eg.SetLimit(s.transferConcurrency)
+		var mu sync.Mutex
+		failed := make(map[string]error)
+		status := newTransferStatus(regions)
+		stopReport := func() {}
+		if s.waitForSnapshotTransfer {
+			stopReport = reportProgress(ui, s.reportInterval(), func() string {
+				return fmt.Sprintf("Snapshot transfers: %s", status)
+			})
+		}
+
 		for _, r := range regions {
 			region := r
 			eg.Go(func() error {
-				transferRequest := &godo.ActionRequest{
-					"type":   "transfer",
-					"region": region,
-				}
-
-				ui.Say(fmt.Sprintf("Transferring snapshot (ID: %d) to %s...", imageId, region))
-				imageTransfer, _, err := client.ImageActions.Transfer(gCtx, imageId, transferRequest)
-				if err != nil {
-					return fmt.Errorf("Error transferring snapshot: %s", err)
-				}
-
-				if s.waitForSnapshotTransfer {
-					if err := WaitForImageState(
-						godo.ActionCompleted,
-						imageId,
-						imageTransfer.ID,
-						client, s.transferTimeout); err != nil {
-						return fmt.Errorf("Error waiting for snapshot transfer: %s", err)
-					}
-					ui.Say(fmt.Sprintf("Transfer to %s is complete.", region))
+				if err := s.transferWithRetries(ctx, ui, client, imageId, region, status); err != nil {
+					mu.Lock()
+					failed[region] = err
+					mu.Unlock()
 				}
-
 				return nil
 			})
 		}
+		eg.Wait()
+		stopReport()
+		if s.waitForSnapshotTransfer {
+			ui.Message(fmt.Sprintf("Snapshot transfers: %s", status))
+		}
 
-		if err := eg.Wait(); err != nil {
+		if len(failed) > 0 {
+			failedRegions := make([]string, 0, len(failed))
+			for region := range failed {
+				failedRegions = append(failedRegions, region)
+			}
+			sort.Strings(failedRegions)
+			msgs := make([]string, 0, len(failed))
+			for _, region := range failedRegions {
+				msgs = append(msgs, fmt.Sprintf("%s: %s", region, failed[region]))
+			}
+			err := fmt.Errorf("Error transferring snapshot to %s: %s",
+				strings.Join(failedRegions, ", "), strings.Join(msgs, "; "))
 			state.Put("error", err)
 			ui.Error(err.Error())
 			return multistep.ActionHalt
@@ -134,13 +182,113 @@ func (s *stepSnapshot) Run(ctx context.Context, state multistep.StateBag) multis
 
 	snapshotRegions = append(snapshotRegions, c.Region)
 
-	state.Put("snapshot_image_id", imageId)
-	state.Put("snapshot_name", c.SnapshotName)
-	state.Put("regions", snapshotRegions)
+	stateSnapshotImageID.Put(state, imageId)
+	stateSnapshotName.Put(state, c.SnapshotName)
+	stateRegions.Put(state, snapshotRegions)
 
 	return multistep.ActionContinue
 }
 
+func (s *stepSnapshot) reportInterval() time.Duration {
+	if s.progressInterval == 0 {
+		return progressInterval
+	}
+	return s.progressInterval
+}
+
+// createSnapshot requests the snapshot of the droplet, retrying for up to
+// snapshotRetryTimeout while the API rejects it because another event of
+// the droplet is pending.
+func (s *stepSnapshot) createSnapshot(
+	ctx context.Context, ui packersdk.Ui, client *godo.Client, dropletId int, name string) (*godo.Action, error) {
+	deadline := waitClock.Now().Add(s.snapshotRetryTimeout)
+	wait := snapshotRetryWait
+	for {
+		action, _, err := client.DropletActions.Snapshot(ctx, dropletId, name)
+		remaining := deadline.Sub(waitClock.Now())
+		if err == nil || !isPendingEventError(err) || remaining <= 0 {
+			return action, err
+		}
+
+		// The last retry comes at the end of the window.
+		delay := min(wait, remaining)
+		ui.Message(fmt.Sprintf("The droplet has a pending event, retrying the snapshot in %s...", delay))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-waitClock.After(delay):
+		}
+		wait = min(2*wait, snapshotRetryWaitMax)
+	}
+}
+
+// isPendingEventError reports whether err is the API refusing an action
+// because another event of the droplet is still in progress.
+func isPendingEventError(err error) bool {
+	var errResp *godo.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return false
+	}
+	return errResp.Response.StatusCode == http.StatusUnprocessableEntity &&
+		strings.Contains(strings.ToLower(errResp.Message), "pending event")
+}
+
+// transferWithRetries transfers the image to region, starting the transfer
+// over when it fails, up to transferRetries times.
+func (s *stepSnapshot) transferWithRetries(
+	ctx context.Context, ui packersdk.Ui, client *godo.Client, imageId int, region string, status *transferStatus) error {
+	for attempt := 0; ; attempt++ {
+		status.set(region, transferInProgress)
+		err := s.transfer(ctx, ui, client, imageId, region)
+		if err == nil {
+			status.set(region, transferCompleted)
+			return nil
+		}
+		if attempt >= s.transferRetries || ctx.Err() != nil {
+			status.set(region, transferErrored)
+			return err
+		}
+		status.set(region, transferRetrying)
+
+		wait := transferRetryWait << attempt
+		ui.Message(fmt.Sprintf("Transfer to %s failed, retrying in %s (%d/%d): %s",
+			region, wait, attempt+1, s.transferRetries, err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-waitClock.After(wait):
+		}
+	}
+}
+
+// transfer transfers the image to region.
+func (s *stepSnapshot) transfer(ctx context.Context, ui packersdk.Ui, client *godo.Client, imageId int, region string) error {
+	transferRequest := &godo.ActionRequest{
+		"type":   "transfer",
+		"region": region,
+	}
+
+	ui.Say(fmt.Sprintf("Transferring snapshot (ID: %d) to %s...", imageId, region))
+	imageTransfer, _, err := client.ImageActions.Transfer(ctx, imageId, transferRequest)
+	if err != nil {
+		return fmt.Errorf("Error transferring snapshot: %s", err)
+	}
+
+	if s.waitForSnapshotTransfer {
+		if err := WaitForImageStateContext(
+			ctx,
+			godo.ActionCompleted,
+			imageId,
+			imageTransfer.ID,
+			client, s.transferTimeout); err != nil {
+			return fmt.Errorf("Error waiting for snapshot transfer: %s", err)
+		}
+		ui.Say(fmt.Sprintf("Transfer to %s is complete.", region))
+	}
+
+	return nil
+}
+
 func (s *stepSnapshot) Cleanup(state multistep.StateBag) {
 	// no cleanup
 }
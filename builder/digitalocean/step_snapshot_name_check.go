@@ -0,0 +1,70 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepSnapshotNameCheck looks for snapshots already named snapshot_name
+// before anything is created. The build fails when there are some, unless
+// the force option is set, when stepReplaceSnapshots deletes them once the
+// new snapshot exists. packer build -force isn't enough, since deleting
+// snapshots can't be undone.
+type stepSnapshotNameCheck struct{}
+
+func (s *stepSnapshotNameCheck) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	ui.Say(fmt.Sprintf("Checking for existing snapshots named %s...", c.SnapshotName))
+	images, err := listUserImages(client)
+	if err != nil {
+		err := fmt.Errorf("Error listing snapshots: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	var existing []godo.Image
+	for _, image := range images {
+		if image.Name == c.SnapshotName {
+			existing = append(existing, image)
+		}
+	}
+	if len(existing) == 0 {
+		return multistep.ActionContinue
+	}
+
+	if !c.Force {
+		err := fmt.Errorf("A snapshot named %s already exists (ID: %d). "+
+			"Set force to true to replace it.", c.SnapshotName, existing[0].ID)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	for _, image := range existing {
+		if IsLocked(&image) {
+			err := fmt.Errorf("Refusing to replace snapshot %d (%s): it is tagged %q",
+				image.ID, image.Name, LockedTag)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	for _, image := range existing {
+		ui.Message(fmt.Sprintf("Snapshot %d (%s) will be replaced once the build succeeds", image.ID, image.Name))
+	}
+	stateReplacedImages.Put(state, existing)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepSnapshotNameCheck) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
@@ -0,0 +1,87 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepSnapshotNameCheck(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v2/images" || r.URL.Query().Get("private") != "true" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"images": [
+			{"id": 1, "name": "web", "tags": []},
+			{"id": 2, "name": "base", "tags": []},
+			{"id": 3, "name": "web", "tags": []},
+			{"id": 4, "name": "db", "tags": ["locked"]}
+		]}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		config   *Config
+		action   multistep.StepAction
+		replaced []int
+		err      string
+	}{
+		{"unique", &Config{SnapshotName: "cache"}, multistep.ActionContinue, nil, ""},
+		{"exists", &Config{SnapshotName: "web"}, multistep.ActionHalt, nil, "A snapshot named web already exists (ID: 1)"},
+		{"force", &Config{SnapshotName: "web", Force: true}, multistep.ActionContinue, []int{1, 3}, ""},
+		{"packer force", &Config{SnapshotName: "base", PackerConfig: common.PackerConfig{PackerForce: true}},
+			multistep.ActionHalt, nil, "A snapshot named base already exists (ID: 2)"},
+		{"locked", &Config{SnapshotName: "db", Force: true}, multistep.ActionHalt, nil, `Refusing to replace snapshot 4 (db): it is tagged "locked"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			state := new(multistep.BasicStateBag)
+			state.Put("client", client)
+			state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+			state.Put("config", tc.config)
+
+			step := new(stepSnapshotNameCheck)
+			if action := step.Run(context.Background(), state); action != tc.action {
+				t.Fatalf("bad action: %v: %s", action, out.String())
+			}
+			if tc.err != "" {
+				err, ok := state.GetOk("error")
+				if !ok || !strings.Contains(err.(error).Error(), tc.err) {
+					t.Fatalf("bad error: %v", err)
+				}
+			}
+
+			images, _ := stateReplacedImages.GetOk(state)
+			var ids []int
+			for _, image := range images {
+				ids = append(ids, image.ID)
+			}
+			if len(ids) != len(tc.replaced) {
+				t.Fatalf("bad replaced snapshots: %v, want %v", ids, tc.replaced)
+			}
+			for i := range ids {
+				if ids[i] != tc.replaced[i] {
+					t.Fatalf("bad replaced snapshots: %v, want %v", ids, tc.replaced)
+				}
+			}
+		})
+	}
+}
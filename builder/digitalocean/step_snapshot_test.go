@@ -0,0 +1,258 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// snapshotServer fakes the API calls of stepSnapshot for droplet 1, whose
+// snapshot is image 100. Each transfer is in progress the first time it is
+// checked and completes the second time, unless transfer says otherwise.
+type snapshotServer struct {
+	t *testing.T
+
+	// transfer returns the status of the attempt-th transfer to region
+	// when it is checked for the check-th time.
+	transfer func(region string, attempt, check int) string
+	// pendingEvents is the number of snapshot requests rejected because
+	// the droplet has a pending event before one is accepted.
+	pendingEvents int
+
+	mu          sync.Mutex
+	snapshots   int
+	regions     map[int]string
+	attempts    map[int]int
+	checks      map[int]int
+	started     []string
+	inFlight    int
+	maxInFlight int
+}
+
+func (s *snapshotServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v2/droplets/1/actions":
+		s.mu.Lock()
+		s.snapshots++
+		rejected := s.snapshots <= s.pendingEvents
+		s.mu.Unlock()
+		if rejected {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"id": "unprocessable_entity", "message": "Droplet already has a pending event."}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"action": {"id": 10, "status": "in-progress"}}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/v2/droplets/1/actions/10":
+		w.Write([]byte(`{"action": {"id": 10, "status": "completed"}}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/v2/droplets/1":
+		w.Write([]byte(`{"droplet": {"id": 1, "locked": false}}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/v2/droplets/1/snapshots":
+		w.Write([]byte(`{"snapshots": [{"id": 100, "name": "packer-snapshot"}]}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/v2/images/100/actions":
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		s.mu.Lock()
+		id := 1000 + len(s.started)
+		s.regions[id] = req["region"]
+		s.started = append(s.started, req["region"])
+		s.attempts[id] = 0
+		for _, region := range s.started {
+			if region == req["region"] {
+				s.attempts[id]++
+			}
+		}
+		s.inFlight++
+		if s.inFlight > s.maxInFlight {
+			s.maxInFlight = s.inFlight
+		}
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"action": {"id": %d, "status": "in-progress"}}`, id)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v2/images/100/actions/"):
+		var id int
+		fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/v2/images/100/actions/"), "%d", &id)
+		s.mu.Lock()
+		s.checks[id]++
+		region, check := s.regions[id], s.checks[id]
+		status := godo.ActionInProgress
+		if s.transfer != nil {
+			status = s.transfer(region, s.attempts[id], check)
+		} else if check > 1 {
+			status = godo.ActionCompleted
+		}
+		if status != godo.ActionInProgress {
+			s.inFlight--
+		}
+		s.mu.Unlock()
+		fmt.Fprintf(w, `{"action": {"id": %d, "status": %q, "region_slug": %q}}`, id, status, region)
+	default:
+		s.t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newSnapshotServer(t *testing.T) (*snapshotServer, *godo.Client) {
+	s := &snapshotServer{
+		t:        t,
+		regions:  make(map[int]string),
+		attempts: make(map[int]int),
+		checks:   make(map[int]int),
+	}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, client
+}
+
+func snapshotState(client *godo.Client, c *Config, out *bytes.Buffer) multistep.StateBag {
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: out, ErrorWriter: out})
+	state.Put("config", c)
+	stateDropletID.Put(state, 1)
+	return state
+}
+
+func TestStepSnapshot_TransferConcurrency(t *testing.T) {
+	useFakeClock(t)
+	server, client := newSnapshotServer(t)
+
+	var out bytes.Buffer
+	regions := []string{"ams3", "fra1", "lon1", "sfo3", "sgp1"}
+	state := snapshotState(client, &Config{
+		SnapshotName:    "packer-snapshot",
+		Region:          "nyc3",
+		SnapshotRegions: regions,
+	}, &out)
+
+	step := &stepSnapshot{
+		snapshotTimeout:         time.Minute,
+		transferTimeout:         time.Minute,
+		waitForSnapshotTransfer: true,
+		transferConcurrency:     2,
+	}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+
+	sort.Strings(server.started)
+	if strings.Join(server.started, ",") != strings.Join(regions, ",") {
+		t.Errorf("bad transfers: %v", server.started)
+	}
+	if server.maxInFlight > 2 {
+		t.Errorf("%d transfers ran at once, want at most 2", server.maxInFlight)
+	}
+	if id := stateSnapshotImageID.Get(state); id != 100 {
+		t.Errorf("bad snapshot ID: %d", id)
+	}
+}
+
+func TestStepSnapshot_TransferRetries(t *testing.T) {
+	useFakeClock(t)
+	server, client := newSnapshotServer(t)
+	server.transfer = func(region string, attempt, check int) string {
+		switch {
+		case region == "sgp1":
+			return actionErrored
+		case region == "fra1" && attempt == 1:
+			return actionErrored
+		case check > 1:
+			return godo.ActionCompleted
+		}
+		return godo.ActionInProgress
+	}
+
+	var out bytes.Buffer
+	state := snapshotState(client, &Config{
+		SnapshotName:    "packer-snapshot",
+		Region:          "nyc3",
+		SnapshotRegions: []string{"ams3", "fra1", "sgp1"},
+	}, &out)
+
+	// The waits between retries move the shared fake clock on by minutes.
+	step := &stepSnapshot{
+		snapshotTimeout:         time.Minute,
+		transferTimeout:         time.Hour,
+		waitForSnapshotTransfer: true,
+		transferConcurrency:     8,
+		transferRetries:         2,
+	}
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+
+	sort.Strings(server.started)
+	if got := strings.Join(server.started, ","); got != "ams3,fra1,fra1,sgp1,sgp1,sgp1" {
+		t.Errorf("bad transfers: %s", got)
+	}
+	err := state.Get("error").(error)
+	if !strings.HasPrefix(err.Error(), "Error transferring snapshot to sgp1: ") {
+		t.Errorf("bad error: %s", err)
+	}
+	if !strings.Contains(out.String(), "Transfer to fra1 failed, retrying in 30s (1/2)") {
+		t.Errorf("the retry should be reported: %s", out.String())
+	}
+	if !regexp.MustCompile(`Snapshot transfers: ams3 completed \(\d+s\), fra1 completed \(\d+s\), sgp1 errored \(\d+s\)`).MatchString(out.String()) {
+		t.Errorf("the status of the transfers should be reported: %s", out.String())
+	}
+}
+
+func TestStepSnapshot_PendingEventRetry(t *testing.T) {
+	clock := useFakeClock(t)
+	server, client := newSnapshotServer(t)
+	server.pendingEvents = 3
+
+	var out bytes.Buffer
+	state := snapshotState(client, &Config{SnapshotName: "packer-snapshot"}, &out)
+	step := &stepSnapshot{
+		snapshotTimeout:      time.Hour,
+		snapshotRetryTimeout: time.Minute,
+	}
+	start := clock.Now()
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected halt: %v: %s", state.Get("error"), out.String())
+	}
+	if server.snapshots != 4 {
+		t.Fatalf("should have retried the snapshot until accepted: %d requests", server.snapshots)
+	}
+	// 5s, 10s and 20s between the requests.
+	if waited := clock.Now().Sub(start); waited < 35*time.Second {
+		t.Fatalf("should have backed off between retries: %s", waited)
+	}
+	if !strings.Contains(out.String(), "pending event, retrying the snapshot in 20s") {
+		t.Fatalf("missing retry message in output: %s", out.String())
+	}
+
+	// The rejections outlast the retry window.
+	server, client = newSnapshotServer(t)
+	server.pendingEvents = 100
+	state = snapshotState(client, &Config{SnapshotName: "packer-snapshot"}, &out)
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatal("should halt once the retry window is over")
+	}
+	if err := state.Get("error").(error); !strings.Contains(err.Error(), "pending event") {
+		t.Fatalf("bad error: %s", err)
+	}
+	if server.snapshots != 5 {
+		t.Fatalf("should have stopped retrying after a minute: %d requests", server.snapshots)
+	}
+}
@@ -0,0 +1,97 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepSnapshotVolumes snapshots the volumes attached to the powered off
+// droplet. The snapshots are recorded in the state as volume_snapshots for
+// the artifact, and deleted again if the build fails.
+type stepSnapshotVolumes struct {
+	snapshotIDs []string
+}
+
+func (s *stepSnapshotVolumes) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if !c.SnapshotVolumes {
+		return multistep.ActionContinue
+	}
+
+	snapshots := make([]interface{}, 0, len(c.Volumes))
+	for _, volumeID := range c.Volumes {
+		volume, _, err := client.Storage.GetVolume(context.TODO(), volumeID)
+		if err != nil {
+			err := fmt.Errorf("Error retrieving volume %s: %s", volumeID, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+
+		name := volumeSnapshotName(c.VolumeSnapshotName, volume.Name)
+		ui.Say(fmt.Sprintf("Creating snapshot of volume %s: %v", volume.Name, name))
+
+		snapshotCtx, cancel := context.WithTimeout(ctx, c.VolumeSnapshotTimeout)
+		snapshot, _, err := client.Storage.CreateSnapshot(snapshotCtx, &godo.SnapshotCreateRequest{
+			VolumeID: volumeID,
+			Name:     name,
+			Tags:     c.VolumeSnapshotTags,
+		})
+		cancel()
+		if err != nil {
+			err := fmt.Errorf("Error creating snapshot of volume %s: %s", volume.Name, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+
+		s.snapshotIDs = append(s.snapshotIDs, snapshot.ID)
+		log.Printf("Volume snapshot ID: %s", snapshot.ID)
+
+		snapshots = append(snapshots, map[string]string{
+			"id":        snapshot.ID,
+			"name":      snapshot.Name,
+			"volume_id": volumeID,
+			"regions":   strings.Join(snapshot.Regions, ","),
+		})
+	}
+
+	stateVolumeSnapshots.Put(state, snapshots)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepSnapshotVolumes) Cleanup(state multistep.StateBag) {
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if !cancelled && !halted {
+		return
+	}
+
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	for _, id := range s.snapshotIDs {
+		ui.Say(fmt.Sprintf("Deleting volume snapshot %s...", id))
+		err := retryCleanup(state, "volume snapshot "+id, func() (*godo.Response, error) {
+			return client.Snapshots.Delete(context.TODO(), id)
+		})
+		if err != nil {
+			ui.Error(fmt.Sprintf(
+				"Error deleting volume snapshot. Please delete it manually: %s", err))
+		}
+	}
+}
+
+func volumeSnapshotName(prefix string, volumeName string) string {
+	return fmt.Sprintf("%s-%s", prefix, volumeName)
+}
@@ -0,0 +1,196 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+)
+
+var (
+	gpuDriverVersionRe = regexp.MustCompile(`(?i)driver[^0-9]*([0-9]+(?:\.[0-9]+)*)`)
+	cudaVersionRe      = regexp.MustCompile(`(?i)cuda[^0-9]*([0-9]+(?:\.[0-9]+)*)`)
+)
+
+const (
+	ImageInitAuto      = "auto"
+	ImageInitCloudInit = "cloud-init"
+	ImageInitNone      = "none"
+)
+
+// The values of the KeyInjection build variable.
+const (
+	KeyInjectionCloudInit = "cloud-init"
+	KeyInjectionPassword  = "password"
+	KeyInjectionNone      = "none"
+)
+
+// stepSourceImageInfo looks up the base image before any resources are
+// created. For AI/ML (GPU) images it publishes the NVIDIA driver and CUDA
+// versions advertised by the image as generated data. It also decides
+// whether the image runs cloud-init, and so whether SSH keys can be
+// injected through the droplet metadata.
+type stepSourceImageInfo struct{}
+
+func (s *stepSourceImageInfo) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	generatedData := &packerbuilderdata.GeneratedData{State: state}
+
+	// Always publish the keys so provisioners referencing them don't fail
+	// for non-GPU builds.
+	generatedData.Put("GPUDriverVersion", "")
+	generatedData.Put("CUDAVersion", "")
+
+	// Only custom images, referenced by ID, may lack cloud-init.
+	_, idErr := strconv.Atoi(c.Image)
+	detectInit := c.ImageInit == ImageInitAuto && idErr == nil
+
+	if !isGPUImage(c.Image) && !detectInit {
+		return s.setKeyInjection(state, c.ImageInit != ImageInitNone)
+	}
+
+	ui.Say(fmt.Sprintf("Looking up base image %s...", c.Image))
+	image, err := getImage(client, c.Image)
+	if err != nil {
+		err := fmt.Errorf("Error retrieving base image %s: %s", c.Image, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	// The distribution is only a hint: custom images that don't name one
+	// fall back to the password when there is one, and otherwise still get
+	// the keys injected.
+	cloudInit := c.ImageInit != ImageInitNone
+	if detectInit && !hasCloudInit(image) {
+		log.Printf("Image %s (type %q, distribution %q) may not run cloud-init",
+			c.Image, image.Type, image.Distribution)
+		if c.Comm.Type == "ssh" && c.Comm.SSHPassword != "" {
+			ui.Message(fmt.Sprintf("Image %s is a custom image without a known distribution "+
+				"and may not run cloud-init, using `ssh_password` instead of SSH keys.", c.Image))
+			cloudInit = false
+		} else {
+			ui.Message(fmt.Sprintf("Warning: image %s is a custom image without a known distribution "+
+				"and may not run cloud-init to install SSH keys. If the communicator can't connect, "+
+				"set `ssh_password` to credentials baked into the image.", c.Image))
+		}
+	}
+	if action := s.setKeyInjection(state, cloudInit); action != multistep.ActionContinue {
+		return action
+	}
+
+	if !isGPUImage(c.Image) {
+		return multistep.ActionContinue
+	}
+
+	driver, cuda := parseGPUImageMetadata(image)
+	log.Printf("GPU image %s: driver=%q cuda=%q", c.Image, driver, cuda)
+	if driver != "" || cuda != "" {
+		ui.Message(fmt.Sprintf("GPU image driver: %s, CUDA: %s", driver, cuda))
+	}
+	generatedData.Put("GPUDriverVersion", driver)
+	generatedData.Put("CUDAVersion", cuda)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepSourceImageInfo) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// setKeyInjection records whether SSH keys will be injected through
+// cloud-init, and publishes how the communicator authenticates as the
+// KeyInjection build variable. Without cloud-init the SSH communicator must
+// fall back to password authentication with credentials baked into the
+// image.
+func (s *stepSourceImageInfo) setKeyInjection(state multistep.StateBag, cloudInit bool) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	generatedData := &packerbuilderdata.GeneratedData{State: state}
+
+	stateCloudInit.Put(state, cloudInit)
+	if cloudInit {
+		generatedData.Put("KeyInjection", KeyInjectionCloudInit)
+		return multistep.ActionContinue
+	}
+	if c.Comm.Type == "ssh" {
+		generatedData.Put("KeyInjection", KeyInjectionPassword)
+	} else {
+		generatedData.Put("KeyInjection", KeyInjectionNone)
+	}
+
+	if c.Comm.Type == "ssh" && c.Comm.SSHPassword == "" {
+		err := fmt.Errorf("Image %s does not run cloud-init, so SSH keys "+
+			"cannot be injected. Set `ssh_password` to credentials baked into the image, "+
+			"or set `image_init` to %q if the image does run cloud-init.", c.Image, ImageInitCloudInit)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say("Image does not run cloud-init; SSH keys will not be injected, using password authentication...")
+	return multistep.ActionContinue
+}
+
+// getImage fetches an image by its numeric ID or, failing that, by slug.
+func getImage(client *godo.Client, image string) (*godo.Image, error) {
+	if id, err := strconv.Atoi(image); err == nil {
+		img, _, err := client.Images.GetByID(context.TODO(), id)
+		return img, err
+	}
+
+	img, _, err := client.Images.GetBySlug(context.TODO(), image)
+	return img, err
+}
+
+// hasCloudInit guesses whether an image runs cloud-init. DigitalOcean only
+// provides images with cloud-init, but custom images imported without a
+// known distribution are usually appliances built without it.
+func hasCloudInit(image *godo.Image) bool {
+	if image.Type != "custom" {
+		return true
+	}
+
+	distribution := strings.ToLower(image.Distribution)
+	return distribution != "" && distribution != "unknown" && distribution != "unknown os"
+}
+
+// isGPUImage reports whether the image slug refers to one of the
+// DigitalOcean AI/ML ready images, which only boot on GPU droplets.
+func isGPUImage(image string) bool {
+	return strings.HasPrefix(image, "gpu-")
+}
+
+// gpuSizeRe matches GPU droplet size slugs such as gpu-h100x1-80gb or
+// gpu-mi300x8-1536gb.
+var gpuSizeRe = regexp.MustCompile(`^gpu-[a-z0-9]+x[0-9]+-[0-9]+gb(-[a-z0-9]+)*$`)
+
+// isGPUSize reports whether the size slug is a GPU droplet size.
+func isGPUSize(size string) bool {
+	return strings.HasPrefix(size, "gpu-")
+}
+
+// parseGPUImageMetadata extracts the NVIDIA driver and CUDA versions from
+// the description and tags of an AI/ML image. Either value may be empty if
+// the image does not advertise it.
+func parseGPUImageMetadata(image *godo.Image) (driver string, cuda string) {
+	text := strings.Join(append([]string{image.Description}, image.Tags...), " ")
+
+	if m := gpuDriverVersionRe.FindStringSubmatch(text); m != nil {
+		driver = m[1]
+	}
+	if m := cudaVersionRe.FindStringSubmatch(text); m != nil {
+		cuda = m[1]
+	}
+
+	return driver, cuda
+}
@@ -0,0 +1,138 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestParseGPUImageMetadata(t *testing.T) {
+	tests := []struct {
+		name   string
+		image  *godo.Image
+		driver string
+		cuda   string
+	}{
+		{
+			name:   "description",
+			image:  &godo.Image{Description: "AI/ML Ready with NVIDIA driver 535.183.01 and CUDA 12.2"},
+			driver: "535.183.01",
+			cuda:   "12.2",
+		},
+		{
+			name:   "tags",
+			image:  &godo.Image{Tags: []string{"driver:550.54", "cuda:12.4"}},
+			driver: "550.54",
+			cuda:   "12.4",
+		},
+		{
+			name:  "no metadata",
+			image: &godo.Image{Description: "Ubuntu 22.04 x64"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver, cuda := parseGPUImageMetadata(tt.image)
+			if driver != tt.driver {
+				t.Errorf("driver: got %q, want %q", driver, tt.driver)
+			}
+			if cuda != tt.cuda {
+				t.Errorf("cuda: got %q, want %q", cuda, tt.cuda)
+			}
+		})
+	}
+}
+
+func TestHasCloudInit(t *testing.T) {
+	tests := []struct {
+		name  string
+		image *godo.Image
+		want  bool
+	}{
+		{"distribution", &godo.Image{Type: "base", Distribution: "Ubuntu"}, true},
+		{"snapshot", &godo.Image{Type: "snapshot", Distribution: "Unknown"}, true},
+		{"custom with distribution", &godo.Image{Type: "custom", Distribution: "Debian"}, true},
+		{"custom unknown", &godo.Image{Type: "custom", Distribution: "Unknown OS"}, false},
+		{"custom without distribution", &godo.Image{Type: "custom"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasCloudInit(tt.image); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStepSourceImageInfo_CustomImage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/images/42" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"image": {"id": 42, "type": "custom", "distribution": "Unknown"}}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	c := &Config{Image: "42", ImageInit: ImageInitAuto}
+	c.Comm.Type = "ssh"
+	state.Put("config", c)
+
+	// Keys are still injected into custom images without a known
+	// distribution, with a warning.
+	if action := new(stepSourceImageInfo).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	if !stateCloudInit.Get(state) {
+		t.Error("SSH keys should be injected")
+	}
+	if !strings.Contains(out.String(), "Warning: image 42 is a custom image without a known distribution") {
+		t.Errorf("missing warning: %s", out.String())
+	}
+	if got := state.Get("generated_data").(map[string]interface{})["KeyInjection"]; got != KeyInjectionCloudInit {
+		t.Errorf("KeyInjection: got %v", got)
+	}
+
+	// With a password, the build falls back to it.
+	out.Reset()
+	state = new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	c = &Config{Image: "42", ImageInit: ImageInitAuto}
+	c.Comm.Type = "ssh"
+	c.Comm.SSHPassword = "baked-in-Zq81"
+	state.Put("config", c)
+
+	if action := new(stepSourceImageInfo).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	if stateCloudInit.Get(state) {
+		t.Error("SSH keys should not be injected")
+	}
+	if got := state.Get("generated_data").(map[string]interface{})["KeyInjection"]; got != KeyInjectionPassword {
+		t.Errorf("KeyInjection: got %v", got)
+	}
+	if !strings.Contains(out.String(), "using `ssh_password` instead of SSH keys") {
+		t.Errorf("missing message: %s", out.String())
+	}
+}
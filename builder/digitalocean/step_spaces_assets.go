@@ -0,0 +1,95 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepSpacesAssets downloads the objects selected by spaces_assets onto the
+// droplet. The droplet fetches each object through a presigned URL that
+// expires after spaces_url_ttl.
+type stepSpacesAssets struct{}
+
+func (s *stepSpacesAssets) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if len(c.SpacesAssets) == 0 {
+		return multistep.ActionContinue
+	}
+
+	comm := state.Get("communicator").(packersdk.Communicator)
+
+	ui.Say("Downloading assets from Spaces to the droplet...")
+	for _, asset := range c.SpacesAssets {
+		svc, err := SpacesClient(c.SpacesKey, c.SpacesSecret, asset.Region)
+		if err != nil {
+			err := fmt.Errorf("Error creating Spaces client: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+
+		keys, err := listSpacesObjects(svc, asset.Bucket, asset.Prefix)
+		if err != nil {
+			err := fmt.Errorf(
+				"Error listing objects in Space %s: %s", asset.Bucket, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		if len(keys) == 0 {
+			err := fmt.Errorf(
+				"No objects found in Space %s with prefix %q", asset.Bucket, asset.Prefix)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+
+		for _, key := range keys {
+			req, _ := svc.GetObjectRequest(&s3.GetObjectInput{
+				Bucket: aws.String(asset.Bucket),
+				Key:    aws.String(key),
+			})
+			url, err := req.Presign(c.SpacesURLTTL)
+			if err != nil {
+				err := fmt.Errorf("Error presigning %s: %s", key, err)
+				state.Put("error", err)
+				ui.Error(err.Error())
+				return multistep.ActionHalt
+			}
+			packersdk.LogSecretFilter.Set(url)
+
+			dst := assetPath(asset.Destination, asset.Prefix, key)
+			ui.Message(fmt.Sprintf("%s/%s -> %s", asset.Bucket, key, dst))
+
+			cmd := &packersdk.RemoteCmd{Command: assetDownloadCommand(dst, url)}
+			if err := cmd.RunWithUi(ctx, comm, ui); err != nil {
+				err := fmt.Errorf("Error downloading %s: %s", key, err)
+				state.Put("error", err)
+				ui.Error(err.Error())
+				return multistep.ActionHalt
+			}
+			if status := cmd.ExitStatus(); status != 0 {
+				err := fmt.Errorf(
+					"Error downloading %s: exited with status %d", key, status)
+				state.Put("error", err)
+				ui.Error(err.Error())
+				return multistep.ActionHalt
+			}
+			log.Printf("[DEBUG] Downloaded %s/%s to %s", asset.Bucket, key, dst)
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepSpacesAssets) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
@@ -0,0 +1,41 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepSSHKeyFingerprint looks up the ID of the account SSH key set with
+// ssh_key_fingerprint.
+type stepSSHKeyFingerprint struct{}
+
+func (s *stepSSHKeyFingerprint) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if c.SSHKeyFingerprint == "" {
+		return multistep.ActionContinue
+	}
+
+	key, _, err := client.Keys.GetByFingerprint(ctx, c.SSHKeyFingerprint)
+	if err != nil {
+		err := fmt.Errorf("Error finding SSH key %s: %s", c.SSHKeyFingerprint, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Message(fmt.Sprintf("Using SSH key %s (ID: %d)", key.Name, key.ID))
+	stateFingerprintSSHKeyID.Put(state, key.ID)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepSSHKeyFingerprint) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
@@ -0,0 +1,65 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepSSHKeyFingerprint(t *testing.T) {
+	const fingerprint = "3b:16:bf:e4:8b:00:8b:b8:59:8c:a9:d3:f0:19:45:fa"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/account/keys/"+fingerprint {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"id": "not_found", "message": "The resource you were accessing could not be found."}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ssh_key": {"id": 512190, "name": "ops", "fingerprint": "` + fingerprint + `"}}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		fingerprint string
+		expectedID  int
+	}{
+		{name: "found", fingerprint: fingerprint, expectedID: 512190},
+		{name: "unknown", fingerprint: "00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			state := new(multistep.BasicStateBag)
+			state.Put("client", client)
+			state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+			state.Put("config", &Config{SSHKeyFingerprint: tt.fingerprint})
+
+			action := new(stepSSHKeyFingerprint).Run(context.Background(), state)
+			if tt.expectedID == 0 {
+				if action != multistep.ActionHalt {
+					t.Fatalf("bad action: %v", action)
+				}
+				return
+			}
+			if action != multistep.ActionContinue {
+				t.Fatalf("bad action: %v: %s", action, out.String())
+			}
+			if id := state.Get("fingerprint_ssh_key_id"); id != tt.expectedID {
+				t.Fatalf("bad id: %v", id)
+			}
+		})
+	}
+}
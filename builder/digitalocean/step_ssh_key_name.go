@@ -0,0 +1,72 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepSSHKeyName looks up the ID of the account SSH key set with
+// ssh_key_name, which must be the name of exactly one key.
+type stepSSHKeyName struct{}
+
+func (s *stepSSHKeyName) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if c.SSHKeyName == "" {
+		return multistep.ActionContinue
+	}
+
+	key, err := findSSHKeyByName(ctx, client, c.SSHKeyName)
+	if err != nil {
+		err := fmt.Errorf("Error finding SSH key %q: %s", c.SSHKeyName, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Message(fmt.Sprintf("Using SSH key %s (ID: %d)", key.Name, key.ID))
+	stateNamedSSHKeyID.Put(state, key.ID)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepSSHKeyName) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// findSSHKeyByName returns the account SSH key with the given name. Key
+// names aren't unique, so it fails when several keys share the name.
+func findSSHKeyByName(ctx context.Context, client *godo.Client, name string) (*godo.Key, error) {
+	keys, err := ListAll(ctx, client.Keys.List)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []godo.Key
+	for _, k := range keys {
+		if k.Name == name {
+			matches = append(matches, k)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no SSH key on the account has that name")
+	case 1:
+		return &matches[0], nil
+	}
+
+	ambiguous := make([]string, 0, len(matches))
+	for _, k := range matches {
+		ambiguous = append(ambiguous, fmt.Sprintf("%d (%s)", k.ID, k.Fingerprint))
+	}
+	return nil, fmt.Errorf("%d SSH keys have that name: %s; use ssh_key_id or ssh_key_fingerprint instead",
+		len(matches), strings.Join(ambiguous, ", "))
+}
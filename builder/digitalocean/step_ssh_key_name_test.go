@@ -0,0 +1,80 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepSSHKeyName(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Write([]byte(`{"ssh_keys": [
+				{"id": 512189, "name": "ci", "fingerprint": "3b:16:bf:e4:8b:00:8b:b8:59:8c:a9:d3:f0:19:45:fa"},
+				{"id": 512190, "name": "shared", "fingerprint": "aa:16:bf:e4:8b:00:8b:b8:59:8c:a9:d3:f0:19:45:fa"}
+			], "links": {"pages": {"next": "` + "http://" + r.Host + `/v2/account/keys?page=2&per_page=200", "last": "` + "http://" + r.Host + `/v2/account/keys?page=2&per_page=200"}}}`))
+		case "2":
+			w.Write([]byte(`{"ssh_keys": [
+				{"id": 512191, "name": "ops", "fingerprint": "bb:16:bf:e4:8b:00:8b:b8:59:8c:a9:d3:f0:19:45:fa"},
+				{"id": 512192, "name": "shared", "fingerprint": "cc:16:bf:e4:8b:00:8b:b8:59:8c:a9:d3:f0:19:45:fa"}
+			], "links": {"pages": {"first": "` + "http://" + r.Host + `/v2/account/keys?page=1&per_page=200", "prev": "` + "http://" + r.Host + `/v2/account/keys?page=1&per_page=200"}}}`))
+		default:
+			t.Errorf("unexpected page: %s", r.URL.RawQuery)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		keyName       string
+		expectedID    int
+		expectedError string
+	}{
+		{name: "first page", keyName: "ci", expectedID: 512189},
+		{name: "second page", keyName: "ops", expectedID: 512191},
+		{name: "ambiguous", keyName: "shared", expectedError: "2 SSH keys have that name: 512190"},
+		{name: "unknown", keyName: "nobody", expectedError: "no SSH key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			state := new(multistep.BasicStateBag)
+			state.Put("client", client)
+			state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+			state.Put("config", &Config{SSHKeyName: tt.keyName})
+
+			action := new(stepSSHKeyName).Run(context.Background(), state)
+			if tt.expectedError != "" {
+				if action != multistep.ActionHalt {
+					t.Fatalf("bad action: %v", action)
+				}
+				if err := state.Get("error").(error); !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("bad error: %s", err)
+				}
+				return
+			}
+			if action != multistep.ActionContinue {
+				t.Fatalf("bad action: %v: %s", action, out.String())
+			}
+			if id := state.Get("named_ssh_key_id"); id != tt.expectedID {
+				t.Fatalf("bad id: %v", id)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepTagSnapshot tags the snapshot with the snapshot_tags, the checksum of
+// the user data the droplet was created with, and with its expiry date with
+// image_ttl.
+type stepTagSnapshot struct{}
+
+func (s *stepTagSnapshot) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	imageID := stateSnapshotImageID.Get(state)
+
+	tags := append([]string(nil), c.SnapshotTags...)
+	if checksum, ok := stateUserDataSHA256.GetOk(state); ok {
+		tags = append(tags, userDataTag(checksum))
+	}
+	if c.ImageTTL > 0 {
+		tags = append(tags, ExpiresTag(time.Now().Add(c.ImageTTL)))
+	}
+	if len(tags) == 0 {
+		return multistep.ActionContinue
+	}
+	if !apiSupports(state, apiFeatureTags) {
+		ui.Message("Not tagging snapshot, the API doesn't support tags")
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Tagging snapshot (ID: %d)...", imageID))
+	for _, tag := range tags {
+		if err := TagImage(ctx, client, imageID, tag); err != nil {
+			err := fmt.Errorf("Error tagging snapshot with %s: %s", tag, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+	if len(c.SnapshotTags) > 0 {
+		stateSnapshotTags.Put(state, c.SnapshotTags)
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepTagSnapshot) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// TagImage tags the image, creating the tag if it doesn't exist yet.
+func TagImage(ctx context.Context, client *godo.Client, imageID int, tag string) error {
+	if _, _, err := client.Tags.Create(ctx, &godo.TagCreateRequest{Name: tag}); err != nil {
+		return err
+	}
+
+	_, err := client.Tags.TagResources(ctx, tag, &godo.TagResourcesRequest{
+		Resources: []godo.Resource{{ID: strconv.Itoa(imageID), Type: godo.ImageResourceType}},
+	})
+	return err
+}
@@ -0,0 +1,65 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepTagSnapshot_SnapshotTags(t *testing.T) {
+	var tagged []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/tags":
+			var req godo.TagCreateRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]interface{}{"tag": map[string]string{"name": req.Name}})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/resources"):
+			var req godo.TagResourcesRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if len(req.Resources) != 1 || req.Resources[0].ID != "7938206" || req.Resources[0].Type != godo.ImageResourceType {
+				t.Errorf("bad resources: %#v", req.Resources)
+			}
+			tagged = append(tagged, strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v2/tags/"), "/resources"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{SnapshotTags: []string{"team:web", "base"}})
+	state.Put("snapshot_image_id", 7938206)
+
+	step := new(stepTagSnapshot)
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	if want := []string{"team:web", "base"}; !reflect.DeepEqual(tagged, want) {
+		t.Errorf("bad tags: %v, want %v", tagged, want)
+	}
+	if tags := newArtifactState(state).SnapshotTags; !reflect.DeepEqual(tags, []string{"team:web", "base"}) {
+		t.Errorf("tags not recorded in the artifact: %v", tags)
+	}
+}
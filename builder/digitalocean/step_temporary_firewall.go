@@ -0,0 +1,172 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/uuid"
+)
+
+// defaultPublicIPURL is an external service returning the public IPv4
+// address requests to it come from.
+const defaultPublicIPURL = "https://ipv4.icanhazip.com"
+
+// stepTemporaryFirewall creates a cloud firewall that only lets the
+// communicator in from the machine running Packer, or from the configured
+// sources, and applies it to the droplet for the duration of the build. With
+// outbound_lockdown, it also only lets the allowed traffic out.
+type stepTemporaryFirewall struct {
+	firewallID string
+}
+
+func (s *stepTemporaryFirewall) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+	dropletID := stateDropletID.Get(state)
+
+	if !c.TemporaryFirewall {
+		return multistep.ActionContinue
+	}
+
+	sources := c.TemporaryFirewallSourceCIDRs
+	if len(sources) == 0 {
+		publicIPURL := c.PublicIPURL
+		if publicIPURL == "" {
+			publicIPURL = defaultPublicIPURL
+		}
+		ip, err := runnerPublicIP(ctx, publicIPURL)
+		if err != nil {
+			err := fmt.Errorf("Error finding the public IP address of this machine: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		sources = []string{ip}
+	}
+
+	name := fmt.Sprintf("packer-%s", uuid.TimeOrderedUUID())
+	ui.Say(fmt.Sprintf("Creating temporary firewall %s allowing %s...", name, strings.Join(sources, ", ")))
+	firewall, _, err := client.Firewalls.Create(context.TODO(), &godo.FirewallRequest{
+		Name:          name,
+		InboundRules:  temporaryFirewallInboundRules(c, sources),
+		OutboundRules: temporaryFirewallOutboundRules(c),
+		DropletIDs:    []int{dropletID},
+	})
+	if err != nil {
+		err := fmt.Errorf("Error creating temporary firewall: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	s.firewallID = firewall.ID
+	stateTemporaryFirewallID.Put(state, firewall.ID)
+
+	if c.OutboundLockdown {
+		allowed := make([]string, 0, len(c.OutboundAllow))
+		for i := range c.OutboundAllow {
+			allowed = append(allowed, c.OutboundAllow[i].String())
+		}
+		if len(allowed) > 0 {
+			ui.Message(fmt.Sprintf("Outbound traffic is blocked except %s", strings.Join(allowed, "; ")))
+		} else {
+			ui.Message("All outbound traffic is blocked")
+		}
+		stateOutboundAllowed.Put(state, allowed)
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepTemporaryFirewall) Cleanup(state multistep.StateBag) {
+	if s.firewallID == "" {
+		return
+	}
+
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	ui.Say("Deleting temporary firewall...")
+	err := retryCleanup(state, "firewall "+s.firewallID, func() (*godo.Response, error) {
+		return client.Firewalls.Delete(context.TODO(), s.firewallID)
+	})
+	if err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error deleting temporary firewall %s. Please delete it manually: %s", s.firewallID, err))
+	}
+}
+
+// temporaryFirewallInboundRules lets the communicator in from sources.
+func temporaryFirewallInboundRules(c *Config, sources []string) []godo.InboundRule {
+	port := c.Comm.Port()
+	if c.Comm.Type == "none" || port == 0 {
+		return nil
+	}
+
+	return []godo.InboundRule{{
+		Protocol:  "tcp",
+		PortRange: strconv.Itoa(port),
+		Sources:   &godo.Sources{Addresses: sources},
+	}}
+}
+
+// temporaryFirewallOutboundRules allows all outbound traffic, which a cloud
+// firewall otherwise blocks, or only the allowed traffic with
+// outbound_lockdown.
+func temporaryFirewallOutboundRules(c *Config) []godo.OutboundRule {
+	if c.OutboundLockdown {
+		rules := make([]godo.OutboundRule, 0, len(c.OutboundAllow))
+		for i := range c.OutboundAllow {
+			rules = append(rules, c.OutboundAllow[i].rule())
+		}
+		return rules
+	}
+
+	everywhere := &godo.Destinations{Addresses: []string{"0.0.0.0/0", "::/0"}}
+	return []godo.OutboundRule{
+		{Protocol: "tcp", PortRange: "all", Destinations: everywhere},
+		{Protocol: "udp", PortRange: "all", Destinations: everywhere},
+		{Protocol: "icmp", Destinations: everywhere},
+	}
+}
+
+// runnerPublicIP returns the public IPv4 address of the machine running
+// Packer, as returned by the service at publicIPURL.
+func runnerPublicIP(ctx context.Context, publicIPURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, publicIPURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", publicIPURL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return "", err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil || ip.To4() == nil {
+		return "", fmt.Errorf("%s returned %q, not an IPv4 address", publicIPURL, body)
+	}
+	return ip.String(), nil
+}
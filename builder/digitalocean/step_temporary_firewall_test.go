@@ -0,0 +1,111 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepTemporaryFirewall(t *testing.T) {
+	ipServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("198.51.100.7\n"))
+	}))
+	defer ipServer.Close()
+
+	var created godo.FirewallRequest
+	deleted := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/firewalls":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"firewall": {"id": "fb6045f1-cf1d-4ca3-bfac-18832663025b"}}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/firewalls/fb6045f1-cf1d-4ca3-bfac-18832663025b":
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{
+		TemporaryFirewall: true,
+		PublicIPURL:       ipServer.URL,
+		Comm:              communicator.Config{Type: "ssh", SSH: communicator.SSH{SSHPort: 2222}},
+	})
+	state.Put("droplet_id", 3164444)
+
+	step := new(stepTemporaryFirewall)
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+
+	if len(created.DropletIDs) != 1 || created.DropletIDs[0] != 3164444 {
+		t.Fatalf("bad droplets: %v", created.DropletIDs)
+	}
+	if len(created.InboundRules) != 1 {
+		t.Fatalf("bad inbound rules: %#v", created.InboundRules)
+	}
+	rule := created.InboundRules[0]
+	if rule.PortRange != "2222" || len(rule.Sources.Addresses) != 1 || rule.Sources.Addresses[0] != "198.51.100.7" {
+		t.Fatalf("bad inbound rule: %#v", rule)
+	}
+	if len(created.OutboundRules) == 0 {
+		t.Fatal("outbound traffic should be allowed")
+	}
+	if state.Get("temporary_firewall_id") != "fb6045f1-cf1d-4ca3-bfac-18832663025b" {
+		t.Fatalf("bad firewall id: %v", state.Get("temporary_firewall_id"))
+	}
+
+	step.Cleanup(state)
+	if !deleted {
+		t.Fatal("firewall should have been deleted")
+	}
+}
+
+func TestTemporaryFirewallOutboundRules(t *testing.T) {
+	c := &Config{}
+	if rules := temporaryFirewallOutboundRules(c); len(rules) != 3 {
+		t.Fatalf("all outbound traffic should be allowed: %#v", rules)
+	}
+
+	c.OutboundLockdown = true
+	if rules := temporaryFirewallOutboundRules(c); len(rules) != 0 {
+		t.Fatalf("all outbound traffic should be blocked: %#v", rules)
+	}
+
+	c.OutboundAllow = []OutboundAllow{
+		{Addresses: []string{"10.0.0.0/8"}, Protocol: "tcp", Ports: "443"},
+		{Addresses: []string{"10.0.0.1"}, Protocol: "icmp", Ports: "all"},
+	}
+	rules := temporaryFirewallOutboundRules(c)
+	if len(rules) != 2 {
+		t.Fatalf("bad rules: %#v", rules)
+	}
+	if rules[0].PortRange != "443" || rules[0].Destinations.Addresses[0] != "10.0.0.0/8" {
+		t.Fatalf("bad rule: %#v", rules[0])
+	}
+	if rules[1].Protocol != "icmp" || rules[1].PortRange != "" {
+		t.Fatalf("bad rule: %#v", rules[1])
+	}
+}
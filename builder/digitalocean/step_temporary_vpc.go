@@ -0,0 +1,84 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/uuid"
+)
+
+// stepTemporaryVPC creates a VPC for the droplet with temporary_vpc, and
+// deletes it once the droplet is gone.
+type stepTemporaryVPC struct {
+	vpcID string
+}
+
+func (s *stepTemporaryVPC) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if !c.TemporaryVPC {
+		return multistep.ActionContinue
+	}
+
+	name := fmt.Sprintf("packer-%s", uuid.TimeOrderedUUID())
+	ui.Say(fmt.Sprintf("Creating temporary VPC %s in %s...", name, c.Region))
+	vpc, _, err := client.VPCs.Create(context.TODO(), &godo.VPCCreateRequest{
+		Name:        name,
+		RegionSlug:  c.Region,
+		Description: "Temporary VPC for a Packer build",
+		IPRange:     c.TemporaryVPCIPRange,
+	})
+	if err != nil {
+		err := fmt.Errorf("Error creating temporary VPC: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	s.vpcID = vpc.ID
+	stateTemporaryVPCUUID.Put(state, vpc.ID)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepTemporaryVPC) Cleanup(state multistep.StateBag) {
+	if s.vpcID == "" {
+		return
+	}
+
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if kept, _ := stateDropletKept.GetOk(state); kept {
+		ui.Message(fmt.Sprintf("Keeping temporary VPC %s with the droplet; delete it after the droplet", s.vpcID))
+		return
+	}
+
+	// The droplet leaves the VPC some time after it is deleted, and a VPC
+	// with members can't be deleted.
+	ui.Say("Deleting temporary VPC...")
+	err := poll(context.TODO(), c.StateTimeout, func(ctx context.Context, attempt int) (bool, error) {
+		members, _, err := client.VPCs.ListMembers(ctx, s.vpcID, nil, nil)
+		if err != nil {
+			return false, err
+		}
+		return len(members) == 0, nil
+	})
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error waiting for temporary VPC %s to be empty: %s", s.vpcID, err))
+	}
+
+	err = retryCleanup(state, "VPC "+s.vpcID, func() (*godo.Response, error) {
+		return client.VPCs.Delete(context.TODO(), s.vpcID)
+	})
+	if err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error deleting temporary VPC %s. Please delete it manually: %s", s.vpcID, err))
+	}
+}
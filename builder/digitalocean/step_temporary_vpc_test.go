@@ -0,0 +1,111 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepTemporaryVPC(t *testing.T) {
+	useFakeClock(t)
+
+	var created godo.VPCCreateRequest
+	memberChecks := 0
+	deleted := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/vpcs":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"vpc": {"id": "5a4981aa-9653-4bd1-bef5-d6bff52042e4", "region": "nyc3"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/vpcs/5a4981aa-9653-4bd1-bef5-d6bff52042e4/members":
+			memberChecks++
+			if memberChecks == 1 {
+				w.Write([]byte(`{"members": [{"urn": "do:droplet:3164444", "name": "packer-build"}]}`))
+				return
+			}
+			w.Write([]byte(`{"members": []}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/vpcs/5a4981aa-9653-4bd1-bef5-d6bff52042e4":
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{
+		Region:              "nyc3",
+		TemporaryVPC:        true,
+		TemporaryVPCIPRange: "10.200.0.0/24",
+		StateTimeout:        time.Minute,
+	})
+
+	step := new(stepTemporaryVPC)
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %v: %s", action, out.String())
+	}
+	if created.RegionSlug != "nyc3" || created.IPRange != "10.200.0.0/24" {
+		t.Fatalf("bad request: %#v", created)
+	}
+
+	// The droplet is created in the VPC
+	req, err := new(stepCreateDroplet).buildDropletCreateRequest(state)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if req.VPCUUID != "5a4981aa-9653-4bd1-bef5-d6bff52042e4" {
+		t.Fatalf("bad vpc: %s", req.VPCUUID)
+	}
+
+	step.Cleanup(state)
+	if memberChecks != 2 || !deleted {
+		t.Fatalf("the vpc should be deleted once empty: %d checks, deleted: %t", memberChecks, deleted)
+	}
+}
+
+func TestStepTemporaryVPC_keptDroplet(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("config", &Config{StateTimeout: time.Minute})
+	stateDropletKept.Put(state, true)
+
+	step := &stepTemporaryVPC{vpcID: "5a4981aa-9653-4bd1-bef5-d6bff52042e4"}
+	step.Cleanup(state)
+	if !strings.Contains(out.String(), "Keeping temporary VPC 5a4981aa-9653-4bd1-bef5-d6bff52042e4") {
+		t.Fatalf("missing message in output: %s", out.String())
+	}
+}
@@ -0,0 +1,188 @@
+package digitalocean
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepVerifyCleanup runs first so that its cleanup runs last, once every
+// other step has torn down what it created. It checks through the API that
+// the temporary resources of the build are gone, deletes any that are not,
+// and reports the ones that survive, along with any other resource whose
+// deletion failed, so they don't leak silently.
+type stepVerifyCleanup struct {
+	// Attempts is the number of times a surviving resource is checked for
+	// and deleted again.
+	Attempts int
+	// Interval is how long to wait for a deletion between checks.
+	Interval time.Duration
+}
+
+// temporaryResource is a resource created for the build that must not
+// outlive it.
+type temporaryResource struct {
+	name   string
+	exists func() (bool, error)
+	delete func() (*godo.Response, error)
+}
+
+func (s *stepVerifyCleanup) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	return multistep.ActionContinue
+}
+
+func (s *stepVerifyCleanup) Cleanup(state multistep.StateBag) {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	failures := cleanupFailures(state)
+	for _, r := range temporaryResources(client, state) {
+		if s.verifyDeleted(state, r) {
+			delete(failures, r.name)
+		} else if _, ok := failures[r.name]; !ok {
+			failures[r.name] = errors.New("still exists")
+		}
+	}
+
+	if len(failures) == 0 {
+		return
+	}
+
+	leftovers := make([]string, 0, len(failures))
+	for name, err := range failures {
+		leftovers = append(leftovers, fmt.Sprintf("%s: %s", name, err))
+	}
+	sort.Strings(leftovers)
+	ui.Error(fmt.Sprintf(
+		"Warning: the following resources may still exist after cleanup. "+
+			"Please delete them manually:\n  %s", strings.Join(leftovers, "\n  ")))
+}
+
+// verifyDeleted reports whether r is gone, deleting it again while it is
+// not. Each deletion is made once, the checks being its retries; its last
+// failure is recorded in the state.
+func (s *stepVerifyCleanup) verifyDeleted(state multistep.StateBag, r temporaryResource) bool {
+	for attempt := 1; ; attempt++ {
+		exists, err := r.exists()
+		if err != nil {
+			log.Printf("[DEBUG] Error checking for %s: %s", r.name, err)
+		} else if !exists {
+			return true
+		}
+		if attempt >= s.Attempts {
+			return false
+		}
+
+		if exists {
+			log.Printf("[DEBUG] %s still exists, deleting it again", r.name)
+			if resp, err := r.delete(); err != nil && !isNotFound(resp) {
+				log.Printf("[DEBUG] Error deleting %s: %s", r.name, err)
+				cleanupFailures(state)[r.name] = err
+			}
+		}
+		<-waitClock.After(s.Interval)
+	}
+}
+
+// temporaryResources returns the temporary resources the build created.
+func temporaryResources(client *godo.Client, state multistep.StateBag) []temporaryResource {
+	var resources []temporaryResource
+
+	// Retained droplets are the artifact or the user's droplet_id, and kept
+	// ones were kept on purpose by keep_droplet_on_error.
+	_, retained := stateDropletRetained.GetOk(state)
+	_, kept := stateDropletKept.GetOk(state)
+	if id, ok := stateDropletID.GetOk(state); ok && !retained && !kept {
+		dropletID := id
+		resources = append(resources, temporaryResource{
+			name: "droplet " + strconv.Itoa(dropletID),
+			exists: func() (bool, error) {
+				_, resp, err := client.Droplets.Get(context.TODO(), dropletID)
+				return apiResourceExists(resp, err)
+			},
+			delete: func() (*godo.Response, error) {
+				return client.Droplets.Delete(context.TODO(), dropletID)
+			},
+		})
+	}
+
+	if id, ok := stateSSHKeyID.GetOk(state); ok {
+		keyID := id
+		resources = append(resources, temporaryResource{
+			name: "ssh key " + strconv.Itoa(keyID),
+			exists: func() (bool, error) {
+				_, resp, err := client.Keys.GetByID(context.TODO(), keyID)
+				return apiResourceExists(resp, err)
+			},
+			delete: func() (*godo.Response, error) {
+				return client.Keys.DeleteByID(context.TODO(), keyID)
+			},
+		})
+	}
+
+	if id, ok := stateTemporaryVPCUUID.GetOk(state); ok {
+		vpcID := id
+		resources = append(resources, temporaryResource{
+			name: "VPC " + vpcID,
+			exists: func() (bool, error) {
+				_, resp, err := client.VPCs.Get(context.TODO(), vpcID)
+				return apiResourceExists(resp, err)
+			},
+			delete: func() (*godo.Response, error) {
+				return client.VPCs.Delete(context.TODO(), vpcID)
+			},
+		})
+	}
+
+	if id, ok := stateTemporaryFirewallID.GetOk(state); ok {
+		firewallID := id
+		resources = append(resources, temporaryResource{
+			name: "firewall " + firewallID,
+			exists: func() (bool, error) {
+				_, resp, err := client.Firewalls.Get(context.TODO(), firewallID)
+				return apiResourceExists(resp, err)
+			},
+			delete: func() (*godo.Response, error) {
+				return client.Firewalls.Delete(context.TODO(), firewallID)
+			},
+		})
+	}
+
+	if ip, ok := stateReservedIPCreated.GetOk(state); ok {
+		reservedIP := ip
+		resources = append(resources, temporaryResource{
+			name: "reserved IP " + reservedIP,
+			exists: func() (bool, error) {
+				_, resp, err := client.ReservedIPs.Get(context.TODO(), reservedIP)
+				return apiResourceExists(resp, err)
+			},
+			delete: func() (*godo.Response, error) {
+				return client.ReservedIPs.Delete(context.TODO(), reservedIP)
+			},
+		})
+	}
+
+	return resources
+}
+
+// apiResourceExists interprets the result of getting a resource: a 404
+// means it's gone.
+func apiResourceExists(resp *godo.Response, err error) (bool, error) {
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,75 @@
+package digitalocean
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepVerifyCleanup(t *testing.T) {
+	useFakeClock(t)
+
+	var dropletDeletes, keyDeletes int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/droplets/123":
+			// The droplet survives its first deletion
+			if dropletDeletes < 2 {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"droplet": {"id": 123}}`))
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/droplets/123":
+			dropletDeletes++
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/account/keys/456":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ssh_key": {"id": 456}}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/account/keys/456":
+			keyDeletes++
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &errOut})
+	state.Put("droplet_id", 123)
+	state.Put("ssh_key_id", 456)
+
+	// The droplet was already deleted once by its own step
+	dropletDeletes = 1
+	step := &stepVerifyCleanup{Attempts: 3}
+	step.Cleanup(state)
+
+	if dropletDeletes != 2 {
+		t.Errorf("droplet deleted %d times, want 2", dropletDeletes)
+	}
+	// Once per check, not retried in between.
+	if keyDeletes != 2 {
+		t.Errorf("ssh key deleted %d times, want 2", keyDeletes)
+	}
+	if strings.Contains(errOut.String(), "droplet 123") {
+		t.Errorf("droplet reported as surviving: %s", errOut.String())
+	}
+	if !strings.Contains(errOut.String(), "ssh key 456") {
+		t.Errorf("ssh key not reported as surviving: %s", errOut.String())
+	}
+}
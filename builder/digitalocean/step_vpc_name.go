@@ -0,0 +1,59 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepVPCName looks up the UUID of the VPC named with vpc_name.
+type stepVPCName struct{}
+
+func (s *stepVPCName) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if c.VPCName == "" {
+		return multistep.ActionContinue
+	}
+
+	vpc, err := findVPC(client, c.VPCName, c.Region)
+	if err != nil {
+		err := fmt.Errorf("Error finding VPC %q: %s", c.VPCName, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Message(fmt.Sprintf("Using VPC %s (%s)", vpc.Name, vpc.ID))
+	stateVPCUUID.Put(state, vpc.ID)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepVPCName) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// findVPC returns the VPC named name, which must be in region.
+func findVPC(client *godo.Client, name, region string) (*godo.VPC, error) {
+	vpcs, err := ListAll(context.TODO(), client.VPCs.List)
+	if err != nil {
+		return nil, err
+	}
+	for _, vpc := range vpcs {
+		if vpc.Name != name {
+			continue
+		}
+		if vpc.RegionSlug != region {
+			return nil, fmt.Errorf("the VPC is in %s, not in %s", vpc.RegionSlug, region)
+		}
+		return vpc, nil
+	}
+
+	return nil, fmt.Errorf("no VPC with that name")
+}
@@ -0,0 +1,63 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepVPCName(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"vpcs": [
+			{"id": "5a4981aa-9653-4bd1-bef5-d6bff52042e4", "name": "build-nyc3", "region": "nyc3"},
+			{"id": "e0fe0f4d-596a-465e-a902-571ce57b79fa", "name": "build-ams3", "region": "ams3"}
+		]}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		vpcName      string
+		expectedUUID string
+	}{
+		{name: "found", vpcName: "build-nyc3", expectedUUID: "5a4981aa-9653-4bd1-bef5-d6bff52042e4"},
+		{name: "other region", vpcName: "build-ams3"},
+		{name: "unknown", vpcName: "build-sfo3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			state := new(multistep.BasicStateBag)
+			state.Put("client", client)
+			state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+			state.Put("config", &Config{Region: "nyc3", VPCName: tt.vpcName})
+
+			action := new(stepVPCName).Run(context.Background(), state)
+			if tt.expectedUUID == "" {
+				if action != multistep.ActionHalt {
+					t.Fatalf("bad action: %v", action)
+				}
+				return
+			}
+			if action != multistep.ActionContinue {
+				t.Fatalf("bad action: %v: %s", action, out.String())
+			}
+			if uuid := state.Get("vpc_uuid"); uuid != tt.expectedUUID {
+				t.Fatalf("bad uuid: %v", uuid)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepVPCPeering checks that the build VPC is peered with the VPCs set with
+// required_vpc_peerings, before the droplet is created.
+type stepVPCPeering struct{}
+
+// vpcPeering is a peering between two VPCs. godo doesn't support VPC
+// peerings yet, so they are read with raw requests.
+type vpcPeering struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	VPCIDs []string `json:"vpc_ids"`
+	Status string   `json:"status"`
+}
+
+func (s *stepVPCPeering) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if len(c.RequiredVPCPeerings) == 0 {
+		return multistep.ActionContinue
+	}
+
+	vpcID := c.VPCUUID
+	if id, ok := stateVPCUUID.GetOk(state); ok {
+		vpcID = id
+	}
+
+	ui.Say(fmt.Sprintf("Checking the peerings of VPC %s...", vpcID))
+	peerings, err := listVPCPeerings(ctx, client, vpcID)
+	if err != nil {
+		err := fmt.Errorf("Error listing the peerings of VPC %s: %s", vpcID, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	var problems []string
+	for _, required := range c.RequiredVPCPeerings {
+		peering, ok := findVPCPeering(peerings, vpcID, required)
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("not peered with VPC %s", required))
+		case peering.Status != "ACTIVE":
+			problems = append(problems, fmt.Sprintf("peering %s with VPC %s is %s, not ACTIVE",
+				peering.Name, required, peering.Status))
+		default:
+			ui.Message(fmt.Sprintf("Peered with VPC %s (%s)", required, peering.Name))
+		}
+	}
+	if len(problems) > 0 {
+		err := fmt.Errorf("VPC %s is missing required peerings: %s", vpcID, strings.Join(problems, "; "))
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepVPCPeering) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// findVPCPeering returns the peering between the two VPCs.
+func findVPCPeering(peerings []vpcPeering, vpcID, peerID string) (vpcPeering, bool) {
+	for _, p := range peerings {
+		has := map[string]bool{}
+		for _, id := range p.VPCIDs {
+			has[id] = true
+		}
+		if has[vpcID] && has[peerID] {
+			return p, true
+		}
+	}
+	return vpcPeering{}, false
+}
+
+// listVPCPeerings lists the peerings of the VPC.
+func listVPCPeerings(ctx context.Context, client *godo.Client, vpcID string) ([]vpcPeering, error) {
+	return ListAll(ctx, func(ctx context.Context, opt *godo.ListOptions) ([]vpcPeering, *godo.Response, error) {
+		path := fmt.Sprintf("v2/vpcs/%s/peerings?page=%d&per_page=%d", vpcID, opt.Page, opt.PerPage)
+		req, err := client.NewRequest(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		root := new(struct {
+			Peerings []vpcPeering `json:"peerings"`
+			Links    *godo.Links  `json:"links"`
+		})
+		resp, err := client.Do(ctx, req, root)
+		if err != nil {
+			return nil, resp, err
+		}
+		resp.Links = root.Links
+		return root.Peerings, resp, nil
+	})
+}
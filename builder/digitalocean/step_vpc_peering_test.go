@@ -0,0 +1,74 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepVPCPeering(t *testing.T) {
+	const vpcID = "5a4981aa-9653-4bd1-bef5-d6bff52042e4"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/vpcs/"+vpcID+"/peerings" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"peerings": [
+			{"id": "p-1", "name": "to-services", "status": "ACTIVE",
+			 "vpc_ids": ["` + vpcID + `", "e0fe0f4d-596a-465e-a902-571ce57b79fa"]},
+			{"id": "p-2", "name": "to-data", "status": "PROVISIONING",
+			 "vpc_ids": ["` + vpcID + `", "7b2d6a2d-bd5c-4fde-8fd9-21f7be5d1cc4"]}
+		], "links": {}, "meta": {"total": 2}}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		peers         []string
+		expectedError string
+	}{
+		{name: "active", peers: []string{"e0fe0f4d-596a-465e-a902-571ce57b79fa"}},
+		{name: "provisioning", peers: []string{"7b2d6a2d-bd5c-4fde-8fd9-21f7be5d1cc4"}, expectedError: "is PROVISIONING"},
+		{name: "missing", peers: []string{"e0fe0f4d-596a-465e-a902-571ce57b79fa", "0d3db13e-a604-4944-9827-7ec2642d32ac"},
+			expectedError: "not peered with VPC 0d3db13e-a604-4944-9827-7ec2642d32ac"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			state := new(multistep.BasicStateBag)
+			state.Put("client", client)
+			state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+			state.Put("config", &Config{RequiredVPCPeerings: tt.peers})
+			state.Put("vpc_uuid", vpcID)
+
+			action := new(stepVPCPeering).Run(context.Background(), state)
+			if tt.expectedError == "" {
+				if action != multistep.ActionContinue {
+					t.Fatalf("bad action: %v: %s", action, out.String())
+				}
+				return
+			}
+			if action != multistep.ActionHalt {
+				t.Fatalf("bad action: %v", action)
+			}
+			if err := state.Get("error").(error); !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("bad error: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// cloudInitStatusCommand waits for cloud-init to finish with cloud-init
+// status --wait, bounded by a number of seconds. It exits with status 127
+// when cloud-init is missing or too old to wait.
+const cloudInitStatusCommand = "command -v cloud-init >/dev/null 2>&1 && " +
+	"cloud-init status --help 2>&1 | grep -q -- --wait || exit 127; " +
+	"timeout %d cloud-init status --wait --long"
+
+// cloudInitResultCommand prints the result cloud-init writes once it has
+// finished, for releases without cloud-init status --wait.
+const cloudInitResultCommand = "cat /run/cloud-init/result.json"
+
+// The exit statuses of cloudInitStatusCommand.
+const (
+	cloudInitDone        = 0
+	cloudInitError       = 1
+	cloudInitDegraded    = 2
+	cloudInitTimeout     = 124
+	cloudInitUnsupported = 127
+)
+
+// stepWaitCloudInit waits for cloud-init to finish with wait_for_cloud_init
+// before provisioning, so that provisioners don't race with user_data for
+// package locks and users it hasn't finished creating. The build fails if
+// cloud-init reports errors.
+type stepWaitCloudInit struct{}
+
+func (s *stepWaitCloudInit) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if !c.WaitForCloudInit {
+		return multistep.ActionContinue
+	}
+	if cloudInit, ok := stateCloudInit.GetOk(state); ok && !cloudInit {
+		ui.Say("Image does not run cloud-init, not waiting for it")
+		return multistep.ActionContinue
+	}
+
+	comm := state.Get("communicator").(packersdk.Communicator)
+
+	ui.Say("Waiting for cloud-init to finish...")
+	seconds := int(c.CloudInitTimeout / time.Second)
+	cmd := &packersdk.RemoteCmd{Command: fmt.Sprintf(cloudInitStatusCommand, seconds)}
+	if err := cmd.RunWithUi(ctx, comm, ui); err != nil {
+		err := fmt.Errorf("Error waiting for cloud-init: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	var err error
+	switch status := cmd.ExitStatus(); status {
+	case cloudInitDone:
+		ui.Message("cloud-init finished")
+	case cloudInitDegraded:
+		// Recent releases report deprecated configuration this way
+		ui.Error("Warning: cloud-init finished with recoverable errors, see its status above")
+	case cloudInitError:
+		err = fmt.Errorf("cloud-init finished with errors, see its status above")
+	case cloudInitTimeout:
+		err = fmt.Errorf("Timeout after %s waiting for cloud-init to finish", c.CloudInitTimeout)
+	case cloudInitUnsupported:
+		log.Println("[DEBUG] cloud-init status --wait is unavailable, polling for its result")
+		err = waitCloudInitResult(ctx, comm, c.CloudInitTimeout)
+		if err == nil {
+			ui.Message("cloud-init finished")
+		}
+	default:
+		err = fmt.Errorf("Error waiting for cloud-init: exited with status %d", status)
+	}
+	if err != nil {
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepWaitCloudInit) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// cloudInitResult is the result file cloud-init writes once it has
+// finished.
+type cloudInitResult struct {
+	V1 struct {
+		Errors []string `json:"errors"`
+	} `json:"v1"`
+}
+
+// waitCloudInitResult polls for the result file of cloud-init until it
+// appears or timeout elapses, and fails if the result has errors.
+func waitCloudInitResult(ctx context.Context, comm packersdk.Communicator, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for attempt := 1; ; attempt++ {
+		var stdout bytes.Buffer
+		cmd := &packersdk.RemoteCmd{Command: cloudInitResultCommand, Stdout: &stdout}
+		if err := comm.Start(ctx, cmd); err != nil {
+			log.Printf("[DEBUG] Error reading the cloud-init result (attempt %d): %s", attempt, err)
+		} else if status := cmd.Wait(); status == 0 {
+			var result cloudInitResult
+			if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+				return fmt.Errorf("Error reading the cloud-init result: %s", err)
+			}
+			if len(result.V1.Errors) > 0 {
+				return fmt.Errorf("cloud-init finished with errors: %s", strings.Join(result.V1.Errors, "; "))
+			}
+			return nil
+		} else {
+			log.Printf("[DEBUG] cloud-init hasn't finished (attempt %d)", attempt)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timeout after %s waiting for cloud-init to finish", timeout)
+		}
+		if err := sleepContext(ctx, 10*time.Second); err != nil {
+			return fmt.Errorf("Cancelled waiting for cloud-init")
+		}
+	}
+}
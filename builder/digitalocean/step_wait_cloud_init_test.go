@@ -0,0 +1,126 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// cloudInitCommunicator answers the commands of stepWaitCloudInit.
+type cloudInitCommunicator struct {
+	packersdk.MockCommunicator
+	statusExit   int
+	statusOutput string
+	result       string
+	commands     []string
+}
+
+func (c *cloudInitCommunicator) Start(ctx context.Context, cmd *packersdk.RemoteCmd) error {
+	c.commands = append(c.commands, cmd.Command)
+	go func() {
+		if cmd.Command == cloudInitResultCommand {
+			if cmd.Stdout != nil {
+				io.WriteString(cmd.Stdout, c.result)
+			}
+			cmd.SetExited(0)
+			return
+		}
+		if cmd.Stdout != nil {
+			io.WriteString(cmd.Stdout, c.statusOutput)
+		}
+		cmd.SetExited(c.statusExit)
+	}()
+	return nil
+}
+
+func TestStepWaitCloudInit(t *testing.T) {
+	cases := []struct {
+		name   string
+		comm   *cloudInitCommunicator
+		halt   bool
+		output string
+	}{
+		{
+			name:   "done",
+			comm:   &cloudInitCommunicator{statusExit: cloudInitDone, statusOutput: "status: done\n"},
+			output: "cloud-init finished",
+		},
+		{
+			name:   "degraded",
+			comm:   &cloudInitCommunicator{statusExit: cloudInitDegraded, statusOutput: "status: done\n"},
+			output: "recoverable errors",
+		},
+		{
+			name:   "error",
+			comm:   &cloudInitCommunicator{statusExit: cloudInitError, statusOutput: "status: error\n"},
+			halt:   true,
+			output: "cloud-init finished with errors",
+		},
+		{
+			name:   "timeout",
+			comm:   &cloudInitCommunicator{statusExit: cloudInitTimeout},
+			halt:   true,
+			output: "Timeout after 5m0s",
+		},
+		{
+			name: "result",
+			comm: &cloudInitCommunicator{
+				statusExit: cloudInitUnsupported,
+				result:     `{"v1": {"datasource": "DataSourceDigitalOcean", "errors": []}}`,
+			},
+			output: "cloud-init finished",
+		},
+		{
+			name: "result errors",
+			comm: &cloudInitCommunicator{
+				statusExit: cloudInitUnsupported,
+				result:     `{"v1": {"errors": ["('scripts_user', RuntimeError('Runparts: 1 failures'))"]}}`,
+			},
+			halt:   true,
+			output: "Runparts: 1 failures",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			state := new(multistep.BasicStateBag)
+			state.Put("config", &Config{WaitForCloudInit: true, CloudInitTimeout: 5 * time.Minute})
+			state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+			state.Put("communicator", tc.comm)
+
+			action := new(stepWaitCloudInit).Run(context.Background(), state)
+			if tc.halt != (action == multistep.ActionHalt) {
+				t.Fatalf("unexpected action %v: %s", action, out.String())
+			}
+			if !strings.Contains(out.String(), tc.output) {
+				t.Fatalf("missing %q in output: %s", tc.output, out.String())
+			}
+			if !strings.Contains(tc.comm.commands[0], "timeout 300 cloud-init status --wait") {
+				t.Fatalf("bad command: %s", tc.comm.commands[0])
+			}
+		})
+	}
+}
+
+func TestStepWaitCloudInit_NoCloudInit(t *testing.T) {
+	comm := new(cloudInitCommunicator)
+	state := new(multistep.BasicStateBag)
+	state.Put("config", &Config{WaitForCloudInit: true, CloudInitTimeout: 5 * time.Minute})
+	state.Put("ui", &packersdk.BasicUi{Writer: new(bytes.Buffer)})
+	state.Put("communicator", comm)
+	state.Put("cloud_init", false)
+
+	if action := new(stepWaitCloudInit).Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected halt: %v", state.Get("error"))
+	}
+	if len(comm.commands) != 0 {
+		t.Fatalf("unexpected commands: %v", comm.commands)
+	}
+}
@@ -0,0 +1,65 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// gpuReadyCommand succeeds once the GPU driver stack of an AI/ML image has
+// loaded, on NVIDIA and AMD GPU droplets alike.
+const gpuReadyCommand = "nvidia-smi >/dev/null 2>&1 || rocm-smi >/dev/null 2>&1"
+
+// stepWaitGPU waits for the GPU stack of an AI/ML image to come up on a GPU
+// droplet before provisioning. The drivers finish loading a while after the
+// droplet accepts connections, and provisioners using the GPU fail until
+// they have.
+type stepWaitGPU struct{}
+
+func (s *stepWaitGPU) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if !isGPUImage(c.Image) || !isGPUSize(c.Size) || c.Comm.Type == "none" {
+		return multistep.ActionContinue
+	}
+
+	comm := state.Get("communicator").(packersdk.Communicator)
+
+	ui.Say("Waiting for the GPU stack to become ready...")
+	deadline := time.Now().Add(c.GPUReadyTimeout)
+	for attempt := 1; ; attempt++ {
+		cmd := &packersdk.RemoteCmd{Command: gpuReadyCommand}
+		if err := comm.Start(ctx, cmd); err != nil {
+			log.Printf("[DEBUG] Error checking the GPU stack (attempt %d): %s", attempt, err)
+		} else if status := cmd.Wait(); status == 0 {
+			ui.Message("GPU stack is ready")
+			return multistep.ActionContinue
+		} else {
+			log.Printf("[DEBUG] GPU stack not ready (attempt %d): exit status %d", attempt, status)
+		}
+
+		if time.Now().After(deadline) {
+			err := fmt.Errorf("Timeout after %s waiting for the GPU stack to become ready; "+
+				"check that nvidia-smi or rocm-smi works on the image", c.GPUReadyTimeout)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+
+		if err := sleepContext(ctx, 10*time.Second); err != nil {
+			err := fmt.Errorf("Cancelled waiting for the GPU stack")
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+}
+
+func (s *stepWaitGPU) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
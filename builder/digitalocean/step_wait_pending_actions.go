@@ -0,0 +1,89 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// pendingActionsPageSize is how many of the droplet's most recent actions
+// are checked for pending ones. Actions are listed newest first, and only
+// the recent ones can still be in progress.
+const pendingActionsPageSize = 50
+
+// stepWaitPendingActions waits for the droplet's actions in progress, such
+// as the agent install or networking changes, to complete before the
+// shutdown or the snapshot. The API refuses new actions with a "pending
+// event" error while another one is in progress.
+type stepWaitPendingActions struct {
+	// Before describes what the wait comes before, for the output.
+	Before string
+}
+
+func (s *stepWaitPendingActions) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	c := state.Get("config").(*Config)
+	ui := state.Get("ui").(packersdk.Ui)
+	dropletId := stateDropletID.Get(state)
+
+	var pending []godo.Action
+	err := poll(ctx, c.StateTimeout, func(ctx context.Context, attempt int) (bool, error) {
+		log.Printf("Checking for pending droplet actions... (attempt: %d)", attempt)
+		var err error
+		pending, err = pendingActions(ctx, client, dropletId)
+		if err != nil {
+			return false, err
+		}
+		if len(pending) > 0 && attempt == 1 {
+			ui.Say(fmt.Sprintf("Waiting for pending droplet actions (%s) to complete before %s...",
+				actionTypes(pending), s.Before))
+		}
+		return len(pending) == 0, nil
+	})
+	if err == ErrPollTimeout {
+		err = fmt.Errorf("Timeout while waiting for pending droplet actions (%s) to complete", actionTypes(pending))
+	} else if err != nil {
+		err = fmt.Errorf("Error checking for pending droplet actions: %s", err)
+	}
+	if err != nil {
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepWaitPendingActions) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+// pendingActions returns the droplet's recent actions that are still in
+// progress.
+func pendingActions(ctx context.Context, client *godo.Client, dropletId int) ([]godo.Action, error) {
+	actions, _, err := client.Droplets.Actions(ctx, dropletId, &godo.ListOptions{PerPage: pendingActionsPageSize})
+	if err != nil {
+		return nil, err
+	}
+	var pending []godo.Action
+	for _, a := range actions {
+		if a.Status == godo.ActionInProgress {
+			pending = append(pending, a)
+		}
+	}
+	return pending, nil
+}
+
+// actionTypes lists the types of actions, for the output.
+func actionTypes(actions []godo.Action) string {
+	types := make([]string, len(actions))
+	for i, a := range actions {
+		types[i] = a.Type
+	}
+	return strings.Join(types, ", ")
+}
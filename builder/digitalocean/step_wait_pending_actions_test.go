@@ -0,0 +1,94 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepWaitPendingActions(t *testing.T) {
+	useFakeClock(t)
+
+	// The agent install completes on the third check.
+	checks := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v2/droplets/3164444/actions" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		checks++
+		status := "in-progress"
+		if checks >= 3 {
+			status = "completed"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"actions": [
+			{"id": 2, "type": "agent_install", "status": "` + status + `"},
+			{"id": 1, "type": "create", "status": "completed"}
+		]}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("config", &Config{StateTimeout: time.Minute})
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("droplet_id", 3164444)
+
+	step := &stepWaitPendingActions{Before: "the shutdown"}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected halt: %v", state.Get("error"))
+	}
+	if checks != 3 {
+		t.Fatalf("should have checked until the action completed: %d checks", checks)
+	}
+	if !strings.Contains(out.String(), "Waiting for pending droplet actions (agent_install) to complete before the shutdown") {
+		t.Fatalf("missing message in output: %s", out.String())
+	}
+}
+
+func TestStepWaitPendingActions_timeout(t *testing.T) {
+	useFakeClock(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"actions": [{"id": 2, "type": "enable_ipv6", "status": "in-progress"}]}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("client", client)
+	state.Put("config", &Config{StateTimeout: time.Minute})
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("droplet_id", 3164444)
+
+	step := &stepWaitPendingActions{Before: "the snapshot"}
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatal("should halt when the actions don't complete")
+	}
+	err = state.Get("error").(error)
+	if !strings.Contains(err.Error(), "Timeout while waiting for pending droplet actions (enable_ipv6)") {
+		t.Fatalf("bad error: %s", err)
+	}
+}
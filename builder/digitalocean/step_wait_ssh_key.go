@@ -0,0 +1,141 @@
+package digitalocean
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshKeyPollInterval is how long stepWaitSSHKey waits between connections.
+const sshKeyPollInterval = 5 * time.Second
+
+// errSSHKeyRejected is returned while polling once the droplet has rejected
+// the SSH key for ssh_key_propagation_timeout.
+var errSSHKeyRejected = errors.New("SSH key rejected")
+
+// stepWaitSSHKey waits for the SSH key injected into the droplet to be
+// accepted. A droplet can start its SSH server before cloud-init has
+// installed the key; those authentication failures are retried for
+// ssh_key_propagation_timeout, so that a key that never arrives fails with a
+// clear error. Connection failures, such as a droplet that is still booting,
+// are retried for ssh_timeout.
+type stepWaitSSHKey struct {
+	// Host returns the address to connect to; it's the same function the
+	// communicator uses.
+	Host func(multistep.StateBag) (string, error)
+	// SSHConfig returns the client configuration used by the communicator.
+	SSHConfig func(multistep.StateBag) (*ssh.ClientConfig, error)
+}
+
+func (s *stepWaitSSHKey) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if c.Comm.Type != "ssh" || c.Comm.SSHBastionHost != "" || c.Comm.SSHProxyHost != "" || c.DropletID != 0 {
+		log.Println("[DEBUG] Not waiting for SSH key propagation")
+		return multistep.ActionContinue
+	}
+
+	host, err := s.Host(state)
+	if err != nil {
+		err := fmt.Errorf("Error getting SSH address: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(c.Comm.SSHPort))
+
+	sshConfig, err := s.SSHConfig(state)
+	if err != nil {
+		err := fmt.Errorf("Error getting SSH config: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say("Waiting for the droplet to accept the SSH key...")
+
+	// The droplet is dialed until it accepts the key or ssh_timeout elapses.
+	// The grace period for the key only starts once the droplet rejects it.
+	var authDeadline time.Time
+	var authErr error
+	err = PollEvery(ctx, sshKeyPollInterval, c.Comm.SSHTimeout, func(_ context.Context, attempt int) (bool, error) {
+		err := dialSSH(address, sshConfig)
+		if err == nil {
+			if authErr != nil {
+				ui.Message(fmt.Sprintf("SSH key accepted after %d attempts", attempt))
+			}
+			return true, nil
+		}
+
+		if !isSSHAuthError(err) {
+			log.Printf("[DEBUG] SSH connection to %s failed, retrying: %s", address, err)
+			return false, nil
+		}
+
+		authErr = err
+		if authDeadline.IsZero() {
+			authDeadline = waitClock.Now().Add(c.SSHKeyPropagationTimeout)
+		}
+		if !waitClock.Now().Before(authDeadline) {
+			return false, errSSHKeyRejected
+		}
+		ui.Message(fmt.Sprintf("SSH key not yet accepted by the droplet, retrying (attempt %d)...", attempt))
+		return false, nil
+	})
+	switch {
+	case err == nil:
+		return multistep.ActionContinue
+	case err == ErrPollTimeout && authErr == nil:
+		// The droplet never became reachable; let the communicator, and the
+		// connection recovery around it, deal with that.
+		log.Printf("[DEBUG] SSH on %s unreachable for %s, leaving it to the communicator", address, c.Comm.SSHTimeout)
+		return multistep.ActionContinue
+	case err == ErrPollTimeout || err == errSSHKeyRejected:
+		err := fmt.Errorf("The droplet rejected the SSH key for %s; the key was "+
+			"likely never installed. Check that the image runs cloud-init and "+
+			"that ssh_username is correct. Last error: %s", c.SSHKeyPropagationTimeout, authErr)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	default:
+		err := fmt.Errorf("Cancelled waiting for SSH key propagation")
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+}
+
+func (s *stepWaitSSHKey) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
+
+func dialSSH(address string, config *ssh.ClientConfig) error {
+	cfg := *config
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	client, err := ssh.Dial("tcp", address, &cfg)
+	if err != nil {
+		return err
+	}
+	return client.Close()
+}
+
+// isSSHAuthError reports whether err is an SSH authentication failure, as
+// opposed to a network error or a server that isn't listening yet.
+func isSSHAuthError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "unable to authenticate") ||
+		strings.Contains(msg, "no supported methods remain")
+}
@@ -0,0 +1,179 @@
+package digitalocean
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"golang.org/x/crypto/ssh"
+)
+
+// startSSHServer starts an SSH server on the loopback interface whose n-th
+// connection, from 1, is authenticated if accept(n), and returns its port
+// and the number of connections it got.
+func startSSHServer(t *testing.T, accept func(n int) bool) (int, *int32) {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	var conns int32
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			n := int(atomic.AddInt32(&conns, 1))
+			config := &ssh.ServerConfig{
+				PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+					if accept(n) {
+						return nil, nil
+					}
+					return nil, errors.New("key not installed yet")
+				},
+			}
+			config.AddHostKey(hostSigner)
+			go func() {
+				defer conn.Close()
+				if sconn, chans, reqs, err := ssh.NewServerConn(conn, config); err == nil {
+					go ssh.DiscardRequests(reqs)
+					go func() {
+						for ch := range chans {
+							ch.Reject(ssh.Prohibited, "no channels")
+						}
+					}()
+					sconn.Wait()
+				}
+			}()
+		}
+	}()
+
+	return l.Addr().(*net.TCPAddr).Port, &conns
+}
+
+func testStepWaitSSHKeyState(t *testing.T, port int, sshTimeout, keyTimeout time.Duration) (*stepWaitSSHKey, multistep.StateBag) {
+	_, clientKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientSigner, err := ssh.NewSignerFromKey(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", packersdk.TestUi(t))
+	state.Put("config", &Config{
+		Comm: communicator.Config{
+			Type: "ssh",
+			SSH:  communicator.SSH{SSHPort: port, SSHTimeout: sshTimeout},
+		},
+		SSHKeyPropagationTimeout: keyTimeout,
+	})
+
+	step := &stepWaitSSHKey{
+		Host: func(multistep.StateBag) (string, error) { return "127.0.0.1", nil },
+		SSHConfig: func(multistep.StateBag) (*ssh.ClientConfig, error) {
+			return &ssh.ClientConfig{
+				User:            "root",
+				Auth:            []ssh.AuthMethod{ssh.PublicKeys(clientSigner)},
+				HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+			}, nil
+		},
+	}
+	return step, state
+}
+
+func TestStepWaitSSHKey_KeyPropagates(t *testing.T) {
+	useFakeClock(t)
+
+	// The key is installed after the droplet rejected it twice.
+	port, conns := startSSHServer(t, func(n int) bool { return n > 2 })
+	step, state := testStepWaitSSHKeyState(t, port, time.Hour, time.Minute)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %v", action, state.Get("error"))
+	}
+	if got := atomic.LoadInt32(conns); got != 3 {
+		t.Fatalf("connections: got %d, want 3", got)
+	}
+}
+
+func TestStepWaitSSHKey_KeyNeverPropagates(t *testing.T) {
+	clock := useFakeClock(t)
+	start := clock.Now()
+
+	port, _ := startSSHServer(t, func(int) bool { return false })
+	step, state := testStepWaitSSHKeyState(t, port, time.Hour, time.Minute)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if err := state.Get("error").(error); !strings.Contains(err.Error(), "rejected the SSH key") {
+		t.Fatalf("bad error: %s", err)
+	}
+	// The grace period is the key's, not ssh_timeout.
+	if waited := clock.Now().Sub(start); waited < time.Minute || waited > 2*time.Minute {
+		t.Fatalf("waited %s", waited)
+	}
+}
+
+func TestStepWaitSSHKey_Unreachable(t *testing.T) {
+	clock := useFakeClock(t)
+	start := clock.Now()
+
+	// A port nothing listens on, like a droplet that is still booting.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	step, state := testStepWaitSSHKeyState(t, port, time.Minute, time.Hour)
+
+	// The droplet is retried for ssh_timeout, then left to the communicator.
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %v", action, state.Get("error"))
+	}
+	if waited := clock.Now().Sub(start); waited < time.Minute {
+		t.Fatalf("gave up after %s", waited)
+	}
+}
+
+func TestIsSSHAuthError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none publickey], no supported methods remain"), true},
+		{errors.New("dial tcp 192.0.2.1:22: connect: connection refused"), false},
+		{errors.New("ssh: handshake failed: EOF"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isSSHAuthError(tt.err); got != tt.want {
+			t.Errorf("%s: got %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
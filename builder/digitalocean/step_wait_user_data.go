@@ -0,0 +1,78 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepWaitUserData waits for the user data to finish provisioning the
+// droplet with user_data_completion, for builds with the none communicator,
+// which have no other way to tell when the droplet is ready for the
+// snapshot.
+type stepWaitUserData struct{}
+
+func (s *stepWaitUserData) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	client := state.Get("client").(*godo.Client)
+	c := state.Get("config").(*Config)
+	ui := state.Get("ui").(packersdk.Ui)
+	dropletId := stateDropletID.Get(state)
+
+	var err error
+	switch c.UserDataCompletion {
+	case "":
+		return multistep.ActionContinue
+	case UserDataCompletionTimeout:
+		ui.Say(fmt.Sprintf("Waiting %s for the user data to finish...", c.UserDataTimeout))
+		select {
+		case <-ctx.Done():
+			err = fmt.Errorf("Cancelled waiting for the user data to finish")
+		case <-waitClock.After(c.UserDataTimeout):
+		}
+	case UserDataCompletionTag:
+		ui.Say(fmt.Sprintf("Waiting for the user data to tag the droplet %s...", c.UserDataCompletionTag))
+		err = s.wait(ctx, client, dropletId, c, func(droplet *godo.Droplet) bool {
+			return containsString(droplet.Tags, c.UserDataCompletionTag)
+		})
+	case UserDataCompletionPowerOff:
+		ui.Say("Waiting for the user data to power the droplet off...")
+		err = s.wait(ctx, client, dropletId, c, func(droplet *godo.Droplet) bool {
+			return droplet.Status == "off"
+		})
+	}
+	if err != nil {
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Message("The user data has finished")
+	return multistep.ActionContinue
+}
+
+// wait polls the droplet until done reports that the user data has
+// finished.
+func (s *stepWaitUserData) wait(ctx context.Context, client *godo.Client, dropletId int, c *Config, done func(*godo.Droplet) bool) error {
+	err := poll(ctx, c.UserDataTimeout, func(ctx context.Context, attempt int) (bool, error) {
+		log.Printf("Checking whether the user data has finished... (attempt: %d)", attempt)
+		droplet, _, err := client.Droplets.Get(ctx, dropletId)
+		if err != nil {
+			return false, err
+		}
+		return done(droplet), nil
+	})
+	if err == ErrPollTimeout {
+		return fmt.Errorf("Timeout after %s waiting for the user data to finish", c.UserDataTimeout)
+	} else if err != nil {
+		return fmt.Errorf("Error waiting for the user data to finish: %s", err)
+	}
+	return nil
+}
+
+func (s *stepWaitUserData) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
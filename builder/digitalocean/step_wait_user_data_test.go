@@ -0,0 +1,104 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepWaitUserData(t *testing.T) {
+	cases := []struct {
+		name       string
+		completion string
+		droplets   []string
+		wantErr    string
+	}{
+		{
+			name:       "tag",
+			completion: UserDataCompletionTag,
+			droplets: []string{
+				`{"id": 3164444, "status": "active", "tags": []}`,
+				`{"id": 3164444, "status": "active", "tags": ["web", "packer-user-data-done"]}`,
+			},
+		},
+		{
+			name:       "power off",
+			completion: UserDataCompletionPowerOff,
+			droplets: []string{
+				`{"id": 3164444, "status": "active"}`,
+				`{"id": 3164444, "status": "off"}`,
+			},
+		},
+		{
+			name:       "timeout",
+			completion: UserDataCompletionTimeout,
+		},
+		{
+			name:       "tag timeout",
+			completion: UserDataCompletionTag,
+			droplets:   []string{`{"id": 3164444, "status": "active", "tags": []}`},
+			wantErr:    "Timeout after 30m0s waiting for the user data to finish",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			useFakeClock(t)
+
+			var requests int
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/v2/droplets/3164444" || len(tc.droplets) == 0 {
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				droplet := tc.droplets[min(requests, len(tc.droplets)-1)]
+				requests++
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"droplet": ` + droplet + `}`))
+			}))
+			defer ts.Close()
+
+			client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			state := new(multistep.BasicStateBag)
+			state.Put("client", client)
+			state.Put("config", &Config{
+				UserDataCompletion:    tc.completion,
+				UserDataCompletionTag: "packer-user-data-done",
+				UserDataTimeout:       30 * time.Minute,
+			})
+			state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+			stateDropletID.Put(state, 3164444)
+
+			action := new(stepWaitUserData).Run(context.Background(), state)
+			if tc.wantErr != "" {
+				if action != multistep.ActionHalt {
+					t.Fatalf("bad action: %v", action)
+				}
+				if err := state.Get("error").(error); !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("bad error: %s", err)
+				}
+				return
+			}
+			if action != multistep.ActionContinue {
+				t.Fatalf("bad action: %v: %s", action, out.String())
+			}
+			if requests != len(tc.droplets) {
+				t.Errorf("expected %d requests, got %d", len(tc.droplets), requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// stepWebhook notifies the webhooks that the build reached Event. The
+// build_failed event is sent when the build is torn down after an error,
+// so its step goes first in the list.
+type stepWebhook struct {
+	Event string
+}
+
+func (s *stepWebhook) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Event != WebhookBuildFailed {
+		notifyWebhooks(ctx, state, s.Event)
+	}
+	return multistep.ActionContinue
+}
+
+func (s *stepWebhook) Cleanup(state multistep.StateBag) {
+	if s.Event != WebhookBuildFailed {
+		return
+	}
+
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if cancelled || halted {
+		notifyWebhooks(context.Background(), state, s.Event)
+	}
+}
+
+// notifyWebhooks sends event to the webhooks that subscribe to it. Failed
+// notifications are reported as warnings.
+func notifyWebhooks(ctx context.Context, state multistep.StateBag, event string) {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	payload := webhookPayload(state, event)
+	client := &http.Client{Timeout: webhookTimeout}
+	for i := range c.Webhooks {
+		w := &c.Webhooks[i]
+		if !w.Notifies(event) {
+			continue
+		}
+
+		log.Printf("[DEBUG] Sending %s webhook to %s", event, w.URL)
+		if err := w.send(ctx, client, payload); err != nil {
+			ui.Error(fmt.Sprintf("Warning: error sending %s webhook to %s: %s", event, w.URL, err))
+		}
+	}
+}
+
+// webhookPayload describes the build as far as it has got.
+func webhookPayload(state multistep.StateBag, event string) *WebhookPayload {
+	c := state.Get("config").(*Config)
+
+	payload := &WebhookPayload{
+		Event:     event,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		BuildName: c.PackerBuildName,
+	}
+	if id, ok := stateDropletID.GetOk(state); ok {
+		payload.DropletID = id
+		payload.DropletName = c.DropletName
+	}
+	if id, ok := stateSnapshotImageID.GetOk(state); ok {
+		payload.SnapshotID = id
+		payload.SnapshotName = stateSnapshotName.Get(state)
+		payload.Regions = stateRegions.Get(state)
+	}
+	if err, ok := state.GetOk("error"); ok {
+		payload.Error = err.(error).Error()
+	} else if _, ok := state.GetOk(multistep.StateCancelled); ok {
+		payload.Error = "build cancelled"
+	}
+	return payload
+}
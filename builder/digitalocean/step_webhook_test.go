@@ -0,0 +1,80 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepWebhook(t *testing.T) {
+	var received []WebhookPayload
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("missing header: %v", r.Header)
+		}
+		var payload WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("bad payload: %s", err)
+		}
+		received = append(received, payload)
+	}))
+	defer ts.Close()
+
+	hook := Webhook{
+		URL:     ts.URL,
+		Events:  []string{WebhookDropletCreated, WebhookBuildFailed},
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+	}
+	c := &Config{
+		PackerConfig: common.PackerConfig{PackerBuildName: "ubuntu"},
+		DropletName:  "packer-build",
+		Webhooks:     []Webhook{hook},
+	}
+
+	var out bytes.Buffer
+	state := new(multistep.BasicStateBag)
+	state.Put("config", c)
+	state.Put("ui", &packersdk.BasicUi{Writer: &out, ErrorWriter: &out})
+	state.Put("droplet_id", 123)
+
+	// Not subscribed
+	(&stepWebhook{Event: WebhookProvisioningFinished}).Run(context.Background(), state)
+	if len(received) != 0 {
+		t.Fatalf("unexpected notification: %#v", received)
+	}
+
+	(&stepWebhook{Event: WebhookDropletCreated}).Run(context.Background(), state)
+	if len(received) != 1 {
+		t.Fatalf("expected one notification, got %d", len(received))
+	}
+	if received[0].Event != WebhookDropletCreated || received[0].DropletID != 123 ||
+		received[0].DropletName != "packer-build" || received[0].BuildName != "ubuntu" {
+		t.Fatalf("bad payload: %#v", received[0])
+	}
+
+	// build_failed is only sent when the build failed
+	failed := &stepWebhook{Event: WebhookBuildFailed}
+	failed.Run(context.Background(), state)
+	failed.Cleanup(state)
+	if len(received) != 1 {
+		t.Fatalf("unexpected notification: %#v", received[1:])
+	}
+
+	state.Put("error", errors.New("Error creating snapshot"))
+	state.Put(multistep.StateHalted, true)
+	failed.Cleanup(state)
+	if len(received) != 2 {
+		t.Fatalf("expected two notifications, got %d", len(received))
+	}
+	if received[1].Event != WebhookBuildFailed || received[1].Error != "Error creating snapshot" {
+		t.Fatalf("bad payload: %#v", received[1])
+	}
+}
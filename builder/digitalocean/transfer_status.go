@@ -0,0 +1,79 @@
+package digitalocean
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The statuses of a snapshot transfer to a region.
+const (
+	transferPending    = "pending"
+	transferInProgress = "in progress"
+	transferRetrying   = "retrying"
+	transferCompleted  = "completed"
+	transferErrored    = "errored"
+)
+
+// transferStatus tracks the transfers of a snapshot to its regions, so that
+// the build can report on them while waiting, rather than going silent
+// until they end.
+type transferStatus struct {
+	mu      sync.Mutex
+	regions []string
+	status  map[string]string
+	started map[string]time.Time
+	ended   map[string]time.Time
+}
+
+func newTransferStatus(regions []string) *transferStatus {
+	t := &transferStatus{
+		regions: regions,
+		status:  make(map[string]string),
+		started: make(map[string]time.Time),
+		ended:   make(map[string]time.Time),
+	}
+	for _, region := range regions {
+		t.status[region] = transferPending
+	}
+	return t
+}
+
+// set records the status of the transfer to region. The elapsed time of a
+// transfer runs from its first attempt to its completion or final failure.
+func (t *transferStatus) set(region, status string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := t.started[region]; !ok && status != transferPending {
+		t.started[region] = now
+	}
+	if status == transferCompleted || status == transferErrored {
+		t.ended[region] = now
+	}
+	t.status[region] = status
+}
+
+// String lists the status of each transfer, with its elapsed time once it
+// has started.
+func (t *transferStatus) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	parts := make([]string, 0, len(t.regions))
+	for _, region := range t.regions {
+		part := fmt.Sprintf("%s %s", region, t.status[region])
+		if started, ok := t.started[region]; ok {
+			end, ok := t.ended[region]
+			if !ok {
+				end = now
+			}
+			part += fmt.Sprintf(" (%s)", end.Sub(started).Round(time.Second))
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,25 @@
+package digitalocean
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestTransferStatus(t *testing.T) {
+	status := newTransferStatus([]string{"ams3", "fra1", "sgp1"})
+	status.set("fra1", transferInProgress)
+	status.set("sgp1", transferInProgress)
+	status.set("sgp1", transferRetrying)
+
+	re := regexp.MustCompile(`^ams3 pending, fra1 in progress \(\d+s\), sgp1 retrying \(\d+s\)$`)
+	if s := status.String(); !re.MatchString(s) {
+		t.Fatalf("bad status: %s", s)
+	}
+
+	status.set("fra1", transferCompleted)
+	status.set("sgp1", transferErrored)
+	re = regexp.MustCompile(`^ams3 pending, fra1 completed \(\d+s\), sgp1 errored \(\d+s\)$`)
+	if s := status.String(); !re.MatchString(s) {
+		t.Fatalf("bad status: %s", s)
+	}
+}
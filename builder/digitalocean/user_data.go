@@ -0,0 +1,156 @@
+package digitalocean
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxUserDataSize is the largest user data, in bytes, DigitalOcean accepts.
+const maxUserDataSize = 64 * 1024
+
+// userDataBoundary separates the parts of compressed user data.
+const userDataBoundary = "==PACKER_USER_DATA=="
+
+// userDataTemplateData is the data user_data is rendered with.
+type userDataTemplateData struct {
+	DropletName  string
+	Region       string
+	Size         string
+	Image        string
+	SnapshotName string
+	BuildUUID    string
+}
+
+// userDataChecksum returns the hex SHA-256 of the user data the droplet is
+// created with, which identifies the cloud-init payload an image came from.
+func userDataChecksum(userData string) string {
+	sum := sha256.Sum256([]byte(userData))
+	return hex.EncodeToString(sum[:])
+}
+
+// userDataTag returns the tag recording the user data checksum on the
+// droplet and the snapshot.
+func userDataTag(checksum string) string {
+	return "user-data-sha256:" + checksum
+}
+
+// finalUserData returns the user data the droplet is created with, gzipped
+// with compress_user_data, and checks that it fits in DigitalOcean's limit.
+func finalUserData(userData string, compress bool) (string, error) {
+	if userData == "" {
+		return "", nil
+	}
+
+	final := userData
+	if compress {
+		var err error
+		if final, err = compressUserData(userData); err != nil {
+			return "", fmt.Errorf("Error compressing user data: %s", err)
+		}
+	}
+
+	if len(final) > maxUserDataSize {
+		if compress {
+			return "", fmt.Errorf("user data is %d bytes once compressed, over DigitalOcean's limit of %d bytes",
+				len(final), maxUserDataSize)
+		}
+		return "", fmt.Errorf("user data is %d bytes, over DigitalOcean's limit of %d bytes; "+
+			"set compress_user_data to gzip it", len(final), maxUserDataSize)
+	}
+	return final, nil
+}
+
+// compressUserData gzips the user data into a MIME multi-part message,
+// which cloud-init decompresses. The API only takes user data as text, so
+// the gzipped part is base64 encoded.
+func compressUserData(userData string) (string, error) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write([]byte(userData)); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=\"%s\"\n", userDataBoundary)
+	b.WriteString("MIME-Version: 1.0\n\n")
+	fmt.Fprintf(&b, "--%s\n", userDataBoundary)
+	b.WriteString("Content-Type: application/x-gzip\n")
+	b.WriteString("Content-Transfer-Encoding: base64\n")
+	b.WriteString("Content-Disposition: attachment; filename=\"user-data.gz\"\n\n")
+	encoded := base64.StdEncoding.EncodeToString(compressed.Bytes())
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\n")
+	fmt.Fprintf(&b, "--%s--\n", userDataBoundary)
+	return b.String(), nil
+}
+
+// cloudConfigHeader is the first line of cloud-config user data.
+const cloudConfigHeader = "#cloud-config"
+
+// cloudConfigListKeys are the cloud-config modules whose configuration is
+// a list. cloud-init skips a module whose configuration has the wrong type.
+var cloudConfigListKeys = []string{
+	"bootcmd", "mounts", "packages", "runcmd", "ssh_authorized_keys", "users", "write_files",
+}
+
+// validateCloudConfig parses user data starting with #cloud-config as
+// YAML, and checks that it is a mapping without duplicate keys, in which
+// the modules configured with a list are given one. Other user data isn't
+// checked. The errors are located by line and column, except for YAML
+// syntax errors, which the parser only locates by line.
+func validateCloudConfig(userData string) []error {
+	firstLine := userData
+	if i := strings.IndexByte(userData, '\n'); i >= 0 {
+		firstLine = userData[:i]
+	}
+	if strings.TrimSpace(firstLine) != cloudConfigHeader {
+		return nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(userData), &doc); err != nil {
+		return []error{fmt.Errorf("user data is not valid cloud-config: %s", err)}
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return []error{cloudConfigError(root, "cloud-config must be a mapping")}
+	}
+
+	var errs []error
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if seen[key.Value] {
+			errs = append(errs, cloudConfigError(key, fmt.Sprintf("duplicate key %q", key.Value)))
+			continue
+		}
+		seen[key.Value] = true
+
+		if containsString(cloudConfigListKeys, key.Value) && value.Kind != yaml.SequenceNode &&
+			!(value.Kind == yaml.ScalarNode && value.Tag == "!!null") {
+			errs = append(errs, cloudConfigError(value, fmt.Sprintf("%s must be a list", key.Value)))
+		}
+	}
+	return errs
+}
+
+func cloudConfigError(node *yaml.Node, msg string) error {
+	return fmt.Errorf("user data is not valid cloud-config: line %d, column %d: %s", node.Line, node.Column, msg)
+}
@@ -0,0 +1,127 @@
+package digitalocean
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"math/rand"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestUserDataChecksum(t *testing.T) {
+	// sha256 of "hello\n"
+	expected := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+	if got := userDataChecksum("hello\n"); got != expected {
+		t.Fatalf("bad checksum: %s", got)
+	}
+
+	if got := userDataTag(expected); got != "user-data-sha256:"+expected {
+		t.Fatalf("bad tag: %s", got)
+	}
+}
+
+func TestFinalUserData(t *testing.T) {
+	// Compressible user data over the limit
+	userData := "#cloud-config\nwrite_files:\n" +
+		strings.Repeat("- path: /etc/motd\n  content: Built by Packer\n", 2000)
+
+	if _, err := finalUserData(userData, false); err == nil ||
+		!strings.Contains(err.Error(), "compress_user_data") {
+		t.Fatalf("should have error suggesting compress_user_data: %v", err)
+	}
+
+	final, err := finalUserData(userData, true)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(final) > maxUserDataSize {
+		t.Fatalf("should be compressed: %d bytes", len(final))
+	}
+
+	// Decode the message the way cloud-init does
+	msg, err := mail.ReadMessage(strings.NewReader(final))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("bad content type: %s", msg.Header.Get("Content-Type"))
+	}
+	part, err := multipart.NewReader(msg.Body, params["boundary"]).NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.Header.Get("Content-Type") != "application/x-gzip" {
+		t.Fatalf("bad part content type: %s", part.Header.Get("Content-Type"))
+	}
+	encoded, err := io.ReadAll(part)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\n", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decompressed) != userData {
+		t.Fatal("should decompress to the user data")
+	}
+
+	// Incompressible user data over the limit
+	random := make([]byte, maxUserDataSize)
+	rand.New(rand.NewSource(1)).Read(random)
+	if _, err := finalUserData(base64.StdEncoding.EncodeToString(random), true); err == nil ||
+		!strings.Contains(err.Error(), "once compressed") {
+		t.Fatalf("should have error: %v", err)
+	}
+
+	if final, err := finalUserData("", true); err != nil || final != "" {
+		t.Fatalf("empty user data should stay empty: %q, %v", final, err)
+	}
+}
+
+func TestValidateCloudConfig(t *testing.T) {
+	cases := []struct {
+		name     string
+		userData string
+		errs     []string
+	}{
+		{"not cloud-config", "#!/bin/sh\necho: [\n", nil},
+		{"empty", "#cloud-config\n", nil},
+		{"valid", "#cloud-config\npackages:\n  - nginx\nruncmd:\n  - [systemctl, start, nginx]\n", nil},
+		{"unset list", "#cloud-config\nruncmd:\n", nil},
+		{"syntax", "#cloud-config\npackages:\n  - nginx\n runcmd: []\n", []string{"did not find expected key"}},
+		{"not a mapping", "#cloud-config\n- nginx\n", []string{"line 2, column 1: cloud-config must be a mapping"}},
+		{
+			"bad modules",
+			"#cloud-config\npackages: nginx\nhostname: web\nhostname: db\n",
+			[]string{
+				"line 2, column 11: packages must be a list",
+				"line 4, column 1: duplicate key \"hostname\"",
+			},
+		},
+	}
+	for _, tc := range cases {
+		errs := validateCloudConfig(tc.userData)
+		if len(errs) != len(tc.errs) {
+			t.Fatalf("%s: expected %d errors, got %v", tc.name, len(tc.errs), errs)
+		}
+		for i, err := range errs {
+			if !strings.Contains(err.Error(), tc.errs[i]) {
+				t.Errorf("%s: unexpected error: %s", tc.name, err)
+			}
+		}
+	}
+}
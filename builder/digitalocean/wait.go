@@ -2,160 +2,186 @@ package digitalocean
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/digitalocean/godo"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 )
 
-// waitForDropletUnlocked waits for the Droplet to be unlocked to
-// avoid "pending" errors when making state changes.
-func waitForDropletUnlocked(
-	client *godo.Client, dropletId int, timeout time.Duration) error {
-	done := make(chan struct{})
-	defer close(done)
+// progressInterval is how often long waits report their progress.
+const progressInterval = time.Minute
 
-	result := make(chan error, 1)
+// reportProgress reports the message through ui every interval until the
+// stop function it returns is called, so that long waits don't go silent.
+func reportProgress(ui packersdk.Ui, interval time.Duration, message func() string) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
 	go func() {
-		attempts := 0
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
 		for {
-			attempts += 1
-
-			log.Printf("[DEBUG] Checking droplet lock state... (attempt: %d)", attempts)
-			droplet, _, err := client.Droplets.Get(context.TODO(), dropletId)
-			if err != nil {
-				result <- err
-				return
-			}
-
-			if !droplet.Locked {
-				result <- nil
-				return
-			}
-
-			// Wait 3 seconds in between
-			time.Sleep(3 * time.Second)
-
-			// Verify we shouldn't exit
 			select {
 			case <-done:
-				// We finished, so just exit the goroutine
 				return
-			default:
-				// Keep going
+			case <-ticker.C:
+				ui.Message(message())
 			}
 		}
 	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
 
-	log.Printf("[DEBUG] Waiting for up to %d seconds for droplet to unlock", timeout/time.Second)
-	select {
-	case err := <-result:
-		return err
-	case <-time.After(timeout):
-		return fmt.Errorf(
-			"Timeout while waiting to for droplet to unlock")
+// actionErrored is the status of failed actions, which godo has no
+// constant for.
+const actionErrored = "errored"
+
+// pollInterval is how long the waiters wait between checks, unless the
+// context sets another interval with withPollInterval.
+const pollInterval = 3 * time.Second
+
+type pollIntervalKey struct{}
+
+// withPollInterval returns a context setting how long the waiters given it
+// wait between checks, for state_poll_interval.
+func withPollInterval(ctx context.Context, interval time.Duration) context.Context {
+	return context.WithValue(ctx, pollIntervalKey{}, interval)
+}
+
+// pollIntervalOf returns how long the waiters given ctx wait between checks.
+func pollIntervalOf(ctx context.Context) time.Duration {
+	if interval, ok := ctx.Value(pollIntervalKey{}).(time.Duration); ok && interval > 0 {
+		return interval
 	}
+	return pollInterval
 }
 
-// waitForDropletState simply blocks until the droplet is in
-// a state we expect, while eventually timing out.
-func waitForDropletState(
-	desiredState string, dropletId int,
-	client *godo.Client, timeout time.Duration) error {
-	done := make(chan struct{})
-	defer close(done)
+// clock tells the time for the waiters, so that tests can fake it.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
 
-	result := make(chan error, 1)
-	go func() {
-		attempts := 0
-		for {
-			attempts += 1
+type realClock struct{}
 
-			log.Printf("Checking droplet status... (attempt: %d)", attempts)
-			droplet, _, err := client.Droplets.Get(context.TODO(), dropletId)
-			if err != nil {
-				result <- err
-				return
-			}
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
 
-			if droplet.Status == desiredState {
-				result <- nil
-				return
-			}
+// waitClock is the clock used by the waiters.
+var waitClock clock = realClock{}
 
-			// Wait 3 seconds in between
-			time.Sleep(3 * time.Second)
+// ErrPollTimeout is returned by poll and PollEvery when the condition isn't
+// met in time.
+var ErrPollTimeout = errors.New("timeout")
 
-			// Verify we shouldn't exit
-			select {
-			case <-done:
-				// We finished, so just exit the goroutine
-				return
-			default:
-				// Keep going
+// poll calls check every pollInterval, or the interval ctx sets, until it
+// reports that the awaited condition is met, check fails, ctx is done or
+// timeout elapses. The context passed to check is also bounded by timeout,
+// so a hanging request doesn't outlive the wait.
+func poll(ctx context.Context, timeout time.Duration, check func(ctx context.Context, attempt int) (bool, error)) error {
+	return PollEvery(ctx, pollIntervalOf(ctx), timeout, check)
+}
+
+// PollEvery is poll with a different interval between checks. The other
+// builders of the plugin wait with it too.
+func PollEvery(ctx context.Context, interval, timeout time.Duration, check func(ctx context.Context, attempt int) (bool, error)) error {
+	deadline := waitClock.Now().Add(timeout)
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		done, err := check(checkCtx, attempt)
+		if err != nil {
+			if ctx.Err() == nil && checkCtx.Err() == context.DeadlineExceeded {
+				return ErrPollTimeout
 			}
+			return err
 		}
-	}()
+		if done {
+			return nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !waitClock.Now().Before(deadline) {
+			return ErrPollTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-waitClock.After(interval):
+		}
+	}
+}
+
+// waitForDropletUnlocked waits for the Droplet to be unlocked to
+// avoid "pending" errors when making state changes.
+func waitForDropletUnlocked(
+	ctx context.Context, client *godo.Client, dropletId int, timeout time.Duration) error {
+	log.Printf("[DEBUG] Waiting for up to %d seconds for droplet to unlock", timeout/time.Second)
+	err := poll(ctx, timeout, func(ctx context.Context, attempt int) (bool, error) {
+		log.Printf("[DEBUG] Checking droplet lock state... (attempt: %d)", attempt)
+		droplet, _, err := client.Droplets.Get(ctx, dropletId)
+		if err != nil {
+			return false, err
+		}
+		return !droplet.Locked, nil
+	})
+	if err == ErrPollTimeout {
+		return fmt.Errorf(
+			"Timeout while waiting to for droplet to unlock")
+	}
+	return err
+}
 
+// waitForDropletState simply blocks until the droplet is in
+// a state we expect, while eventually timing out.
+func waitForDropletState(
+	ctx context.Context, desiredState string, dropletId int,
+	client *godo.Client, timeout time.Duration) error {
 	log.Printf("Waiting for up to %d seconds for droplet to become %s", timeout/time.Second, desiredState)
-	select {
-	case err := <-result:
-		return err
-	case <-time.After(timeout):
-		err := fmt.Errorf("Timeout while waiting to for droplet to become '%s'", desiredState)
-		return err
+	err := poll(ctx, timeout, func(ctx context.Context, attempt int) (bool, error) {
+		log.Printf("Checking droplet status... (attempt: %d)", attempt)
+		droplet, _, err := client.Droplets.Get(ctx, dropletId)
+		if err != nil {
+			return false, err
+		}
+		return droplet.Status == desiredState, nil
+	})
+	if err == ErrPollTimeout {
+		return fmt.Errorf("Timeout while waiting to for droplet to become '%s'", desiredState)
 	}
+	return err
 }
 
 // waitForActionState simply blocks until the droplet action is in
 // a state we expect, while eventually timing out.
 func waitForActionState(
-	desiredState string, dropletId, actionId int,
+	ctx context.Context, desiredState string, dropletId, actionId int,
 	client *godo.Client, timeout time.Duration) error {
-	done := make(chan struct{})
-	defer close(done)
-
-	result := make(chan error, 1)
-	go func() {
-		attempts := 0
-		for {
-			attempts += 1
-
-			log.Printf("Checking action status... (attempt: %d)", attempts)
-			action, _, err := client.DropletActions.Get(context.TODO(), dropletId, actionId)
-			if err != nil {
-				result <- err
-				return
-			}
-
-			if action.Status == desiredState {
-				result <- nil
-				return
-			}
-
-			// Wait 3 seconds in between
-			time.Sleep(3 * time.Second)
-
-			// Verify we shouldn't exit
-			select {
-			case <-done:
-				// We finished, so just exit the goroutine
-				return
-			default:
-				// Keep going
-			}
-		}
-	}()
-
 	log.Printf("Waiting for up to %d seconds for action to become %s", timeout/time.Second, desiredState)
-	select {
-	case err := <-result:
-		return err
-	case <-time.After(timeout):
-		err := fmt.Errorf("Timeout while waiting to for action to become '%s'", desiredState)
-		return err
+	err := poll(ctx, timeout, func(ctx context.Context, attempt int) (bool, error) {
+		log.Printf("Checking action status... (attempt: %d)", attempt)
+		action, _, err := client.DropletActions.Get(ctx, dropletId, actionId)
+		if err != nil {
+			return false, err
+		}
+		return action.Status == desiredState, nil
+	})
+	if err == ErrPollTimeout {
+		return fmt.Errorf("Timeout while waiting to for action to become '%s'", desiredState)
 	}
+	return err
 }
 
 // WaitForImageState simply blocks until the image action is in
@@ -163,47 +189,70 @@ func waitForActionState(
 func WaitForImageState(
 	desiredState string, imageId, actionId int,
 	client *godo.Client, timeout time.Duration) error {
-	done := make(chan struct{})
-	defer close(done)
-
-	result := make(chan error, 1)
-	go func() {
-		attempts := 0
-		for {
-			attempts += 1
-
-			log.Printf("Checking action status... (attempt: %d)", attempts)
-			action, _, err := client.ImageActions.Get(context.TODO(), imageId, actionId)
-			if err != nil {
-				result <- err
-				return
-			}
-
-			if action.Status == desiredState {
-				result <- nil
-				return
-			}
+	return WaitForImageStateContext(context.Background(), desiredState, imageId, actionId, client, timeout)
+}
 
-			// Wait 3 seconds in between
-			time.Sleep(3 * time.Second)
+// WaitForImageStateContext is like WaitForImageState, but stops waiting
+// when ctx is done.
+func WaitForImageStateContext(
+	ctx context.Context, desiredState string, imageId, actionId int,
+	client *godo.Client, timeout time.Duration) error {
+	log.Printf("Waiting for up to %d seconds for image transfer to become %s", timeout/time.Second, desiredState)
+	err := poll(ctx, timeout, func(ctx context.Context, attempt int) (bool, error) {
+		log.Printf("Checking action status... (attempt: %d)", attempt)
+		action, _, err := client.ImageActions.Get(ctx, imageId, actionId)
+		if err != nil {
+			return false, err
+		}
+		if action.Status == actionErrored && desiredState != actionErrored {
+			return false, fmt.Errorf("image action %d errored", actionId)
+		}
+		return action.Status == desiredState, nil
+	})
+	if err == ErrPollTimeout {
+		return fmt.Errorf("Timeout while waiting to for image transfer to become '%s'", desiredState)
+	}
+	return err
+}
 
-			// Verify we shouldn't exit
-			select {
-			case <-done:
-				// We finished, so just exit the goroutine
-				return
-			default:
-				// Keep going
-			}
+// WaitForImageAvailable blocks until the custom image being imported is
+// available, failing with the import's error message if it has one, or once
+// timeout elapses.
+func WaitForImageAvailable(ctx context.Context, client *godo.Client, imageId int, timeout time.Duration) error {
+	log.Printf("Waiting for up to %d seconds for image to become available", timeout/time.Second)
+	err := poll(ctx, timeout, func(ctx context.Context, attempt int) (bool, error) {
+		log.Printf("Checking image status... (attempt: %d)", attempt)
+		image, _, err := client.Images.GetByID(ctx, imageId)
+		if err != nil {
+			return false, err
 		}
-	}()
+		if image.ErrorMessage != "" {
+			return false, fmt.Errorf("image import failed: %s", image.ErrorMessage)
+		}
+		return image.Status == "available", nil
+	})
+	if err == ErrPollTimeout {
+		return fmt.Errorf("Timeout while waiting for image %d to become available", imageId)
+	}
+	return err
+}
 
-	log.Printf("Waiting for up to %d seconds for image transfer to become %s", timeout/time.Second, desiredState)
-	select {
-	case err := <-result:
-		return err
-	case <-time.After(timeout):
-		err := fmt.Errorf("Timeout while waiting to for image transfer to become '%s'", desiredState)
-		return err
+// waitForReservedIPAction simply blocks until the reserved IP action is in
+// a state we expect, while eventually timing out.
+func waitForReservedIPAction(
+	ctx context.Context, desiredState string, ip string, actionId int,
+	client *godo.Client, timeout time.Duration) error {
+	log.Printf("Waiting for up to %d seconds for reserved IP action to become %s", timeout/time.Second, desiredState)
+	err := poll(ctx, timeout, func(ctx context.Context, attempt int) (bool, error) {
+		log.Printf("Checking reserved IP action status... (attempt: %d)", attempt)
+		action, _, err := client.ReservedIPActions.Get(ctx, ip, actionId)
+		if err != nil {
+			return false, err
+		}
+		return action.Status == desiredState, nil
+	})
+	if err == ErrPollTimeout {
+		return fmt.Errorf("Timeout while waiting to for reserved IP action to become '%s'", desiredState)
 	}
+	return err
 }
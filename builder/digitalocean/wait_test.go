@@ -0,0 +1,228 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// fakeClock is a clock whose time only moves when it is waited on. Waiters
+// running at once each move it, so tests of concurrent waits should only
+// rely on the number of checks, not on how much time passes.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func useFakeClock(t *testing.T) *fakeClock {
+	c := &fakeClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	waitClock = c
+	t.Cleanup(func() { waitClock = realClock{} })
+	return c
+}
+
+func TestPoll(t *testing.T) {
+	clock := useFakeClock(t)
+
+	attempts := 0
+	err := poll(context.Background(), time.Minute, func(ctx context.Context, attempt int) (bool, error) {
+		attempts = attempt
+		return attempt == 4, nil
+	})
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if attempts != 4 {
+		t.Fatalf("bad attempts: %d", attempts)
+	}
+	if waited := clock.now.Sub(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)); waited != 3*pollInterval {
+		t.Fatalf("bad wait: %s", waited)
+	}
+}
+
+func TestPoll_timeout(t *testing.T) {
+	useFakeClock(t)
+
+	attempts := 0
+	err := poll(context.Background(), time.Minute, func(ctx context.Context, attempt int) (bool, error) {
+		attempts = attempt
+		return false, nil
+	})
+	if err != ErrPollTimeout {
+		t.Fatalf("bad error: %v", err)
+	}
+	if attempts != int(time.Minute/pollInterval)+1 {
+		t.Fatalf("bad attempts: %d", attempts)
+	}
+}
+
+func TestPoll_error(t *testing.T) {
+	useFakeClock(t)
+
+	checkErr := errors.New("bad request")
+	err := poll(context.Background(), time.Minute, func(ctx context.Context, attempt int) (bool, error) {
+		return false, checkErr
+	})
+	if err != checkErr {
+		t.Fatalf("bad error: %v", err)
+	}
+}
+
+func TestPoll_cancel(t *testing.T) {
+	useFakeClock(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := poll(ctx, time.Hour, func(ctx context.Context, attempt int) (bool, error) {
+		attempts = attempt
+		if attempt == 2 {
+			cancel()
+		}
+		return false, nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("bad error: %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("bad attempts: %d", attempts)
+	}
+}
+
+func TestWaitForDropletState(t *testing.T) {
+	useFakeClock(t)
+
+	gets := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		w.Header().Set("Content-Type", "application/json")
+		if gets < 3 {
+			w.Write([]byte(`{"droplet": {"id": 3164444, "status": "new"}}`))
+			return
+		}
+		w.Write([]byte(`{"droplet": {"id": 3164444, "status": "active"}}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := waitForDropletState(context.Background(), "active", 3164444, client, time.Minute); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if gets != 3 {
+		t.Fatalf("bad number of checks: %d", gets)
+	}
+
+	err = waitForDropletState(context.Background(), "off", 3164444, client, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "Timeout") {
+		t.Fatalf("bad error: %v", err)
+	}
+}
+
+func TestWaitForImageAvailable(t *testing.T) {
+	useFakeClock(t)
+
+	gets := 0
+	image := `{"image": {"id": 7555620, "status": "NEW"}}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		w.Header().Set("Content-Type", "application/json")
+		if gets < 3 {
+			w.Write([]byte(`{"image": {"id": 7555620, "status": "NEW"}}`))
+			return
+		}
+		w.Write([]byte(image))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image = `{"image": {"id": 7555620, "status": "available"}}`
+	if err := WaitForImageAvailable(context.Background(), client, 7555620, time.Hour); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if gets != 3 {
+		t.Fatalf("bad number of checks: %d", gets)
+	}
+
+	image = `{"image": {"id": 7555620, "status": "deleted", "error_message": "Unsupported image format"}}`
+	err = WaitForImageAvailable(context.Background(), client, 7555620, time.Hour)
+	if err == nil || !strings.Contains(err.Error(), "Unsupported image format") {
+		t.Fatalf("bad error: %v", err)
+	}
+}
+
+func TestReportProgress(t *testing.T) {
+	var out bytes.Buffer
+	ui := &packersdk.BasicUi{Writer: &out, ErrorWriter: &out}
+
+	var mu sync.Mutex
+	reports := 0
+	stop := reportProgress(ui, time.Millisecond, func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		reports++
+		return "Still waiting..."
+	})
+	time.Sleep(50 * time.Millisecond)
+	stop()
+
+	mu.Lock()
+	n := reports
+	mu.Unlock()
+	if n == 0 || !strings.Contains(out.String(), "Still waiting...") {
+		t.Fatalf("progress not reported: %s", out.String())
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if reports != n {
+		t.Fatal("progress reported after stop")
+	}
+}
+
+func TestPoll_interval(t *testing.T) {
+	clock := useFakeClock(t)
+	start := clock.Now()
+
+	ctx := withPollInterval(context.Background(), 10*time.Second)
+	err := poll(ctx, time.Minute, func(ctx context.Context, attempt int) (bool, error) {
+		return attempt == 3, nil
+	})
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if waited := clock.Now().Sub(start); waited != 20*time.Second {
+		t.Fatalf("bad wait: %s", waited)
+	}
+}
@@ -0,0 +1,109 @@
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Webhook
+
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	WebhookDropletCreated       = "droplet_created"
+	WebhookProvisioningFinished = "provisioning_finished"
+	WebhookSnapshotCreated      = "snapshot_created"
+	WebhookBuildFailed          = "build_failed"
+)
+
+var validWebhookEvents = []string{
+	WebhookDropletCreated, WebhookProvisioningFinished, WebhookSnapshotCreated, WebhookBuildFailed,
+}
+
+// Webhook is an HTTP endpoint notified as the build reaches its milestones,
+// so that chat and deployment systems can follow a build without polling
+// its logs. It is set with one or more `webhook` blocks. Each notification
+// is a POST request with a JSON body describing the event and the build.
+// A failed notification is reported but doesn't fail the build.
+type Webhook struct {
+	// The URL to send the notifications to.
+	URL string `mapstructure:"url" required:"true"`
+	// The events to notify. Any of `droplet_created`,
+	// `provisioning_finished`, `snapshot_created` and `build_failed`.
+	// Defaults to all of them.
+	Events []string `mapstructure:"events" required:"false"`
+	// Additional HTTP headers to send, for example to authenticate.
+	Headers map[string]string `mapstructure:"headers" required:"false"`
+}
+
+// Prepare sets the defaults for the webhook and validates it.
+func (w *Webhook) Prepare() []error {
+	var errs []error
+
+	if w.URL == "" {
+		errs = append(errs, fmt.Errorf("webhook: url must be set"))
+	}
+	if len(w.Events) == 0 {
+		w.Events = append([]string(nil), validWebhookEvents...)
+	}
+	for _, e := range w.Events {
+		if !containsString(validWebhookEvents, e) {
+			errs = append(errs, fmt.Errorf("webhook: invalid event %q, must be one of %v", e, validWebhookEvents))
+		}
+	}
+
+	return errs
+}
+
+// Notifies reports whether the webhook is sent for event.
+func (w *Webhook) Notifies(event string) bool {
+	return containsString(w.Events, event)
+}
+
+// WebhookPayload is the JSON body of a webhook notification. Fields that
+// are not known yet when the event fires are left out.
+type WebhookPayload struct {
+	Event        string   `json:"event"`
+	Timestamp    string   `json:"timestamp"`
+	BuildName    string   `json:"build_name,omitempty"`
+	DropletID    int      `json:"droplet_id,omitempty"`
+	DropletName  string   `json:"droplet_name,omitempty"`
+	SnapshotID   int      `json:"snapshot_id,omitempty"`
+	SnapshotName string   `json:"snapshot_name,omitempty"`
+	Regions      []string `json:"regions,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// send posts the payload to the webhook.
+func (w *Webhook) send(ctx context.Context, client *http.Client, payload *WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	return nil
+}
+
+// webhookTimeout bounds each notification.
+const webhookTimeout = 10 * time.Second
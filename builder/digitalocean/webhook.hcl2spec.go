@@ -0,0 +1,35 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package digitalocean
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatWebhook is an auto-generated flat version of Webhook.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatWebhook struct {
+	URL     *string           `mapstructure:"url" required:"true" cty:"url" hcl:"url"`
+	Events  []string          `mapstructure:"events" required:"false" cty:"events" hcl:"events"`
+	Headers map[string]string `mapstructure:"headers" required:"false" cty:"headers" hcl:"headers"`
+}
+
+// FlatMapstructure returns a new FlatWebhook.
+// FlatWebhook is an auto-generated flat version of Webhook.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*Webhook) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatWebhook)
+}
+
+// HCL2Spec returns the hcl spec of a Webhook.
+// This spec is used by HCL to read the fields of Webhook.
+// The decoded values from this spec will then be applied to a FlatWebhook.
+func (*FlatWebhook) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"url":     &hcldec.AttrSpec{Name: "url", Type: cty.String, Required: false},
+		"events":  &hcldec.AttrSpec{Name: "events", Type: cty.List(cty.String), Required: false},
+		"headers": &hcldec.AttrSpec{Name: "headers", Type: cty.Map(cty.String), Required: false},
+	}
+	return s
+}
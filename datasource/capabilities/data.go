@@ -0,0 +1,193 @@
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+//go:generate packer-sdc struct-markdown
+package capabilities
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	builder "github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	snapshotcopy "github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean-snapshot-copy"
+	digitaloceanconvert "github.com/digitalocean/packer-plugin-digitalocean/post-processor/digitalocean-convert"
+	digitaloceanimport "github.com/digitalocean/packer-plugin-digitalocean/post-processor/digitalocean-import"
+	digitaloceanlock "github.com/digitalocean/packer-plugin-digitalocean/post-processor/digitalocean-lock"
+	digitaloceanprune "github.com/digitalocean/packer-plugin-digitalocean/post-processor/digitalocean-prune"
+	"github.com/digitalocean/packer-plugin-digitalocean/version"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// defaultComponent is the component of the options required without one.
+const defaultComponent = "digitalocean"
+
+// componentSpecs are the configuration specs of the plugin's builders and
+// post-processors, by the name templates use for them.
+var componentSpecs = map[string]func() hcldec.ObjectSpec{
+	"digitalocean": func() hcldec.ObjectSpec {
+		return new(builder.Config).FlatMapstructure().HCL2Spec()
+	},
+	"digitalocean-snapshot-copy": func() hcldec.ObjectSpec {
+		return new(snapshotcopy.Config).FlatMapstructure().HCL2Spec()
+	},
+	"digitalocean-import": func() hcldec.ObjectSpec {
+		return new(digitaloceanimport.Config).FlatMapstructure().HCL2Spec()
+	},
+	"digitalocean-convert": func() hcldec.ObjectSpec {
+		return new(digitaloceanconvert.Config).FlatMapstructure().HCL2Spec()
+	},
+	"digitalocean-lock": func() hcldec.ObjectSpec {
+		return new(digitaloceanlock.Config).FlatMapstructure().HCL2Spec()
+	},
+	"digitalocean-prune": func() hcldec.ObjectSpec {
+		return new(digitaloceanprune.Config).FlatMapstructure().HCL2Spec()
+	},
+}
+
+type Config struct {
+	// The options the template uses, which the installed plugin must
+	// support. Options of the `digitalocean` builder are given by name, such
+	// as `snapshot_name_registry`; options of the other components are
+	// prefixed with the component and a dot, such as
+	// `digitalocean-import.spaces_key`. Blocks are given by their name.
+	RequiredOptions []string `mapstructure:"required_options"`
+	// The oldest version of the plugin the template works with, such as
+	// `1.3.0`. Pre-releases of a version satisfy it.
+	MinVersion string `mapstructure:"min_version"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	// The version of the DigitalOcean plugin.
+	PluginVersion string `mapstructure:"plugin_version"`
+	// The options the plugin supports, each prefixed with its component and
+	// a dot, such as `digitalocean.snapshot_name`.
+	Options []string `mapstructure:"options"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	var errs *packersdk.MultiError
+
+	if d.config.MinVersion != "" {
+		if _, err := goversion.NewVersion(d.config.MinVersion); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid min_version %q: %s", d.config.MinVersion, err))
+		}
+	}
+	for _, opt := range d.config.RequiredOptions {
+		component, _ := splitOption(opt)
+		if _, ok := componentSpecs[component]; !ok {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"required_options: unknown component %q in %q, must be one of %v", component, opt, componentNames()))
+		}
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	output := DatasourceOutput{
+		PluginVersion: version.PluginVersion.FormattedVersion(),
+		Options:       supportedOptions(),
+	}
+
+	if err := checkVersion(version.PluginVersion.SemVer(), d.config.MinVersion); err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	if missing := missingOptions(output.Options, d.config.RequiredOptions); len(missing) > 0 {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf(
+			"DigitalOcean plugin %s doesn't support the options %s, please upgrade the plugin",
+			output.PluginVersion, strings.Join(missing, ", "))
+	}
+
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// supportedOptions returns the options of all components, prefixed with
+// the component and sorted.
+func supportedOptions() []string {
+	var options []string
+	for component, spec := range componentSpecs {
+		for name := range spec() {
+			options = append(options, component+"."+name)
+		}
+	}
+	sort.Strings(options)
+	return options
+}
+
+// missingOptions returns the required options that aren't supported.
+func missingOptions(supported, required []string) []string {
+	set := make(map[string]bool, len(supported))
+	for _, opt := range supported {
+		set[opt] = true
+	}
+
+	var missing []string
+	for _, opt := range required {
+		component, name := splitOption(opt)
+		if !set[component+"."+name] {
+			missing = append(missing, opt)
+		}
+	}
+	return missing
+}
+
+// checkVersion fails if the plugin version is older than min. Only the
+// core of the plugin version is compared, so that development builds
+// satisfy the version they lead up to.
+func checkVersion(plugin *goversion.Version, min string) error {
+	if min == "" {
+		return nil
+	}
+	minVersion, err := goversion.NewVersion(min)
+	if err != nil {
+		return err
+	}
+	if plugin.Core().LessThan(minVersion) {
+		return fmt.Errorf(
+			"the template requires DigitalOcean plugin %s or later, but %s is installed", min, plugin)
+	}
+	return nil
+}
+
+// splitOption splits a required option into its component and name.
+func splitOption(opt string) (string, string) {
+	if i := strings.Index(opt, "."); i >= 0 {
+		return opt[:i], opt[i+1:]
+	}
+	return defaultComponent, opt
+}
+
+func componentNames() []string {
+	names := make([]string, 0, len(componentSpecs))
+	for name := range componentSpecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
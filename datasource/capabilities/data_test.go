@@ -0,0 +1,76 @@
+package capabilities
+
+import (
+	"testing"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportedOptions(t *testing.T) {
+	options := supportedOptions()
+	require.Contains(t, options, "digitalocean.snapshot_name")
+	require.Contains(t, options, "digitalocean.snapshot_name_registry")
+	require.Contains(t, options, "digitalocean-snapshot-copy.snapshot_id")
+	require.Contains(t, options, "digitalocean-import.spaces_key")
+	require.NotContains(t, options, "digitalocean.ami_name")
+}
+
+func TestMissingOptions(t *testing.T) {
+	supported := []string{"digitalocean.snapshot_name", "digitalocean-import.spaces_key"}
+	required := []string{"snapshot_name", "digitalocean.snapshot_name", "digitalocean-import.spaces_key", "snapshot_tags", "digitalocean-import.skip_clean"}
+
+	require.Equal(t, []string{"snapshot_tags", "digitalocean-import.skip_clean"}, missingOptions(supported, required))
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		plugin        string
+		min           string
+		expectedError string
+	}{
+		{name: "no minimum", plugin: "1.3.1", min: ""},
+		{name: "newer", plugin: "1.3.1", min: "1.2.0"},
+		{name: "same", plugin: "1.3.1", min: "1.3.1"},
+		{name: "pre-release", plugin: "1.3.1-dev", min: "1.3.1"},
+		{
+			name:          "older",
+			plugin:        "1.3.1",
+			min:           "1.4.0",
+			expectedError: "the template requires DigitalOcean plugin 1.4.0 or later, but 1.3.1 is installed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkVersion(goversion.Must(goversion.NewVersion(tt.plugin)), tt.min)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	var d Datasource
+	err := d.Configure(map[string]interface{}{
+		"required_options": []string{"snapshot_name", "digitalocean-import.spaces_key"},
+		"min_version":      "1.3.0",
+	})
+	require.NoError(t, err)
+
+	d = Datasource{}
+	err = d.Configure(map[string]interface{}{
+		"required_options": []string{"amazon-ebs.ami_name"},
+	})
+	require.Error(t, err)
+
+	d = Datasource{}
+	err = d.Configure(map[string]interface{}{
+		"min_version": "latest",
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// cacheMaxAge is how long the cache of a Packer run is kept. Data sources
+// are all evaluated when the run starts, so older caches are left over from
+// runs that have ended.
+const cacheMaxAge = 24 * time.Hour
+
+// cachedImage returns the image found by resolve, sharing it with every
+// data source of the same Packer run that searches with an identical
+// filter. This keeps parallel builds on the same base image even if a new
+// one is published while the run is in progress. Outside of a Packer run,
+// resolve is always called.
+func cachedImage(c *Config, resolve func() (DatasourceOutput, error)) (DatasourceOutput, error) {
+	runID := os.Getenv("PACKER_RUN_UUID")
+	if runID == "" {
+		return resolve()
+	}
+
+	// Each run caches its images in its own directory. Nothing tells the
+	// plugin when a run ends, so the directories of earlier runs are removed
+	// by the later ones.
+	root := cacheRoot()
+	removeStaleCaches(root, runID)
+	dir := filepath.Join(root, runID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return DatasourceOutput{}, fmt.Errorf("Error creating image cache: %s", err)
+	}
+	path := filepath.Join(dir, filterKey(c)+".json")
+
+	// Data sources are run by separate plugin processes, so the lock has to
+	// be on disk too.
+	lock := flock.New(path + ".lock")
+	if err := lock.Lock(); err != nil {
+		return DatasourceOutput{}, fmt.Errorf("Error locking image cache: %s", err)
+	}
+	defer lock.Unlock()
+
+	if data, err := os.ReadFile(path); err == nil {
+		var output DatasourceOutput
+		if err := json.Unmarshal(data, &output); err == nil {
+			log.Printf("[DEBUG] using image %d resolved earlier in this run", output.ImageID)
+			return output, nil
+		}
+	}
+
+	output, err := resolve()
+	if err != nil {
+		return output, err
+	}
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		return output, err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		log.Printf("[DEBUG] error caching image: %s", err)
+	}
+
+	return output, nil
+}
+
+// cacheRoot returns the directory holding the caches of the current user.
+// It is private to that user, so every user gets their own instead of
+// sharing one in the temporary directory.
+func cacheRoot() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("packer-digitalocean-image-%d", os.Getuid()))
+}
+
+// removeStaleCaches removes the caches of the runs other than runID that
+// are older than cacheMaxAge.
+func removeStaleCaches(root string, runID string) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.Name() == runID {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < cacheMaxAge {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, entry.Name())); err != nil {
+			log.Printf("[DEBUG] error removing image cache %s: %s", entry.Name(), err)
+		}
+	}
+}
+
+// filterKey identifies the account and filter an image is searched with.
+func filterKey(c *Config) string {
+	filter, _ := json.Marshal([]interface{}{
+		c.APIToken, c.APIURL, c.Name, c.NameRegex, c.Type, c.Region, c.Latest,
+	})
+	sum := sha256.Sum256(filter)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,102 @@
+package image
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachedImage(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	t.Setenv("PACKER_RUN_UUID", "2d1b3c1e-7d43-4b1c-9f5e-0a6f7e1d2c3b")
+
+	c := &Config{APIToken: "token", Name: "ubuntu-22-04-x64", Latest: true}
+	resolve := func(id int) func() (DatasourceOutput, error) {
+		return func() (DatasourceOutput, error) {
+			return DatasourceOutput{ImageID: id, ImageRegions: []string{"nyc3"}}, nil
+		}
+	}
+
+	output, err := cachedImage(c, resolve(1))
+	if err != nil || output.ImageID != 1 {
+		t.Fatalf("got %#v, %v", output, err)
+	}
+
+	// An identical filter reuses the image found first
+	output, err = cachedImage(&Config{APIToken: "token", Name: "ubuntu-22-04-x64", Latest: true}, resolve(2))
+	if err != nil || output.ImageID != 1 {
+		t.Fatalf("got %#v, %v", output, err)
+	}
+
+	// A different filter is resolved again
+	output, err = cachedImage(&Config{APIToken: "token", Name: "ubuntu-22-04-x64", Region: "ams3"}, resolve(3))
+	if err != nil || output.ImageID != 3 {
+		t.Fatalf("got %#v, %v", output, err)
+	}
+
+	// Errors are not cached
+	c = &Config{APIToken: "token", Name: "debian-12-x64"}
+	if _, err := cachedImage(c, func() (DatasourceOutput, error) {
+		return DatasourceOutput{}, errors.New("boom")
+	}); err == nil {
+		t.Fatal("should have error")
+	}
+	output, err = cachedImage(c, resolve(4))
+	if err != nil || output.ImageID != 4 {
+		t.Fatalf("got %#v, %v", output, err)
+	}
+
+	// Outside of a Packer run nothing is cached
+	t.Setenv("PACKER_RUN_UUID", "")
+	output, err = cachedImage(&Config{APIToken: "token", Name: "ubuntu-22-04-x64", Latest: true}, resolve(5))
+	if err != nil || output.ImageID != 5 {
+		t.Fatalf("got %#v, %v", output, err)
+	}
+}
+
+func TestCachedImage_RemovesStaleCaches(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv("PACKER_RUN_UUID", "2d1b3c1e-7d43-4b1c-9f5e-0a6f7e1d2c3b")
+
+	root := cacheRoot()
+	stale := filepath.Join(root, "0c9a7d3e-5f41-4e8b-a1d2-3b4c5d6e7f80")
+	recent := filepath.Join(root, "6f1e2d3c-4b5a-4c7d-8e9f-a0b1c2d3e4f5")
+	for _, dir := range []string{stale, recent} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatalf("should not have error: %s", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "filter.json"), []byte("{}"), 0600); err != nil {
+			t.Fatalf("should not have error: %s", err)
+		}
+	}
+	old := time.Now().Add(-2 * cacheMaxAge)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	_, err := cachedImage(&Config{APIToken: "token", Name: "ubuntu-22-04-x64"}, func() (DatasourceOutput, error) {
+		return DatasourceOutput{ImageID: 1}, nil
+	})
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("the cache of an old run should be removed: %v", err)
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("the cache of a recent run should be kept: %s", err)
+	}
+
+	// Nothing is left directly in the temporary directory.
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != filepath.Base(root) {
+		t.Errorf("unexpected files in TMPDIR: %v", entries)
+	}
+}
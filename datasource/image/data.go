@@ -7,24 +7,19 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"net/url"
 	"os"
 	"regexp"
 	"sort"
-	"strconv"
 	"time"
 
 	builder "github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
-	"github.com/digitalocean/packer-plugin-digitalocean/version"
 
 	"github.com/digitalocean/godo"
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
-	"github.com/hashicorp/packer-plugin-sdk/useragent"
 	"github.com/zclconf/go-cty/cty"
-	"golang.org/x/oauth2"
 )
 
 var (
@@ -40,11 +35,17 @@ type Config struct {
 	APIURL string `mapstructure:"api_url"`
 	// The maximum number of retries for requests that fail with a 429 or 500-level error.
 	// The default value is 5. Set to 0 to disable reties.
+	// Deprecated: use `max_retries` in the `retry` block instead.
 	HTTPRetryMax *int `mapstructure:"http_retry_max" required:"false"`
 	// The maximum wait time (in seconds) between failed API requests. Default: 30.0
+	// Deprecated: use `wait_max` in the `retry` block instead.
 	HTTPRetryWaitMax *float64 `mapstructure:"http_retry_wait_max" required:"false"`
 	// The minimum wait time (in seconds) between failed API requests. Default: 1.0
+	// Deprecated: use `wait_min` in the `retry` block instead.
 	HTTPRetryWaitMin *float64 `mapstructure:"http_retry_wait_min" required:"false"`
+	// Controls how failed API requests are retried. See the
+	// [retry configuration](#retry-configuration) section below.
+	Retry builder.RetryConfig `mapstructure:"retry" required:"false"`
 	// The name of the image to return. Only one of `name` or `name_regex` may be provided.
 	Name string `mapstructure:"name"`
 	// A regex matching the name of the image to return. Only one of `name` or `name_regex` may be provided.
@@ -93,35 +94,8 @@ func (d *Datasource) Configure(raws ...interface{}) error {
 		d.config.APIURL = os.Getenv("DIGITALOCEAN_API_URL")
 	}
 
-	if d.config.HTTPRetryMax == nil {
-		d.config.HTTPRetryMax = godo.PtrTo(5)
-		if max := os.Getenv("DIGITALOCEAN_HTTP_RETRY_MAX"); max != "" {
-			maxInt, err := strconv.Atoi(max)
-			if err != nil {
-				return err
-			}
-			d.config.HTTPRetryMax = godo.PtrTo(maxInt)
-		}
-	}
-	if d.config.HTTPRetryWaitMax == nil {
-		d.config.HTTPRetryWaitMax = godo.PtrTo(30.0)
-		if waitMax := os.Getenv("DIGITALOCEAN_HTTP_RETRY_WAIT_MAX"); waitMax != "" {
-			waitMaxFloat, err := strconv.ParseFloat(waitMax, 64)
-			if err != nil {
-				return err
-			}
-			d.config.HTTPRetryWaitMax = godo.PtrTo(waitMaxFloat)
-		}
-	}
-	if d.config.HTTPRetryWaitMin == nil {
-		d.config.HTTPRetryWaitMin = godo.PtrTo(1.0)
-		if waitMin := os.Getenv("DIGITALOCEAN_HTTP_RETRY_WAIT_MIN"); waitMin != "" {
-			waitMinFloat, err := strconv.ParseFloat(waitMin, 64)
-			if err != nil {
-				return err
-			}
-			d.config.HTTPRetryWaitMin = godo.PtrTo(waitMinFloat)
-		}
+	if es := d.config.Retry.Prepare(d.config.HTTPRetryMax, d.config.HTTPRetryWaitMin, d.config.HTTPRetryWaitMax); len(es) > 0 {
+		return &packersdk.MultiError{Errors: es}
 	}
 
 	if d.config.APIToken == "" {
@@ -154,41 +128,27 @@ func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
 }
 
 func (d *Datasource) Execute() (cty.Value, error) {
-	ua := useragent.String(version.PluginVersion.FormattedVersion())
-	clientOpts := []godo.ClientOpt{godo.SetUserAgent(ua)}
-	if d.config.APIURL != "" {
-		_, err := url.Parse(d.config.APIURL)
-		if err != nil {
-			return cty.NullVal(cty.EmptyObject), fmt.Errorf("invalid API URL, %s.", err)
-		}
-
-		clientOpts = append(clientOpts, godo.SetBaseURL(d.config.APIURL))
-	}
-
-	if *d.config.HTTPRetryMax > 0 {
-		clientOpts = append(clientOpts, godo.WithRetryAndBackoffs(godo.RetryConfig{
-			RetryMax:     *d.config.HTTPRetryMax,
-			RetryWaitMin: d.config.HTTPRetryWaitMin,
-			RetryWaitMax: d.config.HTTPRetryWaitMax,
-			Logger:       log.Default(),
-		}))
+	client, err := builder.NewClient(d.config.APIToken, d.config.APIURL, &d.config.Retry)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
 	}
 
-	oauthClient := oauth2.NewClient(context.TODO(), &builder.APITokenSource{
-		AccessToken: d.config.APIToken,
+	output, err := cachedImage(&d.config, func() (DatasourceOutput, error) {
+		return findImage(client, &d.config)
 	})
-	client, err := godo.New(oauthClient, clientOpts...)
 	if err != nil {
 		return cty.NullVal(cty.EmptyObject), err
 	}
 
-	opts := &godo.ListOptions{
-		Page:    1,
-		PerPage: 200,
-	}
+	log.Printf("[DEBUG] found image: %v", output.ImageID)
+
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
 
+// findImage lists the images and returns the one matching the filter.
+func findImage(client *godo.Client, c *Config) (DatasourceOutput, error) {
 	imageListFunc := client.Images.List
-	switch d.config.Type {
+	switch c.Type {
 	case "user":
 		imageListFunc = client.Images.ListUser
 	case "application":
@@ -197,41 +157,20 @@ func (d *Datasource) Execute() (cty.Value, error) {
 		imageListFunc = client.Images.ListDistribution
 	}
 
-	var imageList []godo.Image
-	for {
-		images, resp, err := imageListFunc(context.Background(), opts)
-
-		if err != nil {
-			return cty.NullVal(cty.EmptyObject), err
-		}
-
-		imageList = append(imageList, images...)
-
-		if resp.Links == nil || resp.Links.IsLastPage() {
-			break
-		}
-
-		page, err := resp.Links.CurrentPage()
-		if err != nil {
-			return cty.NullVal(cty.EmptyObject), err
-		}
-
-		opts.Page = page + 1
+	imageList, err := builder.ListAll(context.Background(), imageListFunc)
+	if err != nil {
+		return DatasourceOutput{}, err
 	}
 
-	result, err := filterImages(&d.config, imageList)
+	result, err := filterImages(c, imageList)
 	if err != nil {
-		return cty.NullVal(cty.EmptyObject), err
+		return DatasourceOutput{}, err
 	}
 
-	output := DatasourceOutput{
+	return DatasourceOutput{
 		ImageID:      result.ID,
 		ImageRegions: result.Regions,
-	}
-
-	log.Printf("[DEBUG] found image: %v", result.ID)
-
-	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+	}, nil
 }
 
 func filterImages(c *Config, images []godo.Image) (godo.Image, error) {
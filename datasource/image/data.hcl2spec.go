@@ -3,6 +3,7 @@
 package image
 
 import (
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -10,16 +11,17 @@ import (
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
-	APIToken         *string  `mapstructure:"api_token" required:"true" cty:"api_token" hcl:"api_token"`
-	APIURL           *string  `mapstructure:"api_url" cty:"api_url" hcl:"api_url"`
-	HTTPRetryMax     *int     `mapstructure:"http_retry_max" required:"false" cty:"http_retry_max" hcl:"http_retry_max"`
-	HTTPRetryWaitMax *float64 `mapstructure:"http_retry_wait_max" required:"false" cty:"http_retry_wait_max" hcl:"http_retry_wait_max"`
-	HTTPRetryWaitMin *float64 `mapstructure:"http_retry_wait_min" required:"false" cty:"http_retry_wait_min" hcl:"http_retry_wait_min"`
-	Name             *string  `mapstructure:"name" cty:"name" hcl:"name"`
-	NameRegex        *string  `mapstructure:"name_regex" cty:"name_regex" hcl:"name_regex"`
-	Type             *string  `mapstructure:"type" cty:"type" hcl:"type"`
-	Region           *string  `mapstructure:"region" cty:"region" hcl:"region"`
-	Latest           *bool    `mapstructure:"latest" cty:"latest" hcl:"latest"`
+	APIToken         *string                       `mapstructure:"api_token" required:"true" cty:"api_token" hcl:"api_token"`
+	APIURL           *string                       `mapstructure:"api_url" cty:"api_url" hcl:"api_url"`
+	HTTPRetryMax     *int                          `mapstructure:"http_retry_max" required:"false" cty:"http_retry_max" hcl:"http_retry_max"`
+	HTTPRetryWaitMax *float64                      `mapstructure:"http_retry_wait_max" required:"false" cty:"http_retry_wait_max" hcl:"http_retry_wait_max"`
+	HTTPRetryWaitMin *float64                      `mapstructure:"http_retry_wait_min" required:"false" cty:"http_retry_wait_min" hcl:"http_retry_wait_min"`
+	Retry            *digitalocean.FlatRetryConfig `mapstructure:"retry" required:"false" cty:"retry" hcl:"retry"`
+	Name             *string                       `mapstructure:"name" cty:"name" hcl:"name"`
+	NameRegex        *string                       `mapstructure:"name_regex" cty:"name_regex" hcl:"name_regex"`
+	Type             *string                       `mapstructure:"type" cty:"type" hcl:"type"`
+	Region           *string                       `mapstructure:"region" cty:"region" hcl:"region"`
+	Latest           *bool                         `mapstructure:"latest" cty:"latest" hcl:"latest"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -39,6 +41,7 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"http_retry_max":      &hcldec.AttrSpec{Name: "http_retry_max", Type: cty.Number, Required: false},
 		"http_retry_wait_max": &hcldec.AttrSpec{Name: "http_retry_wait_max", Type: cty.Number, Required: false},
 		"http_retry_wait_min": &hcldec.AttrSpec{Name: "http_retry_wait_min", Type: cty.Number, Required: false},
+		"retry":               &hcldec.BlockSpec{TypeName: "retry", Nested: hcldec.ObjectSpec((*digitalocean.FlatRetryConfig)(nil).HCL2Spec())},
 		"name":                &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
 		"name_regex":          &hcldec.AttrSpec{Name: "name_regex", Type: cty.String, Required: false},
 		"type":                &hcldec.AttrSpec{Name: "type", Type: cty.String, Required: false},
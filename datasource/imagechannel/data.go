@@ -0,0 +1,181 @@
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+//go:generate packer-sdc struct-markdown
+package imagechannel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	builder "github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// channelTagPrefix prefixes the channel name in the tag marking the images
+// of a channel.
+const channelTagPrefix = "channel:"
+
+var channelRe = regexp.MustCompile("^[[:alnum:]:_-]{1,247}$")
+
+type Config struct {
+	// The API token to used to access your account. It can also be specified via
+	// the DIGITALOCEAN_TOKEN or DIGITALOCEAN_ACCESS_TOKEN environment variables.
+	APIToken string `mapstructure:"api_token" required:"true"`
+	// A non-standard API endpoint URL. Set this if you are  using a DigitalOcean API
+	// compatible service. It can also be specified via environment variable DIGITALOCEAN_API_URL.
+	APIURL string `mapstructure:"api_url"`
+	// Controls how failed API requests are retried. See the
+	// [retry configuration](#retry-configuration) section below.
+	Retry builder.RetryConfig `mapstructure:"retry" required:"false"`
+	// The name of the channel (e.g. `prod`). The image of the channel is the
+	// image tagged `channel:<name>`.
+	Channel string `mapstructure:"channel" required:"true"`
+	// A DigitalOcean region slug (e.g. `nyc3`). When provided, only images
+	// of the channel available in that region are considered.
+	Region string `mapstructure:"region"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	// The ID of the image of the channel.
+	ImageID int `mapstructure:"image_id"`
+	// The name of the image of the channel.
+	ImageName string `mapstructure:"image_name"`
+	// The regions the image of the channel is available in.
+	ImageRegions []string `mapstructure:"image_regions"`
+	// When the image of the channel was created, in RFC 3339 format.
+	Created string `mapstructure:"created"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	var errs *packersdk.MultiError
+
+	if d.config.APIToken == "" {
+		d.config.APIToken = os.Getenv("DIGITALOCEAN_TOKEN")
+		if d.config.APIToken == "" {
+			d.config.APIToken = os.Getenv("DIGITALOCEAN_ACCESS_TOKEN")
+		}
+	}
+	if d.config.APIURL == "" {
+		d.config.APIURL = os.Getenv("DIGITALOCEAN_API_URL")
+	}
+
+	if es := d.config.Retry.Prepare(nil, nil, nil); len(es) > 0 {
+		errs = packersdk.MultiErrorAppend(errs, es...)
+	}
+
+	if d.config.APIToken == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("api_token is required"))
+	}
+
+	if d.config.Channel == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("channel is required"))
+	} else if !channelRe.MatchString(d.config.Channel) {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("invalid channel: %s", d.config.Channel))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	client, err := builder.NewClient(d.config.APIToken, d.config.APIURL, &d.config.Retry)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	tag := channelTagPrefix + d.config.Channel
+
+	images, err := builder.ListAll(context.Background(),
+		func(ctx context.Context, opt *godo.ListOptions) ([]godo.Image, *godo.Response, error) {
+			return client.Images.ListByTag(ctx, tag, opt)
+		})
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	image, err := channelImage(&d.config, images)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	log.Printf("[DEBUG] image of channel %s: %d", d.config.Channel, image.ID)
+
+	output := DatasourceOutput{
+		ImageID:      image.ID,
+		ImageName:    image.Name,
+		ImageRegions: image.Regions,
+		Created:      image.Created,
+	}
+
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// channelImage returns the image of the channel among the images carrying
+// its tag. A promotion normally moves the tag, but when it's left on older
+// images too the most recently created one wins.
+func channelImage(c *Config, images []godo.Image) (godo.Image, error) {
+	candidates := make([]godo.Image, 0, len(images))
+	for _, i := range images {
+		if c.Region == "" || containsRegion(i.Regions, c.Region) {
+			candidates = append(candidates, i)
+		}
+	}
+
+	if len(candidates) == 0 {
+		if c.Region != "" {
+			return godo.Image{}, fmt.Errorf("no image of channel %s is available in %s", c.Channel, c.Region)
+		}
+		return godo.Image{}, fmt.Errorf("no image is tagged %s%s", channelTagPrefix, c.Channel)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		itime, _ := time.Parse(time.RFC3339, candidates[i].Created)
+		jtime, _ := time.Parse(time.RFC3339, candidates[j].Created)
+		return itime.After(jtime)
+	})
+	if len(candidates) > 1 {
+		log.Printf("[WARN] %d images are tagged %s%s, using the latest", len(candidates), channelTagPrefix, c.Channel)
+	}
+
+	return candidates[0], nil
+}
+
+func containsRegion(regions []string, region string) bool {
+	for _, r := range regions {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package imagechannel
+
+import (
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelImage(t *testing.T) {
+	images := []godo.Image{
+		{ID: 1, Name: "web-2023-01", Regions: []string{"nyc3", "ams3"}, Created: "2023-01-10T12:00:00Z"},
+		{ID: 2, Name: "web-2023-03", Regions: []string{"nyc3"}, Created: "2023-03-02T12:00:00Z"},
+		{ID: 3, Name: "web-2023-02", Regions: []string{"nyc3", "ams3"}, Created: "2023-02-05T12:00:00Z"},
+	}
+
+	tests := []struct {
+		name          string
+		config        *Config
+		images        []godo.Image
+		expectedID    int
+		expectedError string
+	}{
+		{
+			name:       "latest",
+			config:     &Config{Channel: "prod"},
+			images:     images,
+			expectedID: 2,
+		},
+		{
+			name:       "latest in region",
+			config:     &Config{Channel: "prod", Region: "ams3"},
+			images:     images,
+			expectedID: 3,
+		},
+		{
+			name:          "not in region",
+			config:        &Config{Channel: "prod", Region: "sfo3"},
+			images:        images,
+			expectedError: "no image of channel prod is available in sfo3",
+		},
+		{
+			name:          "empty channel",
+			config:        &Config{Channel: "staging"},
+			expectedError: "no image is tagged channel:staging",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image, err := channelImage(tt.config, tt.images)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedID, image.ID)
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	var d Datasource
+	err := d.Configure(map[string]interface{}{
+		"api_token": "channel-test-token",
+		"channel":   "prod",
+	})
+	require.NoError(t, err)
+
+	d = Datasource{}
+	err = d.Configure(map[string]interface{}{
+		"api_token": "channel-test-token",
+		"channel":   "prod channel",
+	})
+	require.Error(t, err)
+
+	d = Datasource{}
+	err = d.Configure(map[string]interface{}{
+		"api_token": "channel-test-token",
+	})
+	require.Error(t, err)
+}
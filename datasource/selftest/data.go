@@ -0,0 +1,167 @@
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+//go:generate packer-sdc struct-markdown
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime/debug"
+
+	builder "github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/digitalocean/packer-plugin-digitalocean/version"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const defaultAPIURL = "https://api.digitalocean.com/"
+
+type Config struct {
+	// The API token to used to access your account. It can also be specified via
+	// the DIGITALOCEAN_TOKEN or DIGITALOCEAN_ACCESS_TOKEN environment variables.
+	APIToken string `mapstructure:"api_token" required:"true"`
+	// A non-standard API endpoint URL. Set this if you are  using a DigitalOcean API
+	// compatible service. It can also be specified via environment variable DIGITALOCEAN_API_URL.
+	APIURL string `mapstructure:"api_url"`
+	// Controls how failed API requests are retried. See the
+	// [retry configuration](#retry-configuration) section below.
+	Retry builder.RetryConfig `mapstructure:"retry" required:"false"`
+	// When true, failed checks are reported in the output instead of failing
+	// the build. Defaults to `false`.
+	IgnoreErrors bool `mapstructure:"ignore_errors"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	// The version of the DigitalOcean plugin.
+	PluginVersion string `mapstructure:"plugin_version"`
+	// The version of the godo library used by the plugin.
+	GodoVersion string `mapstructure:"godo_version"`
+	// The API endpoint that was checked.
+	APIURL string `mapstructure:"api_url"`
+	// The proxy used to reach the API, if any, as configured by the
+	// HTTPS_PROXY and NO_PROXY environment variables.
+	Proxy string `mapstructure:"proxy"`
+	// Whether the API responded to the request.
+	APIReachable bool `mapstructure:"api_reachable"`
+	// Whether the API accepted the token.
+	TokenValid bool `mapstructure:"token_valid"`
+	// The status of the account the token belongs to, such as `active`.
+	AccountStatus string `mapstructure:"account_status"`
+	// The error encountered by the failed check, if any.
+	Error string `mapstructure:"error"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	var errs *packersdk.MultiError
+
+	if d.config.APIToken == "" {
+		d.config.APIToken = os.Getenv("DIGITALOCEAN_TOKEN")
+		if d.config.APIToken == "" {
+			d.config.APIToken = os.Getenv("DIGITALOCEAN_ACCESS_TOKEN")
+		}
+	}
+	if d.config.APIURL == "" {
+		d.config.APIURL = os.Getenv("DIGITALOCEAN_API_URL")
+	}
+
+	if es := d.config.Retry.Prepare(nil, nil, nil); len(es) > 0 {
+		errs = packersdk.MultiErrorAppend(errs, es...)
+	}
+
+	if d.config.APIToken == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("api_token is required"))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	output := DatasourceOutput{
+		PluginVersion: version.PluginVersion.FormattedVersion(),
+		GodoVersion:   godoVersion(),
+		APIURL:        defaultAPIURL,
+	}
+
+	client, err := builder.NewClient(d.config.APIToken, d.config.APIURL, &d.config.Retry)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	if d.config.APIURL != "" {
+		output.APIURL = d.config.APIURL
+	}
+
+	if proxy, err := http.ProxyFromEnvironment(&http.Request{URL: client.BaseURL}); err == nil && proxy != nil {
+		output.Proxy = proxy.Redacted()
+	}
+
+	account, _, err := client.Account.Get(context.Background())
+	checkAccount(&output, account, err)
+	log.Printf("[DEBUG] self-test: %+v", output)
+
+	if output.Error != "" && !d.config.IgnoreErrors {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("DigitalOcean self-test failed: %s", output.Error)
+	}
+
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// checkAccount records the result of fetching the account in the output. A
+// response carrying an API error shows the API is reachable; the token is
+// only known to be valid if it wasn't rejected and the API didn't fail.
+func checkAccount(output *DatasourceOutput, account *godo.Account, err error) {
+	if err == nil {
+		output.APIReachable = true
+		output.TokenValid = true
+		output.AccountStatus = account.Status
+		return
+	}
+
+	output.Error = err.Error()
+
+	var errResp *godo.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response != nil {
+		output.APIReachable = true
+		code := errResp.Response.StatusCode
+		output.TokenValid = code != http.StatusUnauthorized && code < 500
+	}
+}
+
+func godoVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/digitalocean/godo" {
+				return dep.Version
+			}
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,75 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package selftest
+
+import (
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatConfig struct {
+	APIToken     *string                       `mapstructure:"api_token" required:"true" cty:"api_token" hcl:"api_token"`
+	APIURL       *string                       `mapstructure:"api_url" cty:"api_url" hcl:"api_url"`
+	Retry        *digitalocean.FlatRetryConfig `mapstructure:"retry" required:"false" cty:"retry" hcl:"retry"`
+	IgnoreErrors *bool                         `mapstructure:"ignore_errors" cty:"ignore_errors" hcl:"ignore_errors"`
+}
+
+// FlatMapstructure returns a new FlatConfig.
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatConfig)
+}
+
+// HCL2Spec returns the hcl spec of a Config.
+// This spec is used by HCL to read the fields of Config.
+// The decoded values from this spec will then be applied to a FlatConfig.
+func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"api_token":     &hcldec.AttrSpec{Name: "api_token", Type: cty.String, Required: false},
+		"api_url":       &hcldec.AttrSpec{Name: "api_url", Type: cty.String, Required: false},
+		"retry":         &hcldec.BlockSpec{TypeName: "retry", Nested: hcldec.ObjectSpec((*digitalocean.FlatRetryConfig)(nil).HCL2Spec())},
+		"ignore_errors": &hcldec.AttrSpec{Name: "ignore_errors", Type: cty.Bool, Required: false},
+	}
+	return s
+}
+
+// FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatDatasourceOutput struct {
+	PluginVersion *string `mapstructure:"plugin_version" cty:"plugin_version" hcl:"plugin_version"`
+	GodoVersion   *string `mapstructure:"godo_version" cty:"godo_version" hcl:"godo_version"`
+	APIURL        *string `mapstructure:"api_url" cty:"api_url" hcl:"api_url"`
+	Proxy         *string `mapstructure:"proxy" cty:"proxy" hcl:"proxy"`
+	APIReachable  *bool   `mapstructure:"api_reachable" cty:"api_reachable" hcl:"api_reachable"`
+	TokenValid    *bool   `mapstructure:"token_valid" cty:"token_valid" hcl:"token_valid"`
+	AccountStatus *string `mapstructure:"account_status" cty:"account_status" hcl:"account_status"`
+	Error         *string `mapstructure:"error" cty:"error" hcl:"error"`
+}
+
+// FlatMapstructure returns a new FlatDatasourceOutput.
+// FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*DatasourceOutput) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatDatasourceOutput)
+}
+
+// HCL2Spec returns the hcl spec of a DatasourceOutput.
+// This spec is used by HCL to read the fields of DatasourceOutput.
+// The decoded values from this spec will then be applied to a FlatDatasourceOutput.
+func (*FlatDatasourceOutput) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"plugin_version": &hcldec.AttrSpec{Name: "plugin_version", Type: cty.String, Required: false},
+		"godo_version":   &hcldec.AttrSpec{Name: "godo_version", Type: cty.String, Required: false},
+		"api_url":        &hcldec.AttrSpec{Name: "api_url", Type: cty.String, Required: false},
+		"proxy":          &hcldec.AttrSpec{Name: "proxy", Type: cty.String, Required: false},
+		"api_reachable":  &hcldec.AttrSpec{Name: "api_reachable", Type: cty.Bool, Required: false},
+		"token_valid":    &hcldec.AttrSpec{Name: "token_valid", Type: cty.Bool, Required: false},
+		"account_status": &hcldec.AttrSpec{Name: "account_status", Type: cty.String, Required: false},
+		"error":          &hcldec.AttrSpec{Name: "error", Type: cty.String, Required: false},
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package selftest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAccount(t *testing.T) {
+	tests := []struct {
+		name           string
+		account        *godo.Account
+		err            error
+		expectedOutput DatasourceOutput
+	}{
+		{
+			name:    "valid token",
+			account: &godo.Account{Status: "active"},
+			expectedOutput: DatasourceOutput{
+				APIReachable:  true,
+				TokenValid:    true,
+				AccountStatus: "active",
+			},
+		},
+		{
+			name: "invalid token",
+			err: &godo.ErrorResponse{
+				Response: &http.Response{StatusCode: http.StatusUnauthorized, Request: &http.Request{Method: "GET"}},
+				Message:  "Unable to authenticate you",
+			},
+			expectedOutput: DatasourceOutput{
+				APIReachable: true,
+				TokenValid:   false,
+			},
+		},
+		{
+			name: "server error",
+			err: &godo.ErrorResponse{
+				Response: &http.Response{StatusCode: http.StatusServiceUnavailable, Request: &http.Request{Method: "GET"}},
+				Message:  "Service Unavailable",
+			},
+			expectedOutput: DatasourceOutput{
+				APIReachable: true,
+				TokenValid:   false,
+			},
+		},
+		{
+			name: "unreachable",
+			err:  errors.New("dial tcp: lookup api.digitalocean.com: no such host"),
+			expectedOutput: DatasourceOutput{
+				Error: "dial tcp: lookup api.digitalocean.com: no such host",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output DatasourceOutput
+			checkAccount(&output, tt.account, tt.err)
+			if tt.err != nil {
+				tt.expectedOutput.Error = tt.err.Error()
+			}
+			require.Equal(t, tt.expectedOutput, output)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+//go:generate packer-sdc struct-markdown
+package size
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	builder "github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type Config struct {
+	// The API token to used to access your account. It can also be specified via
+	// the DIGITALOCEAN_TOKEN or DIGITALOCEAN_ACCESS_TOKEN environment variables.
+	APIToken string `mapstructure:"api_token" required:"true"`
+	// A non-standard API endpoint URL. Set this if you are  using a DigitalOcean API
+	// compatible service. It can also be specified via environment variable DIGITALOCEAN_API_URL.
+	APIURL string `mapstructure:"api_url"`
+	// Controls how failed API requests are retried. See the
+	// [retry configuration](#retry-configuration) section below.
+	Retry builder.RetryConfig `mapstructure:"retry" required:"false"`
+	// The slug of the droplet size to look up (e.g. `s-1vcpu-1gb`).
+	Size string `mapstructure:"size" required:"true"`
+	// The slug of the region to check for availability of the size (e.g. `nyc3`).
+	Region string `mapstructure:"region" required:"true"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	// Whether droplets of the size can currently be created in the region.
+	Available bool `mapstructure:"available"`
+	// The regions in which the size is currently available.
+	AvailableRegions []string `mapstructure:"available_regions"`
+	// The hourly price of the size in US dollars.
+	PriceHourly float64 `mapstructure:"price_hourly"`
+	// The disk size of the size in gigabytes.
+	Disk int `mapstructure:"disk"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	var errs *packersdk.MultiError
+
+	if d.config.APIToken == "" {
+		d.config.APIToken = os.Getenv("DIGITALOCEAN_TOKEN")
+		if d.config.APIToken == "" {
+			d.config.APIToken = os.Getenv("DIGITALOCEAN_ACCESS_TOKEN")
+		}
+	}
+	if d.config.APIURL == "" {
+		d.config.APIURL = os.Getenv("DIGITALOCEAN_API_URL")
+	}
+
+	if es := d.config.Retry.Prepare(nil, nil, nil); len(es) > 0 {
+		errs = packersdk.MultiErrorAppend(errs, es...)
+	}
+
+	if d.config.APIToken == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("api_token is required"))
+	}
+
+	if d.config.Size == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("size is required"))
+	}
+
+	if d.config.Region == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("region is required"))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	client, err := builder.NewClient(d.config.APIToken, d.config.APIURL, &d.config.Retry)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	sizes, err := builder.ListAll(context.Background(), client.Sizes.List)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	regions, err := builder.ListAll(context.Background(), client.Regions.List)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	output, err := sizeAvailability(&d.config, sizes, regions)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	log.Printf("[DEBUG] size %s available in %s: %t", d.config.Size, d.config.Region, output.Available)
+
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// sizeAvailability computes where the configured size can currently be
+// created. A size is available in a region when the size, the region and the
+// region's list of sizes all say so.
+func sizeAvailability(c *Config, sizes []godo.Size, regions []godo.Region) (DatasourceOutput, error) {
+	var size *godo.Size
+	for i := range sizes {
+		if sizes[i].Slug == c.Size {
+			size = &sizes[i]
+			break
+		}
+	}
+	if size == nil {
+		return DatasourceOutput{}, fmt.Errorf("size %s not found", c.Size)
+	}
+
+	regionFound := false
+	availableRegions := make([]string, 0)
+	for _, r := range regions {
+		if r.Slug == c.Region {
+			regionFound = true
+		}
+		if !size.Available || !r.Available {
+			continue
+		}
+		for _, s := range r.Sizes {
+			if s == size.Slug {
+				availableRegions = append(availableRegions, r.Slug)
+				break
+			}
+		}
+	}
+	if !regionFound {
+		return DatasourceOutput{}, fmt.Errorf("region %s not found", c.Region)
+	}
+	sort.Strings(availableRegions)
+
+	available := false
+	for _, r := range availableRegions {
+		if r == c.Region {
+			available = true
+		}
+	}
+
+	return DatasourceOutput{
+		Available:        available,
+		AvailableRegions: availableRegions,
+		PriceHourly:      size.PriceHourly,
+		Disk:             size.Disk,
+	}, nil
+}
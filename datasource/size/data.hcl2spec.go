@@ -0,0 +1,69 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package size
+
+import (
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatConfig struct {
+	APIToken *string                       `mapstructure:"api_token" required:"true" cty:"api_token" hcl:"api_token"`
+	APIURL   *string                       `mapstructure:"api_url" cty:"api_url" hcl:"api_url"`
+	Retry    *digitalocean.FlatRetryConfig `mapstructure:"retry" required:"false" cty:"retry" hcl:"retry"`
+	Size     *string                       `mapstructure:"size" required:"true" cty:"size" hcl:"size"`
+	Region   *string                       `mapstructure:"region" required:"true" cty:"region" hcl:"region"`
+}
+
+// FlatMapstructure returns a new FlatConfig.
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatConfig)
+}
+
+// HCL2Spec returns the hcl spec of a Config.
+// This spec is used by HCL to read the fields of Config.
+// The decoded values from this spec will then be applied to a FlatConfig.
+func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"api_token": &hcldec.AttrSpec{Name: "api_token", Type: cty.String, Required: false},
+		"api_url":   &hcldec.AttrSpec{Name: "api_url", Type: cty.String, Required: false},
+		"retry":     &hcldec.BlockSpec{TypeName: "retry", Nested: hcldec.ObjectSpec((*digitalocean.FlatRetryConfig)(nil).HCL2Spec())},
+		"size":      &hcldec.AttrSpec{Name: "size", Type: cty.String, Required: false},
+		"region":    &hcldec.AttrSpec{Name: "region", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatDatasourceOutput struct {
+	Available        *bool    `mapstructure:"available" cty:"available" hcl:"available"`
+	AvailableRegions []string `mapstructure:"available_regions" cty:"available_regions" hcl:"available_regions"`
+	PriceHourly      *float64 `mapstructure:"price_hourly" cty:"price_hourly" hcl:"price_hourly"`
+	Disk             *int     `mapstructure:"disk" cty:"disk" hcl:"disk"`
+}
+
+// FlatMapstructure returns a new FlatDatasourceOutput.
+// FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*DatasourceOutput) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatDatasourceOutput)
+}
+
+// HCL2Spec returns the hcl spec of a DatasourceOutput.
+// This spec is used by HCL to read the fields of DatasourceOutput.
+// The decoded values from this spec will then be applied to a FlatDatasourceOutput.
+func (*FlatDatasourceOutput) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"available":         &hcldec.AttrSpec{Name: "available", Type: cty.Bool, Required: false},
+		"available_regions": &hcldec.AttrSpec{Name: "available_regions", Type: cty.List(cty.String), Required: false},
+		"price_hourly":      &hcldec.AttrSpec{Name: "price_hourly", Type: cty.Number, Required: false},
+		"disk":              &hcldec.AttrSpec{Name: "disk", Type: cty.Number, Required: false},
+	}
+	return s
+}
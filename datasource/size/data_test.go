@@ -0,0 +1,93 @@
+package size
+
+import (
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeAvailability(t *testing.T) {
+	sizes := []godo.Size{
+		{Slug: "s-1vcpu-1gb", Available: true, PriceHourly: 0.00893, Disk: 25},
+		{Slug: "s-2vcpu-2gb", Available: false, PriceHourly: 0.02679, Disk: 60},
+	}
+	regions := []godo.Region{
+		{Slug: "nyc3", Available: true, Sizes: []string{"s-1vcpu-1gb", "s-2vcpu-2gb"}},
+		{Slug: "ams3", Available: true, Sizes: []string{"s-1vcpu-1gb"}},
+		{Slug: "sfo3", Available: true, Sizes: []string{"s-2vcpu-2gb"}},
+		{Slug: "nyc1", Available: false, Sizes: []string{"s-1vcpu-1gb"}},
+	}
+
+	tests := []struct {
+		name           string
+		config         *Config
+		expectedOutput DatasourceOutput
+		expectedError  string
+	}{
+		{
+			name:   "available",
+			config: &Config{Size: "s-1vcpu-1gb", Region: "nyc3"},
+			expectedOutput: DatasourceOutput{
+				Available:        true,
+				AvailableRegions: []string{"ams3", "nyc3"},
+				PriceHourly:      0.00893,
+				Disk:             25,
+			},
+		},
+		{
+			name:   "size not offered in region",
+			config: &Config{Size: "s-1vcpu-1gb", Region: "sfo3"},
+			expectedOutput: DatasourceOutput{
+				Available:        false,
+				AvailableRegions: []string{"ams3", "nyc3"},
+				PriceHourly:      0.00893,
+				Disk:             25,
+			},
+		},
+		{
+			name:   "region unavailable",
+			config: &Config{Size: "s-1vcpu-1gb", Region: "nyc1"},
+			expectedOutput: DatasourceOutput{
+				Available:        false,
+				AvailableRegions: []string{"ams3", "nyc3"},
+				PriceHourly:      0.00893,
+				Disk:             25,
+			},
+		},
+		{
+			name:   "size unavailable",
+			config: &Config{Size: "s-2vcpu-2gb", Region: "nyc3"},
+			expectedOutput: DatasourceOutput{
+				Available:        false,
+				AvailableRegions: []string{},
+				PriceHourly:      0.02679,
+				Disk:             60,
+			},
+		},
+		{
+			name:          "unknown size",
+			config:        &Config{Size: "s-64vcpu-256gb", Region: "nyc3"},
+			expectedError: "size s-64vcpu-256gb not found",
+		},
+		{
+			name:          "unknown region",
+			config:        &Config{Size: "s-1vcpu-1gb", Region: "xyz1"},
+			expectedError: "region xyz1 not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := sizeAvailability(tt.config, sizes, regions)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedOutput, output)
+		})
+	}
+}
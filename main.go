@@ -5,8 +5,17 @@ import (
 	"os"
 
 	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	digitaloceanchroot "github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean-chroot"
+	digitaloceansnapshotcopy "github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean-snapshot-copy"
+	"github.com/digitalocean/packer-plugin-digitalocean/datasource/capabilities"
 	"github.com/digitalocean/packer-plugin-digitalocean/datasource/image"
+	"github.com/digitalocean/packer-plugin-digitalocean/datasource/imagechannel"
+	"github.com/digitalocean/packer-plugin-digitalocean/datasource/selftest"
+	"github.com/digitalocean/packer-plugin-digitalocean/datasource/size"
+	digitaloceanConvertPP "github.com/digitalocean/packer-plugin-digitalocean/post-processor/digitalocean-convert"
 	digitaloceanPP "github.com/digitalocean/packer-plugin-digitalocean/post-processor/digitalocean-import"
+	digitaloceanLockPP "github.com/digitalocean/packer-plugin-digitalocean/post-processor/digitalocean-lock"
+	digitaloceanPrunePP "github.com/digitalocean/packer-plugin-digitalocean/post-processor/digitalocean-prune"
 	"github.com/digitalocean/packer-plugin-digitalocean/version"
 
 	"github.com/hashicorp/packer-plugin-sdk/plugin"
@@ -15,8 +24,17 @@ import (
 func main() {
 	pps := plugin.NewSet()
 	pps.RegisterBuilder(plugin.DEFAULT_NAME, new(digitalocean.Builder))
+	pps.RegisterBuilder("snapshot-copy", new(digitaloceansnapshotcopy.Builder))
+	pps.RegisterBuilder("chroot", new(digitaloceanchroot.Builder))
 	pps.RegisterPostProcessor("import", new(digitaloceanPP.PostProcessor))
+	pps.RegisterPostProcessor("convert", new(digitaloceanConvertPP.PostProcessor))
+	pps.RegisterPostProcessor("lock", new(digitaloceanLockPP.PostProcessor))
+	pps.RegisterPostProcessor("prune", new(digitaloceanPrunePP.PostProcessor))
 	pps.RegisterDatasource("image", new(image.Datasource))
+	pps.RegisterDatasource("image-channel", new(imagechannel.Datasource))
+	pps.RegisterDatasource("size", new(size.Datasource))
+	pps.RegisterDatasource("selftest", new(selftest.Datasource))
+	pps.RegisterDatasource("capabilities", new(capabilities.Datasource))
 	pps.SetVersion(version.PluginVersion)
 	err := pps.Run()
 	if err != nil {
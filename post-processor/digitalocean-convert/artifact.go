@@ -0,0 +1,36 @@
+package digitaloceanconvert
+
+import (
+	"fmt"
+	"os"
+)
+
+// Artifact is a disk image converted for use with local virtualization.
+type Artifact struct {
+	path   string
+	format string
+}
+
+func (*Artifact) BuilderId() string {
+	return BuilderId
+}
+
+func (a *Artifact) Files() []string {
+	return []string{a.path}
+}
+
+func (a *Artifact) Id() string {
+	return a.path
+}
+
+func (a *Artifact) String() string {
+	return fmt.Sprintf("%s image: %s", a.format, a.path)
+}
+
+func (a *Artifact) State(name string) interface{} {
+	return nil
+}
+
+func (a *Artifact) Destroy() error {
+	return os.Remove(a.path)
+}
@@ -0,0 +1,225 @@
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+package digitaloceanconvert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+const BuilderId = "packer.post-processor.digitalocean-convert"
+
+const (
+	formatQcow2 = "qcow2"
+	formatVMDK  = "vmdk"
+)
+
+var validFormats = []string{formatQcow2, formatVMDK}
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The disk format to convert the image to. This may be `qcow2` or `vmdk`.
+	Format string `mapstructure:"format" required:"true"`
+	// The path of the converted image. If not specified, the image is written
+	// next to the source image with its extension replaced by the format, such
+	// as `disk.qcow2` for `disk.raw`, or with `-converted` added when the
+	// source already has that extension. It can't be the source image itself.
+	Output string `mapstructure:"output"`
+	// The path to the `qemu-img` executable. If not specified, `qemu-img` is
+	// looked up in the `PATH` when the post-processor runs.
+	QemuImgPath string `mapstructure:"qemu_img_path"`
+	// Whether to compress the converted image. Only supported for `qcow2`.
+	// Defaults to `false`.
+	Compress bool `mapstructure:"compress"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+
+	validFormat := false
+	for _, f := range validFormats {
+		if p.config.Format == f {
+			validFormat = true
+		}
+	}
+	if !validFormat {
+		errs = packersdk.MultiErrorAppend(
+			errs, fmt.Errorf("format must be one of %v", validFormats))
+	}
+
+	if p.config.Compress && p.config.Format != formatQcow2 {
+		errs = packersdk.MultiErrorAppend(
+			errs, fmt.Errorf("compress is only supported for the %s format", formatQcow2))
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	source, err := extractRawImage(artifact.Files())
+	if err != nil {
+		return nil, false, false, fmt.Errorf("Raw image not found: %s", err)
+	}
+
+	output := p.config.Output
+	if output == "" {
+		output = defaultOutputPath(source, p.config.Format)
+	}
+	if samePath(source, output) {
+		return nil, false, false, fmt.Errorf(
+			"The output %s is the source image; set output to another path", output)
+	}
+
+	qemuImg := p.config.QemuImgPath
+	if qemuImg == "" {
+		qemuImg, err = exec.LookPath("qemu-img")
+		if err != nil {
+			return nil, false, false, fmt.Errorf(
+				"qemu-img is required to convert images but was not found in PATH; " +
+					"install it or set qemu_img_path")
+		}
+	}
+
+	sourceFormat, err := imageFormat(ctx, qemuImg, source)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("Failed to detect the format of %s: %s", source, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return nil, false, false, fmt.Errorf("Failed to create output directory: %s", err)
+	}
+
+	args := qemuImgArgs(source, sourceFormat, output, p.config.Format, p.config.Compress)
+	log.Printf("Executing: %s %s", qemuImg, strings.Join(args, " "))
+
+	ui.Message(fmt.Sprintf("Converting %s to %s image %s", source, p.config.Format, output))
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, qemuImg, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, false, false, fmt.Errorf("Failed to convert %s: %s: %s",
+			source, err, strings.TrimSpace(stderr.String()))
+	}
+	ui.Message(fmt.Sprintf("Completed conversion of %s", source))
+
+	return &Artifact{path: output, format: p.config.Format}, true, false, nil
+}
+
+// extractRawImage returns the disk image among the artifact files: the only
+// one, or the raw one.
+func extractRawImage(artifacts []string) (string, error) {
+	if len(artifacts) == 0 {
+		return "", fmt.Errorf("no artifacts were provided")
+	}
+
+	if len(artifacts) == 1 {
+		return artifacts[0], nil
+	}
+
+	for _, path := range artifacts {
+		if isRawImage(path) {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("no raw image file found")
+}
+
+func isRawImage(path string) bool {
+	return strings.HasSuffix(path, ".raw") || strings.HasSuffix(path, ".img")
+}
+
+// imageFormat returns the disk format of the image at path: raw for the
+// files builders name so, or the format qemu-img detects otherwise.
+func imageFormat(ctx context.Context, qemuImg string, path string) (string, error) {
+	if isRawImage(path) {
+		return "raw", nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, qemuImg, "info", "--output=json", path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var info struct {
+		Format string `json:"format"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &info); err != nil {
+		return "", fmt.Errorf("unexpected qemu-img info output: %s", err)
+	}
+	if info.Format == "" {
+		return "", fmt.Errorf("qemu-img info did not report a format")
+	}
+	return info.Format, nil
+}
+
+func defaultOutputPath(source string, format string) string {
+	ext := filepath.Ext(source)
+	base := strings.TrimSuffix(source, ext)
+	if ext == "."+format {
+		// Don't write over the source image.
+		base += "-converted"
+	}
+	return base + "." + format
+}
+
+// samePath reports whether a and b name the same file, which qemu-img would
+// truncate while reading it.
+func samePath(a string, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA == nil && errB == nil && absA == absB {
+		return true
+	}
+
+	fiA, errA := os.Stat(a)
+	fiB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(fiA, fiB)
+}
+
+func qemuImgArgs(source string, sourceFormat string, output string, format string, compress bool) []string {
+	args := []string{"convert", "-f", sourceFormat, "-O", format}
+	if compress {
+		args = append(args, "-c")
+	}
+	return append(args, source, output)
+}
@@ -0,0 +1,186 @@
+package digitaloceanconvert
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessor_Configure(t *testing.T) {
+	tt := []struct {
+		Name   string
+		Config map[string]interface{}
+		Error  bool
+	}{
+		{Name: "qcow2", Config: map[string]interface{}{"format": "qcow2"}},
+		{Name: "vmdk", Config: map[string]interface{}{"format": "vmdk"}},
+		{Name: "compressed qcow2", Config: map[string]interface{}{"format": "qcow2", "compress": true}},
+		{Name: "missing format", Config: map[string]interface{}{}, Error: true},
+		{Name: "invalid format", Config: map[string]interface{}{"format": "vhdx"}, Error: true},
+		{Name: "compressed vmdk", Config: map[string]interface{}{"format": "vmdk", "compress": true}, Error: true},
+	}
+
+	for _, tc := range tt {
+		var p PostProcessor
+		err := p.Configure(tc.Config)
+		if tc.Error && err == nil {
+			t.Errorf("%s: should have error", tc.Name)
+		}
+		if !tc.Error && err != nil {
+			t.Errorf("%s: should not have error: %s", tc.Name, err)
+		}
+	}
+}
+
+func TestPostProcessor_RawImageExtraction(t *testing.T) {
+	tt := []struct {
+		Name          string
+		Source        string
+		Artifacts     []string
+		ExpectedError string
+	}{
+		{Name: "EmptyArtifacts", ExpectedError: "no artifacts were provided"},
+		{Name: "SingleArtifact", Source: "disk", Artifacts: []string{"disk"}},
+		{Name: "RawArtifact", Source: "output/disk.raw", Artifacts: []string{"output/disk.ovf", "output/disk.raw"}},
+		{Name: "NoRawArtifact", Artifacts: []string{"disk.vmdk", "disk.ovf"}, ExpectedError: "no raw image file found"},
+	}
+
+	for _, tc := range tt {
+		source, err := extractRawImage(tc.Artifacts)
+
+		if tc.Source != source {
+			t.Errorf("expected the source to be %q, but got %q", tc.Source, source)
+		}
+
+		if err != nil && (tc.ExpectedError != err.Error()) {
+			t.Errorf("unexpected error received; expected %q, but got %q", tc.ExpectedError, err.Error())
+		}
+	}
+}
+
+func TestPostProcessor_QemuImgArgs(t *testing.T) {
+	if got := defaultOutputPath("output/disk.raw", "qcow2"); got != "output/disk.qcow2" {
+		t.Errorf("unexpected output path %q", got)
+	}
+	if got := defaultOutputPath("output/disk.qcow2", "qcow2"); got != "output/disk-converted.qcow2" {
+		t.Errorf("unexpected output path %q", got)
+	}
+
+	got := qemuImgArgs("disk.raw", "raw", "disk.qcow2", "qcow2", true)
+	want := []string{"convert", "-f", "raw", "-O", "qcow2", "-c", "disk.raw", "disk.qcow2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPostProcessor_OutputIsSource(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "disk.raw")
+	if err := os.WriteFile(source, []byte("disk"), 0644); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	link := filepath.Join(dir, "link.raw")
+	if err := os.Symlink(source, link); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	for _, output := range []string{source, filepath.Join(dir, ".", "disk.raw"), link} {
+		var p PostProcessor
+		err := p.Configure(map[string]interface{}{"format": "qcow2", "output": output, "qemu_img_path": "qemu-img"})
+		if err != nil {
+			t.Fatalf("should not have error: %s", err)
+		}
+
+		artifact := &packersdk.MockArtifact{FilesValue: []string{source}}
+		_, _, _, err = p.PostProcess(context.Background(), packersdk.TestUi(t), artifact)
+		if err == nil || !strings.Contains(err.Error(), "is the source image") {
+			t.Errorf("%s: expected the output to be refused, got %v", output, err)
+		}
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil || string(data) != "disk" {
+		t.Fatalf("source image changed: %q, %v", data, err)
+	}
+}
+
+// fakeQemuImg writes a qemu-img script to dir that reports info as the image
+// information and records the arguments it converts with to args.
+func fakeQemuImg(t *testing.T, dir string, info string) (qemuImg string, args string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake qemu-img is a shell script")
+	}
+	qemuImg = filepath.Join(dir, "qemu-img")
+	args = filepath.Join(dir, "args")
+	script := fmt.Sprintf(`#!/bin/sh
+if [ "$1" = info ]; then
+	echo '%s'
+	exit
+fi
+echo "$@" > %s
+`, info, args)
+	if err := os.WriteFile(qemuImg, []byte(script), 0755); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	return qemuImg, args
+}
+
+func TestPostProcessor_DetectsSourceFormat(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "disk")
+	if err := os.WriteFile(source, []byte("disk"), 0644); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	output := filepath.Join(dir, "disk.vmdk")
+
+	tt := []struct {
+		Name          string
+		Info          string
+		ExpectedArgs  string
+		ExpectedError string
+	}{
+		{Name: "Qcow2", Info: `{"format": "qcow2", "virtual-size": 4}`,
+			ExpectedArgs: "convert -f qcow2 -O vmdk " + source + " " + output},
+		{Name: "Unknown", Info: `{"virtual-size": 4}`, ExpectedError: "did not report a format"},
+	}
+
+	for _, tc := range tt {
+		qemuImg, args := fakeQemuImg(t, t.TempDir(), tc.Info)
+
+		var p PostProcessor
+		err := p.Configure(map[string]interface{}{"format": "vmdk", "output": output, "qemu_img_path": qemuImg})
+		if err != nil {
+			t.Fatalf("should not have error: %s", err)
+		}
+
+		artifact := &packersdk.MockArtifact{FilesValue: []string{source}}
+		_, _, _, err = p.PostProcess(context.Background(), packersdk.TestUi(t), artifact)
+		if tc.ExpectedError != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.ExpectedError) {
+				t.Errorf("%s: expected error %q, got %v", tc.Name, tc.ExpectedError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: should not have error: %s", tc.Name, err)
+		}
+		data, err := os.ReadFile(args)
+		if err != nil {
+			t.Fatalf("%s: should not have error: %s", tc.Name, err)
+		}
+		if got := strings.TrimSpace(string(data)); got != tc.ExpectedArgs {
+			t.Errorf("%s: got %q, want %q", tc.Name, got, tc.ExpectedArgs)
+		}
+	}
+}
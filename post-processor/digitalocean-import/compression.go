@@ -0,0 +1,173 @@
+package digitaloceanimport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	compressionNone  = "none"
+	compressionGzip  = "gzip"
+	compressionBzip2 = "bzip2"
+	compressionXz    = "xz"
+	compressionZstd  = "zstd"
+)
+
+var (
+	// The compression formats that may be selected with `compression`.
+	validCompressions = []string{compressionNone, compressionGzip}
+
+	compressionMagic = []struct {
+		format string
+		magic  []byte
+	}{
+		{compressionGzip, []byte{0x1f, 0x8b}},
+		{compressionBzip2, []byte("BZh")},
+		{compressionXz, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+		{compressionZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	}
+
+	// The file extensions of the compression formats that are decompressed
+	// before upload.
+	compressionExtensions = map[string][]string{
+		compressionXz:   {".xz"},
+		compressionZstd: {".zst", ".zstd"},
+	}
+)
+
+// detectCompression inspects the leading bytes of the file at path and
+// returns the compression format it uses, or "none".
+func detectCompression(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, 6)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	header = header[:n]
+
+	for _, c := range compressionMagic {
+		if bytes.HasPrefix(header, c.magic) {
+			return c.format, nil
+		}
+	}
+
+	return compressionNone, nil
+}
+
+// prepareImageForUpload returns the path of the file that should be uploaded
+// to Spaces for the given source image. DigitalOcean can import gzip and bzip2
+// compressed images directly, so those are uploaded untouched. xz and zstd
+// images are decompressed locally first. Uncompressed images are gzipped when
+// `compression` is set to "gzip". When a new file is written, the returned
+// cleanup function removes it, and `space_object_name` is renamed to match
+// its compression.
+func prepareImageForUpload(source string, p *PostProcessor) (string, func(), error) {
+	noop := func() {}
+
+	detected, err := detectCompression(source)
+	if err != nil {
+		return "", noop, fmt.Errorf("Failed to inspect %s: %s", source, err)
+	}
+	log.Printf("Detected %s compression for %s", detected, source)
+
+	if detected == compressionGzip || detected == compressionBzip2 {
+		return source, noop, nil
+	}
+	if detected == compressionNone && p.config.Compression == compressionNone {
+		return source, noop, nil
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return "", noop, fmt.Errorf("Failed to open %s: %s", source, err)
+	}
+	defer in.Close()
+
+	var r io.Reader = in
+	switch detected {
+	case compressionXz:
+		xr, err := xz.NewReader(in)
+		if err != nil {
+			return "", noop, fmt.Errorf("Failed to read xz image %s: %s", source, err)
+		}
+		r = xr
+	case compressionZstd:
+		zr, err := zstd.NewReader(in)
+		if err != nil {
+			return "", noop, fmt.Errorf("Failed to read zstd image %s: %s", source, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	out, err := os.CreateTemp("", "packer-do-import-*")
+	if err != nil {
+		return "", noop, err
+	}
+	cleanup := func() { os.Remove(out.Name()) }
+
+	var w io.WriteCloser = out
+	if p.config.Compression == compressionGzip {
+		gw, err := gzip.NewWriterLevel(out, p.config.CompressionLevel)
+		if err != nil {
+			out.Close()
+			cleanup()
+			return "", noop, err
+		}
+		w = gw
+	}
+
+	if _, err := io.Copy(w, r); err != nil {
+		out.Close()
+		cleanup()
+		return "", noop, fmt.Errorf("Failed to prepare %s for upload: %s", source, err)
+	}
+	if w != out {
+		if err := w.Close(); err != nil {
+			out.Close()
+			cleanup()
+			return "", noop, err
+		}
+	}
+	if err := out.Close(); err != nil {
+		cleanup()
+		return "", noop, err
+	}
+
+	if name := uploadObjectName(p.config.ObjectName, detected, p.config.Compression); name != p.config.ObjectName {
+		log.Printf("Renaming space_object_name %s to %s to match its compression", p.config.ObjectName, name)
+		p.config.ObjectName = name
+	}
+	return out.Name(), cleanup, nil
+}
+
+// uploadObjectName returns the name of the object an image compressed with
+// detected is uploaded as once recompressed with uploaded: an .xz or .zst
+// extension is replaced with .gz for gzip, or dropped for none, so that the
+// name doesn't claim a compression the object no longer has.
+func uploadObjectName(name string, detected string, uploaded string) string {
+	for _, ext := range compressionExtensions[detected] {
+		if strings.HasSuffix(name, ext) {
+			name = strings.TrimSuffix(name, ext)
+			if uploaded == compressionGzip {
+				name += ".gz"
+			}
+			return name
+		}
+	}
+	return name
+}
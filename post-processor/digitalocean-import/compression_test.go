@@ -0,0 +1,96 @@
+package digitaloceanimport
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+)
+
+func TestDetectCompression(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Contents []byte
+		Expected string
+	}{
+		{Name: "gzip", Contents: []byte{0x1f, 0x8b, 0x08, 0x00}, Expected: compressionGzip},
+		{Name: "bzip2", Contents: []byte("BZh91AY"), Expected: compressionBzip2},
+		{Name: "xz", Contents: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00, 0x00}, Expected: compressionXz},
+		{Name: "zstd", Contents: []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}, Expected: compressionZstd},
+		{Name: "raw", Contents: []byte{0xeb, 0x63, 0x90, 0x10}, Expected: compressionNone},
+		{Name: "short", Contents: []byte{0x1f}, Expected: compressionNone},
+	}
+
+	dir := t.TempDir()
+	for _, tc := range tt {
+		path := filepath.Join(dir, tc.Name)
+		if err := os.WriteFile(path, tc.Contents, 0644); err != nil {
+			t.Fatalf("failed to write test file: %s", err)
+		}
+
+		format, err := detectCompression(path)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.Name, err)
+		}
+		if format != tc.Expected {
+			t.Errorf("%s: expected %q, but got %q", tc.Name, tc.Expected, format)
+		}
+	}
+}
+
+func TestUploadObjectName(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Detected string
+		Uploaded string
+		Expected string
+	}{
+		{Name: "disk.img.xz", Detected: compressionXz, Uploaded: compressionGzip, Expected: "disk.img.gz"},
+		{Name: "disk.tar.xz", Detected: compressionXz, Uploaded: compressionNone, Expected: "disk.tar"},
+		{Name: "disk.img.zst", Detected: compressionZstd, Uploaded: compressionGzip, Expected: "disk.img.gz"},
+		{Name: "disk.img.zstd", Detected: compressionZstd, Uploaded: compressionNone, Expected: "disk.img"},
+		{Name: "packer-import-1700000000", Detected: compressionXz, Uploaded: compressionGzip, Expected: "packer-import-1700000000"},
+		{Name: "disk.img", Detected: compressionNone, Uploaded: compressionGzip, Expected: "disk.img"},
+	}
+
+	for _, tc := range tt {
+		if got := uploadObjectName(tc.Name, tc.Detected, tc.Uploaded); got != tc.Expected {
+			t.Errorf("%s: expected %q, but got %q", tc.Name, tc.Expected, got)
+		}
+	}
+}
+
+func TestPrepareImageForUpload_Recompress(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "disk.img.xz")
+	f, err := os.Create(source)
+	if err != nil {
+		t.Fatalf("failed to create test file: %s", err)
+	}
+	w, err := xz.NewWriter(f)
+	if err != nil {
+		t.Fatalf("failed to create xz writer: %s", err)
+	}
+	w.Write([]byte("disk contents"))
+	w.Close()
+	f.Close()
+
+	p := &PostProcessor{}
+	p.config.ObjectName = "images/disk.img.xz"
+	p.config.Compression = compressionGzip
+	p.config.CompressionLevel = gzip.DefaultCompression
+
+	upload, cleanup, err := prepareImageForUpload(source, p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer cleanup()
+
+	if format, err := detectCompression(upload); err != nil || format != compressionGzip {
+		t.Errorf("expected a gzip upload, got %q, %v", format, err)
+	}
+	if p.config.ObjectName != "images/disk.img.gz" {
+		t.Errorf("expected the object to be renamed, got %q", p.config.ObjectName)
+	}
+}
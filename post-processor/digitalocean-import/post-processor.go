@@ -8,12 +8,9 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
-	"golang.org/x/oauth2"
-
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -22,13 +19,11 @@ import (
 	"github.com/digitalocean/godo"
 
 	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
-	"github.com/digitalocean/packer-plugin-digitalocean/version"
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/hashicorp/packer-plugin-sdk/common"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
-	"github.com/hashicorp/packer-plugin-sdk/useragent"
 )
 
 const BuilderId = "packer.post-processor.digitalocean-import"
@@ -48,11 +43,17 @@ type Config struct {
 	SpacesSecret string `mapstructure:"spaces_secret" required:"true"`
 	// The maximum number of retries for requests that fail with a 429 or 500-level error.
 	// The default value is 5. Set to 0 to disable reties.
+	// Deprecated: use `max_retries` in the `retry` block instead.
 	HTTPRetryMax *int `mapstructure:"http_retry_max" required:"false"`
 	// The maximum wait time (in seconds) between failed API requests. Default: 30.0
+	// Deprecated: use `wait_max` in the `retry` block instead.
 	HTTPRetryWaitMax *float64 `mapstructure:"http_retry_wait_max" required:"false"`
 	// The minimum wait time (in seconds) between failed API requests. Default: 1.0
+	// Deprecated: use `wait_min` in the `retry` block instead.
 	HTTPRetryWaitMin *float64 `mapstructure:"http_retry_wait_min" required:"false"`
+	// Controls how failed API requests are retried. See the
+	// [retry configuration](#retry-configuration) section below.
+	Retry digitalocean.RetryConfig `mapstructure:"retry" required:"false"`
 	// The name of the region, such as `nyc3`, in which to upload the image to Spaces.
 	SpacesRegion string `mapstructure:"spaces_region" required:"true"`
 	// The name of the specific Space where the image file will be copied to for
@@ -83,6 +84,17 @@ type Config struct {
 	// as well as distributing the resulting image to additional regions. If not
 	// specified, this will default to 20.
 	Timeout time.Duration `mapstructure:"timeout"`
+	// The compression to apply to uncompressed images before uploading them
+	// to Spaces. This may be `none` or `gzip`. Images that are already
+	// compressed with gzip or bzip2 are uploaded as-is, while xz and zstd
+	// compressed images are decompressed first since DigitalOcean can not
+	// import them, and then compressed with `compression`. An `.xz` or
+	// `.zst` extension of `space_object_name` is then replaced with `.gz`, or
+	// dropped for `none`. Defaults to `none`.
+	Compression string `mapstructure:"compression"`
+	// The compression level (1-9) to use when `compression` is `gzip`.
+	// Defaults to 6.
+	CompressionLevel int `mapstructure:"compression_level"`
 
 	ctx interpolate.Context
 }
@@ -91,20 +103,10 @@ type PostProcessor struct {
 	config Config
 }
 
-type apiTokenSource struct {
-	AccessToken string
-}
-
 type logger struct {
 	logger *log.Logger
 }
 
-func (t *apiTokenSource) Token() (*oauth2.Token, error) {
-	return &oauth2.Token{
-		AccessToken: t.AccessToken,
-	}, nil
-}
-
 func (l logger) Log(args ...interface{}) {
 	l.logger.Println(args...)
 }
@@ -142,35 +144,8 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 		p.config.APIToken = os.Getenv("DIGITALOCEAN_API_TOKEN")
 	}
 
-	if p.config.HTTPRetryMax == nil {
-		p.config.HTTPRetryMax = godo.PtrTo(5)
-		if max := os.Getenv("DIGITALOCEAN_HTTP_RETRY_MAX"); max != "" {
-			maxInt, err := strconv.Atoi(max)
-			if err != nil {
-				return err
-			}
-			p.config.HTTPRetryMax = godo.PtrTo(maxInt)
-		}
-	}
-	if p.config.HTTPRetryWaitMax == nil {
-		p.config.HTTPRetryWaitMax = godo.PtrTo(30.0)
-		if waitMax := os.Getenv("DIGITALOCEAN_HTTP_RETRY_WAIT_MAX"); waitMax != "" {
-			waitMaxFloat, err := strconv.ParseFloat(waitMax, 64)
-			if err != nil {
-				return err
-			}
-			p.config.HTTPRetryWaitMax = godo.PtrTo(waitMaxFloat)
-		}
-	}
-	if p.config.HTTPRetryWaitMin == nil {
-		p.config.HTTPRetryWaitMin = godo.PtrTo(1.0)
-		if waitMin := os.Getenv("DIGITALOCEAN_HTTP_RETRY_WAIT_MIN"); waitMin != "" {
-			waitMinFloat, err := strconv.ParseFloat(waitMin, 64)
-			if err != nil {
-				return err
-			}
-			p.config.HTTPRetryWaitMin = godo.PtrTo(waitMinFloat)
-		}
+	if es := p.config.Retry.Prepare(p.config.HTTPRetryMax, p.config.HTTPRetryWaitMin, p.config.HTTPRetryWaitMax); len(es) > 0 {
+		return &packersdk.MultiError{Errors: es}
 	}
 
 	if p.config.ObjectName == "" {
@@ -185,8 +160,32 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 		p.config.Timeout = 20 * time.Minute
 	}
 
+	if p.config.Compression == "" {
+		p.config.Compression = compressionNone
+	}
+
+	if p.config.CompressionLevel == 0 {
+		p.config.CompressionLevel = 6
+	}
+
 	errs := new(packersdk.MultiError)
 
+	validCompression := false
+	for _, c := range validCompressions {
+		if p.config.Compression == c {
+			validCompression = true
+		}
+	}
+	if !validCompression {
+		errs = packersdk.MultiErrorAppend(
+			errs, fmt.Errorf("compression must be one of %v", validCompressions))
+	}
+
+	if p.config.CompressionLevel < 1 || p.config.CompressionLevel > 9 {
+		errs = packersdk.MultiErrorAppend(
+			errs, fmt.Errorf("compression_level must be between 1 and 9"))
+	}
+
 	if err = interpolate.Validate(p.config.ObjectName, &p.config.ctx); err != nil {
 		errs = packersdk.MultiErrorAppend(
 			errs, fmt.Errorf("Error parsing space_object_name template: %s", err))
@@ -249,6 +248,7 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifa
 		Credentials: spacesCreds,
 		Endpoint:    aws.String(spacesEndpoint),
 		Region:      aws.String(p.config.SpacesRegion),
+		MaxRetries:  p.config.Retry.MaxRetries,
 		LogLevel:    aws.LogLevel(aws.LogDebugWithSigning),
 		Logger: &logger{
 			logger: log.New(os.Stderr, "", log.LstdFlags),
@@ -259,30 +259,22 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifa
 		return nil, false, false, err
 	}
 
-	ui.Message(fmt.Sprintf("Uploading %s to spaces://%s/%s", source, p.config.SpaceName, p.config.ObjectName))
-	err = uploadImageToSpaces(source, p, sess)
+	upload, cleanupUpload, err := prepareImageForUpload(source, p)
 	if err != nil {
 		return nil, false, false, err
 	}
-	ui.Message(fmt.Sprintf("Completed upload of %s to spaces://%s/%s", source, p.config.SpaceName, p.config.ObjectName))
+	defer cleanupUpload()
 
-	ua := useragent.String(version.PluginVersion.FormattedVersion())
-	opts := []godo.ClientOpt{godo.SetUserAgent(ua)}
-
-	if *p.config.HTTPRetryMax > 0 {
-		opts = append(opts, godo.WithRetryAndBackoffs(godo.RetryConfig{
-			RetryMax:     *p.config.HTTPRetryMax,
-			RetryWaitMin: p.config.HTTPRetryWaitMin,
-			RetryWaitMax: p.config.HTTPRetryWaitMax,
-			Logger:       log.Default(),
-		}))
+	ui.Message(fmt.Sprintf("Uploading %s to spaces://%s/%s", source, p.config.SpaceName, p.config.ObjectName))
+	err = uploadImageToSpaces(upload, p, sess)
+	if err != nil {
+		return nil, false, false, err
 	}
+	ui.Message(fmt.Sprintf("Completed upload of %s to spaces://%s/%s", source, p.config.SpaceName, p.config.ObjectName))
 
-	client, err := godo.New(oauth2.NewClient(context.TODO(), &apiTokenSource{
-		AccessToken: p.config.APIToken,
-	}), opts...)
+	client, err := digitalocean.NewClient(p.config.APIToken, "", &p.config.Retry)
 	if err != nil {
-		return nil, false, false, fmt.Errorf("DigitalOcean: could not create client, %s", err)
+		return nil, false, false, err
 	}
 
 	ui.Message(fmt.Sprintf("Started import of spaces://%s/%s", p.config.SpaceName, p.config.ObjectName))
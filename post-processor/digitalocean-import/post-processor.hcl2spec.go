@@ -3,6 +3,7 @@
 package digitaloceanimport
 
 import (
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -10,30 +11,33 @@ import (
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
-	PackerBuildName     *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
-	PackerBuilderType   *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
-	PackerCoreVersion   *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
-	PackerDebug         *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
-	PackerForce         *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
-	PackerOnError       *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
-	PackerUserVars      map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
-	PackerSensitiveVars []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
-	APIToken            *string           `mapstructure:"api_token" required:"true" cty:"api_token" hcl:"api_token"`
-	SpacesKey           *string           `mapstructure:"spaces_key" required:"true" cty:"spaces_key" hcl:"spaces_key"`
-	SpacesSecret        *string           `mapstructure:"spaces_secret" required:"true" cty:"spaces_secret" hcl:"spaces_secret"`
-	HTTPRetryMax        *int              `mapstructure:"http_retry_max" required:"false" cty:"http_retry_max" hcl:"http_retry_max"`
-	HTTPRetryWaitMax    *float64          `mapstructure:"http_retry_wait_max" required:"false" cty:"http_retry_wait_max" hcl:"http_retry_wait_max"`
-	HTTPRetryWaitMin    *float64          `mapstructure:"http_retry_wait_min" required:"false" cty:"http_retry_wait_min" hcl:"http_retry_wait_min"`
-	SpacesRegion        *string           `mapstructure:"spaces_region" required:"true" cty:"spaces_region" hcl:"spaces_region"`
-	SpaceName           *string           `mapstructure:"space_name" required:"true" cty:"space_name" hcl:"space_name"`
-	ObjectName          *string           `mapstructure:"space_object_name" cty:"space_object_name" hcl:"space_object_name"`
-	SkipClean           *bool             `mapstructure:"skip_clean" cty:"skip_clean" hcl:"skip_clean"`
-	Tags                []string          `mapstructure:"image_tags" cty:"image_tags" hcl:"image_tags"`
-	Name                *string           `mapstructure:"image_name" required:"true" cty:"image_name" hcl:"image_name"`
-	Description         *string           `mapstructure:"image_description" cty:"image_description" hcl:"image_description"`
-	Distribution        *string           `mapstructure:"image_distribution" cty:"image_distribution" hcl:"image_distribution"`
-	ImageRegions        []string          `mapstructure:"image_regions" required:"true" cty:"image_regions" hcl:"image_regions"`
-	Timeout             *string           `mapstructure:"timeout" cty:"timeout" hcl:"timeout"`
+	PackerBuildName     *string                       `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType   *string                       `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion   *string                       `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug         *bool                         `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce         *bool                         `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError       *string                       `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerUserVars      map[string]string             `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars []string                      `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	APIToken            *string                       `mapstructure:"api_token" required:"true" cty:"api_token" hcl:"api_token"`
+	SpacesKey           *string                       `mapstructure:"spaces_key" required:"true" cty:"spaces_key" hcl:"spaces_key"`
+	SpacesSecret        *string                       `mapstructure:"spaces_secret" required:"true" cty:"spaces_secret" hcl:"spaces_secret"`
+	HTTPRetryMax        *int                          `mapstructure:"http_retry_max" required:"false" cty:"http_retry_max" hcl:"http_retry_max"`
+	HTTPRetryWaitMax    *float64                      `mapstructure:"http_retry_wait_max" required:"false" cty:"http_retry_wait_max" hcl:"http_retry_wait_max"`
+	HTTPRetryWaitMin    *float64                      `mapstructure:"http_retry_wait_min" required:"false" cty:"http_retry_wait_min" hcl:"http_retry_wait_min"`
+	Retry               *digitalocean.FlatRetryConfig `mapstructure:"retry" required:"false" cty:"retry" hcl:"retry"`
+	SpacesRegion        *string                       `mapstructure:"spaces_region" required:"true" cty:"spaces_region" hcl:"spaces_region"`
+	SpaceName           *string                       `mapstructure:"space_name" required:"true" cty:"space_name" hcl:"space_name"`
+	ObjectName          *string                       `mapstructure:"space_object_name" cty:"space_object_name" hcl:"space_object_name"`
+	SkipClean           *bool                         `mapstructure:"skip_clean" cty:"skip_clean" hcl:"skip_clean"`
+	Tags                []string                      `mapstructure:"image_tags" cty:"image_tags" hcl:"image_tags"`
+	Name                *string                       `mapstructure:"image_name" required:"true" cty:"image_name" hcl:"image_name"`
+	Description         *string                       `mapstructure:"image_description" cty:"image_description" hcl:"image_description"`
+	Distribution        *string                       `mapstructure:"image_distribution" cty:"image_distribution" hcl:"image_distribution"`
+	ImageRegions        []string                      `mapstructure:"image_regions" required:"true" cty:"image_regions" hcl:"image_regions"`
+	Timeout             *string                       `mapstructure:"timeout" cty:"timeout" hcl:"timeout"`
+	Compression         *string                       `mapstructure:"compression" cty:"compression" hcl:"compression"`
+	CompressionLevel    *int                          `mapstructure:"compression_level" cty:"compression_level" hcl:"compression_level"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -62,6 +66,7 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"http_retry_max":             &hcldec.AttrSpec{Name: "http_retry_max", Type: cty.Number, Required: false},
 		"http_retry_wait_max":        &hcldec.AttrSpec{Name: "http_retry_wait_max", Type: cty.Number, Required: false},
 		"http_retry_wait_min":        &hcldec.AttrSpec{Name: "http_retry_wait_min", Type: cty.Number, Required: false},
+		"retry":                      &hcldec.BlockSpec{TypeName: "retry", Nested: hcldec.ObjectSpec((*digitalocean.FlatRetryConfig)(nil).HCL2Spec())},
 		"spaces_region":              &hcldec.AttrSpec{Name: "spaces_region", Type: cty.String, Required: false},
 		"space_name":                 &hcldec.AttrSpec{Name: "space_name", Type: cty.String, Required: false},
 		"space_object_name":          &hcldec.AttrSpec{Name: "space_object_name", Type: cty.String, Required: false},
@@ -72,6 +77,8 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"image_distribution":         &hcldec.AttrSpec{Name: "image_distribution", Type: cty.String, Required: false},
 		"image_regions":              &hcldec.AttrSpec{Name: "image_regions", Type: cty.List(cty.String), Required: false},
 		"timeout":                    &hcldec.AttrSpec{Name: "timeout", Type: cty.String, Required: false},
+		"compression":                &hcldec.AttrSpec{Name: "compression", Type: cty.String, Required: false},
+		"compression_level":          &hcldec.AttrSpec{Name: "compression_level", Type: cty.Number, Required: false},
 	}
 	return s
 }
@@ -0,0 +1,191 @@
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+package digitaloceanlock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	digitaloceanimport "github.com/digitalocean/packer-plugin-digitalocean/post-processor/digitalocean-import"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// A personal access token used to communicate with the DigitalOcean v2 API.
+	// This may also be set using the `DIGITALOCEAN_TOKEN` or
+	// `DIGITALOCEAN_ACCESS_TOKEN` environmental variables.
+	APIToken string `mapstructure:"api_token" required:"true"`
+	// Non standard api endpoint URL. Set this if you are
+	// using a DigitalOcean API compatible service. It can also be specified via
+	// environment variable DIGITALOCEAN_API_URL.
+	APIURL string `mapstructure:"api_url"`
+	// Controls how failed API requests are retried. See the
+	// [retry configuration](#retry-configuration) section below.
+	Retry digitalocean.RetryConfig `mapstructure:"retry" required:"false"`
+	// The path of a file to which a protection entry is appended for every
+	// locked image, one JSON object per line. If not specified, no ledger is
+	// written.
+	LedgerPath string `mapstructure:"ledger_path"`
+	// Why the image is locked. This is recorded in the ledger.
+	Reason string `mapstructure:"reason"`
+
+	ctx interpolate.Context
+}
+
+// LedgerEntry is the protection entry recorded for a locked image.
+type LedgerEntry struct {
+	ImageID   int       `json:"image_id"`
+	ImageName string    `json:"image_name"`
+	Regions   []string  `json:"regions"`
+	Tag       string    `json:"tag"`
+	Reason    string    `json:"reason,omitempty"`
+	LockedAt  time.Time `json:"locked_at"`
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if p.config.APIToken == "" {
+		p.config.APIToken = os.Getenv("DIGITALOCEAN_TOKEN")
+	}
+	if p.config.APIToken == "" {
+		p.config.APIToken = os.Getenv("DIGITALOCEAN_ACCESS_TOKEN")
+	}
+	if p.config.APIURL == "" {
+		p.config.APIURL = os.Getenv("DIGITALOCEAN_API_URL")
+	}
+
+	errs := new(packersdk.MultiError)
+
+	if es := p.config.Retry.Prepare(nil, nil, nil); len(es) > 0 {
+		errs = packersdk.MultiErrorAppend(errs, es...)
+	}
+
+	if p.config.APIToken == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("api_token must be set"))
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(p.config.APIToken)
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	switch artifact.BuilderId() {
+	case digitalocean.BuilderId, digitaloceanimport.BuilderId:
+	default:
+		return nil, false, false, fmt.Errorf(
+			"Unknown artifact type: %s\nCan only lock DigitalOcean images.", artifact.BuilderId())
+	}
+
+	imageID, regions, err := parseArtifactId(artifact.Id())
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	client, err := digitalocean.NewClient(p.config.APIToken, p.config.APIURL, &p.config.Retry)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	image, _, err := client.Images.GetByID(ctx, imageID)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("Error retrieving image %d: %s", imageID, err)
+	}
+
+	ui.Message(fmt.Sprintf("Locking image %d (%s) with tag %q", imageID, image.Name, digitalocean.LockedTag))
+	if _, _, err := client.Tags.Create(ctx, &godo.TagCreateRequest{Name: digitalocean.LockedTag}); err != nil {
+		return nil, false, false, fmt.Errorf("Error creating tag %q: %s", digitalocean.LockedTag, err)
+	}
+	_, err = client.Tags.TagResources(ctx, digitalocean.LockedTag, &godo.TagResourcesRequest{
+		Resources: []godo.Resource{{ID: strconv.Itoa(imageID), Type: godo.ImageResourceType}},
+	})
+	if err != nil {
+		return nil, false, false, fmt.Errorf("Error tagging image %d: %s", imageID, err)
+	}
+
+	if p.config.LedgerPath != "" {
+		entry := LedgerEntry{
+			ImageID:   imageID,
+			ImageName: image.Name,
+			Regions:   regions,
+			Tag:       digitalocean.LockedTag,
+			Reason:    p.config.Reason,
+			LockedAt:  time.Now().UTC(),
+		}
+		if err := appendLedgerEntry(p.config.LedgerPath, entry); err != nil {
+			return nil, false, false, fmt.Errorf("Error writing ledger %s: %s", p.config.LedgerPath, err)
+		}
+		log.Printf("Recorded lock of image %d in %s", imageID, p.config.LedgerPath)
+	}
+
+	return artifact, true, false, nil
+}
+
+// parseArtifactId splits an artifact ID of the form "region,...:id".
+func parseArtifactId(id string) (int, []string, error) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 2 {
+		return 0, nil, fmt.Errorf("Invalid artifact ID %q", id)
+	}
+
+	imageID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, nil, fmt.Errorf("Invalid image ID in artifact ID %q: %s", id, err)
+	}
+
+	var regions []string
+	if parts[0] != "" {
+		regions = strings.Split(parts[0], ",")
+	}
+
+	return imageID, regions, nil
+}
+
+func appendLedgerEntry(path string, entry LedgerEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
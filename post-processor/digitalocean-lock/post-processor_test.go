@@ -0,0 +1,82 @@
+package digitaloceanlock
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessor_ParseArtifactId(t *testing.T) {
+	tt := []struct {
+		Name    string
+		Id      string
+		ImageID int
+		Regions []string
+		Error   bool
+	}{
+		{Name: "SingleRegion", Id: "nyc3:12345", ImageID: 12345, Regions: []string{"nyc3"}},
+		{Name: "MultipleRegions", Id: "nyc3,sfo3:12345", ImageID: 12345, Regions: []string{"nyc3", "sfo3"}},
+		{Name: "NoRegions", Id: ":12345", ImageID: 12345},
+		{Name: "Invalid", Id: "12345", Error: true},
+		{Name: "InvalidImageID", Id: "nyc3:abc", Error: true},
+	}
+
+	for _, tc := range tt {
+		imageID, regions, err := parseArtifactId(tc.Id)
+		if tc.Error {
+			if err == nil {
+				t.Errorf("%s: should have error", tc.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: should not have error: %s", tc.Name, err)
+		}
+		if imageID != tc.ImageID || !reflect.DeepEqual(regions, tc.Regions) {
+			t.Errorf("%s: got %d %v, want %d %v", tc.Name, imageID, regions, tc.ImageID, tc.Regions)
+		}
+	}
+}
+
+func TestPostProcessor_AppendLedgerEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+
+	entries := []LedgerEntry{
+		{ImageID: 1, ImageName: "first", Tag: "locked", LockedAt: time.Unix(0, 0).UTC()},
+		{ImageID: 2, ImageName: "second", Tag: "locked", Reason: "production", LockedAt: time.Unix(0, 0).UTC()},
+	}
+	for _, e := range entries {
+		if err := appendLedgerEntry(path, e); err != nil {
+			t.Fatalf("should not have error: %s", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var got []LedgerEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e LedgerEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e)
+	}
+	if !reflect.DeepEqual(got, entries) {
+		t.Errorf("got %#v, want %#v", got, entries)
+	}
+}
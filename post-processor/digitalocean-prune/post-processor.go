@@ -0,0 +1,143 @@
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+package digitaloceanprune
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/digitalocean/packer-plugin-digitalocean/builder/digitalocean"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// A personal access token used to communicate with the DigitalOcean v2 API.
+	// This may also be set using the `DIGITALOCEAN_TOKEN` or
+	// `DIGITALOCEAN_ACCESS_TOKEN` environmental variables.
+	APIToken string `mapstructure:"api_token" required:"true"`
+	// Non standard api endpoint URL. Set this if you are
+	// using a DigitalOcean API compatible service. It can also be specified via
+	// environment variable DIGITALOCEAN_API_URL.
+	APIURL string `mapstructure:"api_url"`
+	// Controls how failed API requests are retried. See the
+	// [retry configuration](#retry-configuration) section below.
+	Retry digitalocean.RetryConfig `mapstructure:"retry" required:"false"`
+	// Set to true to only report the expired images that would be deleted.
+	// Defaults to `false`.
+	DryRun bool `mapstructure:"dry_run"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if p.config.APIToken == "" {
+		p.config.APIToken = os.Getenv("DIGITALOCEAN_TOKEN")
+	}
+	if p.config.APIToken == "" {
+		p.config.APIToken = os.Getenv("DIGITALOCEAN_ACCESS_TOKEN")
+	}
+	if p.config.APIURL == "" {
+		p.config.APIURL = os.Getenv("DIGITALOCEAN_API_URL")
+	}
+
+	errs := new(packersdk.MultiError)
+
+	if es := p.config.Retry.Prepare(nil, nil, nil); len(es) > 0 {
+		errs = packersdk.MultiErrorAppend(errs, es...)
+	}
+
+	if p.config.APIToken == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("api_token must be set"))
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packersdk.LogSecretFilter.Set(p.config.APIToken)
+	return nil
+}
+
+// PostProcess sweeps the account for expired images and passes the artifact
+// through unchanged.
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	client, err := digitalocean.NewClient(p.config.APIToken, p.config.APIURL, &p.config.Retry)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	ui.Say("Pruning expired images...")
+	if err := prune(ctx, client, ui, time.Now(), p.config.DryRun); err != nil {
+		return nil, false, false, err
+	}
+
+	return artifact, true, false, nil
+}
+
+// prune deletes the account's images whose expiry date is on or before now,
+// leaving locked images alone.
+func prune(ctx context.Context, client *godo.Client, ui packersdk.Ui, now time.Time, dryRun bool) error {
+	images, err := listUserImages(ctx, client)
+	if err != nil {
+		return fmt.Errorf("Error listing images: %s", err)
+	}
+
+	pruned := 0
+	for _, image := range images {
+		expires, ok := digitalocean.ImageExpiry(&image)
+		if !ok || now.Before(expires) {
+			continue
+		}
+		if digitalocean.IsLocked(&image) {
+			ui.Message(fmt.Sprintf("Keeping image %d (%s), which expired on %s: it is tagged %q",
+				image.ID, image.Name, expires.Format("2006-01-02"), digitalocean.LockedTag))
+			continue
+		}
+
+		if dryRun {
+			ui.Message(fmt.Sprintf("Would delete image %d (%s), which expired on %s",
+				image.ID, image.Name, expires.Format("2006-01-02")))
+			continue
+		}
+
+		ui.Message(fmt.Sprintf("Deleting image %d (%s), which expired on %s",
+			image.ID, image.Name, expires.Format("2006-01-02")))
+		if _, err := client.Images.Delete(ctx, image.ID); err != nil {
+			return fmt.Errorf("Error deleting image %d: %s", image.ID, err)
+		}
+		pruned++
+	}
+
+	if !dryRun {
+		ui.Message(fmt.Sprintf("Deleted %d expired images", pruned))
+	}
+	return nil
+}
+
+func listUserImages(ctx context.Context, client *godo.Client) ([]godo.Image, error) {
+	return digitalocean.ListAll(ctx, client.Images.ListUser)
+}
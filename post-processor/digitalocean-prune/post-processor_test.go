@@ -0,0 +1,61 @@
+package digitaloceanprune
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestPostProcessor_ImplementsPostProcessor(t *testing.T) {
+	var _ packersdk.PostProcessor = new(PostProcessor)
+}
+
+func TestPostProcessor_Prune(t *testing.T) {
+	var deleted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"images": [
+			{"id": 1, "name": "expired", "tags": ["expires:2025-05-31"]},
+			{"id": 2, "name": "expires-today", "tags": ["expires:2025-06-01"]},
+			{"id": 3, "name": "current", "tags": ["expires:2025-06-02"]},
+			{"id": 4, "name": "expired-locked", "tags": ["expires:2025-05-01", "locked"]},
+			{"id": 5, "name": "untagged", "tags": []}
+		], "links": {}}`))
+	}))
+	defer ts.Close()
+
+	client, err := godo.New(http.DefaultClient, godo.SetBaseURL(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+
+	var out bytes.Buffer
+	ui := &packersdk.BasicUi{Writer: &out, ErrorWriter: &out}
+	if err := prune(context.Background(), client, ui, now, true); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(deleted) > 0 {
+		t.Fatalf("dry run should not delete: %v", deleted)
+	}
+
+	if err := prune(context.Background(), client, ui, now, false); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	expected := []string{"/v2/images/1", "/v2/images/2"}
+	if !reflect.DeepEqual(deleted, expected) {
+		t.Fatalf("bad deletions: %v", deleted)
+	}
+}